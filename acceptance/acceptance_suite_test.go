@@ -43,6 +43,10 @@ func TestAcceptance(t *testing.T) {
 	RunSpecs(t, "Acceptance Suite")
 }
 
+// Send rate of the agents whose transfers are observed while they are in
+// progress. The transfers over the loopback are over too quickly otherwise.
+const observableSendRate = 50 * 1024 * 1024
+
 func startClique(cfg config.Config, args ...string) (*runner.ClqProcess, error) {
 	if useIperf && !cfg.UseIperf {
 		cfg.UseIperf = true
@@ -26,8 +26,9 @@ var _ = Describe("Api", func() {
 		srcTPort = testhelpers.SelectPort(GinkgoParallelNode())
 		srcAPort = testhelpers.SelectPort(GinkgoParallelNode())
 		srcClique, err = startClique(config.Config{
-			TransferPort: srcTPort,
-			APIPort:      srcAPort,
+			TransferPort:  srcTPort,
+			APIPort:       srcAPort,
+			SendRateLimit: observableSendRate,
 		})
 		Expect(err).NotTo(HaveOccurred())
 
@@ -46,6 +47,33 @@ var _ = Describe("Api", func() {
 		})
 	})
 
+	Describe("Scheduler", func() {
+		It("should report the configured amount of workers", func() {
+			status, err := srcClient.SchedulerStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Workers).To(Equal(1))
+		})
+
+		It("should report the default task selector", func() {
+			status, err := srcClient.SchedulerStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.TaskSelector.Name).To(Equal("lottery"))
+		})
+
+		It("should pause and resume the scheduler", func() {
+			Expect(srcClient.PauseScheduler()).To(Succeed())
+			status, err := srcClient.SchedulerStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.State).To(Equal("running"))
+			Expect(status.Paused).To(BeTrue())
+
+			Expect(srcClient.ResumeScheduler()).To(Succeed())
+			status, err = srcClient.SchedulerStatus()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.Paused).To(BeFalse())
+		})
+	})
+
 	Context("when there is a second clique-agent running", func() {
 		var (
 			destTPort  uint16
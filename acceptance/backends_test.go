@@ -0,0 +1,106 @@
+package acceptance_test
+
+import (
+	"net"
+	"time"
+
+	"github.com/ice-stuff/clique/acceptance/runner"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multiple backends", func() {
+	var (
+		booAPort, fooTPort   uint16
+		fooBackends          []string
+		booClique, fooClique *runner.ClqProcess
+		booClient            *api.Client
+	)
+
+	BeforeEach(func() {
+		fooBackends = []string{"simple", "latency", "udp"}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		fooTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		fooClique, err = startClique(config.Config{
+			NodeID:       "foo",
+			TransferPort: fooTPort,
+			Backends:     fooBackends,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		booAPort = testhelpers.SelectPort(GinkgoParallelNode())
+		booClique, err = startClique(config.Config{
+			NodeID:       "boo",
+			TransferPort: testhelpers.SelectPort(GinkgoParallelNode()),
+			APIPort:      booAPort,
+			Backends:     []string{"simple", "latency", "udp"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		booClient = api.NewClient("127.0.0.1", booAPort, time.Millisecond*100)
+	})
+
+	AfterEach(func() {
+		Expect(booClique.Stop()).To(Succeed())
+		Expect(fooClique.Stop()).To(Succeed())
+	})
+
+	transfer := func(backend string) api.TransferResults {
+		Expect(booClient.CreateTransfer(api.TransferSpec{
+			IP:      net.ParseIP("127.0.0.1"),
+			Port:    fooTPort,
+			Size:    1024 * 1024,
+			Backend: backend,
+		})).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByIP(net.ParseIP("127.0.0.1"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		return resList[0]
+	}
+
+	It("should use the default backend when none is requested", func() {
+		res := transfer("")
+		Expect(res.Backend).To(Equal("simple"))
+		Expect(res.BytesSent).To(BeNumerically("==", 1024*1024))
+	})
+
+	It("should measure the latency with the latency backend", func() {
+		res := transfer("latency")
+		Expect(res.Backend).To(Equal("latency"))
+		Expect(res.Packets).To(BeNumerically("==", 10))
+		Expect(res.RTT).NotTo(BeZero())
+	})
+
+	It("should measure the loss with the udp backend", func() {
+		res := transfer("udp")
+		Expect(res.Backend).To(Equal("udp"))
+		Expect(res.Packets).NotTo(BeZero())
+		Expect(res.LostPackets).To(BeNumerically("<", res.Packets))
+	})
+
+	Context("when the peer does not support the requested backend", func() {
+		BeforeEach(func() {
+			fooBackends = []string{"simple"}
+		})
+
+		It("should fall back to a backend that both agents support", func() {
+			res := transfer("latency")
+			Expect(res.Backend).To(Equal("simple"))
+			Expect(res.BytesSent).To(BeNumerically("==", 1024*1024))
+		})
+	})
+})
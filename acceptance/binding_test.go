@@ -0,0 +1,94 @@
+package acceptance_test
+
+import (
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/ice-stuff/clique/acceptance/runner"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Binding", func() {
+	var (
+		srcTPort, srcAPort, destTPort, destAPort uint16
+		srcClique, destClique                    *runner.ClqProcess
+		srcClient                                *api.Client
+	)
+
+	BeforeEach(func() {
+		// the other loopback addresses are only routed on linux
+		if runtime.GOOS != "linux" {
+			Skip("This test can only run with Linux.")
+		}
+
+		var err error
+
+		srcTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		srcAPort = testhelpers.SelectPort(GinkgoParallelNode())
+		srcClique, err = startClique(config.Config{
+			TransferPort:  srcTPort,
+			APIPort:       srcAPort,
+			SendRateLimit: observableSendRate,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srcClient = api.NewClient(
+			"127.0.0.1", srcAPort, time.Millisecond*100,
+		)
+
+		destTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		destAPort = testhelpers.SelectPort(GinkgoParallelNode())
+		destClique, err = startClique(config.Config{
+			TransferPort:        destTPort,
+			APIPort:             destAPort,
+			TransferBindAddress: "127.0.0.2",
+			APIBindAddress:      "127.0.0.2",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if srcClique != nil {
+			Expect(srcClique.Stop()).To(Succeed())
+		}
+		if destClique != nil {
+			Expect(destClique.Stop()).To(Succeed())
+		}
+	})
+
+	It("should serve the API on the bind address only", func() {
+		boundClient := api.NewClient("127.0.0.2", destAPort, time.Millisecond*100)
+		Expect(boundClient.Ping()).To(Succeed())
+
+		otherClient := api.NewClient("127.0.0.1", destAPort, time.Millisecond*100)
+		Expect(otherClient.Ping()).NotTo(Succeed())
+	})
+
+	It("should receive the transfers on the bind address from the source address", func() {
+		spec := api.TransferSpec{
+			IP:       net.ParseIP("127.0.0.2"),
+			Port:     destTPort,
+			Size:     1024 * 1024,
+			SourceIP: net.ParseIP("127.0.0.3"),
+		}
+		Expect(srcClient.CreateTransfer(spec)).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = srcClient.TransferResultsByIP(net.ParseIP("127.0.0.2"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		Expect(resList[0].BytesSent).To(BeNumerically(
+			"~", spec.Size, float32(spec.Size)*0.20,
+		))
+	})
+})
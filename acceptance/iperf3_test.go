@@ -0,0 +1,112 @@
+package acceptance_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ice-stuff/clique/acceptance/runner"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Go implementation of iperf3", func() {
+	var (
+		booTPort, booAPort, fooTPort, fooIperfPort uint16
+		booClique, fooClique                       *runner.ClqProcess
+		booClient                                  *api.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		fooTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		fooIperfPort = testhelpers.SelectPort(GinkgoParallelNode())
+		fooClique, err = startClique(config.Config{
+			NodeID:              "foo",
+			TransferPort:        fooTPort,
+			UseIperf:            true,
+			IperfPort:           fooIperfPort,
+			IperfImplementation: "go",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if booClique != nil {
+			Expect(booClique.Stop()).To(Succeed())
+		}
+		Expect(fooClique.Stop()).To(Succeed())
+	})
+
+	startBoo := func(cfg config.Config) {
+		booTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		booAPort = testhelpers.SelectPort(GinkgoParallelNode())
+		cfg.NodeID = "boo"
+		cfg.TransferPort = booTPort
+		cfg.APIPort = booAPort
+
+		var err error
+		booClique, err = startClique(cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		booClient = api.NewClient(
+			"127.0.0.1", booAPort, time.Millisecond*100,
+		)
+	}
+
+	It("should transfer between the agents", func() {
+		startBoo(config.Config{
+			UseIperf:            true,
+			IperfPort:           testhelpers.SelectPort(GinkgoParallelNode()),
+			IperfImplementation: "go",
+		})
+
+		spec := api.TransferSpec{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: fooTPort,
+			Size: 10 * 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByIP(net.ParseIP("127.0.0.1"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		res := resList[0]
+		Expect(res.BytesSent).To(BeNumerically("==", spec.Size))
+		Expect(res.Backend).To(Equal("iperf"))
+		Expect(res.SourceNodeID).To(Equal("boo"))
+		Expect(res.DestinationNodeID).To(Equal("foo"))
+	})
+
+	It("should measure the iperf servers", func() {
+		iperfServer := fmt.Sprintf("127.0.0.1:%d", fooIperfPort)
+		startBoo(config.Config{
+			IperfServers:     []string{iperfServer},
+			InitTransferSize: 1024 * 1024,
+		})
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByPeer(iperfServer)
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).ShouldNot(BeEmpty())
+
+		res := resList[0]
+		Expect(res.BytesSent).To(BeNumerically("==", 1024*1024))
+		Expect(res.Backend).To(Equal("iperf"))
+		Expect(res.SourceNodeID).To(Equal("boo"))
+		Expect(res.DestinationNodeID).To(BeEmpty())
+	})
+})
@@ -0,0 +1,80 @@
+package acceptance_test
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/ice-stuff/clique/acceptance/runner"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MTU discovery", func() {
+	var (
+		srcTPort, srcAPort, destTPort uint16
+		srcClique, destClique         *runner.ClqProcess
+		srcClient                     *api.Client
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("This test can only run with Linux.")
+		}
+
+		var err error
+
+		destTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		destClique, err = startClique(config.Config{
+			TransferPort: destTPort,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srcTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		srcAPort = testhelpers.SelectPort(GinkgoParallelNode())
+		srcClique, err = startClique(config.Config{
+			TransferPort: srcTPort,
+			APIPort:      srcAPort,
+			RemoteHosts:  []string{fmt.Sprintf("127.0.0.1:%d", destTPort)},
+			MTUDiscovery: config.MTUDiscoveryConfig{
+				Interval: config.Duration(time.Minute),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srcClient = api.NewClient(
+			"127.0.0.1", srcAPort, time.Millisecond*100,
+		)
+	})
+
+	AfterEach(func() {
+		if srcClique != nil {
+			Expect(srcClique.Stop()).To(Succeed())
+		}
+		if destClique != nil {
+			Expect(destClique.Stop()).To(Succeed())
+		}
+	})
+
+	It("should discover the path MTU to the remote hosts", func() {
+		var mtuResults []api.MTUResults
+		Eventually(func() []api.MTUResults {
+			var err error
+			mtuResults, err = srcClient.MTUResults()
+			Expect(err).NotTo(HaveOccurred())
+			return mtuResults
+		}, 5.0).ShouldNot(BeEmpty())
+
+		res := mtuResults[0]
+		Expect(res.Peer).To(Equal(fmt.Sprintf("127.0.0.1:%d", destTPort)))
+		Expect(res.IP.Equal(net.ParseIP("127.0.0.1"))).To(BeTrue())
+		Expect(res.PathMTU).NotTo(BeZero())
+		Expect(res.Interface).NotTo(BeEmpty())
+		Expect(res.Mismatch).To(BeFalse())
+	})
+})
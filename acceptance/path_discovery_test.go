@@ -0,0 +1,81 @@
+package acceptance_test
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/ice-stuff/clique/acceptance/runner"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path discovery", func() {
+	var (
+		srcTPort, srcAPort, destTPort uint16
+		srcClique, destClique         *runner.ClqProcess
+		srcClient                     *api.Client
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("This test can only run with Linux.")
+		}
+
+		var err error
+
+		destTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		destClique, err = startClique(config.Config{
+			TransferPort: destTPort,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srcTPort = testhelpers.SelectPort(GinkgoParallelNode())
+		srcAPort = testhelpers.SelectPort(GinkgoParallelNode())
+		srcClique, err = startClique(config.Config{
+			TransferPort: srcTPort,
+			APIPort:      srcAPort,
+			RemoteHosts:  []string{fmt.Sprintf("127.0.0.1:%d", destTPort)},
+			PathDiscovery: config.PathDiscoveryConfig{
+				Interval: config.Duration(time.Minute),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		srcClient = api.NewClient(
+			"127.0.0.1", srcAPort, time.Millisecond*100,
+		)
+	})
+
+	AfterEach(func() {
+		if srcClique != nil {
+			Expect(srcClique.Stop()).To(Succeed())
+		}
+		if destClique != nil {
+			Expect(destClique.Stop()).To(Succeed())
+		}
+	})
+
+	It("should discover the path to the remote hosts", func() {
+		var paths []api.PathResults
+		Eventually(func() []api.PathResults {
+			var err error
+			paths, err = srcClient.Paths()
+			Expect(err).NotTo(HaveOccurred())
+			return paths
+		}, 5.0).ShouldNot(BeEmpty())
+
+		path := paths[0]
+		Expect(path.Peer).To(Equal(fmt.Sprintf("127.0.0.1:%d", destTPort)))
+		Expect(path.Protocol).To(Equal("udp"))
+		Expect(path.Reached).To(BeTrue())
+		Expect(path.Changed).To(BeFalse())
+		Expect(path.Hops).To(HaveLen(1))
+		Expect(path.Hops[0].IP.Equal(net.ParseIP("127.0.0.1"))).To(BeTrue())
+	})
+})
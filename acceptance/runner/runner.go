@@ -40,3 +40,14 @@ func (c *ClqProcess) Stop() error {
 
 	return nil
 }
+
+// Wait waits for the process to exit on its own.
+func (c *ClqProcess) Wait() error {
+	waitErr := c.Cmd.Wait()
+
+	if err := os.RemoveAll(c.ConfigDirPath); err != nil {
+		return err
+	}
+
+	return waitErr
+}
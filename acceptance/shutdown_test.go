@@ -0,0 +1,47 @@
+package acceptance_test
+
+import (
+	"time"
+
+	"github.com/ice-stuff/clique/acceptance/runner"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Shutdown", func() {
+	var (
+		tPort, aPort uint16
+		proc         *runner.ClqProcess
+		client       *api.Client
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tPort = testhelpers.SelectPort(GinkgoParallelNode())
+		aPort = testhelpers.SelectPort(GinkgoParallelNode())
+		proc, err = startClique(config.Config{
+			TransferPort: tPort,
+			APIPort:      aPort,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		client = api.NewClient("127.0.0.1", aPort, time.Millisecond*100)
+	})
+
+	It("should exit when requested through the API", func() {
+		Expect(client.Shutdown()).To(Succeed())
+
+		exited := make(chan error)
+		go func() {
+			exited <- proc.Wait()
+		}()
+		Eventually(exited, 5.0).Should(Receive(BeNil()))
+		Expect(proc.Buffer).To(gbytes.Say("Clique agent is done"))
+	})
+})
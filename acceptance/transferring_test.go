@@ -2,6 +2,8 @@ package acceptance_test
 
 import (
 	"net"
+	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/ice-stuff/clique/acceptance/runner"
@@ -26,8 +28,13 @@ var _ = Describe("Transferring", func() {
 		booTPort = testhelpers.SelectPort(GinkgoParallelNode())
 		booAPort = testhelpers.SelectPort(GinkgoParallelNode())
 		booClique, err = startClique(config.Config{
-			TransferPort: booTPort,
-			APIPort:      booAPort,
+			NodeID:        "boo",
+			Labels:        map[string]string{"dc": "ams"},
+			TransferPort:  booTPort,
+			APIPort:       booAPort,
+			SendRateLimit: observableSendRate,
+			// the transfers take a few intervals
+			MeasurementInterval: config.Duration(50 * time.Millisecond),
 		})
 		Expect(err).NotTo(HaveOccurred())
 
@@ -37,6 +44,8 @@ var _ = Describe("Transferring", func() {
 
 		fooTPort = testhelpers.SelectPort(GinkgoParallelNode())
 		fooClique, err = startClique(config.Config{
+			NodeID:       "foo",
+			Labels:       map[string]string{"dc": "fra"},
 			TransferPort: fooTPort,
 		})
 		Expect(err).NotTo(HaveOccurred())
@@ -72,6 +81,102 @@ var _ = Describe("Transferring", func() {
 		Expect(res.BytesSent).To(BeNumerically("~", spec.Size, margin))
 	})
 
+	It("should identify both ends of the transfer", func() {
+		spec := api.TransferSpec{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: fooTPort,
+			Size: 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByIP(net.ParseIP("127.0.0.1"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		res := resList[0]
+		Expect(res.SourceNodeID).To(Equal("boo"))
+		Expect(res.SourcePort).To(Equal(booTPort))
+		Expect(res.SourceLabels).To(Equal(map[string]string{"dc": "ams"}))
+		Expect(res.DestinationNodeID).To(Equal("foo"))
+		Expect(res.DestinationPort).To(Equal(fooTPort))
+		Expect(res.DestinationLabels).To(Equal(map[string]string{"dc": "fra"}))
+		Expect(res.Backend).To(Equal("simple"))
+	})
+
+	It("should aggregate the results by the labels of the nodes", func() {
+		spec := api.TransferSpec{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: fooTPort,
+			Size: 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		var stats []api.TransferStats
+		Eventually(func() []api.TransferStats {
+			var err error
+			stats, err = booClient.TransferStats(
+				[]string{"dc"}, nil, map[string]string{"dc": "fra"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			return stats
+		}, 5.0).Should(HaveLen(1))
+
+		Expect(stats[0].Source).To(Equal(map[string]string{"dc": "ams"}))
+		Expect(stats[0].Destination).To(Equal(map[string]string{"dc": "fra"}))
+		Expect(stats[0].Transfers).To(Equal(1))
+		Expect(stats[0].MedianThroughput).NotTo(BeZero())
+	})
+
+	It("should transfer to a peer by its hostname", func() {
+		spec := api.TransferSpec{
+			Host: "localhost",
+			Port: fooTPort,
+			Size: 10 * 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		peer := net.JoinHostPort("localhost", strconv.Itoa(int(fooTPort)))
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByPeer(peer)
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		res := resList[0]
+		Expect(res.Peer).To(Equal(peer))
+		Expect(res.IP.IsLoopback()).To(BeTrue())
+	})
+
+	It("should transfer to a peer over IPv6", func() {
+		spec := api.TransferSpec{
+			Host: "::1",
+			Port: fooTPort,
+			Size: 10 * 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByIP(net.ParseIP("::1"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		res := resList[0]
+		Expect(res.Peer).To(Equal(
+			net.JoinHostPort("::1", strconv.Itoa(int(fooTPort))),
+		))
+		margin := float32(spec.Size) * 0.20
+		Expect(res.BytesSent).To(BeNumerically("~", spec.Size, margin))
+	})
+
 	It("should populate the duration", func() {
 		spec := api.TransferSpec{
 			IP:   net.ParseIP("127.0.0.1"),
@@ -93,6 +198,69 @@ var _ = Describe("Transferring", func() {
 		Expect(res.Duration).NotTo(BeZero())
 	})
 
+	It("should report the throughput series of the transfer", func() {
+		spec := api.TransferSpec{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: fooTPort,
+			Size: 10 * 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByIP(net.ParseIP("127.0.0.1"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		res := resList[0]
+		Expect(res.ID).NotTo(BeEmpty())
+
+		intervals, err := booClient.TransferIntervals(res.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(intervals).NotTo(BeEmpty())
+
+		var bytesSent uint64
+		for _, interval := range intervals {
+			bytesSent += interval.Bytes
+		}
+		Expect(bytesSent).To(Equal(res.BytesSent))
+	})
+
+	It("should report the statistics of the connection", func() {
+		if useIperf {
+			Skip("Iperf transfers do not go through the sampled connection.")
+		}
+		if runtime.GOOS != "linux" {
+			Skip("This test can only run with Linux.")
+		}
+
+		spec := api.TransferSpec{
+			IP:   net.ParseIP("127.0.0.1"),
+			Port: fooTPort,
+			Size: 10 * 1024 * 1024,
+		}
+		Expect(booClient.CreateTransfer(spec)).To(Succeed())
+
+		var resList []api.TransferResults
+		Eventually(func() []api.TransferResults {
+			var err error
+			resList, err = booClient.TransferResultsByIP(net.ParseIP("127.0.0.1"))
+			Expect(err).NotTo(HaveOccurred())
+			return resList
+		}, 5.0).Should(HaveLen(1))
+
+		res := resList[0]
+		Expect(res.TCPInfo).NotTo(BeNil())
+		Expect(res.TCPInfo.CongestionWindow).NotTo(BeZero())
+		Expect(res.RTT).To(Equal(res.TCPInfo.RTT))
+
+		samples, err := booClient.TransferTCPInfo(res.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(samples).NotTo(BeEmpty())
+	})
+
 	Context("when there are three clique agents", func() {
 		var (
 			mooAPort, mooTPort uint16
@@ -106,8 +274,9 @@ var _ = Describe("Transferring", func() {
 			mooAPort = testhelpers.SelectPort(GinkgoParallelNode())
 			mooTPort = testhelpers.SelectPort(GinkgoParallelNode())
 			mooClique, err = startClique(config.Config{
-				APIPort:      mooAPort,
-				TransferPort: mooTPort,
+				APIPort:       mooAPort,
+				TransferPort:  mooTPort,
+				SendRateLimit: observableSendRate,
 			})
 			Expect(err).NotTo(HaveOccurred())
 
@@ -1,23 +1,155 @@
 package api
 
 import (
+	"math"
 	"net"
 	"time"
 )
 
+// The API clients send their version in the APIVersionHeader. The clients
+// that can only decode 32-bit byte counters ask for the LegacyAPIVersion, and
+// the counters are saturated to 32 bits in the JSON responses to them, which
+// carry the LegacyAPIVersion in the same header. The rest of the clients get
+// the full counters.
+const (
+	APIVersionHeader = "X-Clique-Api-Version"
+	APIVersion       = "2"
+	LegacyAPIVersion = "1"
+)
+
 type TransferResults struct {
 	IP        net.IP        `json:"ip"`
-	BytesSent uint32        `json:"bytes_sent"`
+	BytesSent uint64        `json:"bytes_sent"`
 	Checksum  uint32        `json:"checksum"`
 	Duration  time.Duration `json:"duration"`
 	RTT       time.Duration `json:"rtt"`
 	Time      time.Time     `json:"time"`
+	// Requested size of the transfer, which is chosen per peer with adaptive
+	// sizing
+	Size uint64 `json:"size"`
+	// The transfer was delayed by the bandwidth budget or slowed down by the
+	// rate limit.
+	Throttled bool `json:"throttled"`
+	// Identifier of the transfer, which identifies its intervals
+	ID string `json:"id,omitempty"`
+	// Throughput series of the transfer. It is served separately, so that
+	// the lists of results stay small.
+	Intervals []TransferInterval `json:"-"`
+	// Kernel statistics of the connection at the end of the transfer. The
+	// samples during the transfer are served separately.
+	TCPInfo        *TCPInfo  `json:"tcp_info,omitempty"`
+	TCPInfoSamples []TCPInfo `json:"-"`
+	// Identity of the peer, i.e. its `host:port` as it was requested. The IP
+	// is the address that the hostname resolved to.
+	Peer string `json:"peer,omitempty"`
+	// Identities of the agents on both ends, as they were exchanged in the
+	// handshake of the transfer, and their transfer ports
+	SourceNodeID      string            `json:"source_node_id,omitempty"`
+	SourcePort        uint16            `json:"source_port,omitempty"`
+	SourceLabels      map[string]string `json:"source_labels,omitempty"`
+	DestinationNodeID string            `json:"destination_node_id,omitempty"`
+	DestinationPort   uint16            `json:"destination_port,omitempty"`
+	DestinationLabels map[string]string `json:"destination_labels,omitempty"`
+	// Transfer backend that conducted the transfer, e.g. "simple" or "iperf"
+	Backend string `json:"backend,omitempty"`
+	// Datagrams or probes of the backends that count them, e.g. "udp" and
+	// "latency", the ones of them that were lost and the variation of their
+	// delay
+	Packets     uint64        `json:"packets,omitempty"`
+	LostPackets uint64        `json:"lost_packets,omitempty"`
+	Jitter      time.Duration `json:"jitter,omitempty"`
+	// Segments that the sender retransmitted and the CPU utilisation of the
+	// agents in percent, as reported by the backends that measure them, e.g.
+	// "iperf"
+	Retransmits    uint64  `json:"retransmits,omitempty"`
+	SourceCPU      float64 `json:"source_cpu,omitempty"`
+	DestinationCPU float64 `json:"destination_cpu,omitempty"`
+}
+
+type TransferInterval struct {
+	// Offset from the start of the transfer
+	Start    time.Duration `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Bytes    uint64        `json:"bytes"`
+}
+
+type TCPInfo struct {
+	// Offset from the start of the transfer
+	Offset time.Duration `json:"offset"`
+	// Segments that were retransmitted
+	Retransmits uint32 `json:"retransmits"`
+	// Congestion window in segments
+	CongestionWindow uint32        `json:"congestion_window"`
+	RTT              time.Duration `json:"rtt"`
+	RTTVar           time.Duration `json:"rtt_var"`
+	// Bytes per second
+	PacingRate   uint64 `json:"pacing_rate"`
+	DeliveryRate uint64 `json:"delivery_rate"`
+}
+
+// PathResults is the outcome of a path discovery towards the transfer port
+// of a peer.
+type PathResults struct {
+	// `host:port` of the peer
+	Peer string `json:"peer"`
+	IP   net.IP `json:"ip"`
+	// `udp` or `tcp`
+	Protocol string    `json:"protocol"`
+	Time     time.Time `json:"time"`
+	Hops     []Hop     `json:"hops"`
+	// The last hop is the peer
+	Reached bool `json:"reached"`
+	// The path differs from the previous path to the peer
+	Changed bool `json:"changed"`
+}
+
+type Hop struct {
+	TTL int `json:"ttl"`
+	// Null when the hop did not answer
+	IP  net.IP        `json:"ip"`
+	RTT time.Duration `json:"rtt"`
+}
+
+// MTUResults is the outcome of a path MTU discovery towards a peer.
+type MTUResults struct {
+	// `host:port` of the peer
+	Peer string    `json:"peer"`
+	IP   net.IP    `json:"ip"`
+	Time time.Time `json:"time"`
+	// Largest IP packet that reaches the peer without fragmentation
+	PathMTU int `json:"path_mtu"`
+	// Local interface of the route to the peer
+	Interface    string `json:"interface"`
+	InterfaceMTU int    `json:"interface_mtu"`
+	// The path MTU is smaller than the MTU of the interface
+	Mismatch bool `json:"mismatch"`
 }
 
 type TransferSpec struct {
+	// Hostname or IPv4 or IPv6 address of the peer. Either the host or the
+	// IP is required.
+	Host string `json:"host,omitempty"`
 	IP   net.IP `json:"ip"`
 	Port uint16 `json:"port"`
-	Size uint32 `json:"size"`
+	Size uint64 `json:"size"`
+	// Share of the scheduler that the transfer gets. The default priority is
+	// used when it is zero.
+	Priority int `json:"priority,omitempty"`
+	// The transfer expires when it has not run by the deadline. It never
+	// expires without a deadline.
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// Run the transfer before any transfer that is not urgent.
+	RunNow bool `json:"run_now,omitempty"`
+	// Local address and network interface of the transfer, to measure a
+	// specific NIC of a multi-homed host. The routing table of the system
+	// picks them when they are empty. Interfaces are supported on linux only.
+	SourceIP        net.IP `json:"source_ip,omitempty"`
+	SourceInterface string `json:"source_interface,omitempty"`
+	// Transfer backend, e.g. "simple", "iperf", "udp" or "latency". The
+	// transfer falls back to a backend that both agents support when either
+	// of them does not support it. The agent picks its default backend when
+	// it is empty.
+	Backend string `json:"backend,omitempty"`
 }
 
 type TransferState string
@@ -30,6 +162,8 @@ const (
 	TransferStatePending   TransferState = "pending"
 	TransferStateRunning   TransferState = "running"
 	TransferStateCompleted TransferState = "completed"
+	TransferStateAborted   TransferState = "aborted"
+	TransferStateExpired   TransferState = "expired"
 	TransferStateUnknown   TransferState = "unknown"
 )
 
@@ -41,6 +175,10 @@ func ParseTransferState(state string) TransferState {
 		return TransferStateRunning
 	case "completed":
 		return TransferStateCompleted
+	case "aborted":
+		return TransferStateAborted
+	case "expired":
+		return TransferStateExpired
 	default:
 		return TransferStateUnknown
 	}
@@ -50,3 +188,70 @@ type Transfer struct {
 	Spec  TransferSpec  `json:"spec"`
 	State TransferState `json:"state"`
 }
+
+type SchedulerStatus struct {
+	// `idle`, `running` or `stopping`
+	State  string `json:"state"`
+	Paused bool   `json:"paused"`
+	// Maximum amount of tasks that run concurrently
+	Workers      int `json:"workers"`
+	RunningTasks int `json:"running_tasks"`
+	// Scheduled tasks, including the running ones
+	Tasks int `json:"tasks"`
+	// Sleep of every worker between its tasks
+	Sleep time.Duration `json:"sleep"`
+	// Algorithm that selects the next task to run
+	TaskSelector TaskSelectorStatus `json:"task_selector"`
+	// Scheduled tasks, in the order that they were scheduled
+	Queue []ScheduledTask `json:"queue"`
+	// Most recent selections, most recent first
+	Selections []TaskSelection `json:"selections"`
+}
+
+type ScheduledTask struct {
+	Task     string `json:"task"`
+	Priority int    `json:"priority"`
+	// Share of the sum of the priorities, i.e. of the lottery tickets
+	Share   float64 `json:"share"`
+	Running bool    `json:"running"`
+	// Start of the current run; zero when the task is not running
+	StartTime time.Time `json:"start_time"`
+}
+
+type TaskSelection struct {
+	Task      string    `json:"task"`
+	Priority  int       `json:"priority"`
+	StartTime time.Time `json:"start_time"`
+	// Zero while the task is running
+	Duration time.Duration `json:"duration"`
+}
+
+type TaskSelectorStatus struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params"`
+}
+
+// Legacy returns the results as the clients that predate the 64-bit byte
+// counters can decode them.
+func (res TransferResults) Legacy() TransferResults {
+	res.BytesSent = saturateUint32(res.BytesSent)
+	res.Size = saturateUint32(res.Size)
+
+	return res
+}
+
+// Legacy returns the transfer as the clients that predate the 64-bit byte
+// counters can decode it.
+func (t Transfer) Legacy() Transfer {
+	t.Spec.Size = saturateUint32(t.Spec.Size)
+
+	return t
+}
+
+func saturateUint32(v uint64) uint64 {
+	if v > math.MaxUint32 {
+		return math.MaxUint32
+	}
+
+	return v
+}
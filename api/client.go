@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -99,6 +101,191 @@ func (c *Client) TransferResultsByIP(ip net.IP) ([]TransferResults, error) {
 	return res, nil
 }
 
+// TransferResultsByPeer returns the results of the transfers to the peer at
+// the `host:port` address.
+func (c *Client) TransferResultsByPeer(peer string) ([]TransferResults, error) {
+	data, err := c.do(
+		"get",
+		fmt.Sprintf("transfer_results?peer=%s", url.QueryEscape(peer)),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TransferResults
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+// TransferResultsByLabels returns the results of the transfers whose source
+// and destination have all the requested labels.
+func (c *Client) TransferResultsByLabels(
+	source, destination map[string]string,
+) ([]TransferResults, error) {
+	data, err := c.do(
+		"get",
+		"transfer_results?"+labelsQuery(source, destination).Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TransferResults
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+// TransferStats aggregates the results of the transfers whose source and
+// destination have all the requested labels by the values of the `groupBy`
+// labels.
+func (c *Client) TransferStats(
+	groupBy []string, source, destination map[string]string,
+) ([]TransferStats, error) {
+	query := labelsQuery(source, destination)
+	if len(groupBy) > 0 {
+		query.Set("group_by", strings.Join(groupBy, ","))
+	}
+
+	data, err := c.do("get", "transfer_stats?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TransferStats
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+func labelsQuery(source, destination map[string]string) url.Values {
+	query := url.Values{}
+	if len(source) > 0 {
+		query.Set("source_labels", FormatLabels(source))
+	}
+	if len(destination) > 0 {
+		query.Set("destination_labels", FormatLabels(destination))
+	}
+
+	return query
+}
+
+func (c *Client) ExportTransferResults(format ResultsFormat) ([]byte, error) {
+	return c.do(
+		"get", fmt.Sprintf("transfer_results?format=%s", format.String()), nil,
+	)
+}
+
+func (c *Client) ExportTransferResultsByIP(
+	ip net.IP, format ResultsFormat,
+) ([]byte, error) {
+	return c.do(
+		"get",
+		fmt.Sprintf("transfer_results/%s?format=%s", ip, format.String()),
+		nil,
+	)
+}
+
+func (c *Client) TransferIntervals(id string) ([]TransferInterval, error) {
+	data, err := c.do("get", fmt.Sprintf("transfer_results/%s/intervals", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TransferInterval
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+func (c *Client) TransferTCPInfo(id string) ([]TCPInfo, error) {
+	data, err := c.do("get", fmt.Sprintf("transfer_results/%s/tcp_info", id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []TCPInfo
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+func (c *Client) Paths() ([]PathResults, error) {
+	return c.paths("paths")
+}
+
+// PathsByPeer returns the paths that were discovered towards the peer at the
+// `host:port` address.
+func (c *Client) PathsByPeer(peer string) ([]PathResults, error) {
+	return c.paths(fmt.Sprintf("paths?peer=%s", url.QueryEscape(peer)))
+}
+
+// PathChanges returns the discovered paths that differ from the previous
+// path to their peer.
+func (c *Client) PathChanges() ([]PathResults, error) {
+	return c.paths("paths?changed=true")
+}
+
+func (c *Client) paths(path string) ([]PathResults, error) {
+	data, err := c.do("get", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []PathResults
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+func (c *Client) MTUResults() ([]MTUResults, error) {
+	return c.mtuResults("mtu_results")
+}
+
+// MTUResultsByPeer returns the path MTUs that were discovered towards the
+// peer at the `host:port` address.
+func (c *Client) MTUResultsByPeer(peer string) ([]MTUResults, error) {
+	return c.mtuResults(
+		fmt.Sprintf("mtu_results?peer=%s", url.QueryEscape(peer)),
+	)
+}
+
+func (c *Client) mtuResults(path string) ([]MTUResults, error) {
+	data, err := c.do("get", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []MTUResults
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return nil, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
 func (c *Client) CreateTransfer(spec TransferSpec) error {
 	if _, err := c.do("post", "transfers", spec); err != nil {
 		return err
@@ -107,17 +294,62 @@ func (c *Client) CreateTransfer(spec TransferSpec) error {
 	return nil
 }
 
+func (c *Client) SchedulerStatus() (SchedulerStatus, error) {
+	data, err := c.do("get", "scheduler", nil)
+	if err != nil {
+		return SchedulerStatus{}, err
+	}
+
+	var res SchedulerStatus
+	if err := json.Unmarshal(data, &res); err != nil {
+		// untested return
+		return SchedulerStatus{}, fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return res, nil
+}
+
+// PauseScheduler stops the agent from starting new transfers. The transfers
+// in progress are not interrupted.
+func (c *Client) PauseScheduler() error {
+	if _, err := c.do("post", "scheduler/pause", nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) ResumeScheduler() error {
+	if _, err := c.do("post", "scheduler/resume", nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown asks the agent to shut down gracefully. It returns as soon as the
+// shutdown has started.
+func (c *Client) Shutdown() error {
+	if _, err := c.do("post", "shutdown", nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (c *Client) route(path string) string {
 	return fmt.Sprintf("http://%s:%d/%s", c.host, c.port, path)
 }
 
 func (c *Client) do(method, path string, req interface{}) ([]byte, error) {
-	var (
-		resp *http.Response
-		err  error
-	)
+	var httpReq *http.Request
 	if method == "get" {
-		resp, err = c.httpClient.Get(c.route(path))
+		var err error
+		httpReq, err = http.NewRequest("GET", c.route(path), nil)
+		if err != nil {
+			// untested return
+			return nil, fmt.Errorf("invalid request: %s", err)
+		}
 	} else if method == "post" {
 		data, err := json.Marshal(req)
 		if err != nil {
@@ -125,16 +357,21 @@ func (c *Client) do(method, path string, req interface{}) ([]byte, error) {
 			return nil, fmt.Errorf("invalid request: %s", err)
 		}
 
-		resp, err = c.httpClient.Post(
-			c.route(path),
-			"application/json",
-			bytes.NewBuffer(data),
+		httpReq, err = http.NewRequest(
+			"POST", c.route(path), bytes.NewBuffer(data),
 		)
+		if err != nil {
+			// untested return
+			return nil, fmt.Errorf("invalid request: %s", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
 	} else {
 		// untested return
 		return nil, fmt.Errorf("unknown method '%s'", method)
 	}
+	httpReq.Header.Set(APIVersionHeader, APIVersion)
 
+	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("making request: %s", err)
 	}
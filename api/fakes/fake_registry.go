@@ -31,6 +31,51 @@ type FakeRegistry struct {
 	transferResultsByIPReturns struct {
 		result1 []api.TransferResults
 	}
+	TransferResultsByPeerStub        func(peer string) []api.TransferResults
+	transferResultsByPeerMutex       sync.RWMutex
+	transferResultsByPeerArgsForCall []struct {
+		peer string
+	}
+	transferResultsByPeerReturns struct {
+		result1 []api.TransferResults
+	}
+	TransferResultsByIDStub        func(id string) (api.TransferResults, bool)
+	transferResultsByIDMutex       sync.RWMutex
+	transferResultsByIDArgsForCall []struct {
+		id string
+	}
+	transferResultsByIDReturns struct {
+		result1 api.TransferResults
+		result2 bool
+	}
+	PathsStub        func() []api.PathResults
+	pathsMutex       sync.RWMutex
+	pathsArgsForCall []struct{}
+	pathsReturns     struct {
+		result1 []api.PathResults
+	}
+	PathsByPeerStub        func(peer string) []api.PathResults
+	pathsByPeerMutex       sync.RWMutex
+	pathsByPeerArgsForCall []struct {
+		peer string
+	}
+	pathsByPeerReturns struct {
+		result1 []api.PathResults
+	}
+	MTUResultsStub        func() []api.MTUResults
+	mTUResultsMutex       sync.RWMutex
+	mTUResultsArgsForCall []struct{}
+	mTUResultsReturns     struct {
+		result1 []api.MTUResults
+	}
+	MTUResultsByPeerStub        func(peer string) []api.MTUResults
+	mTUResultsByPeerMutex       sync.RWMutex
+	mTUResultsByPeerArgsForCall []struct {
+		peer string
+	}
+	mTUResultsByPeerReturns struct {
+		result1 []api.MTUResults
+	}
 }
 
 func (fake *FakeRegistry) TransfersByState(state api.TransferState) []api.Transfer {
@@ -121,4 +166,181 @@ func (fake *FakeRegistry) TransferResultsByIPReturns(result1 []api.TransferResul
 	}{result1}
 }
 
+func (fake *FakeRegistry) TransferResultsByPeer(peer string) []api.TransferResults {
+	fake.transferResultsByPeerMutex.Lock()
+	fake.transferResultsByPeerArgsForCall = append(fake.transferResultsByPeerArgsForCall, struct {
+		peer string
+	}{peer})
+	fake.transferResultsByPeerMutex.Unlock()
+	if fake.TransferResultsByPeerStub != nil {
+		return fake.TransferResultsByPeerStub(peer)
+	} else {
+		return fake.transferResultsByPeerReturns.result1
+	}
+}
+
+func (fake *FakeRegistry) TransferResultsByPeerCallCount() int {
+	fake.transferResultsByPeerMutex.RLock()
+	defer fake.transferResultsByPeerMutex.RUnlock()
+	return len(fake.transferResultsByPeerArgsForCall)
+}
+
+func (fake *FakeRegistry) TransferResultsByPeerArgsForCall(i int) string {
+	fake.transferResultsByPeerMutex.RLock()
+	defer fake.transferResultsByPeerMutex.RUnlock()
+	return fake.transferResultsByPeerArgsForCall[i].peer
+}
+
+func (fake *FakeRegistry) TransferResultsByPeerReturns(result1 []api.TransferResults) {
+	fake.TransferResultsByPeerStub = nil
+	fake.transferResultsByPeerReturns = struct {
+		result1 []api.TransferResults
+	}{result1}
+}
+
+func (fake *FakeRegistry) TransferResultsByID(id string) (api.TransferResults, bool) {
+	fake.transferResultsByIDMutex.Lock()
+	fake.transferResultsByIDArgsForCall = append(fake.transferResultsByIDArgsForCall, struct {
+		id string
+	}{id})
+	fake.transferResultsByIDMutex.Unlock()
+	if fake.TransferResultsByIDStub != nil {
+		return fake.TransferResultsByIDStub(id)
+	} else {
+		return fake.transferResultsByIDReturns.result1, fake.transferResultsByIDReturns.result2
+	}
+}
+
+func (fake *FakeRegistry) TransferResultsByIDCallCount() int {
+	fake.transferResultsByIDMutex.RLock()
+	defer fake.transferResultsByIDMutex.RUnlock()
+	return len(fake.transferResultsByIDArgsForCall)
+}
+
+func (fake *FakeRegistry) TransferResultsByIDArgsForCall(i int) string {
+	fake.transferResultsByIDMutex.RLock()
+	defer fake.transferResultsByIDMutex.RUnlock()
+	return fake.transferResultsByIDArgsForCall[i].id
+}
+
+func (fake *FakeRegistry) TransferResultsByIDReturns(result1 api.TransferResults, result2 bool) {
+	fake.TransferResultsByIDStub = nil
+	fake.transferResultsByIDReturns = struct {
+		result1 api.TransferResults
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeRegistry) Paths() []api.PathResults {
+	fake.pathsMutex.Lock()
+	fake.pathsArgsForCall = append(fake.pathsArgsForCall, struct{}{})
+	fake.pathsMutex.Unlock()
+	if fake.PathsStub != nil {
+		return fake.PathsStub()
+	} else {
+		return fake.pathsReturns.result1
+	}
+}
+
+func (fake *FakeRegistry) PathsCallCount() int {
+	fake.pathsMutex.RLock()
+	defer fake.pathsMutex.RUnlock()
+	return len(fake.pathsArgsForCall)
+}
+
+func (fake *FakeRegistry) PathsReturns(result1 []api.PathResults) {
+	fake.PathsStub = nil
+	fake.pathsReturns = struct {
+		result1 []api.PathResults
+	}{result1}
+}
+
+func (fake *FakeRegistry) PathsByPeer(peer string) []api.PathResults {
+	fake.pathsByPeerMutex.Lock()
+	fake.pathsByPeerArgsForCall = append(fake.pathsByPeerArgsForCall, struct {
+		peer string
+	}{peer})
+	fake.pathsByPeerMutex.Unlock()
+	if fake.PathsByPeerStub != nil {
+		return fake.PathsByPeerStub(peer)
+	} else {
+		return fake.pathsByPeerReturns.result1
+	}
+}
+
+func (fake *FakeRegistry) PathsByPeerCallCount() int {
+	fake.pathsByPeerMutex.RLock()
+	defer fake.pathsByPeerMutex.RUnlock()
+	return len(fake.pathsByPeerArgsForCall)
+}
+
+func (fake *FakeRegistry) PathsByPeerArgsForCall(i int) string {
+	fake.pathsByPeerMutex.RLock()
+	defer fake.pathsByPeerMutex.RUnlock()
+	return fake.pathsByPeerArgsForCall[i].peer
+}
+
+func (fake *FakeRegistry) PathsByPeerReturns(result1 []api.PathResults) {
+	fake.PathsByPeerStub = nil
+	fake.pathsByPeerReturns = struct {
+		result1 []api.PathResults
+	}{result1}
+}
+
+func (fake *FakeRegistry) MTUResults() []api.MTUResults {
+	fake.mTUResultsMutex.Lock()
+	fake.mTUResultsArgsForCall = append(fake.mTUResultsArgsForCall, struct{}{})
+	fake.mTUResultsMutex.Unlock()
+	if fake.MTUResultsStub != nil {
+		return fake.MTUResultsStub()
+	} else {
+		return fake.mTUResultsReturns.result1
+	}
+}
+
+func (fake *FakeRegistry) MTUResultsCallCount() int {
+	fake.mTUResultsMutex.RLock()
+	defer fake.mTUResultsMutex.RUnlock()
+	return len(fake.mTUResultsArgsForCall)
+}
+
+func (fake *FakeRegistry) MTUResultsReturns(result1 []api.MTUResults) {
+	fake.MTUResultsStub = nil
+	fake.mTUResultsReturns = struct {
+		result1 []api.MTUResults
+	}{result1}
+}
+
+func (fake *FakeRegistry) MTUResultsByPeer(peer string) []api.MTUResults {
+	fake.mTUResultsByPeerMutex.Lock()
+	fake.mTUResultsByPeerArgsForCall = append(fake.mTUResultsByPeerArgsForCall, struct {
+		peer string
+	}{peer})
+	fake.mTUResultsByPeerMutex.Unlock()
+	if fake.MTUResultsByPeerStub != nil {
+		return fake.MTUResultsByPeerStub(peer)
+	} else {
+		return fake.mTUResultsByPeerReturns.result1
+	}
+}
+
+func (fake *FakeRegistry) MTUResultsByPeerCallCount() int {
+	fake.mTUResultsByPeerMutex.RLock()
+	defer fake.mTUResultsByPeerMutex.RUnlock()
+	return len(fake.mTUResultsByPeerArgsForCall)
+}
+
+func (fake *FakeRegistry) MTUResultsByPeerArgsForCall(i int) string {
+	fake.mTUResultsByPeerMutex.RLock()
+	defer fake.mTUResultsByPeerMutex.RUnlock()
+	return fake.mTUResultsByPeerArgsForCall[i].peer
+}
+
+func (fake *FakeRegistry) MTUResultsByPeerReturns(result1 []api.MTUResults) {
+	fake.MTUResultsByPeerStub = nil
+	fake.mTUResultsByPeerReturns = struct {
+		result1 []api.MTUResults
+	}{result1}
+}
+
 var _ api.Registry = new(FakeRegistry)
@@ -0,0 +1,79 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/ice-stuff/clique/api"
+)
+
+type FakeScheduler struct {
+	StatusStub        func() api.SchedulerStatus
+	statusMutex       sync.RWMutex
+	statusArgsForCall []struct{}
+	statusReturns     struct {
+		result1 api.SchedulerStatus
+	}
+	PauseStub         func()
+	pauseMutex        sync.RWMutex
+	pauseArgsForCall  []struct{}
+	ResumeStub        func()
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct{}
+}
+
+func (fake *FakeScheduler) Status() api.SchedulerStatus {
+	fake.statusMutex.Lock()
+	fake.statusArgsForCall = append(fake.statusArgsForCall, struct{}{})
+	fake.statusMutex.Unlock()
+	if fake.StatusStub != nil {
+		return fake.StatusStub()
+	} else {
+		return fake.statusReturns.result1
+	}
+}
+
+func (fake *FakeScheduler) StatusCallCount() int {
+	fake.statusMutex.RLock()
+	defer fake.statusMutex.RUnlock()
+	return len(fake.statusArgsForCall)
+}
+
+func (fake *FakeScheduler) StatusReturns(result1 api.SchedulerStatus) {
+	fake.StatusStub = nil
+	fake.statusReturns = struct {
+		result1 api.SchedulerStatus
+	}{result1}
+}
+
+func (fake *FakeScheduler) Pause() {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct{}{})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		fake.PauseStub()
+	}
+}
+
+func (fake *FakeScheduler) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeScheduler) Resume() {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct{}{})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		fake.ResumeStub()
+	}
+}
+
+func (fake *FakeScheduler) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+var _ api.Scheduler = new(FakeScheduler)
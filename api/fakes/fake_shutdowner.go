@@ -0,0 +1,31 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/ice-stuff/clique/api"
+)
+
+type FakeShutdowner struct {
+	ShutdownStub        func()
+	shutdownMutex       sync.RWMutex
+	shutdownArgsForCall []struct{}
+}
+
+func (fake *FakeShutdowner) Shutdown() {
+	fake.shutdownMutex.Lock()
+	fake.shutdownArgsForCall = append(fake.shutdownArgsForCall, struct{}{})
+	fake.shutdownMutex.Unlock()
+	if fake.ShutdownStub != nil {
+		fake.ShutdownStub()
+	}
+}
+
+func (fake *FakeShutdowner) ShutdownCallCount() int {
+	fake.shutdownMutex.RLock()
+	defer fake.shutdownMutex.RUnlock()
+	return len(fake.shutdownArgsForCall)
+}
+
+var _ api.Shutdowner = new(FakeShutdowner)
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ResultsFormat string
+
+func (format ResultsFormat) String() string {
+	return string(format)
+}
+
+const (
+	ResultsFormatJSON      ResultsFormat = "json"
+	ResultsFormatJSONLines ResultsFormat = "jsonl"
+	ResultsFormatCSV       ResultsFormat = "csv"
+	ResultsFormatInflux    ResultsFormat = "influx"
+	ResultsFormatUnknown   ResultsFormat = "unknown"
+)
+
+func ParseResultsFormat(format string) ResultsFormat {
+	switch format {
+	case "json":
+		return ResultsFormatJSON
+	case "jsonl":
+		return ResultsFormatJSONLines
+	case "csv":
+		return ResultsFormatCSV
+	case "influx":
+		return ResultsFormatInflux
+	default:
+		return ResultsFormatUnknown
+	}
+}
+
+// ResultsFormatFromMediaType maps the media type of an `Accept` header to a
+// results format. InfluxDB line protocol is plain text so it can only be
+// selected explicitly.
+func ResultsFormatFromMediaType(mediaType string) ResultsFormat {
+	switch mediaType {
+	case "application/json":
+		return ResultsFormatJSON
+	case "application/x-ndjson", "application/jsonl":
+		return ResultsFormatJSONLines
+	case "text/csv":
+		return ResultsFormatCSV
+	default:
+		return ResultsFormatUnknown
+	}
+}
+
+func (format ResultsFormat) ContentType() string {
+	switch format {
+	case ResultsFormatJSONLines:
+		return "application/x-ndjson"
+	case ResultsFormatCSV:
+		return "text/csv; charset=utf-8"
+	case ResultsFormatInflux:
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// ResultsEncoder encodes transfer results one at a time, so that they can be
+// appended to a stream.
+type ResultsEncoder interface {
+	WriteHeader(w io.Writer) error
+	Encode(w io.Writer, res TransferResults) error
+}
+
+func NewResultsEncoder(format ResultsFormat) (ResultsEncoder, error) {
+	switch format {
+	case ResultsFormatJSONLines:
+		return jsonLinesEncoder{}, nil
+	case ResultsFormatCSV:
+		return csvEncoder{}, nil
+	case ResultsFormatInflux:
+		return influxEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("format `%s` cannot be streamed", format)
+	}
+}
+
+func EncodeResults(
+	w io.Writer, format ResultsFormat, results []TransferResults,
+) error {
+	if format == ResultsFormatJSON {
+		return json.NewEncoder(w).Encode(results)
+	}
+
+	enc, err := NewResultsEncoder(format)
+	if err != nil {
+		return err
+	}
+
+	if err := enc.WriteHeader(w); err != nil {
+		return err
+	}
+	for _, res := range results {
+		if err := enc.Encode(w, res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type jsonLinesEncoder struct{}
+
+func (jsonLinesEncoder) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (jsonLinesEncoder) Encode(w io.Writer, res TransferResults) error {
+	// json.Encoder terminates every value with a newline
+	return json.NewEncoder(w).Encode(res)
+}
+
+var csvColumns = []string{
+	"time", "ip", "bytes_sent", "checksum", "duration_ns", "rtt_ns", "size",
+	"throttled", "peer", "source_node_id", "destination_node_id", "backend",
+}
+
+type csvEncoder struct{}
+
+func (csvEncoder) WriteHeader(w io.Writer) error {
+	return writeCSVRecord(w, csvColumns)
+}
+
+func (csvEncoder) Encode(w io.Writer, res TransferResults) error {
+	return writeCSVRecord(w, []string{
+		res.Time.UTC().Format(time.RFC3339Nano),
+		res.IP.String(),
+		strconv.FormatUint(res.BytesSent, 10),
+		strconv.FormatUint(uint64(res.Checksum), 10),
+		strconv.FormatInt(int64(res.Duration), 10),
+		strconv.FormatInt(int64(res.RTT), 10),
+		strconv.FormatUint(res.Size, 10),
+		strconv.FormatBool(res.Throttled),
+		res.Peer,
+		res.SourceNodeID,
+		res.DestinationNodeID,
+		res.Backend,
+	})
+}
+
+func writeCSVRecord(w io.Writer, record []string) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(record); err != nil {
+		return err
+	}
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
+
+const influxMeasurement = "transfer_results"
+
+type influxEncoder struct{}
+
+func (influxEncoder) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (influxEncoder) Encode(w io.Writer, res TransferResults) error {
+	tags := "ip=" + influxEscape(res.IP.String())
+	if res.Peer != "" {
+		tags += ",peer=" + influxEscape(res.Peer)
+	}
+	if res.SourceNodeID != "" {
+		tags += ",source_node_id=" + influxEscape(res.SourceNodeID)
+	}
+	if res.DestinationNodeID != "" {
+		tags += ",destination_node_id=" + influxEscape(res.DestinationNodeID)
+	}
+	if res.Backend != "" {
+		tags += ",backend=" + influxEscape(res.Backend)
+	}
+
+	fields := fmt.Sprintf(
+		"bytes_sent=%di,checksum=%di,duration=%di,rtt=%di,size=%di,throttled=%t",
+		res.BytesSent, res.Checksum, int64(res.Duration), int64(res.RTT),
+		res.Size, res.Throttled,
+	)
+	if res.Packets != 0 {
+		fields += fmt.Sprintf(
+			",packets=%di,lost_packets=%di,jitter=%di",
+			res.Packets, res.LostPackets, int64(res.Jitter),
+		)
+	}
+	if res.Retransmits != 0 {
+		fields += fmt.Sprintf(",retransmits=%di", res.Retransmits)
+	}
+	if res.SourceCPU != 0 || res.DestinationCPU != 0 {
+		fields += fmt.Sprintf(
+			",source_cpu=%s,destination_cpu=%s",
+			strconv.FormatFloat(res.SourceCPU, 'f', -1, 64),
+			strconv.FormatFloat(res.DestinationCPU, 'f', -1, 64),
+		)
+	}
+
+	_, err := fmt.Fprintf(
+		w, "%s,%s %s %d\n",
+		influxMeasurement, tags, fields, res.Time.UnixNano(),
+	)
+
+	return err
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func influxEscape(tag string) string {
+	return influxEscaper.Replace(tag)
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/transfer"
 )
 
 //go:generate counterfeiter . TransferStater
@@ -19,9 +20,9 @@ type liveTransfer struct {
 }
 
 func (t *liveTransfer) state() api.TransferState {
-	if t.savedState != api.TransferStateCompleted {
+	if !isFinalState(t.savedState) {
 		t.savedState = t.stater.TransferState()
-		if t.savedState == api.TransferStateCompleted {
+		if isFinalState(t.savedState) {
 			t.stater = nil
 		}
 	}
@@ -29,6 +30,12 @@ func (t *liveTransfer) state() api.TransferState {
 	return t.savedState
 }
 
+func isFinalState(state api.TransferState) bool {
+	return state == api.TransferStateCompleted ||
+		state == api.TransferStateAborted ||
+		state == api.TransferStateExpired
+}
+
 func (t *liveTransfer) transfer() api.Transfer {
 	return api.Transfer{
 		Spec:  t.spec,
@@ -39,16 +46,33 @@ func (t *liveTransfer) transfer() api.Transfer {
 type Registry struct {
 	results    []api.TransferResults
 	resultsMap map[string][]*api.TransferResults
+	// by transfer id
+	resultsByID map[string]*api.TransferResults
+	// by peer identity, which stays the same when the peer changes address
+	resultsByPeer map[string][]*api.TransferResults
 
 	liveTransfers []liveTransfer
 
+	paths []api.PathResults
+	// by peer identity
+	pathsByPeer map[string][]*api.PathResults
+
+	mtuResults []api.MTUResults
+	// by peer identity
+	mtuResultsByPeer map[string][]*api.MTUResults
+
 	lock sync.Mutex
 }
 
 func NewRegistry() *Registry {
 	return &Registry{
-		results:    make([]api.TransferResults, 0, 64),
-		resultsMap: make(map[string][]*api.TransferResults),
+		results:       make([]api.TransferResults, 0, 64),
+		resultsMap:    make(map[string][]*api.TransferResults),
+		resultsByID:   make(map[string]*api.TransferResults),
+		resultsByPeer: make(map[string][]*api.TransferResults),
+		pathsByPeer:   make(map[string][]*api.PathResults),
+
+		mtuResultsByPeer: make(map[string][]*api.MTUResults),
 	}
 }
 
@@ -104,7 +128,23 @@ func (r *Registry) TransferResultsByIP(ip net.IP) []api.TransferResults {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
-	resPtrs := r.resultsMap[ip.String()]
+	return copyResults(r.resultsMap[ip.String()])
+}
+
+// TransferResultsByPeer returns the results of the transfers to the peer at
+// the `host:port` address, whichever addresses its hostname resolved to.
+func (r *Registry) TransferResultsByPeer(peer string) []api.TransferResults {
+	if host, port, err := transfer.ParsePeer(peer); err == nil {
+		peer = transfer.PeerName(host, port)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return copyResults(r.resultsByPeer[peer])
+}
+
+func copyResults(resPtrs []*api.TransferResults) []api.TransferResults {
 	if len(resPtrs) == 0 {
 		return []api.TransferResults{}
 	}
@@ -117,10 +157,103 @@ func (r *Registry) TransferResultsByIP(ip net.IP) []api.TransferResults {
 	return res
 }
 
+// TransferResultsByID returns the results of the transfer with the given id.
+func (r *Registry) TransferResultsByID(id string) (api.TransferResults, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	res, ok := r.resultsByID[id]
+	if !ok {
+		return api.TransferResults{}, false
+	}
+
+	return *res, true
+}
+
 func (r *Registry) RegisterResults(ip net.IP, res api.TransferResults) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 
 	r.results = append(r.results, res)
 	r.resultsMap[ip.String()] = append(r.resultsMap[ip.String()], &res)
+	if res.ID != "" {
+		r.resultsByID[res.ID] = &res
+	}
+	if res.Peer != "" {
+		r.resultsByPeer[res.Peer] = append(r.resultsByPeer[res.Peer], &res)
+	}
+}
+
+func (r *Registry) RegisterPath(res api.PathResults) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.paths = append(r.paths, res)
+	r.pathsByPeer[res.Peer] = append(r.pathsByPeer[res.Peer], &res)
+}
+
+func (r *Registry) Paths() []api.PathResults {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	res := make([]api.PathResults, len(r.paths))
+	copy(res, r.paths)
+
+	return res
+}
+
+// PathsByPeer returns the paths that were discovered towards the peer at the
+// `host:port` address.
+func (r *Registry) PathsByPeer(peer string) []api.PathResults {
+	if host, port, err := transfer.ParsePeer(peer); err == nil {
+		peer = transfer.PeerName(host, port)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	resPtrs := r.pathsByPeer[peer]
+	res := make([]api.PathResults, len(resPtrs))
+	for i := range resPtrs {
+		res[i] = *resPtrs[i]
+	}
+
+	return res
+}
+
+func (r *Registry) RegisterMTU(res api.MTUResults) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.mtuResults = append(r.mtuResults, res)
+	r.mtuResultsByPeer[res.Peer] = append(r.mtuResultsByPeer[res.Peer], &res)
+}
+
+func (r *Registry) MTUResults() []api.MTUResults {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	res := make([]api.MTUResults, len(r.mtuResults))
+	copy(res, r.mtuResults)
+
+	return res
+}
+
+// MTUResultsByPeer returns the path MTUs that were discovered towards the
+// peer at the `host:port` address.
+func (r *Registry) MTUResultsByPeer(peer string) []api.MTUResults {
+	if host, port, err := transfer.ParsePeer(peer); err == nil {
+		peer = transfer.PeerName(host, port)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	resPtrs := r.mtuResultsByPeer[peer]
+	res := make([]api.MTUResults, len(resPtrs))
+	for i := range resPtrs {
+		res[i] = *resPtrs[i]
+	}
+
+	return res
 }
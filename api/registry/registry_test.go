@@ -95,6 +95,36 @@ var _ = Describe("Registry", func() {
 				})
 			})
 
+			Context("when a stater is aborted", func() {
+				It("should return it in the aborted transfers", func() {
+					staterA.TransferStateReturns(api.TransferStateAborted)
+
+					Expect(r.TransfersByState(api.TransferStateAborted)).To(Equal(
+						[]api.Transfer{
+							api.Transfer{
+								Spec:  transferSpecA,
+								State: api.TransferStateAborted,
+							},
+						},
+					))
+				})
+			})
+
+			Context("when a stater is expired", func() {
+				It("should return it in the expired transfers", func() {
+					staterA.TransferStateReturns(api.TransferStateExpired)
+
+					Expect(r.TransfersByState(api.TransferStateExpired)).To(Equal(
+						[]api.Transfer{
+							api.Transfer{
+								Spec:  transferSpecA,
+								State: api.TransferStateExpired,
+							},
+						},
+					))
+				})
+			})
+
 			Context("when a stater changes state", func() {
 				It("returns a new transfer instance", func() {
 					Expect(r.Transfers()).To(Equal([]api.Transfer{
@@ -230,9 +260,171 @@ var _ = Describe("Registry", func() {
 			})
 		})
 	})
+
+	Describe("TransferResultsByID", func() {
+		It("should not find unknown transfers", func() {
+			_, ok := r.TransferResultsByID("banana")
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("when results have been registered", func() {
+			var transferResults api.TransferResults
+
+			BeforeEach(func() {
+				ip := net.ParseIP("129.168.1.14")
+
+				otherRes := makeTranaferResults(ip, 10*1024*1024)
+				otherRes.ID = "other-transfer"
+				r.RegisterResults(ip, otherRes)
+
+				transferResults = makeTranaferResults(ip, 20*1024*1024)
+				transferResults.ID = "some-transfer"
+				transferResults.Intervals = []api.TransferInterval{
+					{Duration: time.Second, Bytes: 20 * 1024 * 1024},
+				}
+				r.RegisterResults(ip, transferResults)
+			})
+
+			It("should return the results of the transfer", func() {
+				res, ok := r.TransferResultsByID("some-transfer")
+				Expect(ok).To(BeTrue())
+				Expect(res).To(Equal(transferResults))
+			})
+		})
+	})
+
+	Describe("TransferResultsByPeer", func() {
+		It("should return an empty list", func() {
+			Expect(r.TransferResultsByPeer("peer.example.com:5000")).To(BeEmpty())
+		})
+
+		Context("when results have been registered", func() {
+			var transferResultsList []api.TransferResults
+
+			BeforeEach(func() {
+				transferResultsList = []api.TransferResults{}
+				for i, ip := range []string{"10.0.0.1", "10.0.0.2", "fe80::1"} {
+					res := makeTranaferResults(net.ParseIP(ip), uint64(i+1))
+					res.Peer = "peer.example.com:5000"
+					transferResultsList = append(transferResultsList, res)
+					r.RegisterResults(res.IP, res)
+				}
+
+				otherRes := makeTranaferResults(net.ParseIP("10.0.0.1"), 10)
+				otherRes.Peer = "peer.example.com:5001"
+				r.RegisterResults(otherRes.IP, otherRes)
+			})
+
+			It("should return the results of the peer on all its addresses", func() {
+				Expect(r.TransferResultsByPeer("peer.example.com:5000")).To(
+					Equal(transferResultsList),
+				)
+			})
+
+			It("should accept any spelling of the peer", func() {
+				Expect(r.TransferResultsByPeer("Peer.Example.com.:5000")).To(
+					Equal(transferResultsList),
+				)
+			})
+		})
+	})
+
+	Describe("Paths", func() {
+		It("should return an empty list", func() {
+			Expect(r.Paths()).To(BeEmpty())
+			Expect(r.PathsByPeer("10.0.0.1:5000")).To(BeEmpty())
+		})
+
+		Context("when paths have been registered", func() {
+			var paths []api.PathResults
+
+			BeforeEach(func() {
+				paths = []api.PathResults{
+					{
+						Peer: "peer.example.com:5000",
+						IP:   net.ParseIP("10.0.0.1"),
+						Hops: []api.Hop{{TTL: 1, IP: net.ParseIP("10.0.0.1")}},
+					},
+					{
+						Peer: "10.0.0.2:5000",
+						IP:   net.ParseIP("10.0.0.2"),
+						Hops: []api.Hop{{TTL: 1}},
+					},
+					{
+						Peer:    "peer.example.com:5000",
+						IP:      net.ParseIP("10.0.0.1"),
+						Hops:    []api.Hop{{TTL: 1}, {TTL: 2}},
+						Changed: true,
+					},
+				}
+				for _, path := range paths {
+					r.RegisterPath(path)
+				}
+			})
+
+			It("should return all the paths in order", func() {
+				Expect(r.Paths()).To(Equal(paths))
+			})
+
+			It("should return the paths of the peer", func() {
+				Expect(r.PathsByPeer("Peer.Example.com:5000")).To(Equal(
+					[]api.PathResults{paths[0], paths[2]},
+				))
+			})
+		})
+	})
+
+	Describe("MTUResults", func() {
+		It("should return an empty list", func() {
+			Expect(r.MTUResults()).To(BeEmpty())
+			Expect(r.MTUResultsByPeer("10.0.0.1:5000")).To(BeEmpty())
+		})
+
+		Context("when path MTUs have been registered", func() {
+			var mtuResults []api.MTUResults
+
+			BeforeEach(func() {
+				mtuResults = []api.MTUResults{
+					{
+						Peer:         "peer.example.com:5000",
+						IP:           net.ParseIP("10.0.0.1"),
+						PathMTU:      1500,
+						InterfaceMTU: 1500,
+					},
+					{
+						Peer:         "10.0.0.2:5000",
+						IP:           net.ParseIP("10.0.0.2"),
+						PathMTU:      1500,
+						InterfaceMTU: 9000,
+						Mismatch:     true,
+					},
+					{
+						Peer:         "peer.example.com:5000",
+						IP:           net.ParseIP("10.0.0.1"),
+						PathMTU:      1400,
+						InterfaceMTU: 1500,
+						Mismatch:     true,
+					},
+				}
+				for _, res := range mtuResults {
+					r.RegisterMTU(res)
+				}
+			})
+
+			It("should return all the path MTUs in order", func() {
+				Expect(r.MTUResults()).To(Equal(mtuResults))
+			})
+
+			It("should return the path MTUs of the peer", func() {
+				Expect(r.MTUResultsByPeer("Peer.Example.com:5000")).To(Equal(
+					[]api.MTUResults{mtuResults[0], mtuResults[2]},
+				))
+			})
+		})
+	})
 })
 
-func makeTranaferResults(ip net.IP, bytesSent uint32) api.TransferResults {
+func makeTranaferResults(ip net.IP, bytesSent uint64) api.TransferResults {
 	return api.TransferResults{
 		IP:        ip,
 		BytesSent: bytesSent,
@@ -1,9 +1,16 @@
 package api_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
 	"net"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique"
 	"github.com/ice-stuff/clique/api"
 	"github.com/ice-stuff/clique/api/fakes"
@@ -14,24 +21,37 @@ import (
 
 var _ = Describe("Roundtrip", func() {
 	var (
-		port uint16
+		logger *logrus.Logger
+		port   uint16
 
 		fakeRegistry        *fakes.FakeRegistry
 		fakeTransferCreator *fakes.FakeTransferCreator
+		fakeScheduler       *fakes.FakeScheduler
+		fakeShutdowner      *fakes.FakeShutdowner
 		server              *api.Server
 
 		client *api.Client
 	)
 
 	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
 		port = testhelpers.SelectPort(GinkgoParallelNode())
 
 		fakeRegistry = new(fakes.FakeRegistry)
 		fakeTransferCreator = new(fakes.FakeTransferCreator)
+		fakeScheduler = new(fakes.FakeScheduler)
+		fakeShutdowner = new(fakes.FakeShutdowner)
 		server = api.NewServer(
-			port,
+			logger,
+			fmt.Sprintf(":%d", port),
 			fakeRegistry,
 			fakeTransferCreator,
+			fakeScheduler,
+			fakeShutdowner,
 		)
 
 		client = api.NewClient("127.0.0.1", port, 0)
@@ -86,7 +106,9 @@ var _ = Describe("Roundtrip", func() {
 			})
 
 			It("should return an error", func() {
-				server := api.NewServer(port, nil, nil)
+				server := api.NewServer(
+					logger, fmt.Sprintf(":%d", port), nil, nil, nil, nil,
+				)
 				Expect(server.Serve()).NotTo(Succeed())
 			})
 		})
@@ -114,9 +136,10 @@ var _ = Describe("Roundtrip", func() {
 					}
 
 					specB = api.TransferSpec{
-						IP:   net.ParseIP("127.0.0.18"),
-						Port: 2424,
-						Size: 2048,
+						IP:      net.ParseIP("127.0.0.18"),
+						Port:    2424,
+						Size:    2048,
+						Backend: "latency",
 					}
 
 					fakeRegistry.TransfersByStateStub = func(
@@ -177,6 +200,7 @@ var _ = Describe("Roundtrip", func() {
 							Duration:  time.Second * 12,
 							RTT:       time.Millisecond * 12,
 							Time:      t,
+							Size:      1024,
 						},
 						api.TransferResults{
 							IP:        net.ParseIP("12.15.12.18"),
@@ -185,6 +209,20 @@ var _ = Describe("Roundtrip", func() {
 							Duration:  time.Second * 29,
 							RTT:       time.Millisecond * 17,
 							Time:      t,
+							Size:      15 * 1024,
+							Throttled: true,
+							TCPInfo: &api.TCPInfo{
+								Retransmits:      2,
+								CongestionWindow: 10,
+								RTT:              time.Millisecond * 17,
+							},
+							SourceNodeID:      "node-a",
+							SourcePort:        5000,
+							SourceLabels:      map[string]string{"dc": "ams"},
+							DestinationNodeID: "node-b",
+							DestinationPort:   5001,
+							DestinationLabels: map[string]string{"dc": "fra"},
+							Backend:           "simple",
 						},
 					}
 					fakeRegistry.TransferResultsReturns(res)
@@ -204,6 +242,192 @@ var _ = Describe("Roundtrip", func() {
 						fakeRegistry.TransferResultsCallCount(),
 					).To(Equal(1))
 				})
+
+				Context("when a format is requested", func() {
+					It("should return the results in CSV", func() {
+						data, err := client.ExportTransferResults(api.ResultsFormatCSV)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(strings.Split(string(data), "\n")).To(Equal([]string{
+							"time,ip,bytes_sent,checksum,duration_ns,rtt_ns,size,throttled," +
+								"peer,source_node_id,destination_node_id,backend",
+							"2015-12-20T17:25:12Z,12.12.12.13,1024,124566,12000000000,12000000,1024,false,,,,",
+							"2015-12-20T17:25:12Z,12.15.12.18,15360,566124,29000000000,17000000,15360,true," +
+								",node-a,node-b,simple",
+							"",
+						}))
+					})
+
+					It("should return the results in JSON lines", func() {
+						data, err := client.ExportTransferResults(
+							api.ResultsFormatJSONLines,
+						)
+						Expect(err).NotTo(HaveOccurred())
+
+						lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+						Expect(lines).To(HaveLen(2))
+						var recvRes api.TransferResults
+						Expect(json.Unmarshal([]byte(lines[1]), &recvRes)).To(Succeed())
+						Expect(recvRes).To(Equal(res[1]))
+					})
+
+					It("should return the results in InfluxDB line protocol", func() {
+						data, err := client.ExportTransferResults(api.ResultsFormatInflux)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(string(data)).To(HavePrefix(
+							"transfer_results,ip=12.12.12.13 bytes_sent=1024i,checksum=124566i," +
+								"duration=12000000000i,rtt=12000000i,size=1024i,throttled=false 1450632312000000000\n",
+						))
+					})
+
+					It("should tag the results with the node identities and the backend", func() {
+						data, err := client.ExportTransferResults(api.ResultsFormatInflux)
+						Expect(err).NotTo(HaveOccurred())
+
+						lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+						Expect(lines).To(HaveLen(2))
+						Expect(lines[1]).To(HavePrefix(
+							"transfer_results,ip=12.15.12.18,source_node_id=node-a," +
+								"destination_node_id=node-b,backend=simple bytes_sent=15360i,",
+						))
+					})
+
+					Context("when the backend counts the datagrams", func() {
+						BeforeEach(func() {
+							res[1].Backend = "udp"
+							res[1].Packets = 100
+							res[1].LostPackets = 2
+							res[1].Jitter = 300 * time.Microsecond
+							fakeRegistry.TransferResultsReturns(res)
+						})
+
+						It("should add the loss and the jitter to the fields", func() {
+							data, err := client.ExportTransferResults(api.ResultsFormatInflux)
+							Expect(err).NotTo(HaveOccurred())
+
+							lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+							Expect(lines).To(HaveLen(2))
+							Expect(lines[0]).NotTo(ContainSubstring("packets"))
+							Expect(lines[1]).To(ContainSubstring(
+								"throttled=true,packets=100i,lost_packets=2i,jitter=300000i ",
+							))
+						})
+					})
+
+					Context("when the backend reports the retransmits and the CPU", func() {
+						BeforeEach(func() {
+							res[1].Backend = "iperf"
+							res[1].Retransmits = 7
+							res[1].SourceCPU = 12.5
+							res[1].DestinationCPU = 30
+							fakeRegistry.TransferResultsReturns(res)
+						})
+
+						It("should add them to the fields", func() {
+							data, err := client.ExportTransferResults(api.ResultsFormatInflux)
+							Expect(err).NotTo(HaveOccurred())
+
+							lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+							Expect(lines).To(HaveLen(2))
+							Expect(lines[0]).NotTo(ContainSubstring("retransmits"))
+							Expect(lines[0]).NotTo(ContainSubstring("cpu"))
+							Expect(lines[1]).To(ContainSubstring(
+								"throttled=true,retransmits=7i,source_cpu=12.5,destination_cpu=30 ",
+							))
+						})
+					})
+
+					It("should fail when the format is unknown", func() {
+						_, err := client.ExportTransferResults(api.ResultsFormat("xml"))
+						Expect(err).To(MatchError(ContainSubstring("Unknown results format")))
+					})
+				})
+
+				Context("when the format is negotiated with the Accept header", func() {
+					get := func(accept string) (*http.Response, []byte) {
+						req, err := http.NewRequest(
+							"GET", fmt.Sprintf("http://127.0.0.1:%d/transfer_results", port), nil,
+						)
+						Expect(err).NotTo(HaveOccurred())
+						req.Header.Set("Accept", accept)
+
+						resp, err := http.DefaultClient.Do(req)
+						Expect(err).NotTo(HaveOccurred())
+						defer resp.Body.Close()
+
+						data, err := ioutil.ReadAll(resp.Body)
+						Expect(err).NotTo(HaveOccurred())
+
+						return resp, data
+					}
+
+					It("should return CSV for text/csv", func() {
+						resp, data := get("text/html;q=0.9, text/csv")
+						Expect(resp.StatusCode).To(Equal(200))
+						Expect(resp.Header.Get("Content-Type")).To(HavePrefix("text/csv"))
+						Expect(string(data)).To(HavePrefix("time,ip,bytes_sent"))
+					})
+
+					It("should return JSON when there is no supported media type", func() {
+						resp, data := get("*/*")
+						Expect(resp.StatusCode).To(Equal(200))
+
+						var recvRes []api.TransferResults
+						Expect(json.Unmarshal(data, &recvRes)).To(Succeed())
+						Expect(recvRes).To(Equal(res))
+					})
+				})
+
+				Context("when the byte counters exceed 32 bits", func() {
+					BeforeEach(func() {
+						res[1].BytesSent = 5 * 1024 * 1024 * 1024
+						res[1].Size = 5 * 1024 * 1024 * 1024
+					})
+
+					It("should return the full counters to the client", func() {
+						recvRes, err := client.TransferResults()
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(recvRes[1].BytesSent).To(Equal(uint64(5 * 1024 * 1024 * 1024)))
+						Expect(recvRes[1].Size).To(Equal(uint64(5 * 1024 * 1024 * 1024)))
+					})
+
+					It("should return the full counters to clients without an API version", func() {
+						resp, err := http.Get(
+							fmt.Sprintf("http://127.0.0.1:%d/transfer_results", port),
+						)
+						Expect(err).NotTo(HaveOccurred())
+						defer resp.Body.Close()
+
+						var recvRes []api.TransferResults
+						Expect(json.NewDecoder(resp.Body).Decode(&recvRes)).To(Succeed())
+						Expect(recvRes[1].BytesSent).To(Equal(uint64(5 * 1024 * 1024 * 1024)))
+						Expect(resp.Header.Get(api.APIVersionHeader)).To(BeEmpty())
+					})
+
+					It("should saturate the counters for clients of the legacy API version", func() {
+						req, err := http.NewRequest(
+							"GET", fmt.Sprintf("http://127.0.0.1:%d/transfer_results", port), nil,
+						)
+						Expect(err).NotTo(HaveOccurred())
+						req.Header.Set(api.APIVersionHeader, api.LegacyAPIVersion)
+
+						resp, err := http.DefaultClient.Do(req)
+						Expect(err).NotTo(HaveOccurred())
+						defer resp.Body.Close()
+						Expect(resp.Header.Get(api.APIVersionHeader)).To(Equal(api.LegacyAPIVersion))
+
+						var recvRes []struct {
+							BytesSent uint32 `json:"bytes_sent"`
+							Size      uint32 `json:"size"`
+						}
+						Expect(json.NewDecoder(resp.Body).Decode(&recvRes)).To(Succeed())
+						Expect(recvRes[0].BytesSent).To(Equal(uint32(1024)))
+						Expect(recvRes[1].BytesSent).To(Equal(uint32(math.MaxUint32)))
+						Expect(recvRes[1].Size).To(Equal(uint32(math.MaxUint32)))
+					})
+				})
 			})
 
 			Describe("GET /transfer_results/<IP>", func() {
@@ -233,6 +457,17 @@ var _ = Describe("Roundtrip", func() {
 					Expect(recvRes).To(Equal(res))
 				})
 
+				It("should return the registry results in the requested format", func() {
+					data, err := client.ExportTransferResultsByIP(
+						net.ParseIP("12.12.12.13"), api.ResultsFormatJSONLines,
+					)
+					Expect(err).NotTo(HaveOccurred())
+
+					var recvRes api.TransferResults
+					Expect(json.Unmarshal(data, &recvRes)).To(Succeed())
+					Expect(recvRes).To(Equal(res[0]))
+				})
+
 				It("should call the registry with the correct argument", func() {
 					ip := net.ParseIP("12.12.12.13")
 
@@ -247,6 +482,364 @@ var _ = Describe("Roundtrip", func() {
 				})
 			})
 
+			Describe("GET /transfer_results?peer=<PEER>", func() {
+				var res []api.TransferResults
+
+				BeforeEach(func() {
+					res = []api.TransferResults{
+						api.TransferResults{
+							IP:        net.ParseIP("fe80::1"),
+							BytesSent: 1024,
+							Size:      1024,
+							Peer:      "[fe80::1]:5000",
+						},
+					}
+					fakeRegistry.TransferResultsByPeerReturns(res)
+				})
+
+				It("should return the registry results", func() {
+					recvRes, err := client.TransferResultsByPeer("[fe80::1]:5000")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvRes).To(Equal(res))
+				})
+
+				It("should call the registry with the peer", func() {
+					client.TransferResultsByPeer("[fe80::1]:5000")
+
+					Expect(fakeRegistry.TransferResultsByPeerCallCount()).To(Equal(1))
+					Expect(
+						fakeRegistry.TransferResultsByPeerArgsForCall(0),
+					).To(Equal("[fe80::1]:5000"))
+					Expect(fakeRegistry.TransferResultsCallCount()).To(BeZero())
+				})
+			})
+
+			Context("when the results are labelled", func() {
+				var res []api.TransferResults
+
+				BeforeEach(func() {
+					ams := map[string]string{"dc": "ams", "rack": "r1"}
+					fra := map[string]string{"dc": "fra", "rack": "r1"}
+					result := func(
+						src, dst map[string]string, bytes uint64, rtt time.Duration,
+					) api.TransferResults {
+						return api.TransferResults{
+							IP:                net.ParseIP("10.0.0.1"),
+							BytesSent:         bytes,
+							Duration:          time.Second,
+							RTT:               rtt,
+							SourceLabels:      src,
+							DestinationLabels: dst,
+						}
+					}
+					res = []api.TransferResults{
+						result(ams, fra, 1000, time.Millisecond*10),
+						result(ams, fra, 3000, time.Millisecond*30),
+						result(ams, fra, 2000, time.Millisecond*20),
+						result(fra, ams, 4000, time.Millisecond*40),
+						result(ams, nil, 5000, time.Millisecond*50),
+					}
+					fakeRegistry.TransferResultsReturns(res)
+				})
+
+				Describe("GET /transfer_results?source_labels=<LABELS>&destination_labels=<LABELS>", func() {
+					It("should return the results between the labelled nodes", func() {
+						recvRes, err := client.TransferResultsByLabels(
+							map[string]string{"dc": "ams"},
+							map[string]string{"dc": "fra"},
+						)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(recvRes).To(Equal(res[:3]))
+					})
+
+					It("should match the results by any subset of the labels", func() {
+						recvRes, err := client.TransferResultsByLabels(
+							map[string]string{"dc": "ams"}, nil,
+						)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(recvRes).To(HaveLen(4))
+					})
+
+					It("should reject invalid labels", func() {
+						resp, err := http.Get(fmt.Sprintf(
+							"http://127.0.0.1:%d/transfer_results?source_labels=dc", port,
+						))
+						Expect(err).NotTo(HaveOccurred())
+						defer resp.Body.Close()
+
+						Expect(resp.StatusCode).To(Equal(400))
+					})
+				})
+
+				Describe("GET /transfer_stats", func() {
+					It("should aggregate the results by the label pairs", func() {
+						stats, err := client.TransferStats([]string{"dc"}, nil, nil)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(stats).To(Equal([]api.TransferStats{
+							{
+								Source:           map[string]string{"dc": "ams"},
+								Destination:      map[string]string{"dc": ""},
+								Transfers:        1,
+								MinThroughput:    40000,
+								MedianThroughput: 40000,
+								MeanThroughput:   40000,
+								MaxThroughput:    40000,
+								MedianRTT:        time.Millisecond * 50,
+							},
+							{
+								Source:           map[string]string{"dc": "ams"},
+								Destination:      map[string]string{"dc": "fra"},
+								Transfers:        3,
+								MinThroughput:    8000,
+								MedianThroughput: 16000,
+								MeanThroughput:   16000,
+								MaxThroughput:    24000,
+								MedianRTT:        time.Millisecond * 20,
+							},
+							{
+								Source:           map[string]string{"dc": "fra"},
+								Destination:      map[string]string{"dc": "ams"},
+								Transfers:        1,
+								MinThroughput:    32000,
+								MedianThroughput: 32000,
+								MeanThroughput:   32000,
+								MaxThroughput:    32000,
+								MedianRTT:        time.Millisecond * 40,
+							},
+						}))
+					})
+
+					It("should aggregate only the results that match the labels", func() {
+						stats, err := client.TransferStats(
+							[]string{"dc", "rack"},
+							map[string]string{"dc": "ams"},
+							map[string]string{"dc": "fra"},
+						)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(stats).To(HaveLen(1))
+						Expect(stats[0].Source).To(Equal(
+							map[string]string{"dc": "ams", "rack": "r1"},
+						))
+						Expect(stats[0].Transfers).To(Equal(3))
+					})
+
+					It("should aggregate all the results when there is no grouping", func() {
+						stats, err := client.TransferStats(nil, nil, nil)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(stats).To(HaveLen(1))
+						Expect(stats[0].Transfers).To(Equal(5))
+						Expect(stats[0].MedianThroughput).To(BeNumerically("==", 24000))
+					})
+				})
+			})
+
+			Describe("GET /paths", func() {
+				var paths []api.PathResults
+
+				BeforeEach(func() {
+					t := time.Date(2015, 12, 20, 17, 25, 12, 0, time.UTC)
+					paths = []api.PathResults{
+						{
+							Peer:     "10.0.0.2:5000",
+							IP:       net.ParseIP("10.0.0.2"),
+							Protocol: "udp",
+							Time:     t,
+							Hops: []api.Hop{
+								{TTL: 1, IP: net.ParseIP("10.0.0.1"), RTT: time.Millisecond},
+								{TTL: 2},
+								{TTL: 3, IP: net.ParseIP("10.0.0.2"), RTT: time.Millisecond * 3},
+							},
+							Reached: true,
+						},
+						{
+							Peer:     "10.0.0.2:5000",
+							IP:       net.ParseIP("10.0.0.2"),
+							Protocol: "udp",
+							Time:     t.Add(time.Minute),
+							Hops: []api.Hop{
+								{TTL: 1, IP: net.ParseIP("10.0.0.2"), RTT: time.Millisecond},
+							},
+							Reached: true,
+							Changed: true,
+						},
+					}
+					fakeRegistry.PathsReturns(paths)
+					fakeRegistry.PathsByPeerReturns(paths[:1])
+				})
+
+				It("should return the registry paths", func() {
+					recvPaths, err := client.Paths()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvPaths).To(Equal(paths))
+				})
+
+				It("should return the paths of a peer", func() {
+					recvPaths, err := client.PathsByPeer("10.0.0.2:5000")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvPaths).To(Equal(paths[:1]))
+					Expect(fakeRegistry.PathsByPeerArgsForCall(0)).To(
+						Equal("10.0.0.2:5000"),
+					)
+				})
+
+				It("should return the changes of the paths", func() {
+					recvPaths, err := client.PathChanges()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvPaths).To(Equal(paths[1:]))
+				})
+			})
+
+			Describe("GET /mtu_results", func() {
+				var mtuResults []api.MTUResults
+
+				BeforeEach(func() {
+					t := time.Date(2015, 12, 20, 17, 25, 12, 0, time.UTC)
+					mtuResults = []api.MTUResults{
+						{
+							Peer:         "10.0.0.2:5000",
+							IP:           net.ParseIP("10.0.0.2"),
+							Time:         t,
+							PathMTU:      1500,
+							Interface:    "eth0",
+							InterfaceMTU: 9000,
+							Mismatch:     true,
+						},
+						{
+							Peer:         "10.0.0.3:5000",
+							IP:           net.ParseIP("10.0.0.3"),
+							Time:         t.Add(time.Minute),
+							PathMTU:      9000,
+							Interface:    "eth0",
+							InterfaceMTU: 9000,
+						},
+					}
+					fakeRegistry.MTUResultsReturns(mtuResults)
+					fakeRegistry.MTUResultsByPeerReturns(mtuResults[:1])
+				})
+
+				It("should return the registry path MTUs", func() {
+					recvResults, err := client.MTUResults()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvResults).To(Equal(mtuResults))
+				})
+
+				It("should return the path MTUs of a peer", func() {
+					recvResults, err := client.MTUResultsByPeer("10.0.0.2:5000")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvResults).To(Equal(mtuResults[:1]))
+					Expect(fakeRegistry.MTUResultsByPeerArgsForCall(0)).To(
+						Equal("10.0.0.2:5000"),
+					)
+				})
+			})
+
+			Describe("GET /transfer_results/<ID>/intervals", func() {
+				var intervals []api.TransferInterval
+
+				BeforeEach(func() {
+					intervals = []api.TransferInterval{
+						{Duration: time.Second, Bytes: 1024},
+						{Start: time.Second, Duration: time.Second, Bytes: 0},
+						{Start: 2 * time.Second, Duration: time.Second, Bytes: 2048},
+					}
+					fakeRegistry.TransferResultsByIDReturns(api.TransferResults{
+						ID:        "a1b2c3",
+						IP:        net.ParseIP("12.12.12.13"),
+						BytesSent: 3072,
+						Intervals: intervals,
+					}, true)
+				})
+
+				It("should return the intervals of the transfer", func() {
+					recvIntervals, err := client.TransferIntervals("a1b2c3")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvIntervals).To(Equal(intervals))
+				})
+
+				It("should call the registry with the transfer id", func() {
+					client.TransferIntervals("a1b2c3")
+
+					Expect(fakeRegistry.TransferResultsByIDCallCount()).To(Equal(1))
+					Expect(fakeRegistry.TransferResultsByIDArgsForCall(0)).To(Equal("a1b2c3"))
+				})
+
+				It("should not route to the results by IP", func() {
+					client.TransferIntervals("a1b2c3")
+
+					Expect(fakeRegistry.TransferResultsByIPCallCount()).To(BeZero())
+				})
+
+				Context("when the transfer is unknown", func() {
+					BeforeEach(func() {
+						fakeRegistry.TransferResultsByIDReturns(api.TransferResults{}, false)
+					})
+
+					It("should fail", func() {
+						_, err := client.TransferIntervals("banana")
+						Expect(err).To(MatchError(ContainSubstring("Unknown transfer")))
+					})
+				})
+			})
+
+			Describe("GET /transfer_results/<ID>/tcp_info", func() {
+				var (
+					tcpInfo api.TCPInfo
+					samples []api.TCPInfo
+				)
+
+				BeforeEach(func() {
+					tcpInfo = api.TCPInfo{
+						Offset:           2 * time.Second,
+						Retransmits:      4,
+						CongestionWindow: 12,
+						RTT:              15 * time.Millisecond,
+						RTTVar:           2 * time.Millisecond,
+						PacingRate:       300 * 1024 * 1024,
+						DeliveryRate:     120 * 1024 * 1024,
+					}
+					samples = []api.TCPInfo{
+						{Offset: time.Second, CongestionWindow: 10},
+						tcpInfo,
+					}
+					fakeRegistry.TransferResultsByIDReturns(api.TransferResults{
+						ID:             "a1b2c3",
+						IP:             net.ParseIP("12.12.12.13"),
+						TCPInfo:        &tcpInfo,
+						TCPInfoSamples: samples,
+					}, true)
+				})
+
+				It("should return the samples of the transfer", func() {
+					recvSamples, err := client.TransferTCPInfo("a1b2c3")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(recvSamples).To(Equal(samples))
+				})
+
+				Context("when the transfer is unknown", func() {
+					BeforeEach(func() {
+						fakeRegistry.TransferResultsByIDReturns(api.TransferResults{}, false)
+					})
+
+					It("should fail", func() {
+						_, err := client.TransferTCPInfo("banana")
+						Expect(err).To(MatchError(ContainSubstring("Unknown transfer")))
+					})
+				})
+			})
+
 			Describe("POST /transfers", func() {
 				var spec api.TransferSpec
 
@@ -268,6 +861,151 @@ var _ = Describe("Roundtrip", func() {
 					Expect(fakeTransferCreator.CreateCallCount()).To(Equal(1))
 					Expect(fakeTransferCreator.CreateArgsForCall(0)).To(Equal(spec))
 				})
+
+				Context("when the transfer is urgent", func() {
+					BeforeEach(func() {
+						spec.Priority = 20
+						deadline := time.Date(2015, 12, 20, 17, 25, 12, 0, time.UTC)
+						spec.Deadline = &deadline
+						spec.RunNow = true
+					})
+
+					It("should pass the priority, the deadline and the flag", func() {
+						Expect(client.CreateTransfer(spec)).To(Succeed())
+
+						Expect(fakeTransferCreator.CreateCallCount()).To(Equal(1))
+						Expect(fakeTransferCreator.CreateArgsForCall(0)).To(Equal(spec))
+					})
+				})
+
+				Context("when the peer is a hostname", func() {
+					BeforeEach(func() {
+						spec.IP = nil
+						spec.Host = "peer.example.com"
+					})
+
+					It("should pass the host", func() {
+						Expect(client.CreateTransfer(spec)).To(Succeed())
+
+						Expect(fakeTransferCreator.CreateCallCount()).To(Equal(1))
+						Expect(fakeTransferCreator.CreateArgsForCall(0)).To(Equal(spec))
+					})
+				})
+
+				Context("when the peer is missing", func() {
+					BeforeEach(func() {
+						spec.IP = nil
+					})
+
+					It("should fail", func() {
+						Expect(client.CreateTransfer(spec)).NotTo(Succeed())
+
+						Expect(fakeTransferCreator.CreateCallCount()).To(BeZero())
+					})
+				})
+
+				Context("when the source interface does not exist", func() {
+					BeforeEach(func() {
+						spec.SourceInterface = "banana0"
+					})
+
+					It("should fail", func() {
+						Expect(client.CreateTransfer(spec)).NotTo(Succeed())
+
+						Expect(fakeTransferCreator.CreateCallCount()).To(BeZero())
+					})
+				})
+
+				Context("when the transfer has a source address", func() {
+					BeforeEach(func() {
+						spec.SourceIP = net.ParseIP("10.0.0.1")
+					})
+
+					It("should pass the source address", func() {
+						Expect(client.CreateTransfer(spec)).To(Succeed())
+
+						Expect(fakeTransferCreator.CreateCallCount()).To(Equal(1))
+						Expect(fakeTransferCreator.CreateArgsForCall(0)).To(Equal(spec))
+					})
+				})
+
+				Context("when the priority is negative", func() {
+					BeforeEach(func() {
+						spec.Priority = -1
+					})
+
+					It("should fail", func() {
+						Expect(client.CreateTransfer(spec)).NotTo(Succeed())
+
+						Expect(fakeTransferCreator.CreateCallCount()).To(BeZero())
+					})
+				})
+			})
+
+			Describe("GET /scheduler", func() {
+				It("should return the scheduler status", func() {
+					startTime, err := time.Parse(time.RFC3339, "2015-12-20T17:25:12Z")
+					Expect(err).NotTo(HaveOccurred())
+
+					status := api.SchedulerStatus{
+						State:        "running",
+						Paused:       true,
+						Workers:      4,
+						RunningTasks: 1,
+						Tasks:        2,
+						Sleep:        time.Second,
+						TaskSelector: api.TaskSelectorStatus{
+							Name:   "lottery",
+							Params: map[string]string{"seed": "42"},
+						},
+						Queue: []api.ScheduledTask{
+							{
+								Task:      "transfer-a",
+								Priority:  15,
+								Share:     0.75,
+								Running:   true,
+								StartTime: startTime,
+							},
+							{Task: "transfer-b", Priority: 5, Share: 0.25},
+						},
+						Selections: []api.TaskSelection{
+							{Task: "transfer-a", Priority: 15, StartTime: startTime},
+							{
+								Task:      "transfer-b",
+								Priority:  5,
+								StartTime: startTime.Add(-time.Minute),
+								Duration:  12 * time.Second,
+							},
+						},
+					}
+					fakeScheduler.StatusReturns(status)
+
+					Expect(client.SchedulerStatus()).To(Equal(status))
+				})
+			})
+
+			Describe("POST /scheduler/pause", func() {
+				It("should pause the scheduler", func() {
+					Expect(client.PauseScheduler()).To(Succeed())
+
+					Expect(fakeScheduler.PauseCallCount()).To(Equal(1))
+				})
+			})
+
+			Describe("POST /scheduler/resume", func() {
+				It("should resume the scheduler", func() {
+					Expect(client.ResumeScheduler()).To(Succeed())
+
+					Expect(fakeScheduler.ResumeCallCount()).To(Equal(1))
+				})
+			})
+
+			Describe("POST /shutdown", func() {
+				It("should shut down the agent", func() {
+					Expect(client.Shutdown()).To(Succeed())
+
+					Expect(fakeShutdowner.ShutdownCallCount()).To(Equal(1))
+				})
 			})
 		})
 	})
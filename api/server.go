@@ -1,11 +1,15 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net"
+	"runtime"
+	"strings"
 	"sync"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/engine"
@@ -17,6 +21,12 @@ type Registry interface {
 	TransfersByState(state TransferState) []Transfer
 	TransferResults() []TransferResults
 	TransferResultsByIP(net.IP) []TransferResults
+	TransferResultsByPeer(peer string) []TransferResults
+	TransferResultsByID(id string) (TransferResults, bool)
+	Paths() []PathResults
+	PathsByPeer(peer string) []PathResults
+	MTUResults() []MTUResults
+	MTUResultsByPeer(peer string) []MTUResults
 }
 
 //go:generate counterfeiter . TransferCreator
@@ -24,12 +34,26 @@ type TransferCreator interface {
 	Create(TransferSpec)
 }
 
+//go:generate counterfeiter . Scheduler
+type Scheduler interface {
+	Status() SchedulerStatus
+	Pause()
+	Resume()
+}
+
+//go:generate counterfeiter . Shutdowner
+type Shutdowner interface {
+	Shutdown()
+}
+
 type SECode string
 
 const (
 	SERegistryFailed SECode = "registry-failed"
 	SEInvalidRequst         = "invalid-request"
 	SECreateFialed          = "create-failed"
+	SEEncodeFailed          = "encode-failed"
+	SENotFound              = "not-found"
 )
 
 type ServerError struct {
@@ -38,37 +62,63 @@ type ServerError struct {
 }
 
 type Server struct {
+	logger *logrus.Logger
+
 	addr string
 
 	httpServer engine.Server
 
 	registry        Registry
 	transferCreator TransferCreator
+	scheduler       Scheduler
+	shutdowner      Shutdowner
 
 	lock sync.Mutex
 }
 
+// NewServer creates a server that listens on the `host:port` address; it
+// listens on all the addresses of the host when the host is empty.
 func NewServer(
-	port uint16,
+	logger *logrus.Logger,
+	addr string,
 	registry Registry,
 	transferCreator TransferCreator,
+	scheduler Scheduler,
+	shutdowner Shutdowner,
 ) *Server {
-	addr := fmt.Sprintf(":%d", port)
-
 	s := &Server{
+		logger: logger,
+
 		addr: addr,
 
 		registry:        registry,
 		transferCreator: transferCreator,
+		scheduler:       scheduler,
+		shutdowner:      shutdowner,
 	}
 
 	e := echo.New()
-	e.Get("/ping", s.handleGetPing)
-	e.Get("/version", s.handleGetVersion)
-	e.Get("/transfers/:state", s.handleGetTransfers)
-	e.Get("/transfer_results", s.handleGetTransferResults)
-	e.Get("/transfer_results/:IP", s.handleGetTransferResultsByIP)
-	e.Post("/transfers", s.handlePostTransfers)
+	e.Get("/ping", s.logged(s.handleGetPing))
+	e.Get("/version", s.logged(s.handleGetVersion))
+	e.Get("/transfers/:state", s.logged(s.handleGetTransfers))
+	e.Get("/transfer_results", s.logged(s.handleGetTransferResults))
+	e.Get("/transfer_results/:IP", s.logged(s.handleGetTransferResultsByIP))
+	e.Get(
+		"/transfer_results/:id/intervals",
+		s.logged(s.handleGetTransferIntervals),
+	)
+	e.Get(
+		"/transfer_results/:id/tcp_info",
+		s.logged(s.handleGetTransferTCPInfo),
+	)
+	e.Get("/transfer_stats", s.logged(s.handleGetTransferStats))
+	e.Get("/paths", s.logged(s.handleGetPaths))
+	e.Get("/mtu_results", s.logged(s.handleGetMTUResults))
+	e.Post("/transfers", s.logged(s.handlePostTransfers))
+	e.Get("/scheduler", s.logged(s.handleGetScheduler))
+	e.Post("/scheduler/pause", s.logged(s.handlePostSchedulerPause))
+	e.Post("/scheduler/resume", s.logged(s.handlePostSchedulerResume))
+	e.Post("/shutdown", s.logged(s.handlePostShutdown))
 
 	s.httpServer = standard.New(addr)
 	s.httpServer.SetHandler(e)
@@ -76,6 +126,19 @@ func NewServer(
 	return s
 }
 
+func (s *Server) logged(handler echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		s.logger.WithFields(logrus.Fields{
+			"method": req.Method(),
+			"path":   req.URL().Path(),
+			"remote": req.RemoteAddress(),
+		}).Debug("Handling API request")
+
+		return handler(c)
+	}
+}
+
 func (s *Server) handleGetPing(c echo.Context) error {
 	return c.String(200, "")
 }
@@ -91,14 +154,80 @@ func (s *Server) handleGetTransfers(c echo.Context) error {
 	state := ParseTransferState(c.Param("state"))
 
 	res := s.registry.TransfersByState(state)
+	if isLegacyClient(c) {
+		for i := range res {
+			res[i] = res[i].Legacy()
+		}
+		c.Response().Header().Set(APIVersionHeader, LegacyAPIVersion)
+	}
 
 	return c.JSON(200, res)
 }
 
 func (s *Server) handleGetTransferResults(c echo.Context) error {
-	res := s.registry.TransferResults()
+	var res []TransferResults
+	if peer := c.QueryParam("peer"); peer != "" {
+		res = s.registry.TransferResultsByPeer(peer)
+	} else {
+		res = s.registry.TransferResults()
+	}
 
-	return c.JSON(200, res)
+	res, err := filterResultsByLabelParams(c, res)
+	if err != nil {
+		return s.invalidLabels(c, err)
+	}
+
+	return s.writeResults(c, res)
+}
+
+// handleGetTransferStats aggregates the results by the values of the
+// `group_by` labels, e.g. `group_by=dc` aggregates the transfers between
+// every pair of datacenters.
+func (s *Server) handleGetTransferStats(c echo.Context) error {
+	res, err := filterResultsByLabelParams(c, s.registry.TransferResults())
+	if err != nil {
+		return s.invalidLabels(c, err)
+	}
+
+	var groupBy []string
+	if groupByParam := c.QueryParam("group_by"); groupByParam != "" {
+		groupBy = strings.Split(groupByParam, ",")
+	}
+
+	return c.JSON(200, AggregateResults(res, groupBy))
+}
+
+// filterResultsByLabelParams keeps the results that match the
+// `source_labels` and `destination_labels` query parameters, which are in
+// the `key=value,key=value` form.
+func filterResultsByLabelParams(
+	c echo.Context, res []TransferResults,
+) ([]TransferResults, error) {
+	sourceParam := c.QueryParam("source_labels")
+	destinationParam := c.QueryParam("destination_labels")
+	if sourceParam == "" && destinationParam == "" {
+		return res, nil
+	}
+
+	source, err := ParseLabels(sourceParam)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := ParseLabels(destinationParam)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterResultsByLabels(res, source, destination), nil
+}
+
+func (s *Server) invalidLabels(c echo.Context, err error) error {
+	return c.JSON(
+		400, &ServerError{
+			Code: SEInvalidRequst,
+			Msg:  fmt.Sprintf("Invalid labels: %s", err),
+		},
+	)
 }
 
 func (s *Server) handleGetTransferResultsByIP(c echo.Context) error {
@@ -106,7 +235,139 @@ func (s *Server) handleGetTransferResultsByIP(c echo.Context) error {
 
 	res := s.registry.TransferResultsByIP(ip)
 
-	return c.JSON(200, res)
+	return s.writeResults(c, res)
+}
+
+func (s *Server) handleGetTransferIntervals(c echo.Context) error {
+	res, ok := s.registry.TransferResultsByID(c.Param("id"))
+	if !ok {
+		return s.unknownTransfer(c)
+	}
+
+	intervals := res.Intervals
+	if intervals == nil {
+		intervals = []TransferInterval{}
+	}
+
+	return c.JSON(200, intervals)
+}
+
+func (s *Server) handleGetTransferTCPInfo(c echo.Context) error {
+	res, ok := s.registry.TransferResultsByID(c.Param("id"))
+	if !ok {
+		return s.unknownTransfer(c)
+	}
+
+	samples := res.TCPInfoSamples
+	if samples == nil {
+		samples = []TCPInfo{}
+	}
+
+	return c.JSON(200, samples)
+}
+
+// handleGetPaths returns the discovered paths, optionally of a single peer
+// (`peer=<host:port>`) and only the ones that changed (`changed=true`).
+func (s *Server) handleGetPaths(c echo.Context) error {
+	var paths []PathResults
+	if peer := c.QueryParam("peer"); peer != "" {
+		paths = s.registry.PathsByPeer(peer)
+	} else {
+		paths = s.registry.Paths()
+	}
+
+	if c.QueryParam("changed") == "true" {
+		changed := []PathResults{}
+		for _, path := range paths {
+			if path.Changed {
+				changed = append(changed, path)
+			}
+		}
+		paths = changed
+	}
+
+	return c.JSON(200, paths)
+}
+
+// handleGetMTUResults returns the discovered path MTUs, optionally of a single
+// peer (`peer=<host:port>`).
+func (s *Server) handleGetMTUResults(c echo.Context) error {
+	if peer := c.QueryParam("peer"); peer != "" {
+		return c.JSON(200, s.registry.MTUResultsByPeer(peer))
+	}
+
+	return c.JSON(200, s.registry.MTUResults())
+}
+
+func (s *Server) unknownTransfer(c echo.Context) error {
+	return c.JSON(
+		404, &ServerError{
+			Code: SENotFound,
+			Msg:  fmt.Sprintf("Unknown transfer `%s`", c.Param("id")),
+		},
+	)
+}
+
+func (s *Server) writeResults(c echo.Context, res []TransferResults) error {
+	format := negotiateResultsFormat(c)
+	if format == ResultsFormatUnknown {
+		return c.JSON(
+			400, &ServerError{
+				Code: SEInvalidRequst,
+				Msg:  fmt.Sprintf("Unknown results format `%s`", c.QueryParam("format")),
+			},
+		)
+	}
+
+	if isLegacyClient(c) &&
+		(format == ResultsFormatJSON || format == ResultsFormatJSONLines) {
+		legacyRes := make([]TransferResults, len(res))
+		for i := range res {
+			legacyRes[i] = res[i].Legacy()
+		}
+		res = legacyRes
+		c.Response().Header().Set(APIVersionHeader, LegacyAPIVersion)
+	}
+
+	if format == ResultsFormatJSON {
+		return c.JSON(200, res)
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := EncodeResults(buffer, format, res); err != nil {
+		// untested return
+		return c.JSON(
+			500, &ServerError{
+				Code: SEEncodeFailed,
+				Msg:  fmt.Sprintf("Encoding results: %s", err),
+			},
+		)
+	}
+
+	return c.Blob(200, format.ContentType(), buffer.Bytes())
+}
+
+// isLegacyClient reports if the client asked for the 32-bit byte counters.
+func isLegacyClient(c echo.Context) bool {
+	return c.Request().Header().Get(APIVersionHeader) == LegacyAPIVersion
+}
+
+// negotiateResultsFormat prefers the `format` query parameter and falls back
+// to the first supported media type of the `Accept` header.
+func negotiateResultsFormat(c echo.Context) ResultsFormat {
+	if formatParam := c.QueryParam("format"); formatParam != "" {
+		return ParseResultsFormat(formatParam)
+	}
+
+	for _, mediaRange := range strings.Split(c.Request().Header().Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.Split(mediaRange, ";")[0])
+		format := ResultsFormatFromMediaType(mediaType)
+		if format != ResultsFormatUnknown {
+			return format
+		}
+	}
+
+	return ResultsFormatJSON
 }
 
 func (s *Server) handlePostTransfers(c echo.Context) error {
@@ -124,11 +385,74 @@ func (s *Server) handlePostTransfers(c echo.Context) error {
 		)
 	}
 
+	if spec.Host == "" && spec.IP == nil {
+		return c.JSON(
+			400, &ServerError{
+				Code: SEInvalidRequst,
+				Msg:  "Invalid transfer spec: host or ip is required",
+			},
+		)
+	}
+
+	if spec.SourceInterface != "" {
+		if runtime.GOOS != "linux" {
+			return c.JSON(
+				400, &ServerError{
+					Code: SEInvalidRequst,
+					Msg:  "Invalid transfer spec: source interfaces are only supported on linux",
+				},
+			)
+		}
+
+		if _, err := net.InterfaceByName(spec.SourceInterface); err != nil {
+			return c.JSON(
+				400, &ServerError{
+					Code: SEInvalidRequst,
+					Msg:  fmt.Sprintf("Invalid transfer spec: %s", err),
+				},
+			)
+		}
+	}
+
+	if spec.Priority < 0 {
+		return c.JSON(
+			400, &ServerError{
+				Code: SEInvalidRequst,
+				Msg:  "Invalid transfer spec: priority cannot be negative",
+			},
+		)
+	}
+
 	s.transferCreator.Create(spec)
 
 	return c.String(200, "")
 }
 
+func (s *Server) handleGetScheduler(c echo.Context) error {
+	return c.JSON(200, s.scheduler.Status())
+}
+
+func (s *Server) handlePostSchedulerPause(c echo.Context) error {
+	s.logger.Info("Scheduler pause is requested through the API")
+	s.scheduler.Pause()
+
+	return c.String(200, "")
+}
+
+func (s *Server) handlePostSchedulerResume(c echo.Context) error {
+	s.logger.Info("Scheduler resume is requested through the API")
+	s.scheduler.Resume()
+
+	return c.String(200, "")
+}
+
+func (s *Server) handlePostShutdown(c echo.Context) error {
+	s.logger.Info("Shutdown is requested through the API")
+	s.shutdowner.Shutdown()
+
+	return c.String(200, "")
+}
+
 func (s *Server) Serve() error {
 	return s.httpServer.Start()
 }
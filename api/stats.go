@@ -0,0 +1,179 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TransferStats aggregates the results of the transfers between the nodes
+// that have the same values of the grouping labels, e.g. the transfers from
+// `dc=ams` to `dc=fra`.
+type TransferStats struct {
+	// Values of the grouping labels at both ends. The labels that an end
+	// does not have are empty.
+	Source      map[string]string `json:"source"`
+	Destination map[string]string `json:"destination"`
+	Transfers   int               `json:"transfers"`
+	// Bits per second
+	MinThroughput    uint64        `json:"min_throughput"`
+	MedianThroughput uint64        `json:"median_throughput"`
+	MeanThroughput   uint64        `json:"mean_throughput"`
+	MaxThroughput    uint64        `json:"max_throughput"`
+	MedianRTT        time.Duration `json:"median_rtt"`
+}
+
+// ParseLabels parses labels in the `key=value,key=value` form.
+func ParseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if s == "" {
+		return labels, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label `%s`", pair)
+		}
+		labels[kv[0]] = kv[1]
+	}
+
+	return labels, nil
+}
+
+// FormatLabels formats labels in the `key=value,key=value` form, sorted by
+// key.
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// FilterResultsByLabels returns the results whose source and destination
+// have all the requested labels.
+func FilterResultsByLabels(
+	results []TransferResults, source, destination map[string]string,
+) []TransferResults {
+	filtered := []TransferResults{}
+	for _, res := range results {
+		if hasLabels(res.SourceLabels, source) &&
+			hasLabels(res.DestinationLabels, destination) {
+			filtered = append(filtered, res)
+		}
+	}
+
+	return filtered
+}
+
+func hasLabels(labels, selector map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AggregateResults groups the results by the values of the `groupBy` labels
+// of their source and destination. The results without a duration are not
+// aggregated. The stats are sorted by source and then by destination.
+func AggregateResults(
+	results []TransferResults, groupBy []string,
+) []TransferStats {
+	type group struct {
+		source, destination map[string]string
+		throughputs         []uint64
+		rtts                []time.Duration
+	}
+
+	groups := make(map[string]*group)
+	var keys []string
+	for _, res := range results {
+		if res.Duration <= 0 {
+			continue
+		}
+
+		source := selectLabels(res.SourceLabels, groupBy)
+		destination := selectLabels(res.DestinationLabels, groupBy)
+		key := FormatLabels(source) + "\x00" + FormatLabels(destination)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{source: source, destination: destination}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+
+		g.throughputs = append(
+			g.throughputs,
+			uint64(float64(res.BytesSent)*8/res.Duration.Seconds()),
+		)
+		g.rtts = append(g.rtts, res.RTT)
+	}
+	sort.Strings(keys)
+
+	stats := make([]TransferStats, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+
+		sort.Slice(g.throughputs, func(i, j int) bool {
+			return g.throughputs[i] < g.throughputs[j]
+		})
+		sort.Slice(g.rtts, func(i, j int) bool {
+			return g.rtts[i] < g.rtts[j]
+		})
+
+		var sum float64
+		for _, throughput := range g.throughputs {
+			sum += float64(throughput)
+		}
+
+		n := len(g.throughputs)
+		stats = append(stats, TransferStats{
+			Source:           g.source,
+			Destination:      g.destination,
+			Transfers:        n,
+			MinThroughput:    g.throughputs[0],
+			MedianThroughput: medianUint64(g.throughputs),
+			MeanThroughput:   uint64(sum / float64(n)),
+			MaxThroughput:    g.throughputs[n-1],
+			MedianRTT:        medianDuration(g.rtts),
+		})
+	}
+
+	return stats
+}
+
+func selectLabels(labels map[string]string, keys []string) map[string]string {
+	selected := make(map[string]string, len(keys))
+	for _, key := range keys {
+		selected[key] = labels[key]
+	}
+
+	return selected
+}
+
+// medianUint64 expects sorted values
+func medianUint64(values []uint64) uint64 {
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+
+	return values[n/2-1] + (values[n/2]-values[n/2-1])/2
+}
+
+// medianDuration expects sorted values
+func medianDuration(values []time.Duration) time.Duration {
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+
+	return (values[n/2-1] + values[n/2]) / 2
+}
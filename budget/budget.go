@@ -0,0 +1,120 @@
+package budget
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+)
+
+type Config struct {
+	// The budgets are renewed at the start of every window.
+	Window time.Duration
+	// Bytes that all the transfers may use in a window. Unlimited when zero.
+	Global uint64
+	// Bytes that the transfers to each peer may use in a window. Unlimited
+	// when zero.
+	PerPeer uint64
+}
+
+// Budget limits the bytes that the transfers use in fixed time windows.
+type Budget struct {
+	cfg   Config
+	clock clock.Clock
+
+	windowStart time.Time
+	used        uint64
+	usedByPeer  map[string]uint64
+
+	lock sync.Mutex
+}
+
+func New(cfg Config, clk clock.Clock) *Budget {
+	return &Budget{
+		cfg:        cfg,
+		clock:      clk,
+		usedByPeer: make(map[string]uint64),
+	}
+}
+
+// Reservation is the part of the budgets that a transfer took.
+type Reservation struct {
+	Peer string
+	Size uint64
+
+	// window that the bytes were taken out of
+	windowStart time.Time
+}
+
+// Limit returns the largest transfer that fits in the budgets of a window, or
+// zero when the transfers are unlimited. The larger transfers are never
+// reserved.
+func (b *Budget) Limit() uint64 {
+	limit := b.cfg.Global
+	if limit == 0 || (b.cfg.PerPeer != 0 && b.cfg.PerPeer < limit) {
+		limit = b.cfg.PerPeer
+	}
+
+	return limit
+}
+
+// Reserve reports if a transfer of `size` bytes to the peer fits in the
+// budgets of the current window and takes them out of the budgets if it does.
+// The bytes that the transfer does not send are given back with Refund.
+func (b *Budget) Reserve(peer string, size uint64) (Reservation, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.renew()
+
+	if b.cfg.Global != 0 && b.used+size > b.cfg.Global {
+		return Reservation{}, false
+	}
+	if b.cfg.PerPeer != 0 && b.usedByPeer[peer]+size > b.cfg.PerPeer {
+		return Reservation{}, false
+	}
+
+	b.used += size
+	b.usedByPeer[peer] += size
+
+	return Reservation{Peer: peer, Size: size, windowStart: b.windowStart}, true
+}
+
+// Refund gives `size` bytes of the reservation back to the budgets, e.g. when
+// the transfer failed before it sent anything. Nothing is refunded once the
+// window of the reservation has passed, as its budgets are renewed.
+func (b *Budget) Refund(r Reservation, size uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.renew()
+
+	if !r.windowStart.Equal(b.windowStart) {
+		return
+	}
+	if size > r.Size {
+		size = r.Size
+	}
+
+	b.used -= size
+	if size >= b.usedByPeer[r.Peer] {
+		delete(b.usedByPeer, r.Peer)
+	} else {
+		b.usedByPeer[r.Peer] -= size
+	}
+}
+
+func (b *Budget) renew() {
+	if b.cfg.Window <= 0 {
+		return
+	}
+
+	windowStart := b.clock.Now().Truncate(b.cfg.Window)
+	if windowStart.Equal(b.windowStart) {
+		return
+	}
+
+	b.windowStart = windowStart
+	b.used = 0
+	b.usedByPeer = make(map[string]uint64)
+}
@@ -0,0 +1,13 @@
+package budget_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Budget Suite")
+}
@@ -0,0 +1,121 @@
+package budget_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/ice-stuff/clique/budget"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Budget", func() {
+	var (
+		clk *fakeclock.FakeClock
+		cfg budget.Config
+		b   *budget.Budget
+	)
+
+	BeforeEach(func() {
+		t, err := time.Parse(time.RFC3339, "2015-11-24T06:30:00+00:00")
+		Expect(err).NotTo(HaveOccurred())
+		clk = fakeclock.NewFakeClock(t)
+
+		cfg = budget.Config{
+			Window:  time.Hour,
+			Global:  100,
+			PerPeer: 60,
+		}
+	})
+
+	JustBeforeEach(func() {
+		b = budget.New(cfg, clk)
+	})
+
+	reserve := func(peer string, size uint64) bool {
+		_, ok := b.Reserve(peer, size)
+		return ok
+	}
+
+	It("should allow the transfers that fit in the budgets", func() {
+		Expect(reserve("10.0.0.1:5000", 60)).To(BeTrue())
+		Expect(reserve("10.0.0.2:5000", 40)).To(BeTrue())
+	})
+
+	It("should enforce the per-peer budget", func() {
+		Expect(reserve("10.0.0.1:5000", 50)).To(BeTrue())
+		Expect(reserve("10.0.0.1:5000", 20)).To(BeFalse())
+		Expect(reserve("10.0.0.2:5000", 20)).To(BeTrue())
+	})
+
+	It("should enforce the global budget", func() {
+		Expect(reserve("10.0.0.1:5000", 50)).To(BeTrue())
+		Expect(reserve("10.0.0.2:5000", 40)).To(BeTrue())
+		Expect(reserve("10.0.0.3:5000", 20)).To(BeFalse())
+	})
+
+	It("should not take rejected transfers out of the budgets", func() {
+		Expect(reserve("10.0.0.1:5000", 70)).To(BeFalse())
+		Expect(reserve("10.0.0.1:5000", 60)).To(BeTrue())
+	})
+
+	It("should give the refunded bytes back to the budgets", func() {
+		r, ok := b.Reserve("10.0.0.1:5000", 60)
+		Expect(ok).To(BeTrue())
+		Expect(reserve("10.0.0.2:5000", 40)).To(BeTrue())
+
+		b.Refund(r, 50)
+		Expect(reserve("10.0.0.1:5000", 50)).To(BeTrue())
+		Expect(reserve("10.0.0.3:5000", 1)).To(BeFalse())
+	})
+
+	It("should not refund more than the reservation", func() {
+		r, ok := b.Reserve("10.0.0.1:5000", 10)
+		Expect(ok).To(BeTrue())
+		Expect(reserve("10.0.0.1:5000", 50)).To(BeTrue())
+
+		b.Refund(r, 60)
+		Expect(reserve("10.0.0.1:5000", 10)).To(BeTrue())
+		Expect(reserve("10.0.0.1:5000", 1)).To(BeFalse())
+	})
+
+	It("should not refund the reservations of the previous windows", func() {
+		r, ok := b.Reserve("10.0.0.1:5000", 60)
+		Expect(ok).To(BeTrue())
+
+		clk.Increment(time.Hour)
+		Expect(reserve("10.0.0.1:5000", 50)).To(BeTrue())
+		b.Refund(r, 60)
+
+		Expect(reserve("10.0.0.1:5000", 10)).To(BeTrue())
+		Expect(reserve("10.0.0.1:5000", 1)).To(BeFalse())
+	})
+
+	It("should limit the transfers to the smallest budget", func() {
+		Expect(b.Limit()).To(BeNumerically("==", 60))
+	})
+
+	It("should renew the budgets in every window", func() {
+		Expect(reserve("10.0.0.1:5000", 60)).To(BeTrue())
+		Expect(reserve("10.0.0.1:5000", 1)).To(BeFalse())
+
+		clk.Increment(time.Hour)
+		Expect(reserve("10.0.0.1:5000", 60)).To(BeTrue())
+	})
+
+	Context("when the budgets are unlimited", func() {
+		BeforeEach(func() {
+			cfg.Global = 0
+			cfg.PerPeer = 0
+		})
+
+		It("should not limit the transfers", func() {
+			Expect(b.Limit()).To(BeZero())
+		})
+
+		It("should allow every transfer", func() {
+			Expect(reserve("10.0.0.1:5000", 1<<31)).To(BeTrue())
+			Expect(reserve("10.0.0.1:5000", 1<<31)).To(BeTrue())
+		})
+	})
+})
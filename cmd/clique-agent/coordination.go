@@ -0,0 +1,21 @@
+package main
+
+import (
+	"code.cloudfoundry.org/clock"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/coordination"
+)
+
+// setupCoordinator returns nil in the best-effort mode.
+func setupCoordinator(cfg config.Config) (*coordination.RoundRobin, error) {
+	if cfg.Coordination.Mode != "round_robin" {
+		return nil, nil
+	}
+
+	return coordination.NewRoundRobin(
+		cfg.Coordination.Self,
+		cfg.RemoteHosts,
+		cfg.Coordination.SlotDuration.Duration(),
+		clock.NewClock(),
+	)
+}
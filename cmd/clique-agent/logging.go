@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/logging"
+)
+
+// setupLoggers builds the loggers of the agent. The `-debug` flag overrides
+// every configured level.
+func setupLoggers(cfg config.LogConfig, debug bool) (*logging.Loggers, error) {
+	loggingCfg := logging.Config{
+		Format:     cfg.Format,
+		Path:       cfg.Path,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		Levels:     make(map[string]logrus.Level),
+	}
+
+	if debug {
+		loggingCfg.Level = logrus.DebugLevel
+		return logging.New(loggingCfg)
+	}
+
+	// levels are validated when the configuration is loaded
+	loggingCfg.Level, _ = logrus.ParseLevel(cfg.Level)
+	for subsystem, levelStr := range cfg.Levels {
+		loggingCfg.Levels[subsystem], _ = logrus.ParseLevel(levelStr)
+	}
+
+	return logging.New(loggingCfg)
+}
@@ -16,10 +16,16 @@ import (
 	"github.com/ice-stuff/clique"
 	"github.com/ice-stuff/clique/api"
 	"github.com/ice-stuff/clique/api/registry"
+	"github.com/ice-stuff/clique/budget"
 	"github.com/ice-stuff/clique/config"
 	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/logging"
+	"github.com/ice-stuff/clique/resolver"
 	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/sizing"
+	"github.com/ice-stuff/clique/traceroute"
 	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/iperf3"
 )
 
 var (
@@ -39,67 +45,116 @@ func main() {
 		fmt.Printf("clique-agent v%s\n", clique.CliqueAgentVersion)
 		os.Exit(0)
 	}
-	level := logrus.InfoLevel
-	if *debug {
-		level = logrus.DebugLevel
-	}
 	if *configPath == "" {
 		fmt.Fprintf(os.Stderr, "`-config` option is required\n")
 		os.Exit(1)
 	}
 
+	///// CONFIGURATION /////////////////////////////////////////////////////////
+
+	cfg, err := config.NewConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
 	///// LOGGING ///////////////////////////////////////////////////////////////
 
-	logger := &logrus.Logger{
-		Out:       os.Stdout,
-		Level:     level,
-		Formatter: new(logrus.TextFormatter),
+	loggers, err := setupLoggers(cfg.Log, *debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Setting up logging: %s\n", err)
+		os.Exit(1)
 	}
+	logger := loggers.Root()
+	transferLogger := loggers.Subsystem(logging.SubsystemTransfer)
 	logger.Debug("Initializing internals...")
 
-	///// CONFIGURATION /////////////////////////////////////////////////////////
+	///// COORDINATION //////////////////////////////////////////////////////////
 
-	cfg, err := config.NewConfig(*configPath)
+	coordinator, err := setupCoordinator(cfg)
 	if err != nil {
-		logger.Fatal(err.Error())
+		logger.Fatalf("Setting up coordination: %s", err.Error())
 	}
 
 	///// TRANSFER //////////////////////////////////////////////////////////////
 
+	transferTimeouts := transfer.Timeouts{
+		Connect:  cfg.TransferTimeouts.Connect.Duration(),
+		Idle:     cfg.TransferTimeouts.Idle.Duration(),
+		Total:    cfg.TransferTimeouts.Total.Duration(),
+		Identity: cfg.TransferTimeouts.Identity.Duration(),
+	}
+
+	socketOpts := transfer.SocketOptions{
+		SendBuffer:        cfg.SimpleTransfer.Socket.SendBuffer,
+		ReceiveBuffer:     cfg.SimpleTransfer.Socket.ReceiveBuffer,
+		NoDelay:           *cfg.SimpleTransfer.Socket.NoDelay,
+		CongestionControl: cfg.SimpleTransfer.Socket.CongestionControl,
+	}
+
 	// Protocol
-	t, err := setupTransferrer(logger, cfg)
+	t, err := setupTransferrer(loggers, cfg, socketOpts, transferTimeouts)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	// Server
-	transferListener, err := net.Listen(
-		"tcp", fmt.Sprintf("0.0.0.0:%d", cfg.TransferPort),
+	// exchanged with the peers in the handshake of every transfer
+	identity := transfer.Identity{
+		NodeID: cfg.NodeID,
+		Labels: cfg.Labels,
+		Port:   cfg.TransferPort,
+	}
+	if coordinator != nil {
+		identity.Schedule = coordinator.Schedule()
+	}
+
+	// only the backends that send the data through the transfer connections
+	// sample them
+	tcpInfoInterval := cfg.MeasurementInterval.Duration()
+
+	// Server; without a bind address it listens on IPv4 and IPv6 where the
+	// system supports both
+	transferListener, err := transfer.Listen(
+		net.JoinHostPort(
+			cfg.TransferBindAddress, strconv.Itoa(int(cfg.TransferPort)),
+		),
+		socketOpts,
 	)
 	if err != nil {
 		logger.Fatalf("Setting up transfer server: %s", err.Error())
 	}
 	transferServer := transfer.NewServer(
-		logger, transferListener, t.transferReceiver,
+		transferLogger, identity, transferListener, t.backends,
+		transferTimeouts, tcpInfoInterval,
 	)
 
 	// Client
-	transferConnector := transfer.NewConnector()
-	transferClient := transfer.NewClient(
-		logger, transferConnector, t.transferSender,
+	transferConnector := transfer.NewConnector(socketOpts)
+	agentsClient := transfer.NewClient(
+		transferLogger, identity, transferConnector, t.backends,
+		transferTimeouts, tcpInfoInterval,
 	)
+	var iperfServersClient *iperf3.Client
+	if len(cfg.IperfServers) != 0 {
+		iperfServersClient, err = iperf3.NewClient(
+			loggers.Subsystem(logging.SubsystemIperf), identity,
+			transferConnector, iperf3Config(cfg, transferTimeouts),
+		)
+		if err != nil {
+			logger.Fatalf("Setting up the iperf3 client: %s", err.Error())
+		}
+	}
+	transferClient := newTransferRouter(agentsClient, iperfServersClient, cfg)
 
 	///// SCHEDULING ////////////////////////////////////////////////////////////
 
-	schedRandGen := scheduler.NewCryptoUIG()
-	schedTaskSelector := &scheduler.LotteryTaskSelector{
-		Rand: schedRandGen,
-	}
+	schedTaskSelector := setupTaskSelector(cfg)
 	schedClock := clock.NewClock()
 	sched := scheduler.NewScheduler(
-		logger,
-		schedTaskSelector, // scheduling algorithm
-		time.Second,       // sleep between tasks
+		loggers.Subsystem(logging.SubsystemScheduler),
+		schedTaskSelector,    // scheduling algorithm
+		cfg.SchedulerWorkers, // concurrent tasks
+		time.Second,          // sleep between tasks
 		schedClock,
 	)
 
@@ -107,6 +162,17 @@ func main() {
 
 	transferRegistry := registry.NewRegistry()
 
+	///// RESULT SINKS //////////////////////////////////////////////////////////
+
+	resultSinks, err := setupResultSinks(logger, cfg)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	dsptchrResultSinks := make([]dispatcher.ResultSink, len(resultSinks))
+	for i, s := range resultSinks {
+		dsptchrResultSinks[i] = s
+	}
+
 	///// DISPATCHER ////////////////////////////////////////////////////////////
 
 	dsptchr := &dispatcher.Dispatcher{
@@ -114,17 +180,62 @@ func main() {
 		TransferInterruptible: t.interruptible,
 		TransferClient:        transferClient,
 		ApiRegistry:           transferRegistry,
-		Logger:                logger,
+		ResultSinks:           dsptchrResultSinks,
+		Resolver: resolver.New(
+			transferLogger, net.DefaultResolver,
+			cfg.DNSRefreshInterval.Duration(), clock.NewClock(),
+		),
+		PeerLabels: peerLabels(cfg),
+		Clock:      clock.NewClock(),
+		Logger:     transferLogger,
+	}
+	if coordinator != nil {
+		dsptchr.Coordinator = coordinator
+	}
+	if cfg.BandwidthBudget.Global != 0 || cfg.BandwidthBudget.PerPeer != 0 {
+		dsptchr.Budget = budget.New(budget.Config{
+			Window:  cfg.BandwidthBudget.Window.Duration(),
+			Global:  cfg.BandwidthBudget.Global,
+			PerPeer: cfg.BandwidthBudget.PerPeer,
+		}, clock.NewClock())
+	}
+	if cfg.PathDiscovery.Interval != 0 {
+		dsptchr.PathTracer = traceroute.New(traceroute.Config{
+			Protocol:     cfg.PathDiscovery.Protocol,
+			MaxHops:      cfg.PathDiscovery.MaxHops,
+			ProbeTimeout: cfg.PathDiscovery.ProbeTimeout.Duration(),
+		})
+		dsptchr.PathProtocol = cfg.PathDiscovery.Protocol
+		dsptchr.PathInterval = cfg.PathDiscovery.Interval.Duration()
+	}
+	if cfg.MTUDiscovery.Interval != 0 {
+		dsptchr.MTUProber = traceroute.NewMTUProber(traceroute.MTUConfig{
+			ProbeTimeout: cfg.MTUDiscovery.ProbeTimeout.Duration(),
+			Retries:      cfg.MTUDiscovery.Retries,
+		})
+		dsptchr.MTUInterval = cfg.MTUDiscovery.Interval.Duration()
+	}
+	if cfg.TransferSizing.Mode == "adaptive" {
+		dsptchr.Sizer = sizing.NewAdaptive(sizing.AdaptiveConfig{
+			TargetDuration: cfg.TransferSizing.TargetDuration.Duration(),
+			MinSize:        cfg.TransferSizing.MinSize,
+			MaxSize:        cfg.TransferSizing.MaxSize,
+		})
 	}
 
 	///// API ///////////////////////////////////////////////////////////////////
 
+	shutdown := newShutdowner()
+
 	var apiServer *api.Server
 	if cfg.APIPort != 0 {
 		apiServer = api.NewServer(
-			cfg.APIPort,
+			loggers.Subsystem(logging.SubsystemAPI),
+			net.JoinHostPort(cfg.APIBindAddress, strconv.Itoa(int(cfg.APIPort))),
 			transferRegistry,
 			dsptchr,
+			scheduler.APIScheduler{Scheduler: sched},
+			shutdown,
 		)
 	}
 
@@ -133,14 +244,38 @@ func main() {
 	sigTermCh := make(chan os.Signal)
 	signal.Notify(sigTermCh, os.Interrupt)
 	signal.Notify(sigTermCh, syscall.SIGTERM)
+	schedDone := make(chan struct{})
 	go func() {
-		<-sigTermCh
+		select {
+		case <-sigTermCh:
+		case <-shutdown.Requested():
+		}
+		deadline := time.Now().Add(cfg.ShutdownGracePeriod.Duration())
 
 		logger.Debug("Closing transfer server...")
-		transferListener.Close()
+		transferServer.Close()
+		if t.iperfReceiver != nil {
+			t.iperfReceiver.Close()
+		}
 
 		logger.Debug("Closing scheduler...")
-		sched.Stop()
+		if err := sched.Stop(); err != nil {
+			logger.Debugf("Stopping scheduler: %s", err)
+		}
+
+		logger.Debug("Waiting for the outgoing transfers in progress...")
+		select {
+		case <-schedDone:
+		case <-time.After(deadline.Sub(time.Now())):
+			logger.Warn("Grace period expired, aborting the outgoing transfers...")
+			transferClient.Close()
+			<-schedDone
+		}
+
+		logger.Debug("Waiting for the incoming transfers in progress...")
+		if !transferServer.Drain(deadline.Sub(time.Now())) {
+			logger.Warn("Grace period expired, aborted the incoming transfers")
+		}
 
 		if apiServer != nil {
 			logger.Debug("Closing API server...")
@@ -156,7 +291,7 @@ func main() {
 	logger.Info("Clique Agent")
 
 	// Populate the dispatcher with tasks
-	if len(cfg.RemoteHosts) != 0 {
+	if len(cfg.RemoteHosts) != 0 || len(cfg.IperfServers) != 0 {
 		createTransferTasks(logger, cfg, dsptchr)
 	}
 
@@ -170,10 +305,21 @@ func main() {
 		wg.Done()
 	}()
 
+	// Start the iperf3 server
+	if t.iperfReceiver != nil {
+		wg.Add(1)
+		go func() {
+			t.iperfReceiver.Serve()
+			logger.Debug("Iperf3 server is done.")
+			wg.Done()
+		}()
+	}
+
 	// Start the scheduler
 	wg.Add(1)
 	go func() {
 		sched.Run()
+		close(schedDone)
 		logger.Debug("Scheduler is done.")
 		wg.Done()
 	}()
@@ -190,7 +336,11 @@ func main() {
 
 	// Wait until everything is done!
 	wg.Wait()
+
+	closeResultSinks(logger, resultSinks)
+
 	logger.Debug("Clique agent is done.")
+	loggers.Close()
 }
 
 func createTransferTasks(
@@ -199,27 +349,43 @@ func createTransferTasks(
 	dsptchr *dispatcher.Dispatcher,
 ) {
 	logger.Debugf(
-		"Found %d remote hosts for the initial transfers", len(cfg.RemoteHosts),
+		"Found %d remote hosts and %d iperf servers for the initial transfers",
+		len(cfg.RemoteHosts), len(cfg.IperfServers),
 	)
 	logger.Debugf("Size of initial transfers = %d bytes", cfg.InitTransferSize)
 
-	for _, remoteHost := range cfg.RemoteHosts {
-		host, portStr, err := net.SplitHostPort(remoteHost)
-		if err != nil {
-			logger.Fatalf("Parsing remote host `%s`: %s", remoteHost, err.Error())
-		}
-		port, err := strconv.ParseInt(portStr, 10, 16)
+	remoteHosts := make([]string, 0, len(cfg.RemoteHosts)+len(cfg.IperfServers))
+	remoteHosts = append(remoteHosts, cfg.RemoteHosts...)
+	remoteHosts = append(remoteHosts, cfg.IperfServers...)
+	for _, remoteHost := range remoteHosts {
+		host, port, err := transfer.ParsePeer(remoteHost)
 		if err != nil {
-			logger.Fatalf(
-				"Parsing remote host's port `%s`: %s",
-				remoteHost, err.Error(),
-			)
+			logger.Fatalf("Parsing remote host: %s", err.Error())
 		}
 
 		dsptchr.Create(api.TransferSpec{
-			IP:   net.ParseIP(host),
-			Port: uint16(port),
+			Host: host,
+			Port: port,
 			Size: cfg.InitTransferSize,
 		})
+		dsptchr.DiscoverPath(host, port)
+		dsptchr.DiscoverMTU(host, port)
+	}
+}
+
+// peerLabels keys the configured labels of the peers by the canonical form
+// of their addresses, which identifies the peers of the transfers.
+func peerLabels(cfg config.Config) map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(cfg.PeerLabels))
+	for addr, peerLabels := range cfg.PeerLabels {
+		host, port, err := transfer.ParsePeer(addr)
+		if err != nil {
+			// unreachable; the addresses are validated with the configuration
+			continue
+		}
+
+		labels[transfer.PeerName(host, port)] = peerLabels
 	}
+
+	return labels
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/scheduler"
+)
+
+func setupTaskSelector(cfg config.Config) scheduler.TaskSelector {
+	switch cfg.TaskSelector {
+	case "round_robin":
+		return scheduler.NewRoundRobinTaskSelector()
+	case "fair_share":
+		return scheduler.NewFairShareTaskSelector()
+	case "earliest_deadline_first":
+		return scheduler.NewEarliestDeadlineTaskSelector()
+	case "least_recently_measured":
+		return scheduler.NewLeastRecentlyMeasuredTaskSelector()
+	}
+
+	if cfg.TaskSelectorSeed != 0 {
+		return &scheduler.LotteryTaskSelector{
+			Rand: scheduler.NewSeededRandUIG(cfg.TaskSelectorSeed),
+			Seed: cfg.TaskSelectorSeed,
+		}
+	}
+
+	return &scheduler.LotteryTaskSelector{
+		Rand: scheduler.NewCryptoUIG(),
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "sync"
+
+// shutdowner is closed once, either by a signal or through the API.
+type shutdowner struct {
+	ch   chan struct{}
+	once sync.Once
+}
+
+func newShutdowner() *shutdowner {
+	return &shutdowner{
+		ch: make(chan struct{}),
+	}
+}
+
+func (s *shutdowner) Shutdown() {
+	s.once.Do(func() {
+		close(s.ch)
+	})
+}
+
+func (s *shutdowner) Requested() <-chan struct{} {
+	return s.ch
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/export"
+	"github.com/ice-stuff/clique/sink"
+)
+
+func setupResultSinks(logger *logrus.Logger, cfg config.Config) (
+	[]*sink.Buffered, error,
+) {
+	sinkCfgs := cfg.ResultSinks
+	if cfg.ExportPath != "" {
+		// the results export is a file sink that writes every result at once
+		sinkCfgs = append(sinkCfgs, config.ResultSinkConfig{
+			Type:       "file",
+			Format:     cfg.ExportFormat,
+			Path:       cfg.ExportPath,
+			MaxSize:    cfg.ExportMaxSize,
+			MaxBackups: cfg.ExportMaxBackups,
+			BufferSize: 1024,
+			BatchSize:  1,
+		})
+	}
+
+	sinks := []*sink.Buffered{}
+	for i, sinkCfg := range sinkCfgs {
+		s, err := newResultSink(sinkCfg)
+		if err != nil {
+			closeResultSinks(logger, sinks)
+			return nil, fmt.Errorf(
+				"setting up %s result sink #%d: %s", sinkCfg.Type, i, err,
+			)
+		}
+
+		sinks = append(sinks, sink.NewBuffered(
+			logger,
+			fmt.Sprintf("%s-%d", sinkCfg.Type, i),
+			s,
+			sink.BufferConfig{
+				BufferSize:    sinkCfg.BufferSize,
+				BatchSize:     sinkCfg.BatchSize,
+				FlushInterval: sinkCfg.FlushInterval.Duration(),
+			},
+			clock.NewClock(),
+		))
+	}
+
+	return sinks, nil
+}
+
+func newResultSink(cfg config.ResultSinkConfig) (sink.Sink, error) {
+	format := api.ParseResultsFormat(cfg.Format)
+
+	switch cfg.Type {
+	case "file":
+		return sink.NewFile(export.FileConfig{
+			Path:       cfg.Path,
+			Format:     format,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+		})
+	case "syslog":
+		return sink.NewSyslog(sink.SyslogConfig{
+			Network: cfg.Network,
+			Address: cfg.Address,
+			Tag:     cfg.Tag,
+			Format:  format,
+		})
+	case "statsd":
+		return sink.NewStatsD(sink.StatsDConfig{
+			Address: cfg.Address,
+			Prefix:  cfg.Prefix,
+		})
+	case "http":
+		return sink.NewHTTP(sink.HTTPConfig{
+			URL:     cfg.URL,
+			Timeout: cfg.Timeout.Duration(),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown type `%s`", cfg.Type)
+	}
+}
+
+func closeResultSinks(logger *logrus.Logger, sinks []*sink.Buffered) {
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			logger.Errorf("Closing result sink `%s`: %s", s.Name(), err)
+		}
+	}
+}
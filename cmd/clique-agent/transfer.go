@@ -1,36 +1,260 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/config"
 	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/logging"
 	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/iperf3"
+	"github.com/ice-stuff/clique/transfer/latency"
 	"github.com/ice-stuff/clique/transfer/simple"
+	"github.com/ice-stuff/clique/transfer/udp"
 )
 
+// transferrer holds the transfer backends of the agent in the order of its
+// preference.
 type transferrer struct {
-	interruptible    dispatcher.Interruptible
-	transferReceiver transfer.TransferReceiver
-	transferSender   transfer.TransferSender
+	backends transfer.Backends
+	// Receivers that give way to the outgoing transfers
+	interruptible interruptibles
+	// Serves the iperf port with the Go implementation of iperf3; nil without
+	// it.
+	iperfReceiver *iperf3.Receiver
 }
 
-func setupTransferrer(logger *logrus.Logger, cfg config.Config) (
-	transferrer, error,
-) {
-	if cfg.UseIperf {
-		return setupIperfTransferrer(logger, cfg)
+func setupTransferrer(
+	loggers *logging.Loggers, cfg config.Config,
+	socketOpts transfer.SocketOptions, timeouts transfer.Timeouts,
+) (transferrer, error) {
+	var t transferrer
+	for _, name := range cfg.Backends {
+		var (
+			backend       transfer.Backend
+			interruptible dispatcher.Interruptible
+			err           error
+		)
+
+		switch name {
+		case "simple":
+			backend, interruptible, err = setupSimpleBackend(
+				loggers.Subsystem(logging.SubsystemTransfer), cfg,
+			)
+		case "iperf":
+			logger := loggers.Subsystem(logging.SubsystemIperf)
+			if cfg.IperfImplementation == "go" {
+				backend, t.iperfReceiver, err = setupIperf3Backend(
+					logger, cfg, socketOpts, timeouts,
+				)
+				interruptible = t.iperfReceiver
+			} else {
+				backend, interruptible, err = setupIperfBackend(logger, cfg)
+			}
+		case "udp":
+			backend, interruptible = setupUDPBackend(
+				loggers.Subsystem(logging.SubsystemTransfer), cfg,
+			)
+		case "latency":
+			backend = setupLatencyBackend(
+				loggers.Subsystem(logging.SubsystemTransfer), cfg,
+			)
+		default:
+			// unreachable; the backends are validated with the configuration
+			err = errors.New("unknown backend")
+		}
+		if err != nil {
+			return transferrer{}, fmt.Errorf(
+				"setting up the `%s` backend: %s", name, err,
+			)
+		}
+
+		t.backends = append(t.backends, backend)
+		if interruptible != nil {
+			t.interruptible = append(t.interruptible, interruptible)
+		}
 	}
 
-	return setupSimpleTransferrer(logger, cfg)
+	return t, nil
 }
 
-func setupSimpleTransferrer(
+func setupSimpleBackend(
 	logger *logrus.Logger, cfg config.Config,
-) (transferrer, error) {
-	receiver := simple.NewReceiver(logger)
-	return transferrer{
-		interruptible:    receiver,
-		transferSender:   simple.NewSender(logger),
-		transferReceiver: receiver,
-	}, nil
+) (transfer.Backend, dispatcher.Interruptible, error) {
+	simpleCfg := simple.Config{
+		BufferSize:          cfg.SimpleTransfer.BufferSize,
+		ZeroCopy:            cfg.SimpleTransfer.ZeroCopy,
+		SkipChecksum:        cfg.SimpleTransfer.SkipChecksum,
+		RateLimit:           cfg.SendRateLimit,
+		MeasurementInterval: cfg.MeasurementInterval.Duration(),
+	}
+
+	receiver, err := simple.NewReceiver(simpleCfg)
+	if err != nil {
+		return transfer.Backend{}, nil, fmt.Errorf(
+			"setting up the receiver: %s", err,
+		)
+	}
+
+	sender, err := simple.NewSender(logger, simpleCfg)
+	if err != nil {
+		return transfer.Backend{}, nil, fmt.Errorf(
+			"setting up the sender: %s", err,
+		)
+	}
+
+	return transfer.Backend{
+		Name:       simple.BackendName,
+		Sender:     sender,
+		Receiver:   receiver,
+		DataOnConn: true,
+	}, receiver, nil
+}
+
+func setupIperf3Backend(
+	logger *logrus.Logger, cfg config.Config,
+	socketOpts transfer.SocketOptions, timeouts transfer.Timeouts,
+) (transfer.Backend, *iperf3.Receiver, error) {
+	listener, err := transfer.Listen(
+		net.JoinHostPort(cfg.TransferBindAddress, strconv.Itoa(int(cfg.IperfPort))),
+		socketOpts,
+	)
+	if err != nil {
+		return transfer.Backend{}, nil, fmt.Errorf(
+			"listening on the iperf port: %s", err,
+		)
+	}
+	receiver := iperf3.NewReceiver(logger, listener, iperf3Config(cfg, timeouts))
+
+	client, err := iperf3.NewClient(
+		logger, transfer.Identity{}, transfer.NewConnector(socketOpts),
+		iperf3Config(cfg, timeouts),
+	)
+	if err != nil {
+		listener.Close()
+		return transfer.Backend{}, nil, fmt.Errorf(
+			"setting up the sender: %s", err,
+		)
+	}
+
+	// the data goes through the connections of the iperf port
+	return transfer.Backend{
+		Name:     iperf3.BackendName,
+		Sender:   iperf3.NewSender(logger, client),
+		Receiver: receiver,
+	}, receiver, nil
+}
+
+func setupUDPBackend(
+	logger *logrus.Logger, cfg config.Config,
+) (transfer.Backend, dispatcher.Interruptible) {
+	receiver := udp.NewReceiver()
+	sender := udp.NewSender(logger, udp.Config{
+		Rate:         cfg.UDPTransfer.Rate,
+		DatagramSize: cfg.UDPTransfer.DatagramSize,
+	})
+
+	// the datagrams go through a UDP port of the receiver
+	return transfer.Backend{
+		Name:     udp.BackendName,
+		Sender:   sender,
+		Receiver: receiver,
+	}, receiver
+}
+
+// setupLatencyBackend sets up the backend of the latency probes, which are
+// light enough to run alongside the other transfers.
+func setupLatencyBackend(
+	logger *logrus.Logger, cfg config.Config,
+) transfer.Backend {
+	return transfer.Backend{
+		Name: latency.BackendName,
+		Sender: latency.NewSender(logger, latency.Config{
+			Probes:   cfg.LatencyTransfer.Probes,
+			Interval: cfg.LatencyTransfer.Interval.Duration(),
+		}),
+		Receiver:   latency.NewReceiver(),
+		DataOnConn: true,
+	}
+}
+
+// interruptibles are the receivers of the backends, which are all
+// interrupted during the outgoing transfers.
+type interruptibles []dispatcher.Interruptible
+
+func (is interruptibles) Interrupt() {
+	for _, i := range is {
+		i.Interrupt()
+	}
+}
+
+func (is interruptibles) Resume() {
+	for _, i := range is {
+		i.Resume()
+	}
+}
+
+func iperf3Config(cfg config.Config, timeouts transfer.Timeouts) iperf3.Config {
+	return iperf3.Config{
+		Parallel:            cfg.IperfOptions.Parallel,
+		Window:              cfg.IperfOptions.Window,
+		Bitrate:             cfg.IperfOptions.Bitrate,
+		Omit:                cfg.IperfOptions.Omit.Duration(),
+		MeasurementInterval: cfg.MeasurementInterval.Duration(),
+		Timeouts:            timeouts,
+	}
+}
+
+// transferRouter sends the transfers to the stock iperf3 servers with the
+// iperf3 client and the rest to the agents of the clique.
+type transferRouter struct {
+	agents *transfer.Client
+	// nil without iperf servers
+	iperfServers *iperf3.Client
+	// canonical `host:port` addresses of the iperf servers
+	iperfServerPeers map[string]struct{}
+}
+
+func newTransferRouter(
+	agents *transfer.Client, iperfServers *iperf3.Client, cfg config.Config,
+) *transferRouter {
+	peers := make(map[string]struct{}, len(cfg.IperfServers))
+	for _, addr := range cfg.IperfServers {
+		host, port, err := transfer.ParsePeer(addr)
+		if err != nil {
+			// unreachable; the addresses are validated with the configuration
+			continue
+		}
+
+		peers[transfer.PeerName(host, port)] = struct{}{}
+	}
+
+	return &transferRouter{
+		agents:           agents,
+		iperfServers:     iperfServers,
+		iperfServerPeers: peers,
+	}
+}
+
+func (r *transferRouter) Transfer(
+	ctx context.Context, spec transfer.TransferSpec,
+) (transfer.TransferResults, error) {
+	if _, ok := r.iperfServerPeers[spec.Peer()]; ok && r.iperfServers != nil {
+		return r.iperfServers.Transfer(ctx, spec)
+	}
+
+	return r.agents.Transfer(ctx, spec)
+}
+
+// Close aborts the outgoing transfers in progress.
+func (r *transferRouter) Close() {
+	r.agents.Close()
+	if r.iperfServers != nil {
+		r.iperfServers.Close()
+	}
 }
@@ -3,18 +3,35 @@
 package main
 
 import (
+	"net"
+
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/dispatcher"
 	"github.com/ice-stuff/clique/iperf"
+	"github.com/ice-stuff/clique/transfer"
 )
 
-func setupIperfTransferrer(
+func setupIperfBackend(
 	logger *logrus.Logger, cfg config.Config,
-) (transferrer, error) {
-	receiver := iperf.NewReceiver(logger, cfg.IperfPort)
-	return transferrer{
-		interruptible:    receiver,
-		transferSender:   iperf.NewSender(logger),
-		transferReceiver: receiver,
-	}, nil
+) (transfer.Backend, dispatcher.Interruptible, error) {
+	receiver := iperf.NewReceiver(
+		cfg.IperfPort, net.ParseIP(cfg.TransferBindAddress),
+	)
+	interval := cfg.MeasurementInterval.Duration()
+	opts := iperf.Options{
+		Parallel: cfg.IperfOptions.Parallel,
+		Reverse:  cfg.IperfOptions.Reverse,
+		Window:   cfg.IperfOptions.Window,
+		Bitrate:  cfg.IperfOptions.Bitrate,
+		Omit:     cfg.IperfOptions.Omit.Duration(),
+		UDP:      cfg.IperfOptions.UDP,
+	}
+
+	// the data goes through the connections of the iperf port
+	return transfer.Backend{
+		Name:     iperf.BackendName,
+		Sender:   iperf.NewSender(logger, interval, opts),
+		Receiver: receiver,
+	}, receiver, nil
 }
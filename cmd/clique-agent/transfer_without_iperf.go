@@ -7,12 +7,14 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/config"
+	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/transfer"
 )
 
-func setupIperfTransferrer(
+func setupIperfBackend(
 	logger *logrus.Logger, cfg config.Config,
-) (transferrer, error) {
-	return transferrer{}, errors.New(
+) (transfer.Backend, dispatcher.Interruptible, error) {
+	return transfer.Backend{}, nil, errors.New(
 		"clique-agent is not compiled with Iperf suppport",
 	)
 }
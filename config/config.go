@@ -5,16 +5,274 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/logging"
+	"github.com/ice-stuff/clique/transfer"
 )
 
 type Config struct {
-	TransferPort     uint16   `json:"transfer_port"`
-	APIPort          uint16   `json:"api_port"`
+	// Identity of the agent, which it exchanges with its peers in the
+	// handshake of every transfer. Default: the hostname.
+	NodeID string `json:"node_id"`
+	// Arbitrary key/values that describe the agent, e.g. its datacenter
+	// (`dc`), `rack` and `zone`. The results are grouped by them.
+	Labels map[string]string `json:"labels"`
+	// Labels of the peers by their `host:port` address. They take precedence
+	// over the labels that the peers advertise, and label the peers that
+	// advertise none.
+	PeerLabels map[string]map[string]string `json:"peer_labels"`
+
+	TransferPort uint16 `json:"transfer_port"`
+	APIPort      uint16 `json:"api_port"`
+	// Local IP addresses that the transfer server, including iperf, and the
+	// API listen on, to measure a specific NIC of a multi-homed host. They
+	// listen on all the addresses when these are empty.
+	TransferBindAddress string `json:"transfer_bind_address"`
+	APIBindAddress      string `json:"api_bind_address"`
+	// `host:port` addresses of the peers of the initial transfers. The host
+	// is a hostname or an IPv4 or IPv6 address; IPv6 addresses are in
+	// brackets.
 	RemoteHosts      []string `json:"remote_hosts"`
-	InitTransferSize uint32   `json:"init_transfer_size"`
-	// Iperf settings
+	InitTransferSize uint64   `json:"init_transfer_size"`
+	// `host:port` addresses of stock iperf3 servers, e.g. `iperf3 -s`, that
+	// are measured along with the remote hosts. The agent measures them with
+	// its Go implementation of iperf3.
+	IperfServers []string `json:"iperf_servers"`
+	// Time that the address of a peer hostname is used before the hostname is
+	// resolved again. Default: 1m.
+	DNSRefreshInterval Duration `json:"dns_refresh_interval"`
+	// Sizing of every transfer; the requested size is the starting point
+	TransferSizing SizingConfig `json:"transfer_sizing"`
+	// Amount of transfers that run concurrently, at most one per peer.
+	// Default: 1.
+	SchedulerWorkers int `json:"scheduler_workers"`
+	// Algorithm that selects the next task to run: `lottery`, `round_robin`,
+	// `fair_share`, `earliest_deadline_first` or `least_recently_measured`.
+	// Default: lottery.
+	TaskSelector string `json:"task_selector"`
+	// Seed of the lottery, for reproducible runs. The lottery uses a
+	// cryptographically secure generator when it is zero.
+	TaskSelectorSeed int64 `json:"task_selector_seed"`
+	// Bytes that the transfers may use in every window
+	BandwidthBudget BudgetConfig `json:"bandwidth_budget"`
+	// Bytes per second that every simple transfer may send. Unlimited when
+	// zero.
+	SendRateLimit uint64 `json:"send_rate_limit"`
+	// Interval of the throughput series of every transfer. Iperf rounds it to
+	// whole seconds. Default: 1s.
+	MeasurementInterval Duration `json:"measurement_interval"`
+	// Transfer backends that the agent supports, in the order of its
+	// preference: `simple`, `iperf`, `udp` and `latency`. The agents
+	// advertise them to each other and every transfer uses the backend that
+	// it requests, when both ends support it, or the most preferred one that
+	// both ends support. Default: `iperf` with use_iperf and `simple`
+	// otherwise.
+	Backends []string `json:"backends"`
+	// Data path of the simple transfers
+	SimpleTransfer SimpleTransferConfig `json:"simple_transfer"`
+	// Pacing of the udp transfers
+	UDPTransfer UDPTransferConfig `json:"udp_transfer"`
+	// Probes of the latency transfers
+	LatencyTransfer LatencyTransferConfig `json:"latency_transfer"`
+	// Iperf settings. use_iperf is the same as the `iperf` backend alone.
 	UseIperf  bool   `json:"use_iperf"`
 	IperfPort uint16 `json:"iperf_port"`
+	// `libiperf`, which needs an agent that is built with the `withIperf`
+	// tag, or `go`. Both speak iperf3 on the iperf port. Default: libiperf.
+	IperfImplementation string `json:"iperf_implementation"`
+	// Options of the iperf tests that the agent sends
+	IperfOptions IperfOptionsConfig `json:"iperf_options"`
+	// Results export settings
+	ExportPath       string `json:"export_path"`
+	ExportFormat     string `json:"export_format"`
+	ExportMaxSize    int64  `json:"export_max_size"`
+	ExportMaxBackups int    `json:"export_max_backups"`
+	// Result sinks
+	ResultSinks []ResultSinkConfig `json:"result_sinks"`
+	// Logging settings
+	Log LogConfig `json:"log"`
+	// Time to wait for the transfers in progress before aborting them on
+	// shutdown. Default: 30s.
+	ShutdownGracePeriod Duration `json:"shutdown_grace_period"`
+	// Timeouts of every incoming and outgoing transfer
+	TransferTimeouts TimeoutsConfig `json:"transfer_timeouts"`
+	// Coordination of the measurements with the rest of the clique
+	Coordination CoordinationConfig `json:"coordination"`
+	// Periodic discovery of the paths to the remote hosts
+	PathDiscovery PathDiscoveryConfig `json:"path_discovery"`
+	// Periodic discovery of the path MTU to the remote hosts
+	MTUDiscovery MTUDiscoveryConfig `json:"mtu_discovery"`
+}
+
+type PathDiscoveryConfig struct {
+	// Time between the discoveries of the path to every remote host. The
+	// paths are not discovered when it is zero. Linux only.
+	Interval Duration `json:"interval"`
+	// `udp` probes a closed UDP port of the peers, `tcp` opens connections
+	// to their transfer port. Default: udp.
+	Protocol string `json:"protocol"`
+	// Default: 30.
+	MaxHops int `json:"max_hops"`
+	// Time to wait for the answer of every hop. Default: 1s.
+	ProbeTimeout Duration `json:"probe_timeout"`
+}
+
+type MTUDiscoveryConfig struct {
+	// Time between the discoveries of the path MTU to every remote host. The
+	// path MTUs are not discovered when it is zero. Linux only.
+	Interval Duration `json:"interval"`
+	// Time to wait for the answer of every probe. Default: 1s.
+	ProbeTimeout Duration `json:"probe_timeout"`
+	// Probes that get no answer are sent again this amount of times.
+	// Default: 2.
+	Retries int `json:"retries"`
+}
+
+type CoordinationConfig struct {
+	// `best_effort` or `round_robin`. Default: best_effort.
+	Mode string `json:"mode"`
+	// Address of this agent as it appears in the `remote_hosts` of the other
+	// agents. Required by the `round_robin` mode.
+	Self string `json:"self"`
+	// Duration of every measurement slot. Default: 30s.
+	SlotDuration Duration `json:"slot_duration"`
+}
+
+type SizingConfig struct {
+	// `fixed` or `adaptive`. Default: fixed.
+	Mode string `json:"mode"`
+	// Duration that every adaptive transfer should last. Default: 10s.
+	TargetDuration Duration `json:"target_duration"`
+	// Bounds of the adaptive transfer sizes. Default: 1MB to 1GB.
+	MinSize uint64 `json:"min_size"`
+	MaxSize uint64 `json:"max_size"`
+}
+
+type SimpleTransferConfig struct {
+	// Size of the blocks that are sent and received. Default: 128KB.
+	BufferSize int `json:"buffer_size"`
+	// Send with sendfile(2) and discard the received data with splice(2).
+	// Linux only. The receiver discards with splice(2) only when the
+	// checksums are skipped.
+	ZeroCopy bool `json:"zero_copy"`
+	// Do not compute the checksums of the transfers.
+	SkipChecksum bool `json:"skip_checksum"`
+	// Tuning of the sockets of the transfer port. It also applies to the
+	// handshakes of iperf.
+	Socket SocketConfig `json:"socket"`
+}
+
+type UDPTransferConfig struct {
+	// Bytes per second that every udp transfer sends. Default: 10Mbit/s.
+	Rate uint64 `json:"rate"`
+	// Size of the datagrams, between 16 and 65507 bytes. Default: 1400.
+	DatagramSize int `json:"datagram_size"`
+}
+
+type LatencyTransferConfig struct {
+	// Round trips of every latency transfer. Default: 10.
+	Probes int `json:"probes"`
+	// Time between the round trips, which follow each other when it is zero.
+	Interval Duration `json:"interval"`
+}
+
+type IperfOptionsConfig struct {
+	// Parallel streams of every test, at most 128. Default: 1.
+	Parallel int `json:"parallel"`
+	// The peer sends the data of the tests. libiperf only.
+	Reverse bool `json:"reverse"`
+	// Socket buffer size of the streams in bytes. The system picks it when
+	// it is zero.
+	Window int `json:"window"`
+	// Bits per second that every stream may send. Default: unlimited for TCP
+	// and 1Mbit/s for UDP.
+	Bitrate uint64 `json:"bitrate"`
+	// Time at the start of every test that is omitted from the results, e.g.
+	// the TCP slow start. Iperf rounds it to whole seconds.
+	Omit Duration `json:"omit"`
+	// Measure UDP, which reports the jitter and the loss. libiperf only.
+	UDP bool `json:"udp"`
+}
+
+type SocketConfig struct {
+	// SO_SNDBUF and SO_RCVBUF in bytes. The system defaults are used when
+	// they are zero.
+	SendBuffer    int `json:"send_buffer"`
+	ReceiveBuffer int `json:"receive_buffer"`
+	// TCP_NODELAY. Default: true.
+	NoDelay *bool `json:"no_delay"`
+	// TCP congestion control algorithm, e.g. `bbr`. Linux only. The system
+	// default is used when it is empty.
+	CongestionControl string `json:"congestion_control"`
+}
+
+type BudgetConfig struct {
+	// Default: 1h.
+	Window Duration `json:"window"`
+	// Budget of all the transfers. Unlimited when zero.
+	Global uint64 `json:"global"`
+	// Budget of the transfers to each peer. Unlimited when zero.
+	PerPeer uint64 `json:"per_peer"`
+}
+
+type TimeoutsConfig struct {
+	// Time to establish a connection to the peer. Default: 10s.
+	Connect Duration `json:"connect"`
+	// Time that the transfer may make no progress. Default: 30s.
+	Idle Duration `json:"idle"`
+	// Duration of the whole transfer. Default: 10m.
+	Total Duration `json:"total"`
+	// Time that the agent waits for the identity of an incoming transfer
+	// before it serves the peer as an agent that predates the identities.
+	// The outgoing transfers wait for a tenth of it for the greeting of such
+	// an agent. Default: 2s.
+	Identity Duration `json:"identity"`
+}
+
+type LogConfig struct {
+	// `text` or `json`. Default: text.
+	Format string `json:"format"`
+	// One of `debug`, `info`, `warning` or `error`. Default: info.
+	Level string `json:"level"`
+	// Log file. The logs are written to the standard output when empty.
+	Path       string `json:"path"`
+	MaxSize    int64  `json:"max_size"`
+	MaxBackups int    `json:"max_backups"`
+	// Levels of the `scheduler`, `transfer`, `api` and `iperf` subsystems.
+	// Subsystems that are not listed use the global level.
+	Levels map[string]string `json:"levels"`
+}
+
+type ResultSinkConfig struct {
+	// One of `file`, `syslog`, `statsd` or `http`.
+	Type string `json:"type"`
+	// Encoding of the `file` and `syslog` results.
+	Format string `json:"format"`
+	// File sink settings
+	Path       string `json:"path"`
+	MaxSize    int64  `json:"max_size"`
+	MaxBackups int    `json:"max_backups"`
+	// Remote endpoint of the `syslog` and `statsd` sinks. The `syslog` sink
+	// uses the local daemon when it is empty.
+	Network string `json:"network"`
+	Address string `json:"address"`
+	// Syslog tag
+	Tag string `json:"tag"`
+	// StatsD metric prefix
+	Prefix string `json:"prefix"`
+	// HTTP sink settings
+	URL     string   `json:"url"`
+	Timeout Duration `json:"timeout"`
+	// Buffering
+	BufferSize    int      `json:"buffer_size"`
+	BatchSize     int      `json:"batch_size"`
+	FlushInterval Duration `json:"flush_interval"`
 }
 
 func NewConfig(configPath string) (Config, error) {
@@ -42,16 +300,528 @@ func validateConfig(cfg Config) error {
 		return errors.New("transfer port is not defined")
 	}
 
+	if err := validateLabels(cfg.Labels); err != nil {
+		return err
+	}
+
+	for peer, labels := range cfg.PeerLabels {
+		if _, _, err := transfer.ParsePeer(peer); err != nil {
+			return fmt.Errorf("peer labels: %s", err)
+		}
+		if err := validateLabels(labels); err != nil {
+			return fmt.Errorf("peer labels of `%s`: %s", peer, err)
+		}
+	}
+
+	for _, server := range cfg.IperfServers {
+		if _, _, err := transfer.ParsePeer(server); err != nil {
+			return fmt.Errorf("iperf servers: %s", err)
+		}
+	}
+
+	if err := validateBackends(cfg.Backends); err != nil {
+		return fmt.Errorf("backends: %s", err)
+	}
+
+	switch cfg.IperfImplementation {
+	case "", "libiperf", "go":
+	default:
+		return fmt.Errorf(
+			"unknown iperf implementation `%s`", cfg.IperfImplementation,
+		)
+	}
+
+	if err := validateIperfOptionsConfig(cfg); err != nil {
+		return fmt.Errorf("iperf options: %s", err)
+	}
+
+	if cfg.TransferBindAddress != "" && net.ParseIP(cfg.TransferBindAddress) == nil {
+		return fmt.Errorf(
+			"transfer bind address `%s` is not an IP address",
+			cfg.TransferBindAddress,
+		)
+	}
+
+	if cfg.APIBindAddress != "" && net.ParseIP(cfg.APIBindAddress) == nil {
+		return fmt.Errorf(
+			"api bind address `%s` is not an IP address", cfg.APIBindAddress,
+		)
+	}
+
+	if cfg.DNSRefreshInterval < 0 {
+		return errors.New("dns refresh interval is negative")
+	}
+
+	if cfg.ExportPath != "" && cfg.ExportFormat != "" {
+		if !isStreamableFormat(cfg.ExportFormat) {
+			return fmt.Errorf(
+				"export format `%s` is not supported", cfg.ExportFormat,
+			)
+		}
+	}
+
+	for i, sinkCfg := range cfg.ResultSinks {
+		if err := validateResultSinkConfig(sinkCfg); err != nil {
+			return fmt.Errorf("result sink #%d: %s", i, err)
+		}
+	}
+
+	if err := validateLogConfig(cfg.Log); err != nil {
+		return fmt.Errorf("log: %s", err)
+	}
+
+	if cfg.SchedulerWorkers < 0 {
+		return errors.New("scheduler workers cannot be negative")
+	}
+
+	if !isTaskSelector(cfg.TaskSelector) {
+		return fmt.Errorf("unknown task selector `%s`", cfg.TaskSelector)
+	}
+
+	if cfg.ShutdownGracePeriod < 0 {
+		return errors.New("shutdown grace period is negative")
+	}
+
+	timeouts := cfg.TransferTimeouts
+	if timeouts.Connect < 0 || timeouts.Idle < 0 || timeouts.Total < 0 ||
+		timeouts.Identity < 0 {
+		return errors.New("transfer timeouts cannot be negative")
+	}
+
+	if err := validateSizingConfig(cfg.TransferSizing); err != nil {
+		return fmt.Errorf("transfer sizing: %s", err)
+	}
+
+	if err := validateSimpleTransferConfig(cfg.SimpleTransfer); err != nil {
+		return fmt.Errorf("simple transfer: %s", err)
+	}
+
+	if err := validateUDPTransferConfig(cfg.UDPTransfer); err != nil {
+		return fmt.Errorf("udp transfer: %s", err)
+	}
+
+	if cfg.LatencyTransfer.Probes < 0 || cfg.LatencyTransfer.Interval < 0 {
+		return errors.New("latency transfer: probes and interval cannot be negative")
+	}
+
+	if cfg.MeasurementInterval < 0 {
+		return errors.New("measurement interval is negative")
+	}
+
+	if cfg.BandwidthBudget.Window < 0 {
+		return errors.New("bandwidth budget window is negative")
+	}
+
+	if err := validateCoordinationConfig(cfg.Coordination); err != nil {
+		return fmt.Errorf("coordination: %s", err)
+	}
+
+	if err := validatePathDiscoveryConfig(cfg.PathDiscovery); err != nil {
+		return fmt.Errorf("path discovery: %s", err)
+	}
+
+	if err := validateMTUDiscoveryConfig(cfg.MTUDiscovery); err != nil {
+		return fmt.Errorf("MTU discovery: %s", err)
+	}
+
+	return nil
+}
+
+func validateMTUDiscoveryConfig(cfg MTUDiscoveryConfig) error {
+	if cfg.Interval < 0 || cfg.ProbeTimeout < 0 || cfg.Retries < 0 {
+		return errors.New("interval, probe timeout and retries cannot be negative")
+	}
+
+	return nil
+}
+
+func validatePathDiscoveryConfig(cfg PathDiscoveryConfig) error {
+	switch cfg.Protocol {
+	case "", "udp", "tcp":
+	default:
+		return fmt.Errorf("unknown protocol `%s`", cfg.Protocol)
+	}
+
+	if cfg.Interval < 0 || cfg.ProbeTimeout < 0 {
+		return errors.New("interval and probe timeout cannot be negative")
+	}
+
+	if cfg.MaxHops < 0 || cfg.MaxHops > 255 {
+		return errors.New("max hops must be between 1 and 255")
+	}
+
+	return nil
+}
+
+func isTaskSelector(selector string) bool {
+	switch selector {
+	case "", "lottery", "round_robin", "fair_share", "earliest_deadline_first",
+		"least_recently_measured":
+		return true
+	default:
+		return false
+	}
+}
+
+func validateSizingConfig(cfg SizingConfig) error {
+	switch cfg.Mode {
+	case "", "fixed", "adaptive":
+	default:
+		return fmt.Errorf("unknown mode `%s`", cfg.Mode)
+	}
+
+	if cfg.TargetDuration < 0 {
+		return errors.New("target duration is negative")
+	}
+
+	if cfg.MaxSize != 0 && cfg.MinSize > cfg.MaxSize {
+		return errors.New("min size is greater than max size")
+	}
+
+	return nil
+}
+
+func validateBackends(backends []string) error {
+	seen := make(map[string]bool, len(backends))
+	for _, backend := range backends {
+		switch backend {
+		case "simple", "iperf", "udp", "latency":
+		default:
+			return fmt.Errorf("unknown backend `%s`", backend)
+		}
+
+		if seen[backend] {
+			return fmt.Errorf("backend `%s` is listed twice", backend)
+		}
+		seen[backend] = true
+	}
+
+	return nil
+}
+
+// validateIperfOptionsConfig checks the iperf options against the iperf
+// implementation. The Go implementation, which also measures the iperf
+// servers, only sends TCP tests.
+func validateIperfOptionsConfig(cfg Config) error {
+	opts := cfg.IperfOptions
+	if opts.Parallel < 0 || opts.Parallel > 128 {
+		return fmt.Errorf("parallel %d is not between 1 and 128", opts.Parallel)
+	}
+	if opts.Window < 0 {
+		return errors.New("window is negative")
+	}
+	if opts.Omit < 0 {
+		return errors.New("omit is negative")
+	}
+
+	if opts.Reverse || opts.UDP {
+		if cfg.IperfImplementation == "go" {
+			return errors.New("reverse and udp tests need the libiperf implementation")
+		}
+		if len(cfg.IperfServers) != 0 {
+			return errors.New("reverse and udp tests cannot measure the iperf servers")
+		}
+	}
+
+	return nil
+}
+
+func validateUDPTransferConfig(cfg UDPTransferConfig) error {
+	if cfg.DatagramSize != 0 && (cfg.DatagramSize < 16 || cfg.DatagramSize > 65507) {
+		return fmt.Errorf(
+			"datagram size %d is not between 16 and 65507", cfg.DatagramSize,
+		)
+	}
+
+	return nil
+}
+
+func validateSimpleTransferConfig(cfg SimpleTransferConfig) error {
+	if cfg.BufferSize < 0 {
+		return errors.New("buffer size is negative")
+	}
+
+	if cfg.ZeroCopy && runtime.GOOS != "linux" {
+		return errors.New("zero copy is only supported on linux")
+	}
+
+	if cfg.Socket.SendBuffer < 0 || cfg.Socket.ReceiveBuffer < 0 {
+		return errors.New("socket buffers cannot be negative")
+	}
+
+	if cfg.Socket.CongestionControl != "" && runtime.GOOS != "linux" {
+		return errors.New("congestion control is only supported on linux")
+	}
+
+	return nil
+}
+
+func validateCoordinationConfig(cfg CoordinationConfig) error {
+	switch cfg.Mode {
+	case "", "best_effort":
+	case "round_robin":
+		if cfg.Self == "" {
+			return errors.New("self is not defined")
+		}
+		if _, _, err := transfer.ParsePeer(cfg.Self); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown mode `%s`", cfg.Mode)
+	}
+
+	if cfg.SlotDuration < 0 {
+		return errors.New("slot duration is negative")
+	}
+
+	return nil
+}
+
+func validateLogConfig(cfg LogConfig) error {
+	if _, err := logging.NewFormatter(cfg.Format); err != nil {
+		return err
+	}
+
+	if cfg.Level != "" {
+		if _, err := logrus.ParseLevel(cfg.Level); err != nil {
+			return err
+		}
+	}
+
+	for subsystem, level := range cfg.Levels {
+		if !isLogSubsystem(subsystem) {
+			return fmt.Errorf("unknown subsystem `%s`", subsystem)
+		}
+
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return fmt.Errorf("subsystem `%s`: %s", subsystem, err)
+		}
+	}
+
+	return nil
+}
+
+func isLogSubsystem(subsystem string) bool {
+	for _, s := range logging.Subsystems {
+		if s == subsystem {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateResultSinkConfig(cfg ResultSinkConfig) error {
+	switch cfg.Type {
+	case "file":
+		if cfg.Path == "" {
+			return errors.New("path is not defined")
+		}
+	case "syslog":
+	case "statsd":
+		if cfg.Address == "" {
+			return errors.New("address is not defined")
+		}
+	case "http":
+		if cfg.URL == "" {
+			return errors.New("url is not defined")
+		}
+	default:
+		return fmt.Errorf("unknown type `%s`", cfg.Type)
+	}
+
+	if cfg.Format != "" && !isStreamableFormat(cfg.Format) {
+		return fmt.Errorf("format `%s` is not supported", cfg.Format)
+	}
+
 	return nil
 }
 
+func validateLabels(labels map[string]string) error {
+	for key := range labels {
+		if key == "" {
+			return errors.New("label keys cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+func isStreamableFormat(format string) bool {
+	switch api.ParseResultsFormat(format) {
+	case api.ResultsFormatCSV, api.ResultsFormatJSONLines,
+		api.ResultsFormatInflux:
+		return true
+	default:
+		return false
+	}
+}
+
 func applyDefaults(cfg Config) Config {
+	if cfg.NodeID == "" {
+		// the node ID stays empty when the hostname is not available
+		cfg.NodeID, _ = os.Hostname()
+	}
 	if cfg.InitTransferSize == 0 {
 		cfg.InitTransferSize = 20 * 1024 * 1024
 	}
+	if cfg.DNSRefreshInterval == 0 {
+		cfg.DNSRefreshInterval = Duration(time.Minute)
+	}
+	if cfg.TransferSizing.Mode == "" {
+		cfg.TransferSizing.Mode = "fixed"
+	}
+	if cfg.TransferSizing.TargetDuration == 0 {
+		cfg.TransferSizing.TargetDuration = Duration(10 * time.Second)
+	}
+	if cfg.TransferSizing.MinSize == 0 {
+		cfg.TransferSizing.MinSize = 1024 * 1024
+	}
+	if cfg.TransferSizing.MaxSize == 0 {
+		cfg.TransferSizing.MaxSize = 1024 * 1024 * 1024
+	}
+	if cfg.SimpleTransfer.BufferSize == 0 {
+		cfg.SimpleTransfer.BufferSize = 128 * 1024
+	}
+	if cfg.SimpleTransfer.Socket.NoDelay == nil {
+		noDelay := true
+		cfg.SimpleTransfer.Socket.NoDelay = &noDelay
+	}
+	if len(cfg.Backends) == 0 {
+		if cfg.UseIperf {
+			cfg.Backends = []string{"iperf"}
+		} else {
+			cfg.Backends = []string{"simple"}
+		}
+	}
+	if cfg.UDPTransfer.Rate == 0 {
+		cfg.UDPTransfer.Rate = 10 * 1000 * 1000 / 8
+	}
+	if cfg.UDPTransfer.DatagramSize == 0 {
+		cfg.UDPTransfer.DatagramSize = 1400
+	}
+	if cfg.LatencyTransfer.Probes == 0 {
+		cfg.LatencyTransfer.Probes = 10
+	}
+	if cfg.MeasurementInterval == 0 {
+		cfg.MeasurementInterval = Duration(time.Second)
+	}
+	if cfg.BandwidthBudget.Window == 0 {
+		cfg.BandwidthBudget.Window = Duration(time.Hour)
+	}
 	if cfg.IperfPort == 0 {
 		cfg.IperfPort = 12222
 	}
+	if cfg.IperfImplementation == "" {
+		cfg.IperfImplementation = "libiperf"
+	}
+	if cfg.IperfOptions.Parallel == 0 {
+		cfg.IperfOptions.Parallel = 1
+	}
+	if cfg.SchedulerWorkers == 0 {
+		cfg.SchedulerWorkers = 1
+	}
+	if cfg.TaskSelector == "" {
+		cfg.TaskSelector = "lottery"
+	}
+	if cfg.ExportPath != "" {
+		if cfg.ExportFormat == "" {
+			cfg.ExportFormat = api.ResultsFormatJSONLines.String()
+		}
+		if cfg.ExportMaxSize == 0 {
+			cfg.ExportMaxSize = 64 * 1024 * 1024
+		}
+		if cfg.ExportMaxBackups == 0 {
+			cfg.ExportMaxBackups = 3
+		}
+	}
+	for i := range cfg.ResultSinks {
+		cfg.ResultSinks[i] = applyResultSinkDefaults(cfg.ResultSinks[i])
+	}
+	if cfg.Log.Format == "" {
+		cfg.Log.Format = "text"
+	}
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = "info"
+	}
+	if cfg.Log.Path != "" {
+		if cfg.Log.MaxSize == 0 {
+			cfg.Log.MaxSize = 64 * 1024 * 1024
+		}
+		if cfg.Log.MaxBackups == 0 {
+			cfg.Log.MaxBackups = 3
+		}
+	}
+	if cfg.ShutdownGracePeriod == 0 {
+		cfg.ShutdownGracePeriod = Duration(30 * time.Second)
+	}
+	if cfg.TransferTimeouts.Connect == 0 {
+		cfg.TransferTimeouts.Connect = Duration(10 * time.Second)
+	}
+	if cfg.TransferTimeouts.Idle == 0 {
+		cfg.TransferTimeouts.Idle = Duration(30 * time.Second)
+	}
+	if cfg.TransferTimeouts.Total == 0 {
+		cfg.TransferTimeouts.Total = Duration(10 * time.Minute)
+	}
+	if cfg.TransferTimeouts.Identity == 0 {
+		cfg.TransferTimeouts.Identity = Duration(2 * time.Second)
+	}
+	if cfg.Coordination.Mode == "" {
+		cfg.Coordination.Mode = "best_effort"
+	}
+	if cfg.Coordination.SlotDuration == 0 {
+		cfg.Coordination.SlotDuration = Duration(30 * time.Second)
+	}
+	if cfg.PathDiscovery.Protocol == "" {
+		cfg.PathDiscovery.Protocol = "udp"
+	}
+	if cfg.PathDiscovery.MaxHops == 0 {
+		cfg.PathDiscovery.MaxHops = 30
+	}
+	if cfg.PathDiscovery.ProbeTimeout == 0 {
+		cfg.PathDiscovery.ProbeTimeout = Duration(time.Second)
+	}
+	if cfg.MTUDiscovery.ProbeTimeout == 0 {
+		cfg.MTUDiscovery.ProbeTimeout = Duration(time.Second)
+	}
+	if cfg.MTUDiscovery.Retries == 0 {
+		cfg.MTUDiscovery.Retries = 2
+	}
+
+	return cfg
+}
+
+func applyResultSinkDefaults(cfg ResultSinkConfig) ResultSinkConfig {
+	if cfg.Format == "" {
+		cfg.Format = api.ResultsFormatJSONLines.String()
+	}
+	if cfg.Type == "file" {
+		if cfg.MaxSize == 0 {
+			cfg.MaxSize = 64 * 1024 * 1024
+		}
+		if cfg.MaxBackups == 0 {
+			cfg.MaxBackups = 3
+		}
+	}
+	if cfg.Type == "syslog" && cfg.Tag == "" {
+		cfg.Tag = "clique-agent"
+	}
+	if cfg.Type == "statsd" && cfg.Prefix == "" {
+		cfg.Prefix = "clique"
+	}
+	if cfg.Type == "http" && cfg.Timeout == 0 {
+		cfg.Timeout = Duration(5 * time.Second)
+	}
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = 1024
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 32
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = Duration(time.Second)
+	}
 
 	return cfg
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/ice-stuff/clique/config"
 	. "github.com/onsi/ginkgo"
@@ -67,6 +68,320 @@ var _ = Describe("Config", func() {
 					TransferPort: 5000,
 					RemoteHosts:  []string{"192.168.1.12", "192.168.1.13"},
 				}, true),
+				Entry("valid export format", config.Config{
+					TransferPort: 5000,
+					ExportPath:   "/tmp/results.csv",
+					ExportFormat: "csv",
+				}, true),
+				Entry("export format that cannot be streamed", config.Config{
+					TransferPort: 5000,
+					ExportPath:   "/tmp/results.json",
+					ExportFormat: "json",
+				}, false),
+				Entry("unknown export format", config.Config{
+					TransferPort: 5000,
+					ExportPath:   "/tmp/results.xml",
+					ExportFormat: "xml",
+				}, false),
+				Entry("valid result sinks", config.Config{
+					TransferPort: 5000,
+					ResultSinks: []config.ResultSinkConfig{
+						{Type: "file", Path: "/tmp/results.csv", Format: "csv"},
+						{Type: "syslog"},
+						{Type: "statsd", Address: "127.0.0.1:8125"},
+						{Type: "http", URL: "http://127.0.0.1:8080/results"},
+					},
+				}, true),
+				Entry("unknown result sink type", config.Config{
+					TransferPort: 5000,
+					ResultSinks: []config.ResultSinkConfig{
+						{Type: "banana"},
+					},
+				}, false),
+				Entry("file result sink without path", config.Config{
+					TransferPort: 5000,
+					ResultSinks: []config.ResultSinkConfig{
+						{Type: "file"},
+					},
+				}, false),
+				Entry("statsd result sink without address", config.Config{
+					TransferPort: 5000,
+					ResultSinks: []config.ResultSinkConfig{
+						{Type: "statsd"},
+					},
+				}, false),
+				Entry("http result sink without url", config.Config{
+					TransferPort: 5000,
+					ResultSinks: []config.ResultSinkConfig{
+						{Type: "http"},
+					},
+				}, false),
+				Entry("valid log configuration", config.Config{
+					TransferPort: 5000,
+					Log: config.LogConfig{
+						Format: "json",
+						Level:  "warning",
+						Levels: map[string]string{"scheduler": "debug", "iperf": "error"},
+					},
+				}, true),
+				Entry("unknown log format", config.Config{
+					TransferPort: 5000,
+					Log:          config.LogConfig{Format: "xml"},
+				}, false),
+				Entry("unknown log level", config.Config{
+					TransferPort: 5000,
+					Log:          config.LogConfig{Level: "banana"},
+				}, false),
+				Entry("unknown log subsystem", config.Config{
+					TransferPort: 5000,
+					Log: config.LogConfig{
+						Levels: map[string]string{"banana": "debug"},
+					},
+				}, false),
+				Entry("unknown log subsystem level", config.Config{
+					TransferPort: 5000,
+					Log: config.LogConfig{
+						Levels: map[string]string{"api": "banana"},
+					},
+				}, false),
+				Entry("result sink with format that cannot be streamed", config.Config{
+					TransferPort: 5000,
+					ResultSinks: []config.ResultSinkConfig{
+						{Type: "syslog", Format: "json"},
+					},
+				}, false),
+				Entry("negative scheduler workers", config.Config{
+					TransferPort:     5000,
+					SchedulerWorkers: -1,
+				}, false),
+				Entry("valid task selector", config.Config{
+					TransferPort: 5000,
+					TaskSelector: "fair_share",
+				}, true),
+				Entry("unknown task selector", config.Config{
+					TransferPort: 5000,
+					TaskSelector: "banana",
+				}, false),
+				Entry("negative shutdown grace period", config.Config{
+					TransferPort:        5000,
+					ShutdownGracePeriod: config.Duration(-time.Second),
+				}, false),
+				Entry("negative transfer timeout", config.Config{
+					TransferPort: 5000,
+					TransferTimeouts: config.TimeoutsConfig{
+						Idle: config.Duration(-time.Second),
+					},
+				}, false),
+				Entry("negative identity timeout", config.Config{
+					TransferPort: 5000,
+					TransferTimeouts: config.TimeoutsConfig{
+						Identity: config.Duration(-time.Second),
+					},
+				}, false),
+				Entry("negative simple transfer buffer size", config.Config{
+					TransferPort: 5000,
+					SimpleTransfer: config.SimpleTransferConfig{
+						BufferSize: -1,
+					},
+				}, false),
+				Entry("negative socket buffer", config.Config{
+					TransferPort: 5000,
+					SimpleTransfer: config.SimpleTransferConfig{
+						Socket: config.SocketConfig{SendBuffer: -1},
+					},
+				}, false),
+				Entry("negative measurement interval", config.Config{
+					TransferPort:        5000,
+					MeasurementInterval: config.Duration(-time.Second),
+				}, false),
+				Entry("valid bind addresses", config.Config{
+					TransferPort:        5000,
+					TransferBindAddress: "10.0.0.1",
+					APIBindAddress:      "::1",
+				}, true),
+				Entry("transfer bind address that is not an IP", config.Config{
+					TransferPort:        5000,
+					TransferBindAddress: "banana",
+				}, false),
+				Entry("api bind address that is not an IP", config.Config{
+					TransferPort:   5000,
+					APIBindAddress: "banana",
+				}, false),
+				Entry("negative dns refresh interval", config.Config{
+					TransferPort:       5000,
+					DNSRefreshInterval: config.Duration(-time.Second),
+				}, false),
+				Entry("valid labels", config.Config{
+					TransferPort: 5000,
+					NodeID:       "node-a",
+					Labels:       map[string]string{"dc": "ams", "rack": ""},
+				}, true),
+				Entry("label with an empty key", config.Config{
+					TransferPort: 5000,
+					Labels:       map[string]string{"": "ams"},
+				}, false),
+				Entry("valid path discovery", config.Config{
+					TransferPort: 5000,
+					PathDiscovery: config.PathDiscoveryConfig{
+						Interval: config.Duration(time.Minute),
+						Protocol: "tcp",
+						MaxHops:  16,
+					},
+				}, true),
+				Entry("unknown path discovery protocol", config.Config{
+					TransferPort: 5000,
+					PathDiscovery: config.PathDiscoveryConfig{
+						Protocol: "icmp",
+					},
+				}, false),
+				Entry("negative path discovery interval", config.Config{
+					TransferPort: 5000,
+					PathDiscovery: config.PathDiscoveryConfig{
+						Interval: config.Duration(-time.Minute),
+					},
+				}, false),
+				Entry("too many path discovery hops", config.Config{
+					TransferPort: 5000,
+					PathDiscovery: config.PathDiscoveryConfig{
+						MaxHops: 256,
+					},
+				}, false),
+				Entry("go iperf implementation", config.Config{
+					TransferPort:        5000,
+					IperfImplementation: "go",
+				}, true),
+				Entry("unknown iperf implementation", config.Config{
+					TransferPort:        5000,
+					IperfImplementation: "iperf2",
+				}, false),
+				Entry("valid iperf options", config.Config{
+					TransferPort: 5000,
+					IperfOptions: config.IperfOptionsConfig{
+						Parallel: 4,
+						Reverse:  true,
+						Window:   256 * 1024,
+						Bitrate:  100 * 1000 * 1000,
+						Omit:     config.Duration(2 * time.Second),
+						UDP:      true,
+					},
+				}, true),
+				Entry("too many parallel iperf streams", config.Config{
+					TransferPort: 5000,
+					IperfOptions: config.IperfOptionsConfig{Parallel: 129},
+				}, false),
+				Entry("negative iperf window", config.Config{
+					TransferPort: 5000,
+					IperfOptions: config.IperfOptionsConfig{Window: -1},
+				}, false),
+				Entry("negative iperf omit", config.Config{
+					TransferPort: 5000,
+					IperfOptions: config.IperfOptionsConfig{
+						Omit: config.Duration(-time.Second),
+					},
+				}, false),
+				Entry("reverse iperf tests with the go implementation", config.Config{
+					TransferPort:        5000,
+					IperfImplementation: "go",
+					IperfOptions:        config.IperfOptionsConfig{Reverse: true},
+				}, false),
+				Entry("udp iperf tests with the go implementation", config.Config{
+					TransferPort:        5000,
+					IperfImplementation: "go",
+					IperfOptions:        config.IperfOptionsConfig{UDP: true},
+				}, false),
+				Entry("udp iperf tests with iperf servers", config.Config{
+					TransferPort: 5000,
+					IperfServers: []string{"iperf.example.com:5201"},
+					IperfOptions: config.IperfOptionsConfig{UDP: true},
+				}, false),
+				Entry("parallel iperf tests with the go implementation", config.Config{
+					TransferPort:        5000,
+					IperfImplementation: "go",
+					IperfOptions:        config.IperfOptionsConfig{Parallel: 8},
+				}, true),
+				Entry("valid iperf servers", config.Config{
+					TransferPort: 5000,
+					IperfServers: []string{"iperf.example.com:5201", "[::1]:5201"},
+				}, true),
+				Entry("iperf server without port", config.Config{
+					TransferPort: 5000,
+					IperfServers: []string{"iperf.example.com"},
+				}, false),
+				Entry("valid MTU discovery", config.Config{
+					TransferPort: 5000,
+					MTUDiscovery: config.MTUDiscoveryConfig{
+						Interval: config.Duration(time.Minute),
+						Retries:  3,
+					},
+				}, true),
+				Entry("negative MTU discovery interval", config.Config{
+					TransferPort: 5000,
+					MTUDiscovery: config.MTUDiscoveryConfig{
+						Interval: config.Duration(-time.Minute),
+					},
+				}, false),
+				Entry("negative MTU discovery retries", config.Config{
+					TransferPort: 5000,
+					MTUDiscovery: config.MTUDiscoveryConfig{
+						Retries: -1,
+					},
+				}, false),
+				Entry("valid backends", config.Config{
+					TransferPort: 5000,
+					Backends:     []string{"udp", "simple", "iperf", "latency"},
+				}, true),
+				Entry("unknown backend", config.Config{
+					TransferPort: 5000,
+					Backends:     []string{"simple", "quic"},
+				}, false),
+				Entry("backend listed twice", config.Config{
+					TransferPort: 5000,
+					Backends:     []string{"simple", "latency", "simple"},
+				}, false),
+				Entry("valid udp transfer", config.Config{
+					TransferPort: 5000,
+					UDPTransfer: config.UDPTransferConfig{
+						Rate:         1024 * 1024,
+						DatagramSize: 8972,
+					},
+				}, true),
+				Entry("udp datagrams smaller than their header", config.Config{
+					TransferPort: 5000,
+					UDPTransfer:  config.UDPTransferConfig{DatagramSize: 8},
+				}, false),
+				Entry("udp datagrams larger than UDP allows", config.Config{
+					TransferPort: 5000,
+					UDPTransfer:  config.UDPTransferConfig{DatagramSize: 65508},
+				}, false),
+				Entry("negative latency probes", config.Config{
+					TransferPort:    5000,
+					LatencyTransfer: config.LatencyTransferConfig{Probes: -1},
+				}, false),
+				Entry("negative latency interval", config.Config{
+					TransferPort: 5000,
+					LatencyTransfer: config.LatencyTransferConfig{
+						Interval: config.Duration(-time.Second),
+					},
+				}, false),
+				Entry("valid peer labels", config.Config{
+					TransferPort: 5000,
+					PeerLabels: map[string]map[string]string{
+						"node-b.example.com:5000": {"dc": "fra"},
+						"[::1]:5000":              {"dc": "ams", "rack": "r1"},
+					},
+				}, true),
+				Entry("peer labels of an invalid address", config.Config{
+					TransferPort: 5000,
+					PeerLabels: map[string]map[string]string{
+						"node-b.example.com": {"dc": "fra"},
+					},
+				}, false),
+				Entry("peer label with an empty key", config.Config{
+					TransferPort: 5000,
+					PeerLabels: map[string]map[string]string{
+						"10.0.0.2:5000": {"": "fra"},
+					},
+				}, false),
 			)
 
 			Describe("Defaults", func() {
@@ -91,11 +406,383 @@ var _ = Describe("Config", func() {
 
 					Expect(cfg.IperfPort).To(BeNumerically("==", 12222))
 				})
+
+				It("should apply the default IperfImplementation", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.IperfImplementation).To(Equal("libiperf"))
+				})
+
+				It("should apply the default iperf options", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.IperfOptions).To(Equal(config.IperfOptionsConfig{
+						Parallel: 1,
+					}))
+				})
+
+				It("should apply the default SchedulerWorkers", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.SchedulerWorkers).To(Equal(1))
+				})
+
+				It("should apply the default TaskSelector", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.TaskSelector).To(Equal("lottery"))
+				})
+
+				It("should apply the export defaults when exporting", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						ExportPath:   "/tmp/results.jsonl",
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.ExportFormat).To(Equal("jsonl"))
+					Expect(cfg.ExportMaxSize).To(BeEquivalentTo(64 * 1024 * 1024))
+					Expect(cfg.ExportMaxBackups).To(Equal(3))
+				})
+
+				It("should apply the result sink defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						ResultSinks: []config.ResultSinkConfig{
+							{Type: "http", URL: "http://127.0.0.1:8080/results"},
+							{Type: "statsd", Address: "127.0.0.1:8125", BatchSize: 4},
+						},
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.ResultSinks[0].Timeout).To(Equal(
+						config.Duration(5 * time.Second),
+					))
+					Expect(cfg.ResultSinks[0].BufferSize).To(Equal(1024))
+					Expect(cfg.ResultSinks[0].BatchSize).To(Equal(32))
+					Expect(cfg.ResultSinks[0].FlushInterval).To(Equal(
+						config.Duration(time.Second),
+					))
+					Expect(cfg.ResultSinks[1].Prefix).To(Equal("clique"))
+					Expect(cfg.ResultSinks[1].BatchSize).To(Equal(4))
+				})
+
+				It("should apply the log defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.Log.Format).To(Equal("text"))
+					Expect(cfg.Log.Level).To(Equal("info"))
+					Expect(cfg.Log.MaxSize).To(BeZero())
+				})
+
+				It("should apply the default ShutdownGracePeriod", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.ShutdownGracePeriod).To(Equal(
+						config.Duration(30 * time.Second),
+					))
+				})
+
+				It("should apply the default TransferTimeouts", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						TransferTimeouts: config.TimeoutsConfig{
+							Idle: config.Duration(time.Minute),
+						},
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.TransferTimeouts).To(Equal(config.TimeoutsConfig{
+						Connect:  config.Duration(10 * time.Second),
+						Idle:     config.Duration(time.Minute),
+						Total:    config.Duration(10 * time.Minute),
+						Identity: config.Duration(2 * time.Second),
+					}))
+				})
+
+				It("should apply the transfer sizing defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.TransferSizing).To(Equal(config.SizingConfig{
+						Mode:           "fixed",
+						TargetDuration: config.Duration(10 * time.Second),
+						MinSize:        1024 * 1024,
+						MaxSize:        1024 * 1024 * 1024,
+					}))
+				})
+
+				It("should apply the default bandwidth budget window", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.BandwidthBudget).To(Equal(config.BudgetConfig{
+						Window: config.Duration(time.Hour),
+					}))
+				})
+
+				It("should apply the simple transfer defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					noDelay := true
+					Expect(cfg.SimpleTransfer).To(Equal(config.SimpleTransferConfig{
+						BufferSize: 128 * 1024,
+						Socket: config.SocketConfig{
+							NoDelay: &noDelay,
+						},
+					}))
+				})
+
+				It("should keep a disabled TCP_NODELAY", func() {
+					noDelay := false
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						SimpleTransfer: config.SimpleTransferConfig{
+							Socket: config.SocketConfig{NoDelay: &noDelay},
+						},
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(*cfg.SimpleTransfer.Socket.NoDelay).To(BeFalse())
+				})
+
+				It("should apply the default measurement interval", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.MeasurementInterval).To(
+						Equal(config.Duration(time.Second)),
+					)
+				})
+
+				It("should apply the default dns refresh interval", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.DNSRefreshInterval).To(
+						Equal(config.Duration(time.Minute)),
+					)
+				})
+
+				It("should use the hostname as the default node ID", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					hostname, err := os.Hostname()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cfg.NodeID).To(Equal(hostname))
+				})
+
+				It("should keep the configured node ID", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						NodeID:       "node-a",
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.NodeID).To(Equal("node-a"))
+				})
+
+				It("should apply the coordination defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.Coordination).To(Equal(config.CoordinationConfig{
+						Mode:         "best_effort",
+						SlotDuration: config.Duration(30 * time.Second),
+					}))
+				})
+
+				It("should apply the path discovery defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.PathDiscovery).To(Equal(config.PathDiscoveryConfig{
+						Protocol:     "udp",
+						MaxHops:      30,
+						ProbeTimeout: config.Duration(time.Second),
+					}))
+				})
+
+				It("should apply the MTU discovery defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.MTUDiscovery).To(Equal(config.MTUDiscoveryConfig{
+						ProbeTimeout: config.Duration(time.Second),
+						Retries:      2,
+					}))
+				})
+
+				It("should apply the default backend", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.Backends).To(Equal([]string{"simple"}))
+				})
+
+				It("should apply the iperf backend with use_iperf", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						UseIperf:     true,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.Backends).To(Equal([]string{"iperf"}))
+				})
+
+				It("should keep the configured backends", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+						UseIperf:     true,
+						Backends:     []string{"latency", "simple"},
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.Backends).To(Equal([]string{"latency", "simple"}))
+				})
+
+				It("should apply the udp and latency transfer defaults", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.UDPTransfer).To(Equal(config.UDPTransferConfig{
+						Rate:         1250000,
+						DatagramSize: 1400,
+					}))
+					Expect(cfg.LatencyTransfer).To(Equal(config.LatencyTransferConfig{
+						Probes: 10,
+					}))
+				})
+
+				It("should not apply the export defaults when not exporting", func() {
+					getConfigFile(cfgPath, config.Config{
+						TransferPort: 5000,
+					})
+
+					cfg, err := config.NewConfig(cfgPath)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cfg.ExportFormat).To(BeEmpty())
+				})
 			})
 		})
 	})
 })
 
+var _ = Describe("Duration", func() {
+	It("should be read from a string", func() {
+		var d config.Duration
+		Expect(json.Unmarshal([]byte(`"1m30s"`), &d)).To(Succeed())
+		Expect(d.Duration()).To(Equal(90 * time.Second))
+	})
+
+	It("should be written as a string", func() {
+		Expect(json.Marshal(config.Duration(1500 * time.Millisecond))).To(
+			MatchJSON(`"1.5s"`),
+		)
+	})
+
+	It("should fail when the duration is not a string", func() {
+		var d config.Duration
+		Expect(json.Unmarshal([]byte("12"), &d)).NotTo(Succeed())
+	})
+
+	It("should fail when the duration is malformed", func() {
+		var d config.Duration
+		Expect(json.Unmarshal([]byte(`"banana"`), &d)).NotTo(Succeed())
+	})
+})
+
 func getConfigFile(cfgPath string, cfg config.Config) {
 	contents, err := json.Marshal(cfg)
 	Expect(err).NotTo(HaveOccurred())
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that is written as a string (e.g. "1.5s") in
+// the configuration file.
+type Duration time.Duration
+
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var durStr string
+	if err := json.Unmarshal(data, &durStr); err != nil {
+		return fmt.Errorf("duration should be a string, got %s", data)
+	}
+
+	dur, err := time.ParseDuration(durStr)
+	if err != nil {
+		return err
+	}
+	*d = Duration(dur)
+
+	return nil
+}
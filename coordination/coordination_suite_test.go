@@ -0,0 +1,13 @@
+package coordination_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCoordination(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Coordination Suite")
+}
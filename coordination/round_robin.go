@@ -0,0 +1,166 @@
+package coordination
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// RoundRobin splits the time into measurement slots that all the agents of
+// the clique agree on. In every slot, each agent either sends to exactly one
+// agent, receives from exactly one agent or idles, so every link is measured
+// in isolation. Every directed link gets one slot per round.
+//
+// The agents must be configured with the same members and slot duration and
+// their clocks must be synchronized (e.g. with NTP). They verify that they
+// agree on the members and the slot duration by exchanging the Schedule.
+type RoundRobin struct {
+	// targets[i] is the agent that this agent sends to in the i-th slot of
+	// the round or an empty string.
+	targets      []string
+	members      map[string]bool
+	slotDuration time.Duration
+	schedule     string
+	clock        clock.Clock
+}
+
+func NewRoundRobin(
+	self string, members []string, slotDuration time.Duration, clk clock.Clock,
+) (*RoundRobin, error) {
+	if slotDuration <= 0 {
+		return nil, errors.New("slot duration must be positive")
+	}
+
+	self, err := NormalizeAddress(self)
+	if err != nil {
+		return nil, err
+	}
+
+	membersMap := map[string]bool{self: true}
+	for _, member := range members {
+		addr, err := NormalizeAddress(member)
+		if err != nil {
+			return nil, err
+		}
+
+		membersMap[addr] = true
+	}
+
+	sortedMembers := []string{}
+	for member := range membersMap {
+		sortedMembers = append(sortedMembers, member)
+	}
+	sort.Strings(sortedMembers)
+
+	return &RoundRobin{
+		targets:      roundTargets(self, sortedMembers),
+		members:      membersMap,
+		slotDuration: slotDuration,
+		schedule:     scheduleHash(sortedMembers, slotDuration),
+		clock:        clk,
+	}, nil
+}
+
+// Slot reports if the current slot is reserved for the link to the peer and
+// when it ends. Peers that are not members of the clique are not coordinated;
+// their transfers are always allowed, with a zero end time.
+func (r *RoundRobin) Slot(peer string) (time.Time, bool) {
+	addr, err := NormalizeAddress(peer)
+	if err != nil || !r.members[addr] {
+		return time.Time{}, true
+	}
+	if len(r.targets) == 0 {
+		// untested return
+		return time.Time{}, false
+	}
+
+	now := r.clock.Now()
+	slot := now.UnixNano() / int64(r.slotDuration)
+	if r.targets[slot%int64(len(r.targets))] != addr {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, (slot+1)*int64(r.slotDuration)), true
+}
+
+// Schedule returns the hash of the members and the slot duration, which is
+// the same for all the agents that agree on the slots.
+func (r *RoundRobin) Schedule() string {
+	return r.schedule
+}
+
+// SlotDuration returns the duration of the slots.
+func (r *RoundRobin) SlotDuration() time.Duration {
+	return r.slotDuration
+}
+
+// RoundLen returns the amount of slots in a round.
+func (r *RoundRobin) RoundLen() int {
+	return len(r.targets)
+}
+
+// roundTargets uses the circle method to pair up the members in n-1 rounds
+// (n is even, with an idle member if needed). Every pairing takes two slots,
+// one for each direction.
+func roundTargets(self string, members []string) []string {
+	if len(members)%2 == 1 {
+		members = append(members, "")
+	}
+	n := len(members)
+	if n < 2 {
+		return nil
+	}
+
+	circle := make([]string, n)
+	copy(circle, members)
+
+	targets := make([]string, 0, 2*(n-1))
+	for round := 0; round < n-1; round++ {
+		var forward, backward string
+		for i := 0; i < n/2; i++ {
+			a, b := circle[i], circle[n-1-i]
+			if a == "" || b == "" {
+				continue
+			}
+
+			if a == self {
+				forward = b
+			} else if b == self {
+				backward = a
+			}
+		}
+		targets = append(targets, forward, backward)
+
+		// keep the first member in place and rotate the rest
+		last := circle[n-1]
+		copy(circle[2:], circle[1:n-1])
+		circle[1] = last
+	}
+
+	return targets
+}
+
+func scheduleHash(members []string, slotDuration time.Duration) string {
+	sum := sha256.Sum256([]byte(
+		strings.Join(members, ",") + "/" + slotDuration.String(),
+	))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// NormalizeAddress returns the canonical form of a `host:port` address,
+// which is the identity of the peer at the address.
+func NormalizeAddress(addr string) (string, error) {
+	host, port, err := transfer.ParsePeer(addr)
+	if err != nil {
+		return "", err
+	}
+
+	return transfer.PeerName(host, port), nil
+}
@@ -0,0 +1,159 @@
+package coordination_test
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/ice-stuff/clique/coordination"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RoundRobin", func() {
+	var (
+		members      []string
+		slotDuration time.Duration
+		clk          *fakeclock.FakeClock
+		agents       map[string]*coordination.RoundRobin
+	)
+
+	BeforeEach(func() {
+		slotDuration = time.Second * 10
+		clk = fakeclock.NewFakeClock(time.Unix(1000, 0))
+	})
+
+	JustBeforeEach(func() {
+		agents = make(map[string]*coordination.RoundRobin)
+		for i, self := range members {
+			others := append([]string{}, members[:i]...)
+			others = append(others, members[i+1:]...)
+
+			rr, err := coordination.NewRoundRobin(self, others, slotDuration, clk)
+			Expect(err).NotTo(HaveOccurred())
+			agents[self] = rr
+		}
+	})
+
+	type link struct {
+		from, to string
+	}
+
+	// links returns the links that are allowed in the current slot
+	links := func() []link {
+		res := []link{}
+		for _, from := range members {
+			for _, to := range members {
+				if from == to {
+					continue
+				}
+
+				if _, ok := agents[from].Slot(to); ok {
+					res = append(res, link{from: from, to: to})
+				}
+			}
+		}
+
+		return res
+	}
+
+	for _, membersAmt := range []int{2, 3, 4, 5} {
+		membersAmt := membersAmt
+
+		Context(fmt.Sprintf("with %d members", membersAmt), func() {
+			BeforeEach(func() {
+				members = []string{}
+				for i := 0; i < membersAmt; i++ {
+					members = append(members, fmt.Sprintf("10.0.0.%d:5000", i+1))
+				}
+			})
+
+			It("should measure every link once per round in isolation", func() {
+				seen := map[link]int{}
+				roundLen := agents[members[0]].RoundLen()
+
+				for slot := 0; slot < roundLen; slot++ {
+					busy := map[string]bool{}
+					for _, l := range links() {
+						seen[l]++
+
+						Expect(busy[l.from]).To(BeFalse())
+						Expect(busy[l.to]).To(BeFalse())
+						busy[l.from] = true
+						busy[l.to] = true
+					}
+
+					clk.Increment(slotDuration)
+				}
+
+				Expect(seen).To(HaveLen(membersAmt * (membersAmt - 1)))
+				for _, count := range seen {
+					Expect(count).To(Equal(1))
+				}
+			})
+		})
+	}
+
+	Context("with three members", func() {
+		BeforeEach(func() {
+			members = []string{"10.0.0.1:5000", "10.0.0.2:5000", "10.0.0.3:5000"}
+		})
+
+		It("should end the slot at the slot boundary", func() {
+			clk.Increment(time.Second * 3)
+
+			for _, from := range members {
+				for _, to := range members {
+					if end, ok := agents[from].Slot(to); ok && from != to {
+						Expect(end).To(Equal(time.Unix(1010, 0)))
+					}
+				}
+			}
+		})
+
+		It("should always allow transfers to peers out of the clique", func() {
+			end, ok := agents[members[0]].Slot("10.0.0.99:5000")
+			Expect(ok).To(BeTrue())
+			Expect(end.IsZero()).To(BeTrue())
+		})
+	})
+
+	Context("when the agents agree on the members", func() {
+		BeforeEach(func() {
+			members = []string{"10.0.0.1:5000", "10.0.0.2:5000", "10.0.0.3:5000"}
+		})
+
+		It("should have the same schedule on every agent", func() {
+			schedule := agents[members[0]].Schedule()
+			Expect(schedule).NotTo(BeEmpty())
+			for _, member := range members {
+				Expect(agents[member].Schedule()).To(Equal(schedule))
+			}
+		})
+
+		It("should have another schedule for other members", func() {
+			rr, err := coordination.NewRoundRobin(
+				members[0], []string{members[1]}, slotDuration, clk,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rr.Schedule()).NotTo(Equal(agents[members[0]].Schedule()))
+		})
+
+		It("should have another schedule for another slot duration", func() {
+			rr, err := coordination.NewRoundRobin(
+				members[0], members[1:], 2*slotDuration, clk,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rr.Schedule()).NotTo(Equal(agents[members[0]].Schedule()))
+		})
+	})
+
+	It("should fail when an address is malformed", func() {
+		_, err := coordination.NewRoundRobin(
+			"10.0.0.1", []string{"10.0.0.2:5000"}, time.Second, clk,
+		)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -1,17 +1,27 @@
 package dispatcher
 
 import (
+	"context"
 	"net"
+	"time"
 
+	"code.cloudfoundry.org/clock"
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/api"
 	"github.com/ice-stuff/clique/api/registry"
+	"github.com/ice-stuff/clique/budget"
 	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/traceroute"
 	"github.com/ice-stuff/clique/transfer"
 )
 
 const TransferTaskPriority int = 5
 
+// ProbeTaskPriority is the priority of the path and path MTU discoveries.
+// They get the same share of the scheduler as the transfers, so that they
+// run close to their interval.
+const ProbeTaskPriority int = 5
+
 //go:generate counterfeiter . Scheduler
 type Scheduler interface {
 	Schedule(task scheduler.Task)
@@ -25,13 +35,71 @@ type Interruptible interface {
 
 //go:generate counterfeiter . TransferClient
 type TransferClient interface {
-	Transfer(spec transfer.TransferSpec) (transfer.TransferResults, error)
+	Transfer(ctx context.Context, spec transfer.TransferSpec) (
+		transfer.TransferResults, error,
+	)
 }
 
 //go:generate counterfeiter . ApiRegistry
 type ApiRegistry interface {
 	RegisterTransfer(spec api.TransferSpec, stater registry.TransferStater)
 	RegisterResults(ip net.IP, res api.TransferResults)
+	RegisterPath(res api.PathResults)
+	RegisterMTU(res api.MTUResults)
+}
+
+//go:generate counterfeiter . Coordinator
+type Coordinator interface {
+	// Slot reports if the transfer to the peer may run now and until when. A
+	// zero end time means that the transfer is not time-boxed.
+	Slot(peer string) (end time.Time, ok bool)
+	// SlotDuration returns the duration of the time-boxed slots.
+	SlotDuration() time.Duration
+}
+
+//go:generate counterfeiter . Sizer
+type Sizer interface {
+	// Size returns the size of the next transfer to the peer.
+	Size(peer string, requested uint64) uint64
+	// Observe records the outcome of a transfer to the peer.
+	Observe(peer string, bytes uint64, duration time.Duration)
+}
+
+//go:generate counterfeiter . Budget
+type Budget interface {
+	// Limit returns the largest transfer that the budget may ever allow, or
+	// zero when it is unlimited.
+	Limit() uint64
+	// Reserve reports if a transfer of `size` bytes to the peer may run now
+	// and accounts for it.
+	Reserve(peer string, size uint64) (budget.Reservation, bool)
+	// Refund gives back the reserved bytes that the transfer did not send.
+	Refund(r budget.Reservation, size uint64)
+}
+
+//go:generate counterfeiter . Resolver
+type Resolver interface {
+	// Resolve returns the address of the hostname or IP address.
+	Resolve(ctx context.Context, host string) (net.IP, error)
+}
+
+// *traceroute.Tracer implements the PathTracer.
+//
+//go:generate counterfeiter . PathTracer
+type PathTracer interface {
+	Trace(ctx context.Context, ip net.IP, port uint16) (traceroute.Path, error)
+}
+
+// *traceroute.MTUProber implements the MTUProber.
+//
+//go:generate counterfeiter . MTUProber
+type MTUProber interface {
+	Probe(ctx context.Context, ip net.IP, port uint16) (traceroute.MTU, error)
+}
+
+//go:generate counterfeiter . ResultSink
+type ResultSink interface {
+	Push(res api.TransferResults)
 }
 
 type Dispatcher struct {
@@ -41,33 +109,129 @@ type Dispatcher struct {
 	TransferClient        TransferClient
 
 	ApiRegistry ApiRegistry
-
+	ResultSinks []ResultSink
+	// Optional; the transfers are best-effort without it.
+	Coordinator Coordinator
+	// Optional; the transfers use the requested size without it.
+	Sizer Sizer
+	// Optional; the transfers are not limited without it.
+	Budget Budget
+	// Optional; only the transfers to IP addresses run without it.
+	Resolver Resolver
+	// Labels of the peers by their canonical `host:port` address. Optional.
+	PeerLabels map[string]map[string]string
+	// Optional; the paths to the peers are not discovered without it.
+	PathTracer PathTracer
+	// `udp` or `tcp`
+	PathProtocol string
+	// Time between the discoveries of the path to every peer
+	PathInterval time.Duration
+	// Optional; the path MTUs to the peers are not discovered without it.
+	MTUProber MTUProber
+	// Time between the discoveries of the path MTU to every peer
+	MTUInterval time.Duration
+
+	// Clock of the probe tasks
+	Clock  clock.Clock
 	Logger *logrus.Logger
 }
 
 func (d *Dispatcher) Create(spec api.TransferSpec) {
-	d.Logger.WithFields(logrus.Fields{
-		"ip":   spec.IP,
-		"port": spec.Port,
-		"size": spec.Size,
-	}).Debug("Received new task")
+	transferSpec := transfer.TransferSpec{
+		ID:   transfer.NewTransferID(),
+		Host: spec.Host,
+		IP:   spec.IP,
+		Port: spec.Port,
+		Size: spec.Size,
+		Source: transfer.Source{
+			IP:        spec.SourceIP,
+			Interface: spec.SourceInterface,
+		},
+		Backend: spec.Backend,
+	}
+	if transferSpec.IP == nil {
+		transferSpec.IP = net.ParseIP(spec.Host)
+	}
+
+	d.Logger.WithFields(
+		transfer.LogFields(transferSpec.ID, transferSpec.Peer()),
+	).WithField("size", spec.Size).Debug("Received new task")
+
+	priority := TransferTaskPriority
+	if spec.Priority > 0 {
+		priority = spec.Priority
+	}
 
 	task := &TransferTask{
 		TransferInterruptible: d.TransferInterruptible,
 		TransferClient:        d.TransferClient,
-		TransferSpec: transfer.TransferSpec{
-			IP:   spec.IP,
-			Port: spec.Port,
-			Size: spec.Size,
-		},
+		TransferSpec:          transferSpec,
 
-		Registry: d.ApiRegistry,
+		Registry:    d.ApiRegistry,
+		ResultSinks: d.ResultSinks,
+		Coordinator: d.Coordinator,
+		Sizer:       d.Sizer,
+		Budget:      d.Budget,
+		Resolver:    d.Resolver,
+		PeerLabels:  d.PeerLabels[transferSpec.Peer()],
 
-		DesiredPriority: TransferTaskPriority,
+		DesiredPriority: priority,
+		RunNow:          spec.RunNow,
 
 		Logger: d.Logger,
 	}
 
+	if spec.Deadline != nil {
+		task.TransferDeadline = *spec.Deadline
+	}
+
 	d.Scheduler.Schedule(task)
 	d.ApiRegistry.RegisterTransfer(spec, task)
 }
+
+// DiscoverPath schedules the periodic discovery of the path to the transfer
+// port of the peer. It does nothing without a path tracer.
+func (d *Dispatcher) DiscoverPath(host string, port uint16) {
+	if d.PathTracer == nil {
+		return
+	}
+
+	d.scheduleProbe(&PathProbe{
+		Tracer:   d.PathTracer,
+		Protocol: d.PathProtocol,
+		Registry: d.ApiRegistry,
+	}, host, port, d.PathInterval)
+}
+
+// DiscoverMTU schedules the periodic discovery of the path MTU to the peer.
+// It does nothing without an MTU prober.
+func (d *Dispatcher) DiscoverMTU(host string, port uint16) {
+	if d.MTUProber == nil {
+		return
+	}
+
+	d.scheduleProbe(&MTUProbe{
+		Prober:   d.MTUProber,
+		Registry: d.ApiRegistry,
+	}, host, port, d.MTUInterval)
+}
+
+func (d *Dispatcher) scheduleProbe(
+	probe Probe, host string, port uint16, interval time.Duration,
+) {
+	task := &ProbeTask{
+		Probe:    probe,
+		Host:     host,
+		IP:       net.ParseIP(host),
+		Port:     port,
+		Interval: interval,
+		Clock:    d.Clock,
+
+		Resolver: d.Resolver,
+
+		Logger: d.Logger,
+	}
+
+	d.Logger.WithField("peer", task.peer()).Debugf("Scheduling %s", probe.Name())
+	d.Scheduler.Schedule(task)
+}
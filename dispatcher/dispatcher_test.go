@@ -2,7 +2,9 @@ package dispatcher_test
 
 import (
 	"net"
+	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/api"
 	"github.com/ice-stuff/clique/dispatcher"
@@ -18,6 +20,12 @@ var _ = Describe("Dispatcher", func() {
 		fakeTransferInterruptible *fakes.FakeInterruptible
 		fakeTransferClient        *fakes.FakeTransferClient
 		fakeApiRegistry           *fakes.FakeApiRegistry
+		fakeResultSink            *fakes.FakeResultSink
+		fakeCoordinator           *fakes.FakeCoordinator
+		fakeSizer                 *fakes.FakeSizer
+		fakeBudget                *fakes.FakeBudget
+		fakeResolver              *fakes.FakeResolver
+		clk                       *fakeclock.FakeClock
 		logger                    *logrus.Logger
 		dsptchr                   *dispatcher.Dispatcher
 	)
@@ -25,6 +33,12 @@ var _ = Describe("Dispatcher", func() {
 	BeforeEach(func() {
 		fakeScheduler = new(fakes.FakeScheduler)
 		fakeApiRegistry = new(fakes.FakeApiRegistry)
+		fakeResultSink = new(fakes.FakeResultSink)
+		fakeCoordinator = new(fakes.FakeCoordinator)
+		fakeSizer = new(fakes.FakeSizer)
+		fakeBudget = new(fakes.FakeBudget)
+		fakeResolver = new(fakes.FakeResolver)
+		clk = fakeclock.NewFakeClock(time.Now())
 		logger = &logrus.Logger{
 			Out:       GinkgoWriter,
 			Level:     logrus.DebugLevel,
@@ -38,7 +52,13 @@ var _ = Describe("Dispatcher", func() {
 			TransferClient:        fakeTransferClient,
 
 			ApiRegistry: fakeApiRegistry,
+			ResultSinks: []dispatcher.ResultSink{fakeResultSink},
+			Coordinator: fakeCoordinator,
+			Sizer:       fakeSizer,
+			Budget:      fakeBudget,
+			Resolver:    fakeResolver,
 
+			Clock:  clk,
 			Logger: logger,
 		}
 	})
@@ -100,21 +120,252 @@ var _ = Describe("Dispatcher", func() {
 
 			It("should contain the correct tranfer spec", func() {
 				Expect(scheduledTask.TransferSpec).To(Equal(transfer.TransferSpec{
+					ID:   scheduledTask.TransferSpec.ID,
 					IP:   spec.IP,
 					Port: spec.Port,
 					Size: spec.Size,
 				}))
 			})
 
+			It("should assign a transfer id", func() {
+				Expect(scheduledTask.TransferSpec.ID).NotTo(BeEmpty())
+			})
+
 			It("should be wired to the correct registry", func() {
 				Expect(scheduledTask.Registry).To(Equal(fakeApiRegistry))
 			})
 
+			It("should be wired to the correct result sinks", func() {
+				Expect(scheduledTask.ResultSinks).To(Equal(
+					[]dispatcher.ResultSink{fakeResultSink},
+				))
+			})
+
+			It("should be wired to the correct coordinator", func() {
+				Expect(scheduledTask.Coordinator).To(Equal(fakeCoordinator))
+			})
+
+			It("should be wired to the correct sizer", func() {
+				Expect(scheduledTask.Sizer).To(Equal(fakeSizer))
+			})
+
+			It("should be wired to the correct budget", func() {
+				Expect(scheduledTask.Budget).To(Equal(fakeBudget))
+			})
+
+			It("should be wired to the correct resolver", func() {
+				Expect(scheduledTask.Resolver).To(Equal(fakeResolver))
+			})
+
 			It("should use the defined propery", func() {
 				Expect(scheduledTask.DesiredPriority).To(
 					Equal(dispatcher.TransferTaskPriority),
 				)
 			})
+
+			It("should have no deadline", func() {
+				Expect(scheduledTask.TransferDeadline).To(BeZero())
+			})
+
+			It("should not be urgent", func() {
+				Expect(scheduledTask.RunNow).To(BeFalse())
+			})
+		})
+
+		Context("when the peer is a hostname", func() {
+			BeforeEach(func() {
+				spec.IP = nil
+				spec.Host = "peer.example.com"
+			})
+
+			It("should leave the resolution to the task", func() {
+				dsptchr.Create(spec)
+
+				task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.TransferTask)
+				Expect(task.TransferSpec.Host).To(Equal("peer.example.com"))
+				Expect(task.TransferSpec.IP).To(BeNil())
+				Expect(task.TransferSpec.Peer()).To(Equal("peer.example.com:1212"))
+			})
+		})
+
+		Context("when the peer has configured labels", func() {
+			BeforeEach(func() {
+				dsptchr.PeerLabels = map[string]map[string]string{
+					"127.88.91.234:1212": {"dc": "fra"},
+					"127.88.91.235:1212": {"dc": "ams"},
+				}
+			})
+
+			It("should pass the labels of the peer to the task", func() {
+				dsptchr.Create(spec)
+
+				task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.TransferTask)
+				Expect(task.PeerLabels).To(Equal(map[string]string{"dc": "fra"}))
+			})
+		})
+
+		Context("when the spec has a source", func() {
+			BeforeEach(func() {
+				spec.SourceIP = net.ParseIP("10.0.0.1")
+				spec.SourceInterface = "eth1"
+			})
+
+			It("should transfer from the source", func() {
+				dsptchr.Create(spec)
+
+				task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.TransferTask)
+				Expect(task.TransferSpec.Source).To(Equal(transfer.Source{
+					IP:        net.ParseIP("10.0.0.1"),
+					Interface: "eth1",
+				}))
+			})
+		})
+
+		Context("when the spec requests a backend", func() {
+			BeforeEach(func() {
+				spec.Backend = "latency"
+			})
+
+			It("should request the backend from the transfer client", func() {
+				dsptchr.Create(spec)
+
+				task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.TransferTask)
+				Expect(task.TransferSpec.Backend).To(Equal("latency"))
+			})
+		})
+
+		Context("when the host is an IPv6 address", func() {
+			BeforeEach(func() {
+				spec.IP = nil
+				spec.Host = "fe80::1"
+			})
+
+			It("should use it as the IP of the transfer", func() {
+				dsptchr.Create(spec)
+
+				task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.TransferTask)
+				Expect(task.TransferSpec.IP.Equal(net.ParseIP("fe80::1"))).To(BeTrue())
+				Expect(task.TransferSpec.Peer()).To(Equal("[fe80::1]:1212"))
+			})
+		})
+
+		Context("when the spec is urgent", func() {
+			var deadline time.Time
+
+			BeforeEach(func() {
+				deadline = time.Now().Add(time.Minute)
+				spec.Priority = 50
+				spec.Deadline = &deadline
+				spec.RunNow = true
+			})
+
+			It("should schedule a task with the requested priority, deadline and urgency", func() {
+				dsptchr.Create(spec)
+
+				Expect(fakeScheduler.ScheduleCallCount()).To(Equal(1))
+				scheduledTask := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.TransferTask)
+
+				Expect(scheduledTask.DesiredPriority).To(Equal(50))
+				Expect(scheduledTask.TransferDeadline).To(Equal(deadline))
+				Expect(scheduledTask.RunNow).To(BeTrue())
+			})
+		})
+	})
+	Describe("DiscoverPath", func() {
+		var fakePathTracer *fakes.FakePathTracer
+
+		BeforeEach(func() {
+			fakePathTracer = new(fakes.FakePathTracer)
+			dsptchr.PathTracer = fakePathTracer
+			dsptchr.PathProtocol = "tcp"
+			dsptchr.PathInterval = time.Minute
+		})
+
+		It("should schedule a path probe task", func() {
+			dsptchr.DiscoverPath("peer.example.com", 1212)
+
+			Expect(fakeScheduler.ScheduleCallCount()).To(Equal(1))
+			task, ok := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.ProbeTask)
+			Expect(ok).To(BeTrue())
+			Expect(task.Host).To(Equal("peer.example.com"))
+			Expect(task.IP).To(BeNil())
+			Expect(task.Port).To(Equal(uint16(1212)))
+			Expect(task.Interval).To(Equal(time.Minute))
+			Expect(task.Resolver).To(Equal(fakeResolver))
+			Expect(task.Clock).To(Equal(clk))
+
+			probe, ok := task.Probe.(*dispatcher.PathProbe)
+			Expect(ok).To(BeTrue())
+			Expect(probe.Tracer).To(Equal(fakePathTracer))
+			Expect(probe.Protocol).To(Equal("tcp"))
+			Expect(probe.Registry).To(Equal(fakeApiRegistry))
+		})
+
+		It("should parse the IP addresses", func() {
+			dsptchr.DiscoverPath("10.0.0.2", 1212)
+
+			task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.ProbeTask)
+			Expect(task.IP).To(Equal(net.ParseIP("10.0.0.2")))
+		})
+
+		Context("when there is no path tracer", func() {
+			BeforeEach(func() {
+				dsptchr.PathTracer = nil
+			})
+
+			It("should not schedule anything", func() {
+				dsptchr.DiscoverPath("10.0.0.2", 1212)
+
+				Expect(fakeScheduler.ScheduleCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Describe("DiscoverMTU", func() {
+		var fakeMTUProber *fakes.FakeMTUProber
+
+		BeforeEach(func() {
+			fakeMTUProber = new(fakes.FakeMTUProber)
+			dsptchr.MTUProber = fakeMTUProber
+			dsptchr.MTUInterval = time.Minute
+		})
+
+		It("should schedule an MTU probe task", func() {
+			dsptchr.DiscoverMTU("peer.example.com", 1212)
+
+			Expect(fakeScheduler.ScheduleCallCount()).To(Equal(1))
+			task, ok := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.ProbeTask)
+			Expect(ok).To(BeTrue())
+			Expect(task.Host).To(Equal("peer.example.com"))
+			Expect(task.IP).To(BeNil())
+			Expect(task.Port).To(Equal(uint16(1212)))
+			Expect(task.Interval).To(Equal(time.Minute))
+			Expect(task.Resolver).To(Equal(fakeResolver))
+			Expect(task.Clock).To(Equal(clk))
+
+			probe, ok := task.Probe.(*dispatcher.MTUProbe)
+			Expect(ok).To(BeTrue())
+			Expect(probe.Prober).To(Equal(fakeMTUProber))
+			Expect(probe.Registry).To(Equal(fakeApiRegistry))
+		})
+
+		It("should parse the IP addresses", func() {
+			dsptchr.DiscoverMTU("10.0.0.2", 1212)
+
+			task := fakeScheduler.ScheduleArgsForCall(0).(*dispatcher.ProbeTask)
+			Expect(task.IP).To(Equal(net.ParseIP("10.0.0.2")))
+		})
+
+		Context("when there is no MTU prober", func() {
+			BeforeEach(func() {
+				dsptchr.MTUProber = nil
+			})
+
+			It("should not schedule anything", func() {
+				dsptchr.DiscoverMTU("10.0.0.2", 1212)
+
+				Expect(fakeScheduler.ScheduleCallCount()).To(BeZero())
+			})
 		})
 	})
 })
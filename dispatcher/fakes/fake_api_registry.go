@@ -23,6 +23,16 @@ type FakeApiRegistry struct {
 		ip  net.IP
 		res api.TransferResults
 	}
+	RegisterPathStub        func(res api.PathResults)
+	registerPathMutex       sync.RWMutex
+	registerPathArgsForCall []struct {
+		res api.PathResults
+	}
+	RegisterMTUStub        func(res api.MTUResults)
+	registerMTUMutex       sync.RWMutex
+	registerMTUArgsForCall []struct {
+		res api.MTUResults
+	}
 }
 
 func (fake *FakeApiRegistry) RegisterTransfer(spec api.TransferSpec, stater registry.TransferStater) {
@@ -73,4 +83,50 @@ func (fake *FakeApiRegistry) RegisterResultsArgsForCall(i int) (net.IP, api.Tran
 	return fake.registerResultsArgsForCall[i].ip, fake.registerResultsArgsForCall[i].res
 }
 
+func (fake *FakeApiRegistry) RegisterPath(res api.PathResults) {
+	fake.registerPathMutex.Lock()
+	fake.registerPathArgsForCall = append(fake.registerPathArgsForCall, struct {
+		res api.PathResults
+	}{res})
+	fake.registerPathMutex.Unlock()
+	if fake.RegisterPathStub != nil {
+		fake.RegisterPathStub(res)
+	}
+}
+
+func (fake *FakeApiRegistry) RegisterPathCallCount() int {
+	fake.registerPathMutex.RLock()
+	defer fake.registerPathMutex.RUnlock()
+	return len(fake.registerPathArgsForCall)
+}
+
+func (fake *FakeApiRegistry) RegisterPathArgsForCall(i int) api.PathResults {
+	fake.registerPathMutex.RLock()
+	defer fake.registerPathMutex.RUnlock()
+	return fake.registerPathArgsForCall[i].res
+}
+
+func (fake *FakeApiRegistry) RegisterMTU(res api.MTUResults) {
+	fake.registerMTUMutex.Lock()
+	fake.registerMTUArgsForCall = append(fake.registerMTUArgsForCall, struct {
+		res api.MTUResults
+	}{res})
+	fake.registerMTUMutex.Unlock()
+	if fake.RegisterMTUStub != nil {
+		fake.RegisterMTUStub(res)
+	}
+}
+
+func (fake *FakeApiRegistry) RegisterMTUCallCount() int {
+	fake.registerMTUMutex.RLock()
+	defer fake.registerMTUMutex.RUnlock()
+	return len(fake.registerMTUArgsForCall)
+}
+
+func (fake *FakeApiRegistry) RegisterMTUArgsForCall(i int) api.MTUResults {
+	fake.registerMTUMutex.RLock()
+	defer fake.registerMTUMutex.RUnlock()
+	return fake.registerMTUArgsForCall[i].res
+}
+
 var _ dispatcher.ApiRegistry = new(FakeApiRegistry)
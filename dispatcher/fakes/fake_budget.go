@@ -0,0 +1,118 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/ice-stuff/clique/budget"
+	"github.com/ice-stuff/clique/dispatcher"
+)
+
+type FakeBudget struct {
+	LimitStub        func() uint64
+	limitMutex       sync.RWMutex
+	limitArgsForCall []struct{}
+	limitReturns     struct {
+		result1 uint64
+	}
+	ReserveStub        func(peer string, size uint64) (budget.Reservation, bool)
+	reserveMutex       sync.RWMutex
+	reserveArgsForCall []struct {
+		peer string
+		size uint64
+	}
+	reserveReturns struct {
+		result1 budget.Reservation
+		result2 bool
+	}
+	RefundStub        func(r budget.Reservation, size uint64)
+	refundMutex       sync.RWMutex
+	refundArgsForCall []struct {
+		r    budget.Reservation
+		size uint64
+	}
+}
+
+func (fake *FakeBudget) Limit() uint64 {
+	fake.limitMutex.Lock()
+	fake.limitArgsForCall = append(fake.limitArgsForCall, struct{}{})
+	fake.limitMutex.Unlock()
+	if fake.LimitStub != nil {
+		return fake.LimitStub()
+	} else {
+		return fake.limitReturns.result1
+	}
+}
+
+func (fake *FakeBudget) LimitCallCount() int {
+	fake.limitMutex.RLock()
+	defer fake.limitMutex.RUnlock()
+	return len(fake.limitArgsForCall)
+}
+
+func (fake *FakeBudget) LimitReturns(result1 uint64) {
+	fake.LimitStub = nil
+	fake.limitReturns = struct {
+		result1 uint64
+	}{result1}
+}
+
+func (fake *FakeBudget) Reserve(peer string, size uint64) (budget.Reservation, bool) {
+	fake.reserveMutex.Lock()
+	fake.reserveArgsForCall = append(fake.reserveArgsForCall, struct {
+		peer string
+		size uint64
+	}{peer, size})
+	fake.reserveMutex.Unlock()
+	if fake.ReserveStub != nil {
+		return fake.ReserveStub(peer, size)
+	} else {
+		return fake.reserveReturns.result1, fake.reserveReturns.result2
+	}
+}
+
+func (fake *FakeBudget) ReserveCallCount() int {
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	return len(fake.reserveArgsForCall)
+}
+
+func (fake *FakeBudget) ReserveArgsForCall(i int) (string, uint64) {
+	fake.reserveMutex.RLock()
+	defer fake.reserveMutex.RUnlock()
+	return fake.reserveArgsForCall[i].peer, fake.reserveArgsForCall[i].size
+}
+
+func (fake *FakeBudget) ReserveReturns(result1 budget.Reservation, result2 bool) {
+	fake.ReserveStub = nil
+	fake.reserveReturns = struct {
+		result1 budget.Reservation
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeBudget) Refund(r budget.Reservation, size uint64) {
+	fake.refundMutex.Lock()
+	fake.refundArgsForCall = append(fake.refundArgsForCall, struct {
+		r    budget.Reservation
+		size uint64
+	}{r, size})
+	fake.refundMutex.Unlock()
+	if fake.RefundStub != nil {
+		fake.RefundStub(r, size)
+	}
+}
+
+func (fake *FakeBudget) RefundCallCount() int {
+	fake.refundMutex.RLock()
+	defer fake.refundMutex.RUnlock()
+	return len(fake.refundArgsForCall)
+}
+
+func (fake *FakeBudget) RefundArgsForCall(i int) (budget.Reservation, uint64) {
+	fake.refundMutex.RLock()
+	defer fake.refundMutex.RUnlock()
+	return fake.refundArgsForCall[i].r, fake.refundArgsForCall[i].size
+}
+
+var _ dispatcher.Budget = new(FakeBudget)
@@ -0,0 +1,86 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ice-stuff/clique/dispatcher"
+)
+
+type FakeCoordinator struct {
+	SlotStub        func(peer string) (time.Time, bool)
+	slotMutex       sync.RWMutex
+	slotArgsForCall []struct {
+		peer string
+	}
+	slotReturns struct {
+		result1 time.Time
+		result2 bool
+	}
+	SlotDurationStub        func() time.Duration
+	slotDurationMutex       sync.RWMutex
+	slotDurationArgsForCall []struct{}
+	slotDurationReturns     struct {
+		result1 time.Duration
+	}
+}
+
+func (fake *FakeCoordinator) Slot(peer string) (time.Time, bool) {
+	fake.slotMutex.Lock()
+	fake.slotArgsForCall = append(fake.slotArgsForCall, struct {
+		peer string
+	}{peer})
+	fake.slotMutex.Unlock()
+	if fake.SlotStub != nil {
+		return fake.SlotStub(peer)
+	} else {
+		return fake.slotReturns.result1, fake.slotReturns.result2
+	}
+}
+
+func (fake *FakeCoordinator) SlotCallCount() int {
+	fake.slotMutex.RLock()
+	defer fake.slotMutex.RUnlock()
+	return len(fake.slotArgsForCall)
+}
+
+func (fake *FakeCoordinator) SlotArgsForCall(i int) string {
+	fake.slotMutex.RLock()
+	defer fake.slotMutex.RUnlock()
+	return fake.slotArgsForCall[i].peer
+}
+
+func (fake *FakeCoordinator) SlotReturns(result1 time.Time, result2 bool) {
+	fake.SlotStub = nil
+	fake.slotReturns = struct {
+		result1 time.Time
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeCoordinator) SlotDuration() time.Duration {
+	fake.slotDurationMutex.Lock()
+	fake.slotDurationArgsForCall = append(fake.slotDurationArgsForCall, struct{}{})
+	fake.slotDurationMutex.Unlock()
+	if fake.SlotDurationStub != nil {
+		return fake.SlotDurationStub()
+	} else {
+		return fake.slotDurationReturns.result1
+	}
+}
+
+func (fake *FakeCoordinator) SlotDurationCallCount() int {
+	fake.slotDurationMutex.RLock()
+	defer fake.slotDurationMutex.RUnlock()
+	return len(fake.slotDurationArgsForCall)
+}
+
+func (fake *FakeCoordinator) SlotDurationReturns(result1 time.Duration) {
+	fake.SlotDurationStub = nil
+	fake.slotDurationReturns = struct {
+		result1 time.Duration
+	}{result1}
+}
+
+var _ dispatcher.Coordinator = new(FakeCoordinator)
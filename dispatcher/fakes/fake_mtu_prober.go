@@ -0,0 +1,62 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/traceroute"
+)
+
+type FakeMTUProber struct {
+	ProbeStub        func(ctx context.Context, ip net.IP, port uint16) (traceroute.MTU, error)
+	probeMutex       sync.RWMutex
+	probeArgsForCall []struct {
+		ctx  context.Context
+		ip   net.IP
+		port uint16
+	}
+	probeReturns struct {
+		result1 traceroute.MTU
+		result2 error
+	}
+}
+
+func (fake *FakeMTUProber) Probe(ctx context.Context, ip net.IP, port uint16) (traceroute.MTU, error) {
+	fake.probeMutex.Lock()
+	fake.probeArgsForCall = append(fake.probeArgsForCall, struct {
+		ctx  context.Context
+		ip   net.IP
+		port uint16
+	}{ctx, ip, port})
+	fake.probeMutex.Unlock()
+	if fake.ProbeStub != nil {
+		return fake.ProbeStub(ctx, ip, port)
+	} else {
+		return fake.probeReturns.result1, fake.probeReturns.result2
+	}
+}
+
+func (fake *FakeMTUProber) ProbeCallCount() int {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	return len(fake.probeArgsForCall)
+}
+
+func (fake *FakeMTUProber) ProbeArgsForCall(i int) (context.Context, net.IP, uint16) {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	return fake.probeArgsForCall[i].ctx, fake.probeArgsForCall[i].ip, fake.probeArgsForCall[i].port
+}
+
+func (fake *FakeMTUProber) ProbeReturns(result1 traceroute.MTU, result2 error) {
+	fake.ProbeStub = nil
+	fake.probeReturns = struct {
+		result1 traceroute.MTU
+		result2 error
+	}{result1, result2}
+}
+
+var _ dispatcher.MTUProber = new(FakeMTUProber)
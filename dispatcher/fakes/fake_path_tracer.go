@@ -0,0 +1,62 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/traceroute"
+)
+
+type FakePathTracer struct {
+	TraceStub        func(ctx context.Context, ip net.IP, port uint16) (traceroute.Path, error)
+	traceMutex       sync.RWMutex
+	traceArgsForCall []struct {
+		ctx  context.Context
+		ip   net.IP
+		port uint16
+	}
+	traceReturns struct {
+		result1 traceroute.Path
+		result2 error
+	}
+}
+
+func (fake *FakePathTracer) Trace(ctx context.Context, ip net.IP, port uint16) (traceroute.Path, error) {
+	fake.traceMutex.Lock()
+	fake.traceArgsForCall = append(fake.traceArgsForCall, struct {
+		ctx  context.Context
+		ip   net.IP
+		port uint16
+	}{ctx, ip, port})
+	fake.traceMutex.Unlock()
+	if fake.TraceStub != nil {
+		return fake.TraceStub(ctx, ip, port)
+	} else {
+		return fake.traceReturns.result1, fake.traceReturns.result2
+	}
+}
+
+func (fake *FakePathTracer) TraceCallCount() int {
+	fake.traceMutex.RLock()
+	defer fake.traceMutex.RUnlock()
+	return len(fake.traceArgsForCall)
+}
+
+func (fake *FakePathTracer) TraceArgsForCall(i int) (context.Context, net.IP, uint16) {
+	fake.traceMutex.RLock()
+	defer fake.traceMutex.RUnlock()
+	return fake.traceArgsForCall[i].ctx, fake.traceArgsForCall[i].ip, fake.traceArgsForCall[i].port
+}
+
+func (fake *FakePathTracer) TraceReturns(result1 traceroute.Path, result2 error) {
+	fake.TraceStub = nil
+	fake.traceReturns = struct {
+		result1 traceroute.Path
+		result2 error
+	}{result1, result2}
+}
+
+var _ dispatcher.PathTracer = new(FakePathTracer)
@@ -0,0 +1,97 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/dispatcher"
+)
+
+type FakeProbe struct {
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct{}
+	nameReturns     struct {
+		result1 string
+	}
+	ProbeStub        func(ctx context.Context, logger *logrus.Entry, peer string, ip net.IP, port uint16, now time.Time) error
+	probeMutex       sync.RWMutex
+	probeArgsForCall []struct {
+		ctx    context.Context
+		logger *logrus.Entry
+		peer   string
+		ip     net.IP
+		port   uint16
+		now    time.Time
+	}
+	probeReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeProbe) Name() string {
+	fake.nameMutex.Lock()
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct{}{})
+	fake.nameMutex.Unlock()
+	if fake.NameStub != nil {
+		return fake.NameStub()
+	} else {
+		return fake.nameReturns.result1
+	}
+}
+
+func (fake *FakeProbe) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeProbe) NameReturns(result1 string) {
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeProbe) Probe(ctx context.Context, logger *logrus.Entry, peer string, ip net.IP, port uint16, now time.Time) error {
+	fake.probeMutex.Lock()
+	fake.probeArgsForCall = append(fake.probeArgsForCall, struct {
+		ctx    context.Context
+		logger *logrus.Entry
+		peer   string
+		ip     net.IP
+		port   uint16
+		now    time.Time
+	}{ctx, logger, peer, ip, port, now})
+	fake.probeMutex.Unlock()
+	if fake.ProbeStub != nil {
+		return fake.ProbeStub(ctx, logger, peer, ip, port, now)
+	} else {
+		return fake.probeReturns.result1
+	}
+}
+
+func (fake *FakeProbe) ProbeCallCount() int {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	return len(fake.probeArgsForCall)
+}
+
+func (fake *FakeProbe) ProbeArgsForCall(i int) (context.Context, *logrus.Entry, string, net.IP, uint16, time.Time) {
+	fake.probeMutex.RLock()
+	defer fake.probeMutex.RUnlock()
+	return fake.probeArgsForCall[i].ctx, fake.probeArgsForCall[i].logger, fake.probeArgsForCall[i].peer, fake.probeArgsForCall[i].ip, fake.probeArgsForCall[i].port, fake.probeArgsForCall[i].now
+}
+
+func (fake *FakeProbe) ProbeReturns(result1 error) {
+	fake.ProbeStub = nil
+	fake.probeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ dispatcher.Probe = new(FakeProbe)
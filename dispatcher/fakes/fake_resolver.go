@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/ice-stuff/clique/dispatcher"
+)
+
+type FakeResolver struct {
+	ResolveStub        func(ctx context.Context, host string) (net.IP, error)
+	resolveMutex       sync.RWMutex
+	resolveArgsForCall []struct {
+		ctx  context.Context
+		host string
+	}
+	resolveReturns struct {
+		result1 net.IP
+		result2 error
+	}
+}
+
+func (fake *FakeResolver) Resolve(ctx context.Context, host string) (net.IP, error) {
+	fake.resolveMutex.Lock()
+	fake.resolveArgsForCall = append(fake.resolveArgsForCall, struct {
+		ctx  context.Context
+		host string
+	}{ctx, host})
+	fake.resolveMutex.Unlock()
+	if fake.ResolveStub != nil {
+		return fake.ResolveStub(ctx, host)
+	} else {
+		return fake.resolveReturns.result1, fake.resolveReturns.result2
+	}
+}
+
+func (fake *FakeResolver) ResolveCallCount() int {
+	fake.resolveMutex.RLock()
+	defer fake.resolveMutex.RUnlock()
+	return len(fake.resolveArgsForCall)
+}
+
+func (fake *FakeResolver) ResolveArgsForCall(i int) (context.Context, string) {
+	fake.resolveMutex.RLock()
+	defer fake.resolveMutex.RUnlock()
+	return fake.resolveArgsForCall[i].ctx, fake.resolveArgsForCall[i].host
+}
+
+func (fake *FakeResolver) ResolveReturns(result1 net.IP, result2 error) {
+	fake.ResolveStub = nil
+	fake.resolveReturns = struct {
+		result1 net.IP
+		result2 error
+	}{result1, result2}
+}
+
+var _ dispatcher.Resolver = new(FakeResolver)
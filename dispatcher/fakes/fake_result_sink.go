@@ -0,0 +1,42 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/dispatcher"
+)
+
+type FakeResultSink struct {
+	PushStub        func(res api.TransferResults)
+	pushMutex       sync.RWMutex
+	pushArgsForCall []struct {
+		res api.TransferResults
+	}
+}
+
+func (fake *FakeResultSink) Push(res api.TransferResults) {
+	fake.pushMutex.Lock()
+	fake.pushArgsForCall = append(fake.pushArgsForCall, struct {
+		res api.TransferResults
+	}{res})
+	fake.pushMutex.Unlock()
+	if fake.PushStub != nil {
+		fake.PushStub(res)
+	}
+}
+
+func (fake *FakeResultSink) PushCallCount() int {
+	fake.pushMutex.RLock()
+	defer fake.pushMutex.RUnlock()
+	return len(fake.pushArgsForCall)
+}
+
+func (fake *FakeResultSink) PushArgsForCall(i int) api.TransferResults {
+	fake.pushMutex.RLock()
+	defer fake.pushMutex.RUnlock()
+	return fake.pushArgsForCall[i].res
+}
+
+var _ dispatcher.ResultSink = new(FakeResultSink)
@@ -0,0 +1,88 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ice-stuff/clique/dispatcher"
+)
+
+type FakeSizer struct {
+	SizeStub        func(peer string, requested uint64) uint64
+	sizeMutex       sync.RWMutex
+	sizeArgsForCall []struct {
+		peer      string
+		requested uint64
+	}
+	sizeReturns struct {
+		result1 uint64
+	}
+	ObserveStub        func(peer string, bytes uint64, duration time.Duration)
+	observeMutex       sync.RWMutex
+	observeArgsForCall []struct {
+		peer     string
+		bytes    uint64
+		duration time.Duration
+	}
+}
+
+func (fake *FakeSizer) Size(peer string, requested uint64) uint64 {
+	fake.sizeMutex.Lock()
+	fake.sizeArgsForCall = append(fake.sizeArgsForCall, struct {
+		peer      string
+		requested uint64
+	}{peer, requested})
+	fake.sizeMutex.Unlock()
+	if fake.SizeStub != nil {
+		return fake.SizeStub(peer, requested)
+	} else {
+		return fake.sizeReturns.result1
+	}
+}
+
+func (fake *FakeSizer) SizeCallCount() int {
+	fake.sizeMutex.RLock()
+	defer fake.sizeMutex.RUnlock()
+	return len(fake.sizeArgsForCall)
+}
+
+func (fake *FakeSizer) SizeArgsForCall(i int) (string, uint64) {
+	fake.sizeMutex.RLock()
+	defer fake.sizeMutex.RUnlock()
+	return fake.sizeArgsForCall[i].peer, fake.sizeArgsForCall[i].requested
+}
+
+func (fake *FakeSizer) SizeReturns(result1 uint64) {
+	fake.SizeStub = nil
+	fake.sizeReturns = struct {
+		result1 uint64
+	}{result1}
+}
+
+func (fake *FakeSizer) Observe(peer string, bytes uint64, duration time.Duration) {
+	fake.observeMutex.Lock()
+	fake.observeArgsForCall = append(fake.observeArgsForCall, struct {
+		peer     string
+		bytes    uint64
+		duration time.Duration
+	}{peer, bytes, duration})
+	fake.observeMutex.Unlock()
+	if fake.ObserveStub != nil {
+		fake.ObserveStub(peer, bytes, duration)
+	}
+}
+
+func (fake *FakeSizer) ObserveCallCount() int {
+	fake.observeMutex.RLock()
+	defer fake.observeMutex.RUnlock()
+	return len(fake.observeArgsForCall)
+}
+
+func (fake *FakeSizer) ObserveArgsForCall(i int) (string, uint64, time.Duration) {
+	fake.observeMutex.RLock()
+	defer fake.observeMutex.RUnlock()
+	return fake.observeArgsForCall[i].peer, fake.observeArgsForCall[i].bytes, fake.observeArgsForCall[i].duration
+}
+
+var _ dispatcher.Sizer = new(FakeSizer)
@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/ice-stuff/clique/dispatcher"
@@ -9,9 +10,10 @@ import (
 )
 
 type FakeTransferClient struct {
-	TransferStub        func(spec transfer.TransferSpec) (transfer.TransferResults, error)
+	TransferStub        func(ctx context.Context, spec transfer.TransferSpec) (transfer.TransferResults, error)
 	transferMutex       sync.RWMutex
 	transferArgsForCall []struct {
+		ctx  context.Context
 		spec transfer.TransferSpec
 	}
 	transferReturns struct {
@@ -20,14 +22,15 @@ type FakeTransferClient struct {
 	}
 }
 
-func (fake *FakeTransferClient) Transfer(spec transfer.TransferSpec) (transfer.TransferResults, error) {
+func (fake *FakeTransferClient) Transfer(ctx context.Context, spec transfer.TransferSpec) (transfer.TransferResults, error) {
 	fake.transferMutex.Lock()
 	fake.transferArgsForCall = append(fake.transferArgsForCall, struct {
+		ctx  context.Context
 		spec transfer.TransferSpec
-	}{spec})
+	}{ctx, spec})
 	fake.transferMutex.Unlock()
 	if fake.TransferStub != nil {
-		return fake.TransferStub(spec)
+		return fake.TransferStub(ctx, spec)
 	} else {
 		return fake.transferReturns.result1, fake.transferReturns.result2
 	}
@@ -39,10 +42,10 @@ func (fake *FakeTransferClient) TransferCallCount() int {
 	return len(fake.transferArgsForCall)
 }
 
-func (fake *FakeTransferClient) TransferArgsForCall(i int) transfer.TransferSpec {
+func (fake *FakeTransferClient) TransferArgsForCall(i int) (context.Context, transfer.TransferSpec) {
 	fake.transferMutex.RLock()
 	defer fake.transferMutex.RUnlock()
-	return fake.transferArgsForCall[i].spec
+	return fake.transferArgsForCall[i].ctx, fake.transferArgsForCall[i].spec
 }
 
 func (fake *FakeTransferClient) TransferReturns(result1 transfer.TransferResults, result2 error) {
@@ -0,0 +1,56 @@
+package dispatcher
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+)
+
+// MTUProbe discovers the path MTU to a peer and flags the mismatches with
+// the MTU of the interface. The probes go to the port of the task, which
+// must not accept UDP.
+type MTUProbe struct {
+	Prober   MTUProber
+	Registry ApiRegistry
+}
+
+func (p *MTUProbe) Name() string {
+	return "path MTU discovery"
+}
+
+func (p *MTUProbe) Probe(
+	ctx context.Context, logger *logrus.Entry, peer string, ip net.IP,
+	port uint16, now time.Time,
+) error {
+	mtu, err := p.Prober.Probe(ctx, ip, port)
+	if err != nil {
+		return err
+	}
+
+	res := api.MTUResults{
+		Peer:         peer,
+		IP:           ip,
+		Time:         now,
+		PathMTU:      mtu.PathMTU,
+		Interface:    mtu.Interface,
+		InterfaceMTU: mtu.InterfaceMTU,
+		Mismatch:     mtu.Mismatch(),
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"path_mtu":      mtu.PathMTU,
+		"interface":     mtu.Interface,
+		"interface_mtu": mtu.InterfaceMTU,
+	})
+	if res.Mismatch {
+		logger.Warn("Path MTU differs from the interface MTU")
+	} else {
+		logger.Debug("Path MTU discovery is completed")
+	}
+	p.Registry.RegisterMTU(res)
+
+	return nil
+}
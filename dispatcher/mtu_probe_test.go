@@ -0,0 +1,131 @@
+package dispatcher_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/dispatcher/fakes"
+	"github.com/ice-stuff/clique/traceroute"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("MTUProbe", func() {
+	var (
+		p             *dispatcher.MTUProbe
+		fakeMTUProber *fakes.FakeMTUProber
+		fakeRegistry  *fakes.FakeApiRegistry
+		logBuffer     *gbytes.Buffer
+		logger        *logrus.Entry
+		now           time.Time
+	)
+
+	probe := func() error {
+		return p.Probe(
+			context.Background(), logger, "92.168.12.19:1245",
+			net.ParseIP("92.168.12.19"), 1245, now,
+		)
+	}
+
+	BeforeEach(func() {
+		fakeMTUProber = new(fakes.FakeMTUProber)
+		fakeRegistry = new(fakes.FakeApiRegistry)
+		logBuffer = gbytes.NewBuffer()
+		logger = logrus.NewEntry(&logrus.Logger{
+			Out:       logBuffer,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		})
+		now = time.Now()
+
+		fakeMTUProber.ProbeReturns(traceroute.MTU{
+			PathMTU:      1500,
+			Interface:    "eth0",
+			InterfaceMTU: 1500,
+		}, nil)
+
+		p = &dispatcher.MTUProbe{
+			Prober:   fakeMTUProber,
+			Registry: fakeRegistry,
+		}
+	})
+
+	It("should be named", func() {
+		Expect(p.Name()).To(Equal("path MTU discovery"))
+	})
+
+	It("should probe the peer", func() {
+		Expect(probe()).To(Succeed())
+
+		Expect(fakeMTUProber.ProbeCallCount()).To(Equal(1))
+		_, ip, port := fakeMTUProber.ProbeArgsForCall(0)
+		Expect(ip).To(Equal(net.ParseIP("92.168.12.19")))
+		Expect(port).To(Equal(uint16(1245)))
+	})
+
+	It("should register the path MTU", func() {
+		Expect(probe()).To(Succeed())
+
+		Expect(fakeRegistry.RegisterMTUCallCount()).To(Equal(1))
+		Expect(fakeRegistry.RegisterMTUArgsForCall(0)).To(Equal(api.MTUResults{
+			Peer:         "92.168.12.19:1245",
+			IP:           net.ParseIP("92.168.12.19"),
+			Time:         now,
+			PathMTU:      1500,
+			Interface:    "eth0",
+			InterfaceMTU: 1500,
+		}))
+	})
+
+	It("should not warn", func() {
+		Expect(probe()).To(Succeed())
+
+		Expect(logBuffer).NotTo(gbytes.Say("level=warning"))
+	})
+
+	Context("when the path MTU is smaller than the interface MTU", func() {
+		BeforeEach(func() {
+			fakeMTUProber.ProbeReturns(traceroute.MTU{
+				PathMTU:      1500,
+				Interface:    "eth0",
+				InterfaceMTU: 9000,
+			}, nil)
+		})
+
+		It("should flag the mismatch", func() {
+			Expect(probe()).To(Succeed())
+
+			Expect(fakeRegistry.RegisterMTUArgsForCall(0).Mismatch).To(BeTrue())
+		})
+
+		It("should warn", func() {
+			Expect(probe()).To(Succeed())
+
+			Expect(logBuffer).To(gbytes.Say(
+				"level=warning msg=\"Path MTU differs from the interface MTU\"",
+			))
+		})
+	})
+
+	Context("when the discovery fails", func() {
+		BeforeEach(func() {
+			fakeMTUProber.ProbeReturns(traceroute.MTU{}, errors.New("banana"))
+		})
+
+		It("should return the error", func() {
+			Expect(probe()).To(MatchError("banana"))
+		})
+
+		It("should not register a path MTU", func() {
+			probe()
+
+			Expect(fakeRegistry.RegisterMTUCallCount()).To(BeZero())
+		})
+	})
+})
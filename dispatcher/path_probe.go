@@ -0,0 +1,85 @@
+package dispatcher
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/traceroute"
+)
+
+// PathProbe discovers the path to the transfer port of a peer and flags its
+// changes.
+type PathProbe struct {
+	Tracer PathTracer
+	// `udp` or `tcp`
+	Protocol string
+	Registry ApiRegistry
+
+	lastPath *traceroute.Path
+}
+
+func (p *PathProbe) Name() string {
+	return "path discovery"
+}
+
+func (p *PathProbe) Probe(
+	ctx context.Context, logger *logrus.Entry, peer string, ip net.IP,
+	port uint16, now time.Time,
+) error {
+	path, err := p.Tracer.Trace(ctx, ip, port)
+	if err != nil {
+		return err
+	}
+
+	res := api.PathResults{
+		Peer:     peer,
+		IP:       ip,
+		Protocol: p.Protocol,
+		Time:     now,
+		Hops:     apiHops(path.Hops),
+		Reached:  path.Reached,
+	}
+	if p.lastPath != nil && !p.lastPath.Equal(path) {
+		res.Changed = true
+		logger.WithFields(logrus.Fields{
+			"previous_hops": formatHops(p.lastPath.Hops),
+			"current_hops":  formatHops(path.Hops),
+		}).Warn("Path to the peer changed")
+	}
+	p.lastPath = &path
+
+	logger.WithFields(logrus.Fields{
+		"hops":    len(path.Hops),
+		"reached": path.Reached,
+	}).Debug("Path discovery is completed")
+	p.Registry.RegisterPath(res)
+
+	return nil
+}
+
+func apiHops(hops []traceroute.Hop) []api.Hop {
+	res := make([]api.Hop, len(hops))
+	for i, hop := range hops {
+		res[i] = api.Hop{TTL: hop.TTL, IP: hop.IP, RTT: hop.RTT}
+	}
+
+	return res
+}
+
+// formatHops lists the addresses of the hops; `*` stands for the hops that
+// did not answer.
+func formatHops(hops []traceroute.Hop) string {
+	addrs := make([]string, len(hops))
+	for i, hop := range hops {
+		addrs[i] = "*"
+		if hop.IP != nil {
+			addrs[i] = hop.IP.String()
+		}
+	}
+
+	return strings.Join(addrs, " ")
+}
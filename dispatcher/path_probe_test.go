@@ -0,0 +1,159 @@
+package dispatcher_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/dispatcher/fakes"
+	"github.com/ice-stuff/clique/traceroute"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("PathProbe", func() {
+	var (
+		p              *dispatcher.PathProbe
+		fakePathTracer *fakes.FakePathTracer
+		fakeRegistry   *fakes.FakeApiRegistry
+		logBuffer      *gbytes.Buffer
+		logger         *logrus.Entry
+		now            time.Time
+		path           traceroute.Path
+	)
+
+	probe := func() error {
+		return p.Probe(
+			context.Background(), logger, "92.168.12.19:1245",
+			net.ParseIP("92.168.12.19"), 1245, now,
+		)
+	}
+
+	BeforeEach(func() {
+		fakePathTracer = new(fakes.FakePathTracer)
+		fakeRegistry = new(fakes.FakeApiRegistry)
+		logBuffer = gbytes.NewBuffer()
+		logger = logrus.NewEntry(&logrus.Logger{
+			Out:       logBuffer,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		})
+		now = time.Now()
+
+		path = traceroute.Path{
+			Hops: []traceroute.Hop{
+				{TTL: 1, IP: net.ParseIP("10.0.0.1"), RTT: time.Millisecond},
+				{TTL: 2},
+				{TTL: 3, IP: net.ParseIP("92.168.12.19"), RTT: time.Millisecond * 3},
+			},
+			Reached: true,
+		}
+		fakePathTracer.TraceReturns(path, nil)
+
+		p = &dispatcher.PathProbe{
+			Tracer:   fakePathTracer,
+			Protocol: "udp",
+			Registry: fakeRegistry,
+		}
+	})
+
+	It("should be named", func() {
+		Expect(p.Name()).To(Equal("path discovery"))
+	})
+
+	It("should trace the transfer port of the peer", func() {
+		Expect(probe()).To(Succeed())
+
+		Expect(fakePathTracer.TraceCallCount()).To(Equal(1))
+		_, ip, port := fakePathTracer.TraceArgsForCall(0)
+		Expect(ip).To(Equal(net.ParseIP("92.168.12.19")))
+		Expect(port).To(Equal(uint16(1245)))
+	})
+
+	It("should register the path", func() {
+		Expect(probe()).To(Succeed())
+
+		Expect(fakeRegistry.RegisterPathCallCount()).To(Equal(1))
+		Expect(fakeRegistry.RegisterPathArgsForCall(0)).To(Equal(api.PathResults{
+			Peer:     "92.168.12.19:1245",
+			IP:       net.ParseIP("92.168.12.19"),
+			Protocol: "udp",
+			Time:     now,
+			Hops: []api.Hop{
+				{TTL: 1, IP: net.ParseIP("10.0.0.1"), RTT: time.Millisecond},
+				{TTL: 2},
+				{TTL: 3, IP: net.ParseIP("92.168.12.19"), RTT: time.Millisecond * 3},
+			},
+			Reached: true,
+		}))
+	})
+
+	Context("when the path is discovered again", func() {
+		BeforeEach(func() {
+			Expect(probe()).To(Succeed())
+		})
+
+		It("should not flag a change", func() {
+			Expect(probe()).To(Succeed())
+
+			Expect(fakeRegistry.RegisterPathCallCount()).To(Equal(2))
+			Expect(fakeRegistry.RegisterPathArgsForCall(1).Changed).To(BeFalse())
+		})
+
+		Context("and the path changed", func() {
+			BeforeEach(func() {
+				changedPath := path
+				changedPath.Hops = []traceroute.Hop{
+					{TTL: 1, IP: net.ParseIP("10.0.0.1")},
+					{TTL: 2, IP: net.ParseIP("10.0.9.1")},
+					{TTL: 3, IP: net.ParseIP("10.0.9.2")},
+					{TTL: 4, IP: net.ParseIP("92.168.12.19")},
+				}
+				fakePathTracer.TraceReturns(changedPath, nil)
+			})
+
+			It("should flag the change", func() {
+				Expect(probe()).To(Succeed())
+
+				Expect(fakeRegistry.RegisterPathArgsForCall(1).Changed).To(BeTrue())
+			})
+
+			It("should warn", func() {
+				Expect(probe()).To(Succeed())
+
+				Expect(logBuffer).To(gbytes.Say(
+					"level=warning msg=\"Path to the peer changed\" current_hops=\"10.0.0.1 10.0.9.1 10.0.9.2 92.168.12.19\" previous_hops=\"10.0.0.1 \\* 92.168.12.19\"",
+				))
+			})
+
+			It("should compare the next path with the changed one", func() {
+				Expect(probe()).To(Succeed())
+				Expect(probe()).To(Succeed())
+
+				Expect(fakeRegistry.RegisterPathCallCount()).To(Equal(3))
+				Expect(fakeRegistry.RegisterPathArgsForCall(2).Changed).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when the discovery fails", func() {
+		BeforeEach(func() {
+			fakePathTracer.TraceReturns(traceroute.Path{}, errors.New("banana"))
+		})
+
+		It("should return the error", func() {
+			Expect(probe()).To(MatchError("banana"))
+		})
+
+		It("should not register a path", func() {
+			probe()
+
+			Expect(fakeRegistry.RegisterPathCallCount()).To(BeZero())
+		})
+	})
+})
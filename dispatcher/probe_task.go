@@ -0,0 +1,118 @@
+package dispatcher
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// Probe measures a peer at its address and registers the results, e.g. the
+// path or the path MTU to the peer.
+//
+//go:generate counterfeiter . Probe
+type Probe interface {
+	// Name describes the probe, e.g. `path discovery`.
+	Name() string
+	Probe(
+		ctx context.Context, logger *logrus.Entry, peer string, ip net.IP,
+		port uint16, now time.Time,
+	) error
+}
+
+// ProbeTask probes a peer every interval. It stays scheduled for as long as
+// the agent runs and waits between the probes, so that it is not selected in
+// vain.
+type ProbeTask struct {
+	Probe Probe
+	Host  string
+	IP    net.IP
+	Port  uint16
+	// Time between the probes
+	Interval time.Duration
+	Clock    clock.Clock
+
+	// Optional; only the peers with IP addresses are probed without it.
+	Resolver Resolver
+
+	Logger *logrus.Logger
+
+	lastRun time.Time
+	lock    sync.Mutex
+}
+
+func (t *ProbeTask) Run() {
+	t.lock.Lock()
+	now := t.Clock.Now()
+	if !t.isDue(now) {
+		t.lock.Unlock()
+		return
+	}
+	// failed probes wait for the next interval as well
+	t.lastRun = now
+	t.lock.Unlock()
+
+	logger := t.Logger.WithFields(logrus.Fields{
+		"peer":  t.peer(),
+		"probe": t.Probe.Name(),
+	})
+	ctx := context.Background()
+
+	ip := t.IP
+	if t.Host != "" && t.Resolver != nil {
+		var err error
+		ip, err = t.Resolver.Resolve(ctx, t.Host)
+		if err != nil {
+			logger.Errorf("Probe will be retried: %s", err.Error())
+			return
+		}
+	}
+	if ip == nil {
+		logger.Error("Probe will be retried: the peer is not resolved")
+		return
+	}
+
+	if err := t.Probe.Probe(ctx, logger, t.peer(), ip, t.Port, now); err != nil {
+		logger.Errorf("Probe will be retried: %s", err.Error())
+	}
+}
+
+func (t *ProbeTask) peer() string {
+	spec := transfer.TransferSpec{Host: t.Host, IP: t.IP, Port: t.Port}
+	return spec.Peer()
+}
+
+func (t *ProbeTask) String() string {
+	return t.Probe.Name() + " to " + t.peer()
+}
+
+func (t *ProbeTask) Priority() int {
+	return ProbeTaskPriority
+}
+
+// ExclusionKey keeps the probes from running during the transfers to the
+// same peer, which would delay the answers to the probes.
+func (t *ProbeTask) ExclusionKey() string {
+	return t.peer()
+}
+
+// State is waiting until the next probe is due.
+func (t *ProbeTask) State() scheduler.TaskState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.isDue(t.Clock.Now()) {
+		return scheduler.TaskStateWaiting
+	}
+
+	return scheduler.TaskStateReady
+}
+
+func (t *ProbeTask) isDue(now time.Time) bool {
+	return t.lastRun.IsZero() || now.Sub(t.lastRun) >= t.Interval
+}
@@ -0,0 +1,172 @@
+package dispatcher_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/dispatcher"
+	"github.com/ice-stuff/clique/dispatcher/fakes"
+	"github.com/ice-stuff/clique/scheduler"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("ProbeTask", func() {
+	var (
+		t         *dispatcher.ProbeTask
+		fakeProbe *fakes.FakeProbe
+		clk       *fakeclock.FakeClock
+		logBuffer *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		fakeProbe = new(fakes.FakeProbe)
+		fakeProbe.NameReturns("path discovery")
+		clk = fakeclock.NewFakeClock(time.Now())
+		logBuffer = gbytes.NewBuffer()
+
+		t = &dispatcher.ProbeTask{
+			Probe:    fakeProbe,
+			IP:       net.ParseIP("92.168.12.19"),
+			Port:     1245,
+			Interval: time.Minute,
+			Clock:    clk,
+			Logger: &logrus.Logger{
+				Out:       logBuffer,
+				Level:     logrus.DebugLevel,
+				Formatter: new(logrus.TextFormatter),
+			},
+		}
+	})
+
+	It("should be exclusive to the peer", func() {
+		Expect(t.ExclusionKey()).To(Equal("92.168.12.19:1245"))
+	})
+
+	It("should describe the probe", func() {
+		Expect(t.String()).To(Equal("path discovery to 92.168.12.19:1245"))
+	})
+
+	It("should be ready to run the first probe", func() {
+		Expect(t.State()).To(Equal(scheduler.TaskStateReady))
+	})
+
+	It("should wait until the next probe is due", func() {
+		t.Run()
+		Expect(t.State()).To(Equal(scheduler.TaskStateWaiting))
+
+		clk.Increment(time.Minute)
+		Expect(t.State()).To(Equal(scheduler.TaskStateReady))
+	})
+
+	It("should probe the peer", func() {
+		t.Run()
+
+		Expect(fakeProbe.ProbeCallCount()).To(Equal(1))
+		_, _, peer, ip, port, now := fakeProbe.ProbeArgsForCall(0)
+		Expect(peer).To(Equal("92.168.12.19:1245"))
+		Expect(ip).To(Equal(net.ParseIP("92.168.12.19")))
+		Expect(port).To(Equal(uint16(1245)))
+		Expect(now).To(Equal(clk.Now()))
+	})
+
+	It("should not probe the peer again before the interval", func() {
+		t.Run()
+		clk.Increment(time.Second * 59)
+		t.Run()
+
+		Expect(fakeProbe.ProbeCallCount()).To(Equal(1))
+	})
+
+	It("should probe the peer again after the interval", func() {
+		t.Run()
+		clk.Increment(time.Minute)
+		t.Run()
+
+		Expect(fakeProbe.ProbeCallCount()).To(Equal(2))
+	})
+
+	Context("when the probe fails", func() {
+		BeforeEach(func() {
+			fakeProbe.ProbeReturns(errors.New("banana"))
+		})
+
+		It("should log the failure", func() {
+			t.Run()
+
+			Expect(logBuffer).To(gbytes.Say(
+				"level=error msg=\"Probe will be retried: banana\" peer=\"92.168.12.19:1245\" probe=\"path discovery\"",
+			))
+		})
+
+		It("should retry after the interval", func() {
+			t.Run()
+			t.Run()
+			Expect(fakeProbe.ProbeCallCount()).To(Equal(1))
+
+			clk.Increment(time.Minute)
+			t.Run()
+			Expect(fakeProbe.ProbeCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when the peer is a hostname", func() {
+		var fakeResolver *fakes.FakeResolver
+
+		BeforeEach(func() {
+			fakeResolver = new(fakes.FakeResolver)
+			fakeResolver.ResolveReturns(net.ParseIP("92.168.12.20"), nil)
+
+			t.Host = "peer.example.com"
+			t.IP = nil
+			t.Resolver = fakeResolver
+		})
+
+		It("should probe the address of the hostname", func() {
+			t.Run()
+
+			_, host := fakeResolver.ResolveArgsForCall(0)
+			Expect(host).To(Equal("peer.example.com"))
+			_, _, peer, ip, _, _ := fakeProbe.ProbeArgsForCall(0)
+			Expect(peer).To(Equal("peer.example.com:1245"))
+			Expect(ip).To(Equal(net.ParseIP("92.168.12.20")))
+		})
+
+		Context("and it cannot be resolved", func() {
+			BeforeEach(func() {
+				fakeResolver.ResolveReturns(nil, errors.New("no such host"))
+			})
+
+			It("should not probe the peer", func() {
+				t.Run()
+
+				Expect(fakeProbe.ProbeCallCount()).To(BeZero())
+			})
+
+			It("should retry after the interval", func() {
+				t.Run()
+				clk.Increment(time.Minute)
+				fakeResolver.ResolveReturns(net.ParseIP("92.168.12.20"), nil)
+				t.Run()
+
+				Expect(fakeProbe.ProbeCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("and there is no resolver", func() {
+			BeforeEach(func() {
+				t.Resolver = nil
+			})
+
+			It("should not probe the peer", func() {
+				t.Run()
+
+				Expect(fakeProbe.ProbeCallCount()).To(BeZero())
+			})
+		})
+	})
+})
@@ -1,11 +1,13 @@
 package dispatcher
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/budget"
 	"github.com/ice-stuff/clique/scheduler"
 	"github.com/ice-stuff/clique/transfer"
 )
@@ -15,30 +17,168 @@ type TransferTask struct {
 	TransferClient        TransferClient
 	TransferSpec          transfer.TransferSpec
 
-	Registry ApiRegistry
+	Registry    ApiRegistry
+	ResultSinks []ResultSink
+	Coordinator Coordinator
+	Sizer       Sizer
+	Budget      Budget
+	Resolver    Resolver
+	// Configured labels of the peer, which take precedence over the labels
+	// that it advertises. Optional.
+	PeerLabels map[string]string
 
 	DesiredPriority int
+	// The transfer expires when it has not run by then. Optional.
+	TransferDeadline time.Time
+	// Run ahead of the transfers that are not urgent.
+	RunNow bool
 
 	Logger *logrus.Logger
 
 	done          bool
 	transferState api.TransferState
+	// the budget delayed the transfer
+	throttled bool
+	// bytes per second of the previous attempts, which tells the size that
+	// fits in a measurement slot; only Run uses it
+	throughput float64
 
 	lock sync.Mutex
 }
 
 func (t *TransferTask) Run() {
+	t.lock.Lock()
+	expired := t.expire()
+	t.lock.Unlock()
+	if expired {
+		return
+	}
+
+	ctx := context.Background()
+	var slotEnd time.Time
+	if t.Coordinator != nil {
+		var ok bool
+		slotEnd, ok = t.Coordinator.Slot(t.TransferSpec.Peer())
+		if !ok {
+			t.Logger.WithFields(
+				transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+			).Debug("Transfer task is waiting for its measurement slot")
+			return
+		}
+
+		if !slotEnd.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, slotEnd)
+			defer cancel()
+		}
+	}
+
+	spec := t.TransferSpec
+	if spec.Host != "" && t.Resolver != nil {
+		ip, err := t.Resolver.Resolve(ctx, spec.Host)
+		if err != nil {
+			t.Logger.WithFields(
+				transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+			).Errorf("Transfer task will be rescheduled: %s", err.Error())
+			return
+		}
+		spec.IP = ip
+	}
+
+	if t.Sizer != nil {
+		spec.Size = t.Sizer.Size(spec.Peer(), spec.Size)
+	}
+
+	if !slotEnd.IsZero() && !t.fitInSlot(&spec, slotEnd) {
+		t.Logger.WithFields(
+			transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+		).WithField("size", spec.Size).Debug(
+			"Transfer task is waiting for a measurement slot that it fits in",
+		)
+		return
+	}
+
+	var reservation budget.Reservation
+	if t.Budget != nil {
+		if limit := t.Budget.Limit(); limit != 0 && spec.Size > limit {
+			t.Logger.WithFields(
+				transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+			).WithField("size", spec.Size).Warnf(
+				"Transfer is larger than the bandwidth budget, capping it to %d bytes",
+				limit,
+			)
+			spec.Size = limit
+		}
+
+		var ok bool
+		reservation, ok = t.Budget.Reserve(spec.Peer(), spec.Size)
+		if !ok {
+			t.Logger.WithFields(
+				transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+			).WithField("size", spec.Size).Debug(
+				"Transfer task is throttled by the bandwidth budget",
+			)
+
+			t.lock.Lock()
+			t.throttled = true
+			t.lock.Unlock()
+
+			return
+		}
+	}
+
 	t.TransferInterruptible.Interrupt()
 	defer t.TransferInterruptible.Resume()
 
 	t.lock.Lock()
+	// the deadline may have passed while the task was preparing
+	if t.expire() {
+		t.lock.Unlock()
+		t.refund(reservation, 0)
+		return
+	}
 	t.transferState = api.TransferStateRunning
 	t.lock.Unlock()
 
-	res, err := t.TransferClient.Transfer(t.TransferSpec)
+	startTime := time.Now()
+	res, err := t.TransferClient.Transfer(ctx, spec)
 	if err != nil {
-		t.Logger.Errorf("Transfer task will be rescheduled: %s", err.Error())
+		// the failed attempts, e.g. to a busy peer, only use the bytes that
+		// they sent
+		t.refund(reservation, res.BytesSent)
+	}
+	if err == transfer.ErrClientClosed {
+		t.Logger.WithFields(
+			transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+		).Warn("Transfer task is aborted")
 
+		t.lock.Lock()
+		t.transferState = api.TransferStateAborted
+		t.done = true
+		t.lock.Unlock()
+
+		return
+	}
+	if err != nil && !slotEnd.IsZero() && ctx.Err() == context.DeadlineExceeded {
+		// the next attempts are sized by what this one sent in the slot
+		duration := time.Since(startTime)
+		t.observeThroughput(res.BytesSent, duration)
+		if t.Sizer != nil {
+			t.Sizer.Observe(spec.Peer(), res.BytesSent, duration)
+		}
+
+		t.Logger.WithFields(
+			transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+		).WithFields(logrus.Fields{
+			"size":       spec.Size,
+			"bytes_sent": res.BytesSent,
+		}).Warn("Transfer did not fit in its measurement slot, it will be resized")
+	} else if err != nil {
+		t.Logger.WithFields(
+			transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+		).Errorf("Transfer task will be rescheduled: %s", err.Error())
+	}
+	if err != nil {
 		t.lock.Lock()
 		t.transferState = api.TransferStatePending
 		t.lock.Unlock()
@@ -46,17 +186,50 @@ func (t *TransferTask) Run() {
 		return
 	}
 
-	t.Registry.RegisterResults(
-		t.TransferSpec.IP,
-		api.TransferResults{
-			IP:        t.TransferSpec.IP,
-			BytesSent: res.BytesSent,
-			Checksum:  res.Checksum,
-			Duration:  res.Duration,
-			RTT:       res.RTT,
-			Time:      time.Now(),
-		},
-	)
+	t.refund(reservation, res.BytesSent)
+	t.observeThroughput(res.BytesSent, res.Duration)
+	if t.Sizer != nil {
+		t.Sizer.Observe(spec.Peer(), res.BytesSent, res.Duration)
+	}
+
+	apiRes := api.TransferResults{
+		ID:        t.TransferSpec.ID,
+		IP:        spec.IP,
+		BytesSent: res.BytesSent,
+		Checksum:  res.Checksum,
+		Duration:  res.Duration,
+		RTT:       res.RTT,
+		Time:      time.Now(),
+		Size:      spec.Size,
+		Throttled: t.isThrottled() || res.Throttled,
+		Intervals: apiIntervals(res.Intervals),
+		Peer:      spec.Peer(),
+
+		SourceNodeID:      res.Source.NodeID,
+		SourcePort:        res.Source.Port,
+		SourceLabels:      res.Source.Labels,
+		DestinationNodeID: res.Destination.NodeID,
+		DestinationPort:   res.Destination.Port,
+		DestinationLabels: mergeLabels(res.Destination.Labels, t.PeerLabels),
+		Backend:           res.Backend,
+		Packets:           res.Packets,
+		LostPackets:       res.LostPackets,
+		Jitter:            res.Jitter,
+		Retransmits:       res.Retransmits,
+		SourceCPU:         res.SourceCPU,
+		DestinationCPU:    res.DestinationCPU,
+	}
+	if res.TCPInfo != nil {
+		tcpInfo := apiTCPInfo(*res.TCPInfo)
+		apiRes.TCPInfo = &tcpInfo
+	}
+	for _, sample := range res.TCPInfoSamples {
+		apiRes.TCPInfoSamples = append(apiRes.TCPInfoSamples, apiTCPInfo(sample))
+	}
+	t.Registry.RegisterResults(spec.IP, apiRes)
+	for _, sink := range t.ResultSinks {
+		sink.Push(apiRes)
+	}
 
 	t.lock.Lock()
 	t.transferState = api.TransferStateCompleted
@@ -64,15 +237,163 @@ func (t *TransferTask) Run() {
 	t.lock.Unlock()
 }
 
+// expire marks the transfer as expired when it is still pending after its
+// deadline and reports if it is expired. It must be called with the lock
+// held.
+func (t *TransferTask) expire() bool {
+	if t.transferState == api.TransferStateExpired {
+		return true
+	}
+	if t.done || t.transferState == api.TransferStateRunning {
+		return false
+	}
+	if t.TransferDeadline.IsZero() || !time.Now().After(t.TransferDeadline) {
+		return false
+	}
+
+	t.Logger.WithFields(
+		transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+	).Warn("Transfer task missed its deadline")
+	t.transferState = api.TransferStateExpired
+	t.done = true
+
+	return true
+}
+
+// slotFitMargin is the share of a measurement slot that the transfers are
+// sized to, which leaves room for their handshakes and for the throughput to
+// vary.
+const slotFitMargin = 0.9
+
+// fitInSlot caps the transfer to the bytes that fit in a whole measurement
+// slot at the throughput of the previous attempts and reports if it fits in
+// the rest of the current slot. The first attempt is never capped.
+func (t *TransferTask) fitInSlot(spec *transfer.TransferSpec, slotEnd time.Time) bool {
+	if t.throughput == 0 {
+		return true
+	}
+
+	slotSize := uint64(
+		t.throughput * slotFitMargin * t.Coordinator.SlotDuration().Seconds(),
+	)
+	if spec.Size > slotSize {
+		t.Logger.WithFields(
+			transfer.LogFields(t.TransferSpec.ID, t.TransferSpec.Peer()),
+		).WithField("size", spec.Size).Warnf(
+			"Transfer is larger than its measurement slot, capping it to %d bytes",
+			slotSize,
+		)
+		spec.Size = slotSize
+	}
+
+	remaining := time.Until(slotEnd).Seconds()
+	return float64(spec.Size) <= t.throughput*slotFitMargin*remaining
+}
+
+// observeThroughput records the throughput of an attempt.
+func (t *TransferTask) observeThroughput(bytes uint64, duration time.Duration) {
+	if bytes == 0 || duration <= 0 {
+		return
+	}
+
+	t.throughput = float64(bytes) / duration.Seconds()
+}
+
+// refund gives the bytes of the reservation that the transfer did not send
+// back to the budget.
+func (t *TransferTask) refund(r budget.Reservation, bytesSent uint64) {
+	if t.Budget == nil || bytesSent >= r.Size {
+		return
+	}
+
+	t.Budget.Refund(r, r.Size-bytesSent)
+}
+
+func (t *TransferTask) isThrottled() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.throttled
+}
+
+// mergeLabels returns the advertised labels overridden by the configured
+// ones.
+func mergeLabels(advertised, configured map[string]string) map[string]string {
+	if len(configured) == 0 {
+		return advertised
+	}
+
+	labels := make(map[string]string, len(advertised)+len(configured))
+	for key, value := range advertised {
+		labels[key] = value
+	}
+	for key, value := range configured {
+		labels[key] = value
+	}
+
+	return labels
+}
+
+func apiIntervals(intervals []transfer.Interval) []api.TransferInterval {
+	if len(intervals) == 0 {
+		return nil
+	}
+
+	res := make([]api.TransferInterval, len(intervals))
+	for i, interval := range intervals {
+		res[i] = api.TransferInterval{
+			Start:    interval.Start,
+			Duration: interval.Duration,
+			Bytes:    interval.Bytes,
+		}
+	}
+
+	return res
+}
+
+func apiTCPInfo(info transfer.TCPInfo) api.TCPInfo {
+	return api.TCPInfo{
+		Offset:           info.Offset,
+		Retransmits:      info.Retransmits,
+		CongestionWindow: info.CongestionWindow,
+		RTT:              info.RTT,
+		RTTVar:           info.RTTVar,
+		PacingRate:       info.PacingRate,
+		DeliveryRate:     info.DeliveryRate,
+	}
+}
+
+func (t *TransferTask) String() string {
+	return "transfer " + t.TransferSpec.ID + " to " + t.TransferSpec.Peer()
+}
+
 func (t *TransferTask) Priority() int {
 	return t.DesiredPriority
 }
 
+// Deadline lets the earliest-deadline-first selector run the transfer before
+// it expires.
+func (t *TransferTask) Deadline() time.Time {
+	return t.TransferDeadline
+}
+
+func (t *TransferTask) Urgent() bool {
+	return t.RunNow
+}
+
+// ExclusionKey makes sure that there is at most one transfer to every peer
+// at any time.
+func (t *TransferTask) ExclusionKey() string {
+	return t.TransferSpec.Peer()
+}
+
+// State is done as soon as the deadline of a pending transfer passes, so that
+// the scheduler drops it without running it.
 func (t *TransferTask) State() scheduler.TaskState {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	if t.done {
+	if t.done || t.expire() {
 		return scheduler.TaskStateDone
 	}
 
@@ -83,6 +404,7 @@ func (t *TransferTask) TransferState() api.TransferState {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	t.expire()
 	if t.transferState == "" {
 		t.transferState = api.TransferStatePending
 	}
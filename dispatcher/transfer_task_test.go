@@ -1,18 +1,21 @@
 package dispatcher_test
 
 import (
+	"context"
 	"errors"
 	"net"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/budget"
 	"github.com/ice-stuff/clique/dispatcher"
 	"github.com/ice-stuff/clique/dispatcher/fakes"
 	"github.com/ice-stuff/clique/scheduler"
 	"github.com/ice-stuff/clique/transfer"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("TransferTask", func() {
@@ -22,6 +25,8 @@ var _ = Describe("TransferTask", func() {
 		fakeTransferClient        *fakes.FakeTransferClient
 		transferSpec              transfer.TransferSpec
 		fakeRegistry              *fakes.FakeApiRegistry
+		fakeResultSinkA           *fakes.FakeResultSink
+		fakeResultSinkB           *fakes.FakeResultSink
 		priority                  int
 		logger                    *logrus.Logger
 	)
@@ -30,11 +35,14 @@ var _ = Describe("TransferTask", func() {
 		fakeTransferInterruptible = new(fakes.FakeInterruptible)
 		fakeTransferClient = new(fakes.FakeTransferClient)
 		transferSpec = transfer.TransferSpec{
+			ID:   "a1b2c3",
 			IP:   net.ParseIP("92.168.12.19"),
 			Port: 1245,
 			Size: 10 * 1024 * 1024,
 		}
 		fakeRegistry = new(fakes.FakeApiRegistry)
+		fakeResultSinkA = new(fakes.FakeResultSink)
+		fakeResultSinkB = new(fakes.FakeResultSink)
 		priority = 10
 		logger = &logrus.Logger{
 			Out:       GinkgoWriter,
@@ -50,16 +58,30 @@ var _ = Describe("TransferTask", func() {
 			DesiredPriority:       priority,
 			Logger:                logger,
 		}
+		t.ResultSinks = []dispatcher.ResultSink{fakeResultSinkA, fakeResultSinkB}
 	})
 
 	It("should return the provided priority", func() {
 		Expect(t.Priority()).To(Equal(priority))
 	})
 
+	It("should be exclusive to the peer", func() {
+		Expect(t.ExclusionKey()).To(Equal(transferSpec.Peer()))
+	})
+
+	It("should describe the transfer", func() {
+		Expect(t.String()).To(Equal("transfer a1b2c3 to 92.168.12.19:1245"))
+	})
+
+	It("should not be urgent", func() {
+		Expect(t.Urgent()).To(BeFalse())
+	})
+
 	It("should run the transfer", func() {
 		t.Run()
 		Expect(fakeTransferClient.TransferCallCount()).To(Equal(1))
-		Expect(fakeTransferClient.TransferArgsForCall(0)).To(Equal(transferSpec))
+		_, spec := fakeTransferClient.TransferArgsForCall(0)
+		Expect(spec).To(Equal(transferSpec))
 	})
 
 	It("should pause the server", func() {
@@ -109,7 +131,39 @@ var _ = Describe("TransferTask", func() {
 				Duration:  time.Millisecond * 100,
 				RTT:       time.Millisecond * 20,
 				Checksum:  uint32(12),
-				BytesSent: uint32(10 * 1024 * 1024),
+				BytesSent: uint64(10 * 1024 * 1024),
+				Intervals: []transfer.Interval{
+					{Duration: 50 * time.Millisecond, Bytes: 4 * 1024 * 1024},
+					{
+						Start:    50 * time.Millisecond,
+						Duration: 50 * time.Millisecond,
+						Bytes:    6 * 1024 * 1024,
+					},
+				},
+				TCPInfo: &transfer.TCPInfo{
+					Offset:           100 * time.Millisecond,
+					Retransmits:      3,
+					CongestionWindow: 10,
+					RTT:              20 * time.Millisecond,
+					RTTVar:           time.Millisecond,
+					PacingRate:       200 * 1024 * 1024,
+					DeliveryRate:     100 * 1024 * 1024,
+				},
+				TCPInfoSamples: []transfer.TCPInfo{
+					{Offset: 50 * time.Millisecond, CongestionWindow: 8},
+					{Offset: 100 * time.Millisecond, CongestionWindow: 10},
+				},
+				Source: transfer.Identity{
+					NodeID: "node-a",
+					Labels: map[string]string{"dc": "ams"},
+					Port:   5000,
+				},
+				Destination: transfer.Identity{
+					NodeID: "node-b",
+					Labels: map[string]string{"dc": "fra"},
+					Port:   1245,
+				},
+				Backend: "simple",
 			}
 			fakeTransferClient.TransferReturns(transferResults, nil)
 		})
@@ -120,12 +174,633 @@ var _ = Describe("TransferTask", func() {
 			Expect(fakeRegistry.RegisterResultsCallCount()).To(Equal(1))
 			ip, res := fakeRegistry.RegisterResultsArgsForCall(0)
 			Expect(ip).To(Equal(transferSpec.IP))
+			Expect(res.ID).To(Equal(transferSpec.ID))
 			Expect(res.IP).To(Equal(transferSpec.IP))
 			Expect(res.BytesSent).To(Equal(transferResults.BytesSent))
 			Expect(res.Checksum).To(Equal(transferResults.Checksum))
 			Expect(res.Duration).To(Equal(transferResults.Duration))
 			Expect(res.RTT).To(Equal(transferResults.RTT))
 			Expect(res.Time).To(BeTemporally("~", time.Now(), time.Second))
+			Expect(res.Size).To(Equal(transferSpec.Size))
+			Expect(res.Throttled).To(BeFalse())
+			Expect(res.Peer).To(Equal("92.168.12.19:1245"))
+			Expect(res.Intervals).To(Equal([]api.TransferInterval{
+				{Duration: 50 * time.Millisecond, Bytes: 4 * 1024 * 1024},
+				{
+					Start:    50 * time.Millisecond,
+					Duration: 50 * time.Millisecond,
+					Bytes:    6 * 1024 * 1024,
+				},
+			}))
+		})
+
+		It("should register the statistics of the connection", func() {
+			t.Run()
+
+			_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+			Expect(res.TCPInfo).To(Equal(&api.TCPInfo{
+				Offset:           100 * time.Millisecond,
+				Retransmits:      3,
+				CongestionWindow: 10,
+				RTT:              20 * time.Millisecond,
+				RTTVar:           time.Millisecond,
+				PacingRate:       200 * 1024 * 1024,
+				DeliveryRate:     100 * 1024 * 1024,
+			}))
+			Expect(res.TCPInfoSamples).To(Equal([]api.TCPInfo{
+				{Offset: 50 * time.Millisecond, CongestionWindow: 8},
+				{Offset: 100 * time.Millisecond, CongestionWindow: 10},
+			}))
+		})
+
+		It("should register the identities of both ends and the backend", func() {
+			t.Run()
+
+			_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+			Expect(res.SourceNodeID).To(Equal("node-a"))
+			Expect(res.SourcePort).To(Equal(uint16(5000)))
+			Expect(res.SourceLabels).To(Equal(map[string]string{"dc": "ams"}))
+			Expect(res.DestinationNodeID).To(Equal("node-b"))
+			Expect(res.DestinationPort).To(Equal(uint16(1245)))
+			Expect(res.DestinationLabels).To(Equal(map[string]string{"dc": "fra"}))
+			Expect(res.Backend).To(Equal("simple"))
+		})
+
+		Context("when the backend counts the datagrams", func() {
+			BeforeEach(func() {
+				transferResults.Backend = "udp"
+				transferResults.Packets = 100
+				transferResults.LostPackets = 3
+				transferResults.Jitter = 200 * time.Microsecond
+				fakeTransferClient.TransferReturns(transferResults, nil)
+			})
+
+			It("should register the loss and the jitter", func() {
+				t.Run()
+
+				_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+				Expect(res.Backend).To(Equal("udp"))
+				Expect(res.Packets).To(Equal(uint64(100)))
+				Expect(res.LostPackets).To(Equal(uint64(3)))
+				Expect(res.Jitter).To(Equal(200 * time.Microsecond))
+			})
+		})
+
+		Context("when the backend reports the retransmits and the CPU", func() {
+			BeforeEach(func() {
+				transferResults.Backend = "iperf"
+				transferResults.Retransmits = 12
+				transferResults.SourceCPU = 45.5
+				transferResults.DestinationCPU = 20.25
+				fakeTransferClient.TransferReturns(transferResults, nil)
+			})
+
+			It("should register them", func() {
+				t.Run()
+
+				_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+				Expect(res.Retransmits).To(Equal(uint64(12)))
+				Expect(res.SourceCPU).To(Equal(45.5))
+				Expect(res.DestinationCPU).To(Equal(20.25))
+			})
+		})
+
+		Context("when the peer has configured labels", func() {
+			BeforeEach(func() {
+				t.PeerLabels = map[string]string{"dc": "ber", "rack": "r1"}
+			})
+
+			It("should override the labels that the peer advertised", func() {
+				t.Run()
+
+				_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+				Expect(res.DestinationLabels).To(Equal(map[string]string{
+					"dc": "ber", "rack": "r1",
+				}))
+				Expect(transferResults.Destination.Labels).To(Equal(
+					map[string]string{"dc": "fra"},
+				))
+			})
+		})
+
+		It("should push the registered transfer results to every sink", func() {
+			t.Run()
+
+			_, registeredRes := fakeRegistry.RegisterResultsArgsForCall(0)
+			Expect(fakeResultSinkA.PushCallCount()).To(Equal(1))
+			Expect(fakeResultSinkA.PushArgsForCall(0)).To(Equal(registeredRes))
+			Expect(fakeResultSinkB.PushCallCount()).To(Equal(1))
+			Expect(fakeResultSinkB.PushArgsForCall(0)).To(Equal(registeredRes))
+		})
+	})
+
+	Context("when the transfers are sized adaptively", func() {
+		var fakeSizer *fakes.FakeSizer
+
+		BeforeEach(func() {
+			fakeSizer = new(fakes.FakeSizer)
+			fakeSizer.SizeReturns(64 * 1024 * 1024)
+			t.Sizer = fakeSizer
+
+			fakeTransferClient.TransferReturns(transfer.TransferResults{
+				BytesSent: 64 * 1024 * 1024,
+				Duration:  time.Second * 8,
+			}, nil)
+		})
+
+		It("should transfer the chosen size", func() {
+			t.Run()
+
+			Expect(fakeSizer.SizeCallCount()).To(Equal(1))
+			peer, requested := fakeSizer.SizeArgsForCall(0)
+			Expect(peer).To(Equal(transferSpec.Peer()))
+			Expect(requested).To(Equal(transferSpec.Size))
+
+			_, spec := fakeTransferClient.TransferArgsForCall(0)
+			Expect(spec.Size).To(BeEquivalentTo(64 * 1024 * 1024))
+		})
+
+		It("should record the chosen size in the results", func() {
+			t.Run()
+
+			_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+			Expect(res.Size).To(BeEquivalentTo(64 * 1024 * 1024))
+		})
+
+		It("should report the outcome to the sizer", func() {
+			t.Run()
+
+			Expect(fakeSizer.ObserveCallCount()).To(Equal(1))
+			peer, bytes, duration := fakeSizer.ObserveArgsForCall(0)
+			Expect(peer).To(Equal(transferSpec.Peer()))
+			Expect(bytes).To(BeEquivalentTo(64 * 1024 * 1024))
+			Expect(duration).To(Equal(time.Second * 8))
+		})
+
+		Context("and the transfer fails", func() {
+			BeforeEach(func() {
+				fakeTransferClient.TransferReturns(
+					transfer.TransferResults{}, errors.New("banana"),
+				)
+			})
+
+			It("should not report anything to the sizer", func() {
+				t.Run()
+
+				Expect(fakeSizer.ObserveCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Context("when the transfers have a bandwidth budget", func() {
+		var (
+			fakeBudget  *fakes.FakeBudget
+			reservation budget.Reservation
+		)
+
+		BeforeEach(func() {
+			fakeBudget = new(fakes.FakeBudget)
+			t.Budget = fakeBudget
+
+			reservation = budget.Reservation{
+				Peer: transferSpec.Peer(),
+				Size: transferSpec.Size,
+			}
+		})
+
+		It("should reserve the size of the transfer", func() {
+			fakeBudget.ReserveReturns(reservation, true)
+
+			t.Run()
+
+			Expect(fakeBudget.ReserveCallCount()).To(Equal(1))
+			peer, size := fakeBudget.ReserveArgsForCall(0)
+			Expect(peer).To(Equal(transferSpec.Peer()))
+			Expect(size).To(Equal(transferSpec.Size))
+		})
+
+		It("should not refund the transfers that send their size", func() {
+			fakeBudget.ReserveReturns(reservation, true)
+			fakeTransferClient.TransferReturns(transfer.TransferResults{
+				BytesSent: transferSpec.Size,
+			}, nil)
+
+			t.Run()
+
+			Expect(fakeBudget.RefundCallCount()).To(BeZero())
+		})
+
+		It("should refund the bytes that the transfer did not send", func() {
+			fakeBudget.ReserveReturns(reservation, true)
+			fakeTransferClient.TransferReturns(transfer.TransferResults{
+				BytesSent: transferSpec.Size - 100,
+			}, nil)
+
+			t.Run()
+
+			Expect(fakeBudget.RefundCallCount()).To(Equal(1))
+			r, size := fakeBudget.RefundArgsForCall(0)
+			Expect(r).To(Equal(reservation))
+			Expect(size).To(BeNumerically("==", 100))
+		})
+
+		Context("and the transfer is larger than the budget", func() {
+			BeforeEach(func() {
+				fakeBudget.LimitReturns(transferSpec.Size - 100)
+				fakeBudget.ReserveReturns(reservation, true)
+			})
+
+			It("should cap the transfer to the budget", func() {
+				t.Run()
+
+				_, size := fakeBudget.ReserveArgsForCall(0)
+				Expect(size).To(Equal(transferSpec.Size - 100))
+				_, spec := fakeTransferClient.TransferArgsForCall(0)
+				Expect(spec.Size).To(Equal(transferSpec.Size - 100))
+			})
+		})
+
+		Context("and the transfer fails", func() {
+			BeforeEach(func() {
+				fakeBudget.ReserveReturns(reservation, true)
+				fakeTransferClient.TransferReturns(
+					transfer.TransferResults{}, errors.New("i-am-busy"),
+				)
+			})
+
+			It("should not charge anything", func() {
+				t.Run()
+
+				Expect(fakeBudget.RefundCallCount()).To(Equal(1))
+				r, size := fakeBudget.RefundArgsForCall(0)
+				Expect(r).To(Equal(reservation))
+				Expect(size).To(Equal(transferSpec.Size))
+			})
+		})
+
+		Context("and the transfer is aborted", func() {
+			BeforeEach(func() {
+				fakeBudget.ReserveReturns(reservation, true)
+				fakeTransferClient.TransferReturns(transfer.TransferResults{
+					BytesSent: 100,
+				}, transfer.ErrClientClosed)
+			})
+
+			It("should only charge the bytes that it sent", func() {
+				t.Run()
+
+				Expect(fakeBudget.RefundCallCount()).To(Equal(1))
+				r, size := fakeBudget.RefundArgsForCall(0)
+				Expect(r).To(Equal(reservation))
+				Expect(size).To(Equal(transferSpec.Size - 100))
+			})
+		})
+
+		Context("and the transfer does not fit in it", func() {
+			BeforeEach(func() {
+				fakeBudget.ReserveReturns(budget.Reservation{}, false)
+			})
+
+			It("should not run the transfer", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(BeZero())
+				Expect(fakeTransferInterruptible.InterruptCallCount()).To(BeZero())
+				Expect(t.State()).To(Equal(scheduler.TaskStateReady))
+				Expect(t.TransferState()).To(Equal(api.TransferStatePending))
+			})
+
+			It("should report that it was throttled when it runs", func() {
+				t.Run()
+				fakeBudget.ReserveReturns(reservation, true)
+				t.Run()
+
+				Expect(fakeRegistry.RegisterResultsCallCount()).To(Equal(1))
+				_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+				Expect(res.Throttled).To(BeTrue())
+			})
+		})
+	})
+
+	Context("when the peer is a hostname", func() {
+		var fakeResolver *fakes.FakeResolver
+
+		BeforeEach(func() {
+			transferSpec.Host = "peer.example.com"
+			transferSpec.IP = nil
+			t.TransferSpec = transferSpec
+
+			fakeResolver = new(fakes.FakeResolver)
+			fakeResolver.ResolveReturns(net.ParseIP("fe80::1"), nil)
+			t.Resolver = fakeResolver
+		})
+
+		It("should transfer to the address of the host", func() {
+			t.Run()
+
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+			_, host := fakeResolver.ResolveArgsForCall(0)
+			Expect(host).To(Equal("peer.example.com"))
+
+			_, spec := fakeTransferClient.TransferArgsForCall(0)
+			Expect(spec.IP).To(Equal(net.ParseIP("fe80::1")))
+		})
+
+		It("should register the results by the peer and its address", func() {
+			t.Run()
+
+			ip, res := fakeRegistry.RegisterResultsArgsForCall(0)
+			Expect(ip).To(Equal(net.ParseIP("fe80::1")))
+			Expect(res.IP).To(Equal(net.ParseIP("fe80::1")))
+			Expect(res.Peer).To(Equal("peer.example.com:1245"))
+		})
+
+		Context("and it cannot be resolved", func() {
+			BeforeEach(func() {
+				fakeResolver.ResolveReturns(nil, errors.New("no such host"))
+			})
+
+			It("should retry the transfer later", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(BeZero())
+				Expect(t.State()).To(Equal(scheduler.TaskStateReady))
+				Expect(t.TransferState()).To(Equal(api.TransferStatePending))
+			})
+		})
+	})
+
+	Context("when the sender is rate limited", func() {
+		BeforeEach(func() {
+			fakeTransferClient.TransferReturns(transfer.TransferResults{
+				Throttled: true,
+			}, nil)
+		})
+
+		It("should report that the transfer was throttled", func() {
+			t.Run()
+
+			_, res := fakeRegistry.RegisterResultsArgsForCall(0)
+			Expect(res.Throttled).To(BeTrue())
+		})
+	})
+
+	Context("when the task fails", func() {
+		BeforeEach(func() {
+			fakeTransferClient.TransferReturns(
+				transfer.TransferResults{}, errors.New("banana"),
+			)
+		})
+
+		It("should not push anything to the sinks", func() {
+			t.Run()
+
+			Expect(fakeResultSinkA.PushCallCount()).To(BeZero())
+			Expect(fakeResultSinkB.PushCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the task has a deadline", func() {
+		It("should report it", func() {
+			deadline := time.Now().Add(time.Hour)
+			t.TransferDeadline = deadline
+
+			Expect(t.Deadline()).To(Equal(deadline))
+		})
+
+		Context("and it is missed", func() {
+			BeforeEach(func() {
+				t.TransferDeadline = time.Now().Add(-time.Second)
+			})
+
+			It("should not run the transfer", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(BeZero())
+				Expect(fakeTransferInterruptible.InterruptCallCount()).To(BeZero())
+			})
+
+			It("should be done and expired", func() {
+				t.Run()
+
+				Expect(t.State()).To(Equal(scheduler.TaskStateDone))
+				Expect(t.TransferState()).To(Equal(api.TransferStateExpired))
+			})
+
+			It("should be expired before it runs", func() {
+				Expect(t.TransferState()).To(Equal(api.TransferStateExpired))
+				Expect(t.State()).To(Equal(scheduler.TaskStateDone))
+			})
+		})
+
+		Context("and it passes while the transfer is rescheduled", func() {
+			BeforeEach(func() {
+				t.TransferDeadline = time.Now().Add(time.Millisecond * 50)
+				fakeTransferClient.TransferReturns(
+					transfer.TransferResults{}, errors.New("banana"),
+				)
+			})
+
+			It("should expire", func() {
+				t.Run()
+				Expect(t.TransferState()).To(Equal(api.TransferStatePending))
+
+				Eventually(t.TransferState).Should(Equal(api.TransferStateExpired))
+				Expect(t.State()).To(Equal(scheduler.TaskStateDone))
+			})
+		})
+
+		Context("and it passes while the task prepares the transfer", func() {
+			BeforeEach(func() {
+				t.TransferDeadline = time.Now().Add(time.Millisecond * 50)
+				fakeTransferInterruptible.InterruptStub = func() {
+					time.Sleep(time.Millisecond * 100)
+				}
+			})
+
+			It("should not run the transfer", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(BeZero())
+				Expect(t.TransferState()).To(Equal(api.TransferStateExpired))
+			})
+		})
+
+		Context("and it is not missed", func() {
+			BeforeEach(func() {
+				t.TransferDeadline = time.Now().Add(time.Hour)
+			})
+
+			It("should run the transfer", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the task should run now", func() {
+		It("should be urgent", func() {
+			t.RunNow = true
+
+			Expect(t.Urgent()).To(BeTrue())
+		})
+	})
+
+	Context("when the transfers are coordinated", func() {
+		var fakeCoordinator *fakes.FakeCoordinator
+
+		BeforeEach(func() {
+			fakeCoordinator = new(fakes.FakeCoordinator)
+			t.Coordinator = fakeCoordinator
+		})
+
+		It("should ask for the slot of the peer", func() {
+			t.Run()
+
+			Expect(fakeCoordinator.SlotCallCount()).To(Equal(1))
+			Expect(fakeCoordinator.SlotArgsForCall(0)).To(Equal(transferSpec.Peer()))
+		})
+
+		Context("and it is not the slot of the peer", func() {
+			BeforeEach(func() {
+				fakeCoordinator.SlotReturns(time.Time{}, false)
+			})
+
+			It("should not run the transfer", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(BeZero())
+				Expect(fakeTransferInterruptible.InterruptCallCount()).To(BeZero())
+				Expect(t.State()).To(Equal(scheduler.TaskStateReady))
+				Expect(t.TransferState()).To(Equal(api.TransferStatePending))
+			})
+		})
+
+		Context("and it is the slot of the peer", func() {
+			var slotEnd time.Time
+
+			BeforeEach(func() {
+				slotEnd = time.Now().Add(time.Minute)
+				fakeCoordinator.SlotReturns(slotEnd, true)
+			})
+
+			It("should run the transfer until the end of the slot", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(Equal(1))
+				ctx, _ := fakeTransferClient.TransferArgsForCall(0)
+				deadline, ok := ctx.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(deadline).To(Equal(slotEnd))
+			})
+		})
+
+		Context("and the transfer does not fit in the slot", func() {
+			var logs *gbytes.Buffer
+
+			BeforeEach(func() {
+				logs = gbytes.NewBuffer()
+				logger.Out = logs
+
+				fakeCoordinator.SlotDurationReturns(time.Second)
+				fakeCoordinator.SlotReturns(time.Now().Add(100*time.Millisecond), true)
+				// the link sends a hundredth of the transfer in the slot
+				fakeTransferClient.TransferStub = func(
+					ctx context.Context, _ transfer.TransferSpec,
+				) (transfer.TransferResults, error) {
+					<-ctx.Done()
+					return transfer.TransferResults{
+						BytesSent: transferSpec.Size / 100,
+					}, ctx.Err()
+				}
+			})
+
+			It("should reschedule the transfer", func() {
+				t.Run()
+
+				Expect(t.State()).To(Equal(scheduler.TaskStateReady))
+				Expect(t.TransferState()).To(Equal(api.TransferStatePending))
+				Expect(logs).To(gbytes.Say("Transfer did not fit in its measurement slot"))
+			})
+
+			It("should cap the next attempts to the bytes that fit in a slot", func() {
+				t.Run()
+				fakeCoordinator.SlotReturns(time.Now().Add(time.Minute), true)
+				fakeTransferClient.TransferStub = nil
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(Equal(2))
+				_, spec := fakeTransferClient.TransferArgsForCall(1)
+				Expect(spec.Size).To(BeNumerically(">", 0))
+				Expect(spec.Size).To(BeNumerically("<", transferSpec.Size/10))
+			})
+
+			It("should not start the next attempts late in a slot", func() {
+				t.Run()
+				fakeCoordinator.SlotReturns(time.Now().Add(10*time.Millisecond), true)
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(Equal(1))
+				Expect(t.TransferState()).To(Equal(api.TransferStatePending))
+			})
+
+			Context("and the transfers are sized", func() {
+				var fakeSizer *fakes.FakeSizer
+
+				BeforeEach(func() {
+					fakeSizer = new(fakes.FakeSizer)
+					fakeSizer.SizeStub = func(_ string, requested uint64) uint64 {
+						return requested
+					}
+					t.Sizer = fakeSizer
+				})
+
+				It("should report the bytes that it sent to the sizer", func() {
+					t.Run()
+
+					Expect(fakeSizer.ObserveCallCount()).To(Equal(1))
+					_, bytes, duration := fakeSizer.ObserveArgsForCall(0)
+					Expect(bytes).To(Equal(transferSpec.Size / 100))
+					Expect(duration).To(BeNumerically(">", 0))
+				})
+			})
+		})
+
+		Context("and the peer is not coordinated", func() {
+			BeforeEach(func() {
+				fakeCoordinator.SlotReturns(time.Time{}, true)
+			})
+
+			It("should run the transfer without a deadline", func() {
+				t.Run()
+
+				Expect(fakeTransferClient.TransferCallCount()).To(Equal(1))
+				ctx, _ := fakeTransferClient.TransferArgsForCall(0)
+				_, ok := ctx.Deadline()
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when the transfer client is closed", func() {
+		BeforeEach(func() {
+			fakeTransferClient.TransferReturns(
+				transfer.TransferResults{}, transfer.ErrClientClosed,
+			)
+		})
+
+		It("should be done", func() {
+			t.Run()
+
+			Expect(t.State()).To(Equal(scheduler.TaskStateDone))
+		})
+
+		It("should record the transfer as aborted", func() {
+			t.Run()
+
+			Expect(t.TransferState()).To(Equal(api.TransferStateAborted))
+			Expect(fakeRegistry.RegisterResultsCallCount()).To(BeZero())
 		})
 	})
 
@@ -136,7 +811,7 @@ var _ = Describe("TransferTask", func() {
 			transferrerChan = make(chan bool)
 
 			fakeTransferClient.TransferStub = func(
-				_ transfer.TransferSpec,
+				_ context.Context, _ transfer.TransferSpec,
 			) (transfer.TransferResults, error) {
 				transferrerChan <- true
 
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/rotate"
+)
+
+type FileConfig struct {
+	Path       string
+	Format     api.ResultsFormat
+	MaxSize    int64
+	MaxBackups int
+}
+
+// FileExporter appends every exported transfer result to a rotating local
+// file.
+type FileExporter struct {
+	encoder api.ResultsEncoder
+	file    *rotate.File
+
+	lock sync.Mutex
+}
+
+func NewFileExporter(cfg FileConfig) (*FileExporter, error) {
+	encoder, err := api.NewResultsEncoder(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	header := new(bytes.Buffer)
+	if err := encoder.WriteHeader(header); err != nil {
+		return nil, err
+	}
+
+	file, err := rotate.Open(cfg.Path, rotate.Options{
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		Header:     header.Bytes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileExporter{
+		encoder: encoder,
+		file:    file,
+	}, nil
+}
+
+func (e *FileExporter) Export(res api.TransferResults) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// encode first so that a record is never split between two files
+	record := new(bytes.Buffer)
+	if err := e.encoder.Encode(record, res); err != nil {
+		return err
+	}
+
+	_, err := e.file.Write(record.Bytes())
+	return err
+}
+
+func (e *FileExporter) Close() error {
+	return e.file.Close()
+}
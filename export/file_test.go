@@ -0,0 +1,132 @@
+package export_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/export"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileExporter", func() {
+	var (
+		dir      string
+		cfg      export.FileConfig
+		exporter *export.FileExporter
+		res      api.TransferResults
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = export.FileConfig{
+			Path:   filepath.Join(dir, "results.csv"),
+			Format: api.ResultsFormatCSV,
+		}
+
+		t, err := time.Parse(time.RFC3339, "2015-12-20T17:25:12Z")
+		Expect(err).NotTo(HaveOccurred())
+		res = api.TransferResults{
+			IP:        net.ParseIP("12.12.12.13"),
+			BytesSent: 1024,
+			Checksum:  124566,
+			Duration:  time.Second * 12,
+			RTT:       time.Millisecond * 12,
+			Time:      t,
+			Size:      1024,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	readLines := func(path string) []string {
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return strings.Split(strings.TrimSpace(string(contents)), "\n")
+	}
+
+	Context("when the format cannot be streamed", func() {
+		It("should fail", func() {
+			cfg.Format = api.ResultsFormatJSON
+
+			_, err := export.NewFileExporter(cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the file cannot be created", func() {
+		It("should fail", func() {
+			cfg.Path = "/path/to/banana/results.csv"
+
+			_, err := export.NewFileExporter(cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when it is set up", func() {
+		JustBeforeEach(func() {
+			var err error
+			exporter, err = export.NewFileExporter(cfg)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(exporter.Close()).To(Succeed())
+		})
+
+		It("should append the encoded results", func() {
+			Expect(exporter.Export(res)).To(Succeed())
+			Expect(exporter.Export(res)).To(Succeed())
+
+			lines := readLines(cfg.Path)
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(HavePrefix("time,ip,bytes_sent"))
+			Expect(lines[1]).To(Equal(
+				"2015-12-20T17:25:12Z,12.12.12.13,1024,124566,12000000000,12000000,1024,false,,,,",
+			))
+			Expect(lines[2]).To(Equal(lines[1]))
+		})
+
+		Context("and the file grows over the maximum size", func() {
+			BeforeEach(func() {
+				cfg.MaxSize = 100
+				cfg.MaxBackups = 1
+			})
+
+			It("should rotate it and repeat the header", func() {
+				for i := 0; i < 3; i++ {
+					Expect(exporter.Export(res)).To(Succeed())
+				}
+
+				Expect(readLines(cfg.Path)).To(HaveLen(2))
+				Expect(readLines(cfg.Path)[0]).To(HavePrefix("time,ip,bytes_sent"))
+				Expect(readLines(cfg.Path + ".1")).To(HaveLen(2))
+			})
+		})
+
+		Context("and the format is JSON lines", func() {
+			BeforeEach(func() {
+				cfg.Format = api.ResultsFormatJSONLines
+			})
+
+			It("should write one object per line", func() {
+				Expect(exporter.Export(res)).To(Succeed())
+				Expect(exporter.Export(res)).To(Succeed())
+
+				lines := readLines(cfg.Path)
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0]).To(HavePrefix(`{"ip":"12.12.12.13"`))
+			})
+		})
+	})
+})
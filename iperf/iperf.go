@@ -1,5 +1,31 @@
 package iperf
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var ErrBusy = errors.New("server is busy")
+
+// BackendName names the iperf transfers in the results.
+const BackendName = "iperf"
+
+// Options tune the tests of the senders. The receivers follow the options
+// of the senders, like iperf servers do.
+type Options struct {
+	// Parallel streams of every test. Default: 1.
+	Parallel int
+	// The receiver sends the data to the sender.
+	Reverse bool
+	// Socket buffer size of the streams in bytes. The system picks it when
+	// it is zero.
+	Window int
+	// Bits per second that every stream may send. Default: unlimited for TCP
+	// and 1 Mbit/s for UDP.
+	Bitrate uint64
+	// Time at the start of every test that is omitted from the results, e.g.
+	// the TCP slow start. Iperf counts it in whole seconds.
+	Omit time.Duration
+	// The tests measure UDP, whose results carry the jitter and the loss.
+	UDP bool
+}
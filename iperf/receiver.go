@@ -1,8 +1,10 @@
 package iperf
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -11,25 +13,26 @@ import (
 )
 
 type Receiver struct {
-	logger    *logrus.Logger
 	iperfPort uint16
+	// iperf listens on all the addresses when nil
+	bindAddress net.IP
 
-	isBusy   bool
-	isPaused bool
+	isBusy bool
+	// Interrupt calls that are not resumed yet
+	pauses int
 
 	stateMutex          *sync.Mutex
 	transferFinishMutex *sync.Mutex
 	transferFinish      *sync.Cond
 }
 
-func NewReceiver(logger *logrus.Logger, iperfPort uint16) *Receiver {
+func NewReceiver(iperfPort uint16, bindAddress net.IP) *Receiver {
 	transferFinishMutex := new(sync.Mutex)
 	return &Receiver{
-		logger:    logger,
-		iperfPort: iperfPort,
+		iperfPort:   iperfPort,
+		bindAddress: bindAddress,
 
-		isBusy:   false,
-		isPaused: false,
+		isBusy: false,
 
 		stateMutex:          new(sync.Mutex),
 		transferFinishMutex: transferFinishMutex,
@@ -37,37 +40,50 @@ func NewReceiver(logger *logrus.Logger, iperfPort uint16) *Receiver {
 	}
 }
 
-func (r *Receiver) ReceiveTransfer(conn io.ReadWriter) (
-	transfer.TransferResults, error,
-) {
+func (r *Receiver) ReceiveTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
 	r.stateMutex.Lock()
-	isBusy := r.isPaused || r.isBusy
+	isBusy := r.pauses > 0 || r.isBusy
 	if !isBusy {
 		r.isBusy = true
 	}
 	r.stateMutex.Unlock()
 
 	if isBusy {
-		if err := r.handleBusy(conn); err != nil {
-			r.logger.Errorf("Failed to send busy message: %s", err)
+		if err := r.handleBusy(logger, conn); err != nil {
+			logger.Errorf("Failed to send busy message: %s", err)
 		}
 
 		return transfer.TransferResults{}, ErrBusy
 	}
 
-	defer func() {
-		// reset state
-		r.stateMutex.Lock()
-		r.isBusy = false
-		r.stateMutex.Unlock()
-		r.transferFinish.Broadcast()
-	}()
-	return r.handleTransfer(conn)
+	res, done, err := r.handleTransfer(ctx, logger, conn)
+	select {
+	case <-done:
+		r.release()
+	default:
+		// libiperf holds the port until its test ends
+		logger.Debug("[IPERF] Waiting for iperf to release its port...")
+		go func() {
+			<-done
+			r.release()
+		}()
+	}
+
+	return res, err
+}
+
+func (r *Receiver) release() {
+	r.stateMutex.Lock()
+	r.isBusy = false
+	r.stateMutex.Unlock()
+	r.transferFinish.Broadcast()
 }
 
 func (r *Receiver) Interrupt() {
 	r.stateMutex.Lock()
-	r.isPaused = true
+	r.pauses++
 	isBusy := r.isBusy
 	r.stateMutex.Unlock()
 
@@ -81,18 +97,20 @@ func (r *Receiver) Interrupt() {
 func (r *Receiver) Resume() {
 	r.stateMutex.Lock()
 	defer r.stateMutex.Unlock()
-	r.isPaused = false
+	if r.pauses > 0 {
+		r.pauses--
+	}
 }
 
 func (r *Receiver) IsBusy() bool {
 	r.stateMutex.Lock()
 	defer r.stateMutex.Unlock()
 
-	return r.isBusy || r.isPaused
+	return r.isBusy || r.pauses > 0
 }
 
-func (r *Receiver) handleBusy(conn io.ReadWriter) error {
-	r.logger.Debug("[IPERF] Server is busy!")
+func (r *Receiver) handleBusy(logger *logrus.Entry, conn io.ReadWriter) error {
+	logger.Debug("[IPERF] Server is busy!")
 	if _, err := conn.Write([]byte("i-am-busy")); err != nil {
 		return err
 	}
@@ -100,16 +118,29 @@ func (r *Receiver) handleBusy(conn io.ReadWriter) error {
 	return nil
 }
 
-func (r *Receiver) handleTransfer(conn io.ReadWriter) (
-	transfer.TransferResults, error,
-) {
-	r.logger.Debug("[IPERF] Handling the transfer...")
+// handleTransfer returns the results and a channel that is closed when
+// libiperf is done with the iperf port.
+func (r *Receiver) handleTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, <-chan struct{}, error) {
+	logger.Debug("[IPERF] Handling the transfer...")
 	msg := fmt.Sprintf("ok - %d", r.iperfPort)
 	if _, err := conn.Write([]byte(msg)); err != nil {
-		return transfer.TransferResults{}, err
+		return transfer.TransferResults{}, closedChan(), err
 	}
 
-	return runner.ListenAndServe(runner.ServerConfig{
-		ListenPort: r.iperfPort,
+	res, done, err := runner.ListenAndServe(ctx, runner.ServerConfig{
+		ListenPort:  r.iperfPort,
+		BindAddress: r.bindAddress,
 	})
+	res.Backend = BackendName
+
+	return res, done, err
+}
+
+func closedChan() <-chan struct{} {
+	c := make(chan struct{})
+	close(c)
+
+	return c
 }
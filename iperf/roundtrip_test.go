@@ -1,7 +1,9 @@
 package iperf_test
 
 import (
+	"context"
 	"net"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/iperf"
@@ -14,6 +16,7 @@ import (
 var _ = Describe("Roundtrip", func() {
 	var (
 		logger       *logrus.Logger
+		iperfPort    uint16
 		receiver     *iperf.Receiver
 		sender       *iperf.Sender
 		senderConn   net.Conn
@@ -26,10 +29,10 @@ var _ = Describe("Roundtrip", func() {
 			Level:     logrus.DebugLevel,
 			Formatter: new(logrus.TextFormatter),
 		}
-		iperfPort := testhelpers.SelectPort(GinkgoParallelNode())
+		iperfPort = testhelpers.SelectPort(GinkgoParallelNode())
 
-		receiver = iperf.NewReceiver(logger, iperfPort)
-		sender = iperf.NewSender(logger)
+		receiver = iperf.NewReceiver(iperfPort, nil)
+		sender = iperf.NewSender(logger, time.Second, iperf.Options{})
 
 		senderConn, receiverConn = net.Pipe()
 	})
@@ -53,7 +56,7 @@ var _ = Describe("Roundtrip", func() {
 
 		Describe("Receiver.ReceiveTransfer", func() {
 			It("returns an error", func() {
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).To(MatchError(ContainSubstring("on closed pipe")))
 			})
 		})
@@ -64,7 +67,7 @@ var _ = Describe("Roundtrip", func() {
 					IP:   net.ParseIP("127.0.0.1"),
 					Size: 100 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, senderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).To(MatchError(ContainSubstring("on closed pipe")))
 			})
 		})
@@ -82,7 +85,7 @@ var _ = Describe("Roundtrip", func() {
 						IP:   net.ParseIP("127.0.0.1"),
 						Size: 100 * 1024 * 1024,
 					}
-					_, err := sender.SendTransfer(spec, senderConn)
+					_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(senderConn.Close()).To(Succeed())
 
@@ -91,7 +94,7 @@ var _ = Describe("Roundtrip", func() {
 
 				Consistently(senderDone).ShouldNot(BeClosed())
 
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(senderDone).Should(BeClosed())
 
@@ -111,7 +114,7 @@ var _ = Describe("Roundtrip", func() {
 			go func() {
 				defer GinkgoRecover()
 				var err error
-				receiverRes, err = receiver.ReceiveTransfer(receiverConn)
+				receiverRes, err = receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).NotTo(HaveOccurred())
 				close(receiverDone)
 			}()
@@ -124,7 +127,7 @@ var _ = Describe("Roundtrip", func() {
 					Size: 100 * 1024 * 1024,
 				}
 
-				senderRes, err := sender.SendTransfer(spec, senderConn)
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 
@@ -148,7 +151,7 @@ var _ = Describe("Roundtrip", func() {
 					Size: 100 * 1024 * 1024,
 				}
 
-				senderRes, err := sender.SendTransfer(spec, senderConn)
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 
@@ -164,7 +167,7 @@ var _ = Describe("Roundtrip", func() {
 					Size: 100 * 1024 * 1024,
 				}
 
-				senderRes, err := sender.SendTransfer(spec, senderConn)
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 
@@ -174,9 +177,147 @@ var _ = Describe("Roundtrip", func() {
 					BeNumerically("~", receiverRes.Duration, 60000000),
 				) // +/- 60000000ns = 60ms
 			})
+
+			It("should report the CPU utilisation of both ends", func() {
+				spec := transfer.TransferSpec{
+					IP:   net.ParseIP("127.0.0.1"),
+					Size: 100 * 1024 * 1024,
+				}
+
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(senderConn.Close()).To(Succeed())
+
+				Eventually(receiverDone).Should(BeClosed())
+				Expect(senderRes.SourceCPU).To(BeNumerically(">", 0))
+				Expect(senderRes.DestinationCPU).To(BeNumerically(">", 0))
+				Expect(receiverRes.SourceCPU).To(Equal(senderRes.SourceCPU))
+			})
+
+			Context("with parallel streams and a window", func() {
+				BeforeEach(func() {
+					sender = iperf.NewSender(logger, time.Second, iperf.Options{
+						Parallel: 4,
+						Window:   256 * 1024,
+					})
+				})
+
+				It("should send the requested number of bytes", func() {
+					spec := transfer.TransferSpec{
+						IP:   net.ParseIP("127.0.0.1"),
+						Size: 100 * 1024 * 1024,
+					}
+
+					senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+
+					Eventually(receiverDone).Should(BeClosed())
+					margin := float32(spec.Size) * 0.10
+					Expect(senderRes.BytesSent).To(
+						BeNumerically("~", spec.Size, margin),
+					)
+					Expect(senderRes.BytesSent).To(
+						BeNumerically("~", receiverRes.BytesSent, margin),
+					)
+				})
+			})
+
+			Context("in reverse", func() {
+				BeforeEach(func() {
+					sender = iperf.NewSender(logger, time.Second, iperf.Options{
+						Reverse: true,
+					})
+				})
+
+				It("should report the bytes that the receiver sent", func() {
+					spec := transfer.TransferSpec{
+						IP:   net.ParseIP("127.0.0.1"),
+						Size: 100 * 1024 * 1024,
+					}
+
+					senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+
+					Eventually(receiverDone).Should(BeClosed())
+					margin := float32(spec.Size) * 0.10
+					Expect(receiverRes.BytesSent).To(
+						BeNumerically("~", spec.Size, margin),
+					)
+					Expect(senderRes.BytesSent).To(
+						BeNumerically("~", receiverRes.BytesSent, margin),
+					)
+					Expect(receiverRes.RTT).NotTo(BeZero())
+				})
+			})
+
+			Context("with UDP", func() {
+				BeforeEach(func() {
+					sender = iperf.NewSender(logger, time.Second, iperf.Options{
+						Bitrate: 100 * 1000 * 1000,
+						UDP:     true,
+					})
+				})
+
+				It("should report the datagrams and their loss", func() {
+					spec := transfer.TransferSpec{
+						IP:   net.ParseIP("127.0.0.1"),
+						Size: 1024 * 1024,
+					}
+
+					senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+
+					Eventually(receiverDone).Should(BeClosed())
+					Expect(senderRes.Packets).NotTo(BeZero())
+					Expect(senderRes.LostPackets).To(BeNumerically("<=", senderRes.Packets))
+					Expect(receiverRes.Packets).To(Equal(senderRes.Packets))
+					Expect(senderRes.Retransmits).To(BeZero())
+				})
+			})
 		})
 	})
 
+	Context("when the transfer of the receiver is cancelled", func() {
+		It("should release the iperf port although no sender connects", func(done Done) {
+			ctx, cancel := context.WithCancel(context.Background())
+			receiverErrs := make(chan error, 1)
+			go func() {
+				_, err := receiver.ReceiveTransfer(ctx, logrus.NewEntry(logger), receiverConn)
+				receiverErrs <- err
+			}()
+
+			// the handshake starts the iperf server, which no test reaches
+			handshake := make([]byte, 16)
+			_, err := senderConn.Read(handshake)
+			Expect(err).NotTo(HaveOccurred())
+			cancel()
+			Eventually(receiverErrs).Should(Receive(Equal(context.Canceled)))
+			Eventually(receiver.IsBusy, 5).Should(BeFalse())
+
+			// the next transfer gets the port
+			nextSenderConn, nextReceiverConn := net.Pipe()
+			defer nextSenderConn.Close()
+			defer nextReceiverConn.Close()
+			go func() {
+				defer GinkgoRecover()
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), nextReceiverConn)
+				Expect(err).NotTo(HaveOccurred())
+			}()
+			spec := transfer.TransferSpec{
+				IP:   net.ParseIP("127.0.0.1"),
+				Size: 1024 * 1024,
+			}
+			res, err := sender.SendTransfer(context.Background(), spec, nextSenderConn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.BytesSent).To(BeNumerically(">", 0))
+
+			close(done)
+		}, 10.0)
+	})
+
 	Context("when the receiver is busy", func() {
 		var receiverDone chan struct{}
 
@@ -184,7 +325,7 @@ var _ = Describe("Roundtrip", func() {
 			receiverDone = make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).NotTo(HaveOccurred())
 				close(receiverDone)
 			}()
@@ -194,7 +335,7 @@ var _ = Describe("Roundtrip", func() {
 		})
 
 		AfterEach(func() {
-			_, err := sender.SendTransfer(transfer.TransferSpec{
+			_, err := sender.SendTransfer(context.Background(), transfer.TransferSpec{
 				IP:   net.ParseIP("127.0.0.1"),
 				Size: 100 * 1024 * 1024,
 			}, senderConn)
@@ -213,12 +354,12 @@ var _ = Describe("Roundtrip", func() {
 						IP:   net.ParseIP("127.0.0.1"),
 						Size: 100 * 1024 * 1024,
 					}
-					_, err := sender.SendTransfer(spec, newSenderConn)
+					_, err := sender.SendTransfer(context.Background(), spec, newSenderConn)
 					Expect(err).To(HaveOccurred())
 					close(newSenderDone)
 				}()
 
-				_, err := receiver.ReceiveTransfer(newReceiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), newReceiverConn)
 				Expect(err).To(Equal(iperf.ErrBusy))
 				Eventually(newSenderDone).Should(BeClosed())
 
@@ -232,7 +373,7 @@ var _ = Describe("Roundtrip", func() {
 				newReceiverDone := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
-					_, err := receiver.ReceiveTransfer(newReceiverConn)
+					_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), newReceiverConn)
 					Expect(err).To(HaveOccurred())
 					close(newReceiverDone)
 				}()
@@ -241,7 +382,7 @@ var _ = Describe("Roundtrip", func() {
 					IP:   net.ParseIP("127.0.0.1"),
 					Size: 100 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, newSenderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, newSenderConn)
 				Expect(err).To(Equal(iperf.ErrBusy))
 				Eventually(newReceiverDone).Should(BeClosed())
 
@@ -265,12 +406,12 @@ var _ = Describe("Roundtrip", func() {
 						IP:   net.ParseIP("127.0.0.1"),
 						Size: 100 * 1024 * 1024,
 					}
-					_, err := sender.SendTransfer(spec, senderConn)
+					_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 					Expect(err).To(HaveOccurred())
 					close(senderDone)
 				}()
 
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).To(Equal(iperf.ErrBusy))
 				Eventually(senderDone).Should(BeClosed())
 
@@ -284,7 +425,7 @@ var _ = Describe("Roundtrip", func() {
 				receiverDone := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
-					_, err := receiver.ReceiveTransfer(receiverConn)
+					_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 					Expect(err).To(HaveOccurred())
 					close(receiverDone)
 				}()
@@ -293,7 +434,7 @@ var _ = Describe("Roundtrip", func() {
 					IP:   net.ParseIP("127.0.0.1"),
 					Size: 100 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, senderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).To(Equal(iperf.ErrBusy))
 				Eventually(receiverDone).Should(BeClosed())
 
@@ -311,7 +452,7 @@ var _ = Describe("Roundtrip", func() {
 				receiverDone := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
-					_, err := receiver.ReceiveTransfer(receiverConn)
+					_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 					Expect(err).NotTo(HaveOccurred())
 					close(receiverDone)
 				}()
@@ -320,7 +461,7 @@ var _ = Describe("Roundtrip", func() {
 					IP:   net.ParseIP("127.0.0.1"),
 					Size: 100 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, senderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 				Eventually(receiverDone).Should(BeClosed())
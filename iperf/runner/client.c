@@ -17,6 +17,18 @@ IRResult ir_run_test(IRClientConfig cfg, int json_fd) {
   // configuration
   iperf_defaults(test);
   iperf_set_test_role(test, 'c');
+  switch (cfg.protocol) {
+  case IR_PROTOCOL_UDP:
+    set_protocol(test, Pudp);
+    // like iperf3, which paces UDP tests unless it is told otherwise
+    iperf_set_test_rate(test, UDP_RATE);
+    break;
+  case IR_PROTOCOL_SCTP:
+    set_protocol(test, Psctp);
+    break;
+  default:
+    break;
+  }
   if (cfg.duration_secs > 0)
     iperf_set_test_duration(test, cfg.duration_secs);
   if (cfg.buffer_size > 0)
@@ -25,10 +37,22 @@ IRResult ir_run_test(IRClientConfig cfg, int json_fd) {
     test->settings->bytes = cfg.bytes_amt;
   if (cfg.packets_amt > 0)
     test->settings->blocks = cfg.packets_amt;
+  if (cfg.num_streams > 0)
+    iperf_set_test_num_streams(test, cfg.num_streams);
+  if (cfg.reverse)
+    iperf_set_test_reverse(test, 1);
+  if (cfg.window > 0)
+    iperf_set_test_socket_bufsize(test, cfg.window);
+  if (cfg.bitrate > 0)
+    iperf_set_test_rate(test, cfg.bitrate);
+  if (cfg.omit_secs > 0)
+    iperf_set_test_omit(test, cfg.omit_secs);
 
   // server
   iperf_set_test_server_hostname(test, cfg.target_host_ip);
   iperf_set_test_server_port(test, cfg.target_host_port);
+  if (cfg.bind_address != NULL)
+    iperf_set_test_bind_address(test, cfg.bind_address);
 
   // output
   test->outfile = fdopen(json_fd, "w");
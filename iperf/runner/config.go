@@ -23,12 +23,15 @@ type ServerConfig struct {
 	Config
 	// Port to listen to
 	ListenPort uint16
+	// Address to listen on. Iperf listens on all the addresses when it is nil.
+	BindAddress net.IP
 }
 
 func (c ServerConfig) ToIRServerConfig() C.IRServerConfig {
 	return C.IRServerConfig{
-		ir_config:   c.Config.ToIRConfig(),
-		listen_port: C.int(c.ListenPort),
+		ir_config:    c.Config.ToIRConfig(),
+		listen_port:  C.int(c.ListenPort),
+		bind_address: bindAddress(c.BindAddress),
 	}
 }
 
@@ -49,16 +52,31 @@ type ClientConfig struct {
 	// Target  to connect to
 	TargetHostIP   net.IP
 	TargetHostPort uint16
+	// Local address of the connections. The system picks it when it is nil.
+	BindAddress net.IP
 	// Transport protocol to use for the measurements.
 	Protocol Protocol
 	// Duration of the stream. Default: 10 seconds.
 	Duration time.Duration
-	// Amount of buffers to send.
-	BytesAmt uint
+	// Amount of bytes to send.
+	BytesAmt uint64
 	// Size of transmission buffer. Default: 128 KB for TCP and 8 KB for UDP.
 	BufferSize uint
 	// Amount of packets to send.
 	PacketsAmt uint
+	// Parallel streams of the test. Default: 1.
+	NumStreams int
+	// The server sends the data to the client.
+	Reverse bool
+	// Socket buffer size of the streams in bytes. The system picks it when it
+	// is zero.
+	Window int
+	// Bits per second that every stream may send. Default: unlimited for TCP
+	// and 1 Mbit/s for UDP.
+	Bitrate uint64
+	// Time at the start of the test that is omitted from the results. Iperf
+	// counts it in whole seconds.
+	Omit time.Duration
 }
 
 func (c ClientConfig) ToIRClientConfig() C.IRClientConfig {
@@ -66,10 +84,32 @@ func (c ClientConfig) ToIRClientConfig() C.IRClientConfig {
 		ir_config:        c.Config.ToIRConfig(),
 		target_host_ip:   C.CString(c.TargetHostIP.String()),
 		target_host_port: C.int(c.TargetHostPort),
+		bind_address:     bindAddress(c.BindAddress),
 		protocol:         c.Protocol.ToIRProtocol(),
 		duration_secs:    C.int(c.Duration.Seconds()),
-		bytes_amt:        C.int(c.BytesAmt),
+		bytes_amt:        C.uint64_t(c.BytesAmt),
 		buffer_size:      C.int(c.BufferSize),
 		packets_amt:      C.int(c.PacketsAmt),
+		num_streams:      C.int(c.NumStreams),
+		reverse:          cBool(c.Reverse),
+		window:           C.int(c.Window),
+		bitrate:          C.uint64_t(c.Bitrate),
+		omit_secs:        C.int(c.Omit.Seconds()),
 	}
 }
+
+func bindAddress(ip net.IP) *C.char {
+	if ip == nil {
+		return nil
+	}
+
+	return C.CString(ip.String())
+}
+
+func cBool(b bool) C.int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -5,9 +5,11 @@ package runner
 // #include "runner.h"
 import "C"
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"time"
@@ -26,19 +28,200 @@ type Stream struct {
 type Measurement struct {
 	Bytes   uint64
 	Seconds float64
+	// Only reported by the sender of TCP tests
+	Retransmits uint64
+}
+
+// UDPMeasurement is the summary of the UDP tests, which the receiver counts.
+type UDPMeasurement struct {
+	Bytes       uint64
+	Seconds     float64
+	JitterMS    float64 `json:"jitter_ms"`
+	LostPackets uint64  `json:"lost_packets"`
+	Packets     uint64
+}
+
+// CPUUtilization is in percent of a CPU for the local and the remote host.
+type CPUUtilization struct {
+	HostTotal   float64 `json:"host_total"`
+	RemoteTotal float64 `json:"remote_total"`
 }
 
 type EndReport struct {
-	Streams     []Stream
-	SumReceived Measurement `json:"sum_received"`
-	SumSent     Measurement `json:"sum_sent"`
+	Streams        []Stream
+	Sum            UDPMeasurement
+	SumReceived    Measurement    `json:"sum_received"`
+	SumSent        Measurement    `json:"sum_sent"`
+	CPUUtilization CPUUtilization `json:"cpu_utilization_percent"`
+}
+
+type TestStart struct {
+	Protocol string
+	// 1 when the server sends the data
+	Reverse int
+}
+
+type StartReport struct {
+	TestStart TestStart `json:"test_start"`
+}
+
+type IntervalSum struct {
+	Start   float64
+	Seconds float64
+	Bytes   uint64
+}
+
+type IntervalReport struct {
+	Sum IntervalSum
 }
 
 type report struct {
-	End EndReport
+	Start     StartReport
+	Intervals []IntervalReport
+	End       EndReport
+}
+
+// results returns the results of the local end of the test. The client and
+// the server report the bytes that they sent or received, depending on which
+// of them sent the data.
+func (r report) results(isClient bool) transfer.TransferResults {
+	var res transfer.TransferResults
+
+	isSender := isClient != (r.Start.TestStart.Reverse != 0)
+	if r.Start.TestStart.Protocol == "UDP" {
+		res.BytesSent = r.End.Sum.Bytes
+		res.Duration = secondsToDuration(r.End.Sum.Seconds)
+		res.Packets = r.End.Sum.Packets
+		res.LostPackets = r.End.Sum.LostPackets
+		res.Jitter = secondsToDuration(r.End.Sum.JitterMS / 1000)
+	} else {
+		if isSender {
+			res.BytesSent = r.End.SumSent.Bytes
+		} else {
+			res.BytesSent = r.End.SumReceived.Bytes
+		}
+		res.Duration = secondsToDuration(r.End.SumSent.Seconds)
+		res.Retransmits = r.End.SumSent.Retransmits
+	}
+
+	// only the sender of TCP tests measures the RTT of its streams
+	var rttSum, rttCount int64
+	for _, stream := range r.End.Streams {
+		if stream.Sender.MeanRTT > 0 {
+			rttSum += stream.Sender.MeanRTT
+			rttCount++
+		}
+	}
+	if rttCount > 0 {
+		res.RTT = time.Microsecond * time.Duration(rttSum/rttCount) // in us
+	}
+
+	cpu := r.End.CPUUtilization
+	if isClient {
+		res.SourceCPU, res.DestinationCPU = cpu.HostTotal, cpu.RemoteTotal
+	} else {
+		res.SourceCPU, res.DestinationCPU = cpu.RemoteTotal, cpu.HostTotal
+	}
+	res.Intervals = r.intervals()
+
+	return res
+}
+
+func (r report) intervals() []transfer.Interval {
+	if len(r.Intervals) == 0 {
+		return nil
+	}
+
+	intervals := make([]transfer.Interval, len(r.Intervals))
+	for i, interval := range r.Intervals {
+		intervals[i] = transfer.Interval{
+			Start:    secondsToDuration(interval.Sum.Start),
+			Duration: secondsToDuration(interval.Sum.Seconds),
+			Bytes:    interval.Sum.Bytes,
+		}
+	}
+
+	return intervals
+}
+
+func secondsToDuration(secs float64) time.Duration {
+	return time.Duration(secs * float64(time.Second))
 }
 
-func ListenAndServe(cfg ServerConfig) (transfer.TransferResults, error) {
+type runResult struct {
+	res transfer.TransferResults
+	err error
+}
+
+// withContext returns as soon as the context is done. The iperf calls cannot
+// be interrupted, so run keeps running in the background until it returns;
+// the returned channel is closed then.
+func withContext(
+	ctx context.Context, run func() (transfer.TransferResults, error),
+) (transfer.TransferResults, <-chan struct{}, error) {
+	resChan := make(chan runResult, 1)
+	done := make(chan struct{})
+	go func() {
+		res, err := run()
+		close(done)
+		resChan <- runResult{res: res, err: err}
+	}()
+
+	select {
+	case r := <-resChan:
+		return r.res, done, r.err
+	case <-ctx.Done():
+		return transfer.TransferResults{}, done, ctx.Err()
+	}
+}
+
+// ListenAndServe serves one test on the port of the configuration. When the
+// context is done before the test, it returns while libiperf keeps the port;
+// the returned channel is closed when libiperf releases it.
+func ListenAndServe(ctx context.Context, cfg ServerConfig) (
+	transfer.TransferResults, <-chan struct{}, error,
+) {
+	res, done, err := withContext(ctx, func() (transfer.TransferResults, error) {
+		return listenAndServe(cfg)
+	})
+	if ctx.Err() != nil {
+		go releasePort(cfg, done)
+	}
+
+	return res, done, err
+}
+
+// releasePort ends the wait of libiperf for a test that never comes, which
+// has no timeout. libiperf gives up on the connections that close without
+// the cookie of a test. The tests in progress turn these connections away
+// and end on their own.
+func releasePort(cfg ServerConfig, done <-chan struct{}) {
+	ip := cfg.BindAddress
+	if ip == nil || ip.IsUnspecified() {
+		ip = net.IPv4(127, 0, 0, 1)
+	}
+	addr := net.JoinHostPort(ip.String(), strconv.Itoa(int(cfg.ListenPort)))
+
+	ticker := time.NewTicker(releaseInterval)
+	defer ticker.Stop()
+	for {
+		// libiperf may not listen yet
+		if conn, err := net.DialTimeout("tcp", addr, releaseInterval); err == nil {
+			conn.Close()
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// releaseInterval is the time between the attempts to release the port.
+const releaseInterval = time.Second
+
+func listenAndServe(cfg ServerConfig) (transfer.TransferResults, error) {
 	var res transfer.TransferResults
 
 	pipeR, pipeW, err := os.Pipe()
@@ -60,19 +243,20 @@ func ListenAndServe(cfg ServerConfig) (transfer.TransferResults, error) {
 		return res, fmt.Errorf("decoding iperf response: %s", err)
 	}
 
-	res.BytesSent = uint32(rep.End.SumReceived.Bytes)
-	durStr := fmt.Sprintf("%ss", strconv.FormatFloat(
-		rep.End.SumSent.Seconds, 'f', -1, 64,
-	))
-	res.Duration, err = time.ParseDuration(durStr)
-	if err != nil {
-		return res, fmt.Errorf("parsing duration: %s", err)
-	}
+	return rep.results(false), nil
+}
 
-	return res, nil
+func RunTest(ctx context.Context, cfg ClientConfig) (
+	transfer.TransferResults, error,
+) {
+	res, _, err := withContext(ctx, func() (transfer.TransferResults, error) {
+		return runTest(cfg)
+	})
+
+	return res, err
 }
 
-func RunTest(cfg ClientConfig) (transfer.TransferResults, error) {
+func runTest(cfg ClientConfig) (transfer.TransferResults, error) {
 	var res transfer.TransferResults
 
 	pipeR, pipeW, err := os.Pipe()
@@ -94,18 +278,5 @@ func RunTest(cfg ClientConfig) (transfer.TransferResults, error) {
 		return res, fmt.Errorf("decoding iperf response: %s", err)
 	}
 
-	res.BytesSent = uint32(rep.End.SumSent.Bytes)
-	durStr := fmt.Sprintf("%ss", strconv.FormatFloat(
-		rep.End.SumSent.Seconds, 'f', -1, 64,
-	))
-	res.Duration, err = time.ParseDuration(durStr)
-	if err != nil {
-		return res, fmt.Errorf("parsing duration: %s", err)
-	}
-	if len(rep.End.Streams) != 0 {
-		meanRTT := rep.End.Streams[0].Sender.MeanRTT // this is in us
-		res.RTT = time.Microsecond * time.Duration(meanRTT)
-	}
-
-	return res, nil
+	return rep.results(true), nil
 }
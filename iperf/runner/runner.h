@@ -1,3 +1,5 @@
+#include <stdint.h>
+
 /**
  * Error codes
  */
@@ -24,6 +26,8 @@ typedef struct {
   IRConfig ir_config;
   // Port to listen to
   int listen_port;
+  // Address to listen on; all the addresses when NULL
+  char *bind_address;
 } IRServerConfig;
 
 typedef enum {
@@ -37,16 +41,29 @@ typedef struct {
 	// Target Iperf to connect to
   char *target_host_ip;
   int target_host_port;
+	// Local address of the connections; the system picks it when NULL
+  char *bind_address;
 	// Transport protocol to use for the measurements.
   IRProtocol protocol;
 	// Duration, in seconds, of the stream. Default: 10 seconds.
   int duration_secs;
 	// Amount of bytes to send.
-  int bytes_amt;
+  uint64_t bytes_amt;
 	// Size of transmission buffer. Default: 128 KB for TCP and 8 KB for UDP.
   int buffer_size;
 	// Amount of packets to send.
   int packets_amt;
+	// Parallel streams of the test. Default: 1.
+  int num_streams;
+	// The server sends the data to the client when it is not zero.
+  int reverse;
+	// Socket buffer size of the streams in bytes; the system picks it when 0.
+  int window;
+	// Bits per second that every stream may send. Default: unlimited for TCP
+	// and 1 Mbit/s for UDP.
+  uint64_t bitrate;
+	// Seconds at the start of the test that are omitted from the results.
+  int omit_secs;
 } IRClientConfig;
 
 /**
@@ -19,6 +19,8 @@ IRResult ir_listen_and_serve(IRServerConfig cfg, int json_fd) {
 
   // server
   iperf_set_test_server_port(test, cfg.listen_port);
+  if (cfg.bind_address != NULL)
+    iperf_set_test_bind_address(test, cfg.bind_address);
 
   // output
   test->outfile = fdopen(json_fd, "w");
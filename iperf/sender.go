@@ -1,8 +1,10 @@
 package iperf
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/iperf/runner"
@@ -11,35 +13,72 @@ import (
 
 type Sender struct {
 	logger *logrus.Logger
+	// iperf reports in whole seconds; no intervals are reported when zero
+	measurementInterval time.Duration
+	opts                Options
 }
 
-func NewSender(logger *logrus.Logger) *Sender {
+func NewSender(
+	logger *logrus.Logger, measurementInterval time.Duration, opts Options,
+) *Sender {
 	return &Sender{
-		logger: logger,
+		logger:              logger,
+		measurementInterval: measurementInterval,
+		opts:                opts,
 	}
 }
 
-func (s *Sender) SendTransfer(spec transfer.TransferSpec, conn io.ReadWriter) (
-	transfer.TransferResults, error,
-) {
-	s.logger.Debug("[IPERF] Sending a transfer...")
+func (s *Sender) SendTransfer(
+	ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger := s.logger.WithFields(transfer.LogFields(spec.ID, spec.Peer()))
+	logger.Debug("[IPERF] Sending a transfer...")
 
 	iperfPort, err := s.handshake(conn)
 	if err != nil {
-		s.logger.Debugf("[IPERF] Handshake failed: %s", err)
+		logger.Debugf("[IPERF] Handshake failed: %s", err)
 		return transfer.TransferResults{}, err
 	}
-	s.logger.Debug("[IPERF] Handshake went through!")
+	logger.Debug("[IPERF] Handshake went through!")
 
-	s.logger.Debug("[IPERF] About to run the test...")
-	return runner.RunTest(runner.ClientConfig{
+	// iperf cannot bind to an interface, so it binds to its address
+	bindAddress, err := spec.Source.LocalIP(spec.IP)
+	if err != nil {
+		logger.Debugf("[IPERF] Selecting the source address failed: %s", err)
+		return transfer.TransferResults{}, err
+	}
+
+	logger.Debug("[IPERF] About to run the test...")
+	res, err := runner.RunTest(ctx, runner.ClientConfig{
+		Config: runner.Config{
+			MeasurementInterval: s.measurementInterval,
+		},
 		// Transfer target
 		TargetHostIP:   spec.IP,
 		TargetHostPort: iperfPort,
+		BindAddress:    bindAddress,
 		// Transfer size
 		BufferSize: 1024,
-		BytesAmt:   uint(spec.Size),
+		BytesAmt:   spec.Size,
+		// Test options
+		Protocol:   s.protocol(),
+		NumStreams: s.opts.Parallel,
+		Reverse:    s.opts.Reverse,
+		Window:     s.opts.Window,
+		Bitrate:    s.opts.Bitrate,
+		Omit:       s.opts.Omit,
 	})
+	res.Backend = BackendName
+
+	return res, err
+}
+
+func (s *Sender) protocol() runner.Protocol {
+	if s.opts.UDP {
+		return runner.ProtocolUDP
+	}
+
+	return runner.ProtocolTCP
 }
 
 func (s *Sender) handshake(conn io.ReadWriter) (uint16, error) {
@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/rotate"
+)
+
+const (
+	SubsystemScheduler = "scheduler"
+	SubsystemTransfer  = "transfer"
+	SubsystemAPI       = "api"
+	SubsystemIperf     = "iperf"
+)
+
+var Subsystems = []string{
+	SubsystemScheduler,
+	SubsystemTransfer,
+	SubsystemAPI,
+	SubsystemIperf,
+}
+
+type Config struct {
+	// `text` or `json`
+	Format string
+	Level  logrus.Level
+	// Standard output is used when empty.
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+	// Subsystems that are not listed use Level.
+	Levels map[string]logrus.Level
+}
+
+// Loggers holds one logger per subsystem. All of them write to the same
+// output using the same format, and tag every line with their subsystem.
+type Loggers struct {
+	root       *logrus.Logger
+	subsystems map[string]*logrus.Logger
+	out        io.Writer
+	closer     io.Closer
+}
+
+func New(cfg Config) (*Loggers, error) {
+	formatter, err := NewFormatter(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out    io.Writer = os.Stdout
+		closer io.Closer
+	)
+	if cfg.Path != "" {
+		file, err := rotate.Open(cfg.Path, rotate.Options{
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = file
+		closer = file
+	}
+
+	loggers := &Loggers{
+		root: &logrus.Logger{
+			Out:       out,
+			Level:     cfg.Level,
+			Formatter: formatter,
+		},
+		subsystems: make(map[string]*logrus.Logger),
+		out:        out,
+		closer:     closer,
+	}
+
+	for _, subsystem := range Subsystems {
+		level, ok := cfg.Levels[subsystem]
+		if !ok {
+			level = cfg.Level
+		}
+
+		loggers.subsystems[subsystem] = &logrus.Logger{
+			Out:   out,
+			Level: level,
+			Formatter: &subsystemFormatter{
+				subsystem: subsystem,
+				formatter: formatter,
+			},
+		}
+	}
+
+	return loggers, nil
+}
+
+func (l *Loggers) Root() *logrus.Logger {
+	return l.root
+}
+
+// Subsystem returns the logger of a subsystem. Unknown subsystems get the root
+// logger.
+func (l *Loggers) Subsystem(subsystem string) *logrus.Logger {
+	if logger, ok := l.subsystems[subsystem]; ok {
+		return logger
+	}
+
+	return l.root
+}
+
+func (l *Loggers) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+
+	return l.closer.Close()
+}
+
+func NewFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", "text":
+		return new(logrus.TextFormatter), nil
+	case "json":
+		return new(logrus.JSONFormatter), nil
+	default:
+		return nil, fmt.Errorf("unknown log format `%s`", format)
+	}
+}
+
+type subsystemFormatter struct {
+	subsystem string
+	formatter logrus.Formatter
+}
+
+func (f *subsystemFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// entries can be shared between goroutines so their fields are copied
+	data := make(logrus.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	data["subsystem"] = f.subsystem
+
+	subsystemEntry := *entry
+	subsystemEntry.Data = data
+
+	return f.formatter.Format(&subsystemEntry)
+}
@@ -0,0 +1,114 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Loggers", func() {
+	var (
+		dir     string
+		cfg     logging.Config
+		loggers *logging.Loggers
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = logging.Config{
+			Format: "json",
+			Level:  logrus.InfoLevel,
+			Path:   filepath.Join(dir, "clique.log"),
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		loggers, err = logging.New(cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(loggers.Close()).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	logLines := func() []map[string]interface{} {
+		contents, err := ioutil.ReadFile(cfg.Path)
+		Expect(err).NotTo(HaveOccurred())
+
+		lines := []map[string]interface{}{}
+		for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+			if line == "" {
+				continue
+			}
+
+			var fields map[string]interface{}
+			Expect(json.Unmarshal([]byte(line), &fields)).To(Succeed())
+			lines = append(lines, fields)
+		}
+
+		return lines
+	}
+
+	It("should write JSON lines to the log file", func() {
+		loggers.Root().WithField("transfer_id", "abc").Info("hello")
+
+		lines := logLines()
+		Expect(lines).To(HaveLen(1))
+		Expect(lines[0]["msg"]).To(Equal("hello"))
+		Expect(lines[0]["transfer_id"]).To(Equal("abc"))
+	})
+
+	It("should tag the subsystem lines with the subsystem name", func() {
+		loggers.Subsystem(logging.SubsystemScheduler).Info("hello")
+
+		lines := logLines()
+		Expect(lines).To(HaveLen(1))
+		Expect(lines[0]["subsystem"]).To(Equal("scheduler"))
+	})
+
+	It("should return the root logger for unknown subsystems", func() {
+		Expect(loggers.Subsystem("banana")).To(Equal(loggers.Root()))
+	})
+
+	Context("when a subsystem has its own level", func() {
+		BeforeEach(func() {
+			cfg.Levels = map[string]logrus.Level{
+				logging.SubsystemTransfer: logrus.DebugLevel,
+				logging.SubsystemAPI:      logrus.ErrorLevel,
+			}
+		})
+
+		It("should use it", func() {
+			loggers.Subsystem(logging.SubsystemTransfer).Debug("transfer")
+			loggers.Subsystem(logging.SubsystemAPI).Info("api")
+			loggers.Subsystem(logging.SubsystemScheduler).Debug("scheduler debug")
+			loggers.Subsystem(logging.SubsystemScheduler).Info("scheduler info")
+
+			lines := logLines()
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]["msg"]).To(Equal("transfer"))
+			Expect(lines[1]["msg"]).To(Equal("scheduler info"))
+		})
+	})
+
+	Context("when the format is unknown", func() {
+		It("should fail", func() {
+			cfg.Format = "banana"
+
+			_, err := logging.New(cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/ice-stuff/clique/resolver"
+)
+
+type FakeLookuper struct {
+	LookupIPAddrStub        func(ctx context.Context, host string) ([]net.IPAddr, error)
+	lookupIPAddrMutex       sync.RWMutex
+	lookupIPAddrArgsForCall []struct {
+		ctx  context.Context
+		host string
+	}
+	lookupIPAddrReturns struct {
+		result1 []net.IPAddr
+		result2 error
+	}
+}
+
+func (fake *FakeLookuper) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	fake.lookupIPAddrMutex.Lock()
+	fake.lookupIPAddrArgsForCall = append(fake.lookupIPAddrArgsForCall, struct {
+		ctx  context.Context
+		host string
+	}{ctx, host})
+	fake.lookupIPAddrMutex.Unlock()
+	if fake.LookupIPAddrStub != nil {
+		return fake.LookupIPAddrStub(ctx, host)
+	} else {
+		return fake.lookupIPAddrReturns.result1, fake.lookupIPAddrReturns.result2
+	}
+}
+
+func (fake *FakeLookuper) LookupIPAddrCallCount() int {
+	fake.lookupIPAddrMutex.RLock()
+	defer fake.lookupIPAddrMutex.RUnlock()
+	return len(fake.lookupIPAddrArgsForCall)
+}
+
+func (fake *FakeLookuper) LookupIPAddrArgsForCall(i int) (context.Context, string) {
+	fake.lookupIPAddrMutex.RLock()
+	defer fake.lookupIPAddrMutex.RUnlock()
+	return fake.lookupIPAddrArgsForCall[i].ctx, fake.lookupIPAddrArgsForCall[i].host
+}
+
+func (fake *FakeLookuper) LookupIPAddrReturns(result1 []net.IPAddr, result2 error) {
+	fake.LookupIPAddrStub = nil
+	fake.lookupIPAddrReturns = struct {
+		result1 []net.IPAddr
+		result2 error
+	}{result1, result2}
+}
+
+var _ resolver.Lookuper = new(FakeLookuper)
@@ -0,0 +1,98 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/Sirupsen/logrus"
+)
+
+// *net.Resolver implements the Lookuper.
+//
+//go:generate counterfeiter . Lookuper
+type Lookuper interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type entry struct {
+	ip         net.IP
+	resolvedAt time.Time
+}
+
+// Resolver resolves the hostnames of the peers and remembers their
+// addresses for a while.
+type Resolver struct {
+	logger   *logrus.Logger
+	lookuper Lookuper
+	// time that an address is used before the hostname is resolved again
+	ttl   time.Duration
+	clock clock.Clock
+
+	entries map[string]entry
+
+	lock sync.Mutex
+}
+
+func New(
+	logger *logrus.Logger, lookuper Lookuper, ttl time.Duration, clk clock.Clock,
+) *Resolver {
+	return &Resolver{
+		logger:   logger,
+		lookuper: lookuper,
+		ttl:      ttl,
+		clock:    clk,
+		entries:  make(map[string]entry),
+	}
+}
+
+// Resolve returns the address of the host. IP addresses are returned as they
+// are. A hostname is resolved again when its address is older than the TTL;
+// the previous address is kept as long as the hostname still resolves to it,
+// so that the transfers to a peer with many addresses use the same one.
+func (r *Resolver) Resolve(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	now := r.clock.Now()
+	r.lock.Lock()
+	prev, ok := r.entries[host]
+	r.lock.Unlock()
+	if ok && now.Sub(prev.resolvedAt) < r.ttl {
+		return prev.ip, nil
+	}
+
+	addrs, err := r.lookuper.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("resolving `%s`: %s", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("resolving `%s`: no addresses", host)
+	}
+
+	ip := addrs[0].IP
+	for _, addr := range addrs {
+		if ok && addr.IP.Equal(prev.ip) {
+			ip = prev.ip
+			break
+		}
+	}
+
+	if ok && !ip.Equal(prev.ip) {
+		r.logger.WithFields(logrus.Fields{
+			"host":        host,
+			"previous_ip": prev.ip.String(),
+			"current_ip":  ip.String(),
+		}).Info("Address of the peer changed")
+	}
+
+	r.lock.Lock()
+	r.entries[host] = entry{ip: ip, resolvedAt: now}
+	r.lock.Unlock()
+
+	return ip, nil
+}
@@ -0,0 +1,128 @@
+package resolver_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/resolver"
+	"github.com/ice-stuff/clique/resolver/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolver", func() {
+	var (
+		lookuper *fakes.FakeLookuper
+		clk      *fakeclock.FakeClock
+		r        *resolver.Resolver
+	)
+
+	addrs := func(ips ...string) []net.IPAddr {
+		res := make([]net.IPAddr, len(ips))
+		for i, ip := range ips {
+			res[i] = net.IPAddr{IP: net.ParseIP(ip)}
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		logger := &logrus.Logger{
+			Out:       ioutil.Discard,
+			Formatter: new(logrus.TextFormatter),
+			Hooks:     make(logrus.LevelHooks),
+			Level:     logrus.DebugLevel,
+		}
+
+		t, err := time.Parse(time.RFC3339, "2015-11-24T06:30:00+00:00")
+		Expect(err).NotTo(HaveOccurred())
+		clk = fakeclock.NewFakeClock(t)
+
+		lookuper = new(fakes.FakeLookuper)
+		lookuper.LookupIPAddrReturns(addrs("10.0.0.1"), nil)
+
+		r = resolver.New(logger, lookuper, time.Minute, clk)
+	})
+
+	It("should return the IP addresses without looking them up", func() {
+		ip, err := r.Resolve(context.Background(), "fe80::1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ip.Equal(net.ParseIP("fe80::1"))).To(BeTrue())
+
+		Expect(lookuper.LookupIPAddrCallCount()).To(Equal(0))
+	})
+
+	It("should look up the hostnames", func() {
+		ip, err := r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ip.Equal(net.ParseIP("10.0.0.1"))).To(BeTrue())
+
+		_, host := lookuper.LookupIPAddrArgsForCall(0)
+		Expect(host).To(Equal("peer.example.com"))
+	})
+
+	It("should reuse the address until the TTL passes", func() {
+		_, err := r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		clk.Increment(59 * time.Second)
+		_, err = r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lookuper.LookupIPAddrCallCount()).To(Equal(1))
+
+		clk.Increment(time.Second)
+		_, err = r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lookuper.LookupIPAddrCallCount()).To(Equal(2))
+	})
+
+	It("should pick up the new address of the hostname", func() {
+		_, err := r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		lookuper.LookupIPAddrReturns(addrs("10.0.0.2"), nil)
+		clk.Increment(time.Minute)
+
+		ip, err := r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ip.Equal(net.ParseIP("10.0.0.2"))).To(BeTrue())
+	})
+
+	It("should keep the previous address while the hostname resolves to it", func() {
+		_, err := r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+
+		lookuper.LookupIPAddrReturns(addrs("10.0.0.2", "10.0.0.1"), nil)
+		clk.Increment(time.Minute)
+
+		ip, err := r.Resolve(context.Background(), "peer.example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ip.Equal(net.ParseIP("10.0.0.1"))).To(BeTrue())
+	})
+
+	Context("when the lookup fails", func() {
+		BeforeEach(func() {
+			lookuper.LookupIPAddrReturns(nil, errors.New("no such host"))
+		})
+
+		It("should return an error", func() {
+			_, err := r.Resolve(context.Background(), "peer.example.com")
+			Expect(err).To(MatchError(ContainSubstring("no such host")))
+		})
+	})
+
+	Context("when the hostname has no addresses", func() {
+		BeforeEach(func() {
+			lookuper.LookupIPAddrReturns(nil, nil)
+		})
+
+		It("should return an error", func() {
+			_, err := r.Resolve(context.Background(), "peer.example.com")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+type Options struct {
+	// Size, in bytes, after which the file is rotated. Zero disables rotation.
+	MaxSize int64
+	// Amount of rotated files to keep around (`<path>.1`, `<path>.2`, ...).
+	MaxBackups int
+	// Written at the beginning of every new file.
+	Header []byte
+}
+
+// File is an append-only file which is rotated once it grows over a size
+// limit. Writes are never split across two files.
+type File struct {
+	path string
+	opts Options
+
+	file *os.File
+	size int64
+
+	lock sync.Mutex
+}
+
+func Open(path string, opts Options) (*File, error) {
+	f := &File{
+		path: path,
+		opts: opts,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return 0, fmt.Errorf("file '%s' is closed", f.path)
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *File) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *File) shouldRotate(writeLen int64) bool {
+	if f.opts.MaxSize <= 0 {
+		return false
+	}
+
+	// a file that only holds the header cannot get any smaller
+	if f.size <= int64(len(f.opts.Header)) {
+		return false
+	}
+
+	return f.size+writeLen > f.opts.MaxSize
+}
+
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("closing '%s': %s", f.path, err)
+	}
+	f.file = nil
+
+	if f.opts.MaxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil {
+			return fmt.Errorf("removing '%s': %s", f.path, err)
+		}
+	} else {
+		for i := f.opts.MaxBackups - 1; i > 0; i-- {
+			from := backupPath(f.path, i)
+			if _, err := os.Stat(from); os.IsNotExist(err) {
+				continue
+			}
+
+			if err := os.Rename(from, backupPath(f.path, i+1)); err != nil {
+				return fmt.Errorf("rotating '%s': %s", from, err)
+			}
+		}
+
+		if err := os.Rename(f.path, backupPath(f.path, 1)); err != nil {
+			return fmt.Errorf("rotating '%s': %s", f.path, err)
+		}
+	}
+
+	return f.open()
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("opening '%s': %s", f.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("inspecting '%s': %s", f.path, err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	if f.size == 0 && len(f.opts.Header) > 0 {
+		n, err := f.file.Write(f.opts.Header)
+		f.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("writing header to '%s': %s", f.path, err)
+		}
+	}
+
+	return nil
+}
+
+func backupPath(path string, idx int) string {
+	return fmt.Sprintf("%s.%d", path, idx)
+}
@@ -0,0 +1,13 @@
+package rotate_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Rotate Suite")
+}
@@ -0,0 +1,117 @@
+package rotate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ice-stuff/clique/rotate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File", func() {
+	var (
+		dir  string
+		path string
+		opts rotate.Options
+		f    *rotate.File
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(dir, "results.log")
+		opts = rotate.Options{
+			MaxSize:    10,
+			MaxBackups: 2,
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		f, err = rotate.Open(path, opts)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(f.Close()).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	readFile := func(path string) string {
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	It("should append to the file", func() {
+		Expect(f.Write([]byte("hello\n"))).To(Equal(6))
+		Expect(readFile(path)).To(Equal("hello\n"))
+	})
+
+	It("should rotate when the file grows over the maximum size", func() {
+		f.Write([]byte("first\n"))
+		f.Write([]byte("second\n"))
+
+		Expect(readFile(path)).To(Equal("second\n"))
+		Expect(readFile(path + ".1")).To(Equal("first\n"))
+	})
+
+	It("should keep only the configured amount of backups", func() {
+		f.Write([]byte("first\n"))
+		f.Write([]byte("second\n"))
+		f.Write([]byte("third\n"))
+		f.Write([]byte("fourth\n"))
+
+		Expect(readFile(path)).To(Equal("fourth\n"))
+		Expect(readFile(path + ".1")).To(Equal("third\n"))
+		Expect(readFile(path + ".2")).To(Equal("second\n"))
+		Expect(path + ".3").NotTo(BeAnExistingFile())
+	})
+
+	It("should not split a write that is larger than the maximum size", func() {
+		f.Write([]byte("this is a long line\n"))
+
+		Expect(readFile(path)).To(Equal("this is a long line\n"))
+		Expect(path + ".1").NotTo(BeAnExistingFile())
+	})
+
+	Context("when a header is configured", func() {
+		BeforeEach(func() {
+			opts.Header = []byte("h\n")
+		})
+
+		It("should write the header to every new file", func() {
+			f.Write([]byte("first\n"))
+			f.Write([]byte("second\n"))
+
+			Expect(readFile(path)).To(Equal("h\nsecond\n"))
+			Expect(readFile(path + ".1")).To(Equal("h\nfirst\n"))
+		})
+	})
+
+	Context("when the file already exists", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(path, []byte("old\n"), 0644)).To(Succeed())
+			opts.Header = []byte("h\n")
+		})
+
+		It("should append to it without writing the header", func() {
+			f.Write([]byte("new\n"))
+
+			Expect(readFile(path)).To(Equal("old\nnew\n"))
+		})
+	})
+
+	Context("when the file is closed", func() {
+		It("should fail to write", func() {
+			Expect(f.Close()).To(Succeed())
+
+			_, err := f.Write([]byte("hello\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package scheduler
+
+import "time"
+
+// DeadlineTask is a task that should run before a point in time.
+type DeadlineTask interface {
+	Task
+	// Deadline returns a zero time when the task has no deadline.
+	Deadline() time.Time
+}
+
+// EarliestDeadlineTaskSelector selects the task with the earliest deadline.
+// Tasks without a deadline run only when there is no task with one, in turn.
+type EarliestDeadlineTaskSelector struct {
+	history *selectionHistory
+}
+
+func NewEarliestDeadlineTaskSelector() *EarliestDeadlineTaskSelector {
+	return &EarliestDeadlineTaskSelector{
+		history: newSelectionHistory(),
+	}
+}
+
+func (ts *EarliestDeadlineTaskSelector) Name() string {
+	return "earliest_deadline_first"
+}
+
+func (ts *EarliestDeadlineTaskSelector) Params() map[string]string {
+	return map[string]string{}
+}
+
+func (ts *EarliestDeadlineTaskSelector) SelectTask(tasks []Task) Task {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ts.history.forgetDone()
+
+	var selected Task
+	var selectedDeadline time.Time
+	for _, task := range tasks {
+		deadline := taskDeadline(task)
+		if deadline.IsZero() {
+			continue
+		}
+
+		if selected == nil || deadline.Before(selectedDeadline) {
+			selected = task
+			selectedDeadline = deadline
+		}
+	}
+	if selected == nil {
+		selected = ts.history.leastRecentlySelected(tasks, taskKey)
+	}
+
+	ts.history.selected(selected)
+
+	return selected
+}
+
+func taskDeadline(task Task) time.Time {
+	if deadlineTask, ok := task.(DeadlineTask); ok {
+		return deadlineTask.Deadline()
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,64 @@
+package scheduler_test
+
+import (
+	"time"
+
+	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/scheduler/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type deadlineTask struct {
+	*fakes.FakeTask
+	deadline time.Time
+}
+
+func (t *deadlineTask) Deadline() time.Time {
+	return t.deadline
+}
+
+var _ = Describe("EarliestDeadline", func() {
+	var (
+		ts  *scheduler.EarliestDeadlineTaskSelector
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		ts = scheduler.NewEarliestDeadlineTaskSelector()
+		now = time.Now()
+	})
+
+	It("should select the task with the earliest deadline", func() {
+		late := &deadlineTask{new(fakes.FakeTask), now.Add(time.Hour)}
+		early := &deadlineTask{new(fakes.FakeTask), now.Add(time.Minute)}
+		tasks := []scheduler.Task{new(fakes.FakeTask), late, early}
+
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(early))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(early))
+		Expect(ts.SelectTask(tasks[:2])).To(BeIdenticalTo(late))
+	})
+
+	It("should select the tasks without a deadline in turn", func() {
+		tasks := []scheduler.Task{
+			new(fakes.FakeTask),
+			&deadlineTask{new(fakes.FakeTask), time.Time{}},
+		}
+
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[0]))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[1]))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[0]))
+	})
+
+	It("should describe itself", func() {
+		Expect(ts.Name()).To(Equal("earliest_deadline_first"))
+		Expect(ts.Params()).To(BeEmpty())
+	})
+
+	Context("when the list of tasks is empty", func() {
+		It("should return nil", func() {
+			Expect(ts.SelectTask([]scheduler.Task{})).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,70 @@
+package scheduler
+
+// FairShareTaskSelector implements weighted fair queueing, using the
+// priorities as weights: over time, every task runs as often as its share of
+// the sum of the priorities. Unlike the lottery, the selection is
+// deterministic.
+//
+// Every run of a task takes one unit of virtual time divided by its weight.
+// The task whose next run would finish first is selected. New tasks start at
+// the current virtual time so that they do not starve the older ones.
+type FairShareTaskSelector struct {
+	virtualTime float64
+	startTimes  map[Task]float64
+}
+
+func NewFairShareTaskSelector() *FairShareTaskSelector {
+	return &FairShareTaskSelector{
+		startTimes: make(map[Task]float64),
+	}
+}
+
+func (ts *FairShareTaskSelector) Name() string {
+	return "fair_share"
+}
+
+func (ts *FairShareTaskSelector) Params() map[string]string {
+	return map[string]string{}
+}
+
+func (ts *FairShareTaskSelector) SelectTask(tasks []Task) Task {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	for task := range ts.startTimes {
+		if task.State() == TaskStateDone {
+			delete(ts.startTimes, task)
+		}
+	}
+
+	var selected Task
+	var selectedStart, selectedFinish float64
+	for _, task := range tasks {
+		start, ok := ts.startTimes[task]
+		if !ok {
+			start = ts.virtualTime
+			ts.startTimes[task] = start
+		}
+		finish := start + 1/taskWeight(task)
+
+		if selected == nil || finish < selectedFinish {
+			selected = task
+			selectedStart = start
+			selectedFinish = finish
+		}
+	}
+
+	ts.virtualTime = selectedStart
+	ts.startTimes[selected] = selectedFinish
+
+	return selected
+}
+
+func taskWeight(task Task) float64 {
+	if task.Priority() < 1 {
+		return 1
+	}
+
+	return float64(task.Priority())
+}
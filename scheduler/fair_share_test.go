@@ -0,0 +1,82 @@
+package scheduler_test
+
+import (
+	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/scheduler/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FairShare", func() {
+	var ts *scheduler.FairShareTaskSelector
+
+	BeforeEach(func() {
+		ts = scheduler.NewFairShareTaskSelector()
+	})
+
+	makeTasks := func(priorities []int) []scheduler.Task {
+		tasks := make([]scheduler.Task, len(priorities))
+		for i, priority := range priorities {
+			task := new(fakes.FakeTask)
+			task.PriorityReturns(priority)
+			tasks[i] = task
+		}
+
+		return tasks
+	}
+
+	frequencies := func(tasks []scheduler.Task, rounds int) map[scheduler.Task]int {
+		frequency := map[scheduler.Task]int{}
+		for i := 0; i < rounds; i++ {
+			frequency[ts.SelectTask(tasks)]++
+		}
+
+		return frequency
+	}
+
+	It("should allocate fair shares to proceses over time", func() {
+		priorities := []int{5, 10, 2, 20, 15}
+		tasks := makeTasks(priorities)
+
+		frequency := frequencies(tasks, sum(priorities)*10)
+
+		for i, task := range tasks {
+			Expect(frequency[task]).To(BeNumerically("~", priorities[i]*10, 1))
+		}
+	})
+
+	It("should be deterministic", func() {
+		tasks := makeTasks([]int{3, 1, 2})
+
+		selections := []scheduler.Task{}
+		for i := 0; i < 12; i++ {
+			selections = append(selections, ts.SelectTask(tasks))
+		}
+
+		ts = scheduler.NewFairShareTaskSelector()
+		for i := 0; i < 12; i++ {
+			Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(selections[i]))
+		}
+	})
+
+	It("should not let new tasks starve the older ones", func() {
+		tasks := makeTasks([]int{1, 1})
+		frequencies(tasks[:1], 100)
+
+		frequency := frequencies(tasks, 10)
+		Expect(frequency[tasks[0]]).To(BeNumerically("~", 5, 1))
+		Expect(frequency[tasks[1]]).To(BeNumerically("~", 5, 1))
+	})
+
+	It("should describe itself", func() {
+		Expect(ts.Name()).To(Equal("fair_share"))
+		Expect(ts.Params()).To(BeEmpty())
+	})
+
+	Context("when the list of tasks is empty", func() {
+		It("should return nil", func() {
+			Expect(ts.SelectTask([]scheduler.Task{})).To(BeNil())
+		})
+	})
+})
@@ -23,6 +23,12 @@ type FakeTask struct {
 	stateReturns     struct {
 		result1 scheduler.TaskState
 	}
+	ExclusionKeyStub        func() string
+	exclusionKeyMutex       sync.RWMutex
+	exclusionKeyArgsForCall []struct{}
+	exclusionKeyReturns     struct {
+		result1 string
+	}
 }
 
 func (fake *FakeTask) Run() {
@@ -88,4 +94,28 @@ func (fake *FakeTask) StateReturns(result1 scheduler.TaskState) {
 	}{result1}
 }
 
+func (fake *FakeTask) ExclusionKey() string {
+	fake.exclusionKeyMutex.Lock()
+	fake.exclusionKeyArgsForCall = append(fake.exclusionKeyArgsForCall, struct{}{})
+	fake.exclusionKeyMutex.Unlock()
+	if fake.ExclusionKeyStub != nil {
+		return fake.ExclusionKeyStub()
+	} else {
+		return fake.exclusionKeyReturns.result1
+	}
+}
+
+func (fake *FakeTask) ExclusionKeyCallCount() int {
+	fake.exclusionKeyMutex.RLock()
+	defer fake.exclusionKeyMutex.RUnlock()
+	return len(fake.exclusionKeyArgsForCall)
+}
+
+func (fake *FakeTask) ExclusionKeyReturns(result1 string) {
+	fake.ExclusionKeyStub = nil
+	fake.exclusionKeyReturns = struct {
+		result1 string
+	}{result1}
+}
+
 var _ scheduler.Task = new(FakeTask)
@@ -16,6 +16,18 @@ type FakeTaskSelector struct {
 	selectTaskReturns struct {
 		result1 scheduler.Task
 	}
+	NameStub        func() string
+	nameMutex       sync.RWMutex
+	nameArgsForCall []struct{}
+	nameReturns     struct {
+		result1 string
+	}
+	ParamsStub        func() map[string]string
+	paramsMutex       sync.RWMutex
+	paramsArgsForCall []struct{}
+	paramsReturns     struct {
+		result1 map[string]string
+	}
 }
 
 func (fake *FakeTaskSelector) SelectTask(arg1 []scheduler.Task) scheduler.Task {
@@ -50,4 +62,52 @@ func (fake *FakeTaskSelector) SelectTaskReturns(result1 scheduler.Task) {
 	}{result1}
 }
 
+func (fake *FakeTaskSelector) Name() string {
+	fake.nameMutex.Lock()
+	fake.nameArgsForCall = append(fake.nameArgsForCall, struct{}{})
+	fake.nameMutex.Unlock()
+	if fake.NameStub != nil {
+		return fake.NameStub()
+	} else {
+		return fake.nameReturns.result1
+	}
+}
+
+func (fake *FakeTaskSelector) NameCallCount() int {
+	fake.nameMutex.RLock()
+	defer fake.nameMutex.RUnlock()
+	return len(fake.nameArgsForCall)
+}
+
+func (fake *FakeTaskSelector) NameReturns(result1 string) {
+	fake.NameStub = nil
+	fake.nameReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeTaskSelector) Params() map[string]string {
+	fake.paramsMutex.Lock()
+	fake.paramsArgsForCall = append(fake.paramsArgsForCall, struct{}{})
+	fake.paramsMutex.Unlock()
+	if fake.ParamsStub != nil {
+		return fake.ParamsStub()
+	} else {
+		return fake.paramsReturns.result1
+	}
+}
+
+func (fake *FakeTaskSelector) ParamsCallCount() int {
+	fake.paramsMutex.RLock()
+	defer fake.paramsMutex.RUnlock()
+	return len(fake.paramsArgsForCall)
+}
+
+func (fake *FakeTaskSelector) ParamsReturns(result1 map[string]string) {
+	fake.ParamsStub = nil
+	fake.paramsReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
 var _ scheduler.TaskSelector = new(FakeTaskSelector)
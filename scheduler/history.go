@@ -0,0 +1,51 @@
+package scheduler
+
+// selectionHistory orders the keys by the last time that they were selected.
+// Keys that were never selected come first.
+type selectionHistory struct {
+	seq  uint64
+	last map[interface{}]uint64
+}
+
+func newSelectionHistory() *selectionHistory {
+	return &selectionHistory{
+		last: make(map[interface{}]uint64),
+	}
+}
+
+func (h *selectionHistory) lastSelected(key interface{}) uint64 {
+	return h.last[key]
+}
+
+func (h *selectionHistory) selected(key interface{}) {
+	h.seq++
+	h.last[key] = h.seq
+}
+
+// forgetDone drops the tasks that are done, as they are not going to be
+// selected again.
+func (h *selectionHistory) forgetDone() {
+	for key := range h.last {
+		if task, ok := key.(Task); ok && task.State() == TaskStateDone {
+			delete(h.last, key)
+		}
+	}
+}
+
+// leastRecentlySelected returns the first task whose key was selected least
+// recently.
+func (h *selectionHistory) leastRecentlySelected(
+	tasks []Task, key func(Task) interface{},
+) Task {
+	var selected Task
+	var selectedSeq uint64
+	for _, task := range tasks {
+		seq := h.lastSelected(key(task))
+		if selected == nil || seq < selectedSeq {
+			selected = task
+			selectedSeq = seq
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+// LeastRecentlyMeasuredTaskSelector selects a task of the peer that was
+// selected least recently. The peer of a task is its exclusion key; tasks
+// without one are treated as peers on their own.
+type LeastRecentlyMeasuredTaskSelector struct {
+	history *selectionHistory
+}
+
+func NewLeastRecentlyMeasuredTaskSelector() *LeastRecentlyMeasuredTaskSelector {
+	return &LeastRecentlyMeasuredTaskSelector{
+		history: newSelectionHistory(),
+	}
+}
+
+func (ts *LeastRecentlyMeasuredTaskSelector) Name() string {
+	return "least_recently_measured"
+}
+
+func (ts *LeastRecentlyMeasuredTaskSelector) Params() map[string]string {
+	return map[string]string{}
+}
+
+func (ts *LeastRecentlyMeasuredTaskSelector) SelectTask(tasks []Task) Task {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	// peers are remembered even when their tasks are done
+	ts.history.forgetDone()
+
+	task := ts.history.leastRecentlySelected(tasks, peerKey)
+	ts.history.selected(peerKey(task))
+
+	return task
+}
+
+func peerKey(task Task) interface{} {
+	if key := task.ExclusionKey(); key != "" {
+		return key
+	}
+
+	return task
+}
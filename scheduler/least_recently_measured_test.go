@@ -0,0 +1,66 @@
+package scheduler_test
+
+import (
+	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/scheduler/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeastRecentlyMeasured", func() {
+	var (
+		ts                    *scheduler.LeastRecentlyMeasuredTaskSelector
+		taskA1, taskA2, taskB *fakes.FakeTask
+		tasks                 []scheduler.Task
+	)
+
+	BeforeEach(func() {
+		ts = scheduler.NewLeastRecentlyMeasuredTaskSelector()
+
+		taskA1 = new(fakes.FakeTask)
+		taskA1.ExclusionKeyReturns("10.0.0.1:5000")
+		taskA2 = new(fakes.FakeTask)
+		taskA2.ExclusionKeyReturns("10.0.0.1:5000")
+		taskB = new(fakes.FakeTask)
+		taskB.ExclusionKeyReturns("10.0.0.2:5000")
+
+		tasks = []scheduler.Task{taskA1, taskA2, taskB}
+	})
+
+	It("should select a task of the peer that was measured least recently", func() {
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskA1))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskB))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskA1))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskB))
+	})
+
+	It("should remember the peers after their tasks are done", func() {
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskA1))
+		taskA1.StateReturns(scheduler.TaskStateDone)
+
+		Expect(ts.SelectTask([]scheduler.Task{taskA2, taskB})).To(
+			BeIdenticalTo(taskB),
+		)
+	})
+
+	It("should treat the tasks without an exclusion key as peers", func() {
+		taskC := new(fakes.FakeTask)
+		tasks = append(tasks, taskC)
+
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskA1))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskB))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(taskC))
+	})
+
+	It("should describe itself", func() {
+		Expect(ts.Name()).To(Equal("least_recently_measured"))
+		Expect(ts.Params()).To(BeEmpty())
+	})
+
+	Context("when the list of tasks is empty", func() {
+		It("should return nil", func() {
+			Expect(ts.SelectTask([]scheduler.Task{})).To(BeNil())
+		})
+	})
+})
@@ -1,7 +1,28 @@
 package scheduler
 
+import "strconv"
+
+// LotteryTaskSelector selects every task with a probability that is
+// proportional to its priority.
 type LotteryTaskSelector struct {
 	Rand RandomIntGenerator
+	// Seed of Rand, if it is seeded. It is reported so that the selections
+	// can be reproduced.
+	Seed int64
+}
+
+func (ts *LotteryTaskSelector) Name() string {
+	return "lottery"
+}
+
+func (ts *LotteryTaskSelector) Params() map[string]string {
+	if ts.Seed == 0 {
+		return map[string]string{}
+	}
+
+	return map[string]string{
+		"seed": strconv.FormatInt(ts.Seed, 10),
+	}
 }
 
 func (ts *LotteryTaskSelector) SelectTask(tasks []Task) Task {
@@ -25,6 +25,14 @@ func NewRandUIG() RandomIntGenerator {
 	}
 }
 
+// NewSeededRandUIG returns a generator that always produces the same
+// sequence for the same seed.
+func NewSeededRandUIG(seed int64) RandomIntGenerator {
+	return &randUIG{
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
 func (u *randUIG) Random(max int64) int64 {
 	return u.rand.Int63n(max)
 }
@@ -0,0 +1,38 @@
+package scheduler
+
+// RoundRobinTaskSelector selects the tasks in turn, in the order that they
+// were scheduled. It ignores the priorities.
+type RoundRobinTaskSelector struct {
+	history *selectionHistory
+}
+
+func NewRoundRobinTaskSelector() *RoundRobinTaskSelector {
+	return &RoundRobinTaskSelector{
+		history: newSelectionHistory(),
+	}
+}
+
+func (ts *RoundRobinTaskSelector) Name() string {
+	return "round_robin"
+}
+
+func (ts *RoundRobinTaskSelector) Params() map[string]string {
+	return map[string]string{}
+}
+
+func (ts *RoundRobinTaskSelector) SelectTask(tasks []Task) Task {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	ts.history.forgetDone()
+
+	task := ts.history.leastRecentlySelected(tasks, taskKey)
+	ts.history.selected(task)
+
+	return task
+}
+
+func taskKey(task Task) interface{} {
+	return task
+}
@@ -0,0 +1,59 @@
+package scheduler_test
+
+import (
+	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/scheduler/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RoundRobin", func() {
+	var (
+		ts    *scheduler.RoundRobinTaskSelector
+		tasks []scheduler.Task
+	)
+
+	BeforeEach(func() {
+		ts = scheduler.NewRoundRobinTaskSelector()
+
+		tasks = make([]scheduler.Task, 3)
+		for i := range tasks {
+			task := new(fakes.FakeTask)
+			task.PriorityReturns(10 * (i + 1))
+			tasks[i] = task
+		}
+	})
+
+	It("should select the tasks in turn", func() {
+		for i := 0; i < 9; i++ {
+			Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[i%3]))
+		}
+	})
+
+	It("should select a new task before the ones that already ran", func() {
+		Expect(ts.SelectTask(tasks[:2])).To(BeIdenticalTo(tasks[0]))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[1]))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[2]))
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[0]))
+	})
+
+	It("should skip the tasks that are not candidates", func() {
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[0]))
+		Expect(ts.SelectTask([]scheduler.Task{tasks[0], tasks[2]})).To(
+			BeIdenticalTo(tasks[2]),
+		)
+		Expect(ts.SelectTask(tasks)).To(BeIdenticalTo(tasks[1]))
+	})
+
+	It("should describe itself", func() {
+		Expect(ts.Name()).To(Equal("round_robin"))
+		Expect(ts.Params()).To(BeEmpty())
+	})
+
+	Context("when the list of tasks is empty", func() {
+		It("should return nil", func() {
+			Expect(ts.SelectTask([]scheduler.Task{})).To(BeNil())
+		})
+	})
+})
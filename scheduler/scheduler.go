@@ -14,6 +14,9 @@ type TaskState int
 const (
 	TaskStateReady TaskState = iota
 	TaskStateDone
+	// The task is not due yet. It stays scheduled but it is not selected
+	// until it is ready again.
+	TaskStateWaiting
 )
 
 func (t TaskState) String() string {
@@ -21,6 +24,8 @@ func (t TaskState) String() string {
 		return "ready"
 	} else if t == TaskStateDone {
 		return "done"
+	} else if t == TaskStateWaiting {
+		return "waiting"
 	}
 
 	return "unknown"
@@ -31,11 +36,25 @@ type Task interface {
 	Run()
 	Priority() int
 	State() TaskState
+	// Tasks with the same non-empty exclusion key never run concurrently.
+	ExclusionKey() string
 }
 
+// UrgentTask is a task that may need to run ahead of the rest.
+type UrgentTask interface {
+	Task
+	Urgent() bool
+}
+
+// TaskSelector picks the next task to run among the candidates. It is only
+// called by the scheduler, one call at a time.
+//
 //go:generate counterfeiter . TaskSelector
 type TaskSelector interface {
 	SelectTask([]Task) Task
+	// Name and Params describe the selection algorithm.
+	Name() string
+	Params() map[string]string
 }
 
 type schedulerState int
@@ -46,34 +65,67 @@ const (
 	schedulerStateStopping
 )
 
+func (s schedulerState) String() string {
+	switch s {
+	case schedulerStateIdle:
+		return "idle"
+	case schedulerStateRunning:
+		return "running"
+	case schedulerStateStopping:
+		return "stopping"
+	default:
+		return "unknown"
+	}
+}
+
+// Amount of selections that the scheduler remembers.
+const selectionsHistoryLen = 32
+
 type Scheduler struct {
 	taskSelector TaskSelector
+	workers      int
 
 	csSleep time.Duration
 	csClock clock.Clock
 
-	tasksList []Task
+	tasksList    []Task
+	runningTasks map[Task]*Selection
+	busyKeys     map[string]bool
+	// most recent last
+	selections []*Selection
 
-	state schedulerState
+	state  schedulerState
+	paused bool
 
 	lock sync.RWMutex
 
 	logger *logrus.Logger
 }
 
+// NewScheduler creates a scheduler that runs up to `workers` tasks
+// concurrently. Every worker sleeps for `csSleep` between its tasks.
 func NewScheduler(
 	logger *logrus.Logger,
 	taskSelector TaskSelector,
+	workers int,
 	csSleep time.Duration,
 	csClock clock.Clock,
 ) *Scheduler {
+	if workers < 1 {
+		workers = 1
+	}
+
 	return &Scheduler{
 		taskSelector: taskSelector,
+		workers:      workers,
 
 		csSleep: csSleep,
 		csClock: csClock,
 
-		tasksList: []Task{},
+		tasksList:    []Task{},
+		runningTasks: make(map[Task]*Selection),
+		busyKeys:     make(map[string]bool),
+		selections:   []*Selection{},
 
 		state: schedulerStateIdle,
 
@@ -97,9 +149,22 @@ func (s *Scheduler) Schedule(task Task) {
 func (s *Scheduler) Run() {
 	s.setState(schedulerStateRunning)
 
+	wg := new(sync.WaitGroup)
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			s.work()
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+
+	s.setState(schedulerStateIdle)
+}
+
+func (s *Scheduler) work() {
 	for {
-		if s.tasksLen() > 0 {
-			task := s.taskSelector.SelectTask(s.tasks())
+		if task := s.claimTask(); task != nil {
 			s.logger.WithFields(logrus.Fields{
 				"priority": task.Priority(),
 			}).Debug("Task is selected to run")
@@ -110,9 +175,7 @@ func (s *Scheduler) Run() {
 				"new_state": task.State(),
 			}).Debug("Task finished running for this round")
 
-			if task.State() == TaskStateDone {
-				s.removeTask(task)
-			}
+			s.releaseTask(task)
 		}
 
 		if s.isStopping() {
@@ -124,8 +187,92 @@ func (s *Scheduler) Run() {
 			s.csClock.Sleep(s.csSleep)
 		}
 	}
+}
 
-	s.setState(schedulerStateIdle)
+// claimTask selects a task among the ones that are neither running, waiting
+// nor excluded by a running task.
+func (s *Scheduler) claimTask() Task {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// the scheduler may be stopped while sleeping between tasks
+	if s.state == schedulerStateStopping || s.paused {
+		return nil
+	}
+
+	candidates := []Task{}
+	for _, task := range s.tasksList {
+		if s.runningTasks[task] != nil {
+			continue
+		}
+		if key := task.ExclusionKey(); key != "" && s.busyKeys[key] {
+			continue
+		}
+		if task.State() == TaskStateWaiting {
+			continue
+		}
+
+		candidates = append(candidates, task)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// urgent tasks preempt the order of the task selector
+	if urgent := urgentTasks(candidates); len(urgent) != 0 {
+		candidates = urgent
+	}
+
+	task := s.taskSelector.SelectTask(candidates)
+	if task == nil {
+		return nil
+	}
+
+	selection := &Selection{
+		Task:      describeTask(task),
+		Priority:  task.Priority(),
+		StartTime: s.csClock.Now(),
+	}
+	s.runningTasks[task] = selection
+	s.selections = append(s.selections, selection)
+	if len(s.selections) > selectionsHistoryLen {
+		s.selections = s.selections[1:]
+	}
+	if key := task.ExclusionKey(); key != "" {
+		s.busyKeys[key] = true
+	}
+
+	return task
+}
+
+func urgentTasks(tasks []Task) []Task {
+	urgent := []Task{}
+	for _, task := range tasks {
+		if urgentTask, ok := task.(UrgentTask); ok && urgentTask.Urgent() {
+			urgent = append(urgent, task)
+		}
+	}
+
+	return urgent
+}
+
+func (s *Scheduler) releaseTask(task Task) {
+	isDone := task.State() == TaskStateDone
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if selection := s.runningTasks[task]; selection != nil {
+		selection.Duration = s.csClock.Since(selection.StartTime)
+	}
+	delete(s.runningTasks, task)
+	if key := task.ExclusionKey(); key != "" {
+		delete(s.busyKeys, key)
+	}
+
+	if isDone {
+		s.removeTaskLocked(task)
+	}
 }
 
 func (s *Scheduler) setState(schedulerState schedulerState) {
@@ -149,24 +296,7 @@ func (s *Scheduler) isStopping() bool {
 	return s.state == schedulerStateStopping
 }
 
-func (s *Scheduler) tasks() []Task {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
-	return s.tasksList
-}
-
-func (s *Scheduler) tasksLen() int {
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-
-	return len(s.tasksList)
-}
-
-func (s *Scheduler) removeTask(task Task) {
-	s.lock.Lock()
-	defer s.lock.Unlock()
-
+func (s *Scheduler) removeTaskLocked(task Task) {
 	var i int
 	for i = 0; i < len(s.tasksList); i++ {
 		if s.tasksList[i] == task {
@@ -37,6 +37,7 @@ var _ = Describe("Scheduler", func() {
 		sched = scheduler.NewScheduler(
 			logger,
 			taskSelector,
+			1,
 			csSleep,
 			clk,
 		)
@@ -101,6 +102,34 @@ var _ = Describe("Scheduler", func() {
 		})
 	})
 
+	Context("when the scheduler is stopped between tasks", func() {
+		It("should not run another task", func() {
+			task := new(fakes.FakeTask)
+			task.StateReturns(scheduler.TaskStateReady)
+			sched.Schedule(task)
+			taskSelector.SelectTaskStub = func(
+				tasks []scheduler.Task,
+			) scheduler.Task {
+				return tasks[0]
+			}
+
+			schedCh := make(chan struct{})
+			go func() {
+				sched.Run()
+				close(schedCh)
+			}()
+
+			Eventually(clk.WatcherCount).Should(Equal(1))
+			Expect(task.RunCallCount()).To(Equal(1))
+
+			Expect(sched.Stop()).To(Succeed())
+			clk.Increment(csSleep)
+
+			Eventually(schedCh).Should(BeClosed())
+			Expect(task.RunCallCount()).To(Equal(1))
+		})
+	})
+
 	Describe("Run", func() {
 		var schedDone chan struct{}
 
@@ -170,6 +199,43 @@ var _ = Describe("Scheduler", func() {
 				Expect(taskA.RunCallCount()).To(Equal(3))
 			})
 
+			Context("and one is urgent", func() {
+				var taskC *urgentTask
+
+				BeforeEach(func() {
+					taskC = &urgentTask{FakeTask: new(fakes.FakeTask), urgent: true}
+					taskC.PriorityReturns(1)
+					taskC.StateReturns(scheduler.TaskStateReady)
+					sched.Schedule(taskC)
+				})
+
+				It("should select among the urgent tasks only", func() {
+					Expect(taskSelector.SelectTaskCallCount()).To(BeNumerically(">", 0))
+					Expect(taskSelector.SelectTaskArgsForCall(0)).To(Equal(
+						[]scheduler.Task{taskC},
+					))
+					Expect(taskC.RunCallCount()).To(BeNumerically(">=", 1))
+				})
+			})
+
+			Context("and one is waiting", func() {
+				BeforeEach(func() {
+					taskA.StateReturns(scheduler.TaskStateWaiting)
+				})
+
+				It("should not select it", func() {
+					Expect(taskSelector.SelectTaskCallCount()).To(BeNumerically(">", 0))
+					Expect(taskSelector.SelectTaskArgsForCall(0)).To(Equal(
+						[]scheduler.Task{taskB},
+					))
+					Expect(taskA.RunCallCount()).To(BeZero())
+				})
+
+				It("should keep it scheduled", func() {
+					Expect(sched.Status().Tasks).To(HaveLen(2))
+				})
+			})
+
 			Context("and one is done", func() {
 				var (
 					taskAState      scheduler.TaskState
@@ -205,4 +271,117 @@ var _ = Describe("Scheduler", func() {
 			})
 		})
 	})
+
+	Describe("Concurrency", func() {
+		var (
+			taskCh    chan struct{}
+			schedDone chan struct{}
+		)
+
+		newBlockingTask := func(key string) *fakes.FakeTask {
+			task := new(fakes.FakeTask)
+			task.ExclusionKeyReturns(key)
+			task.StateReturns(scheduler.TaskStateDone)
+			task.RunStub = func() {
+				<-taskCh
+			}
+
+			return task
+		}
+
+		runsAfterSleeping := func(task *fakes.FakeTask) func() int {
+			return func() int {
+				clk.Increment(csSleep)
+				return task.RunCallCount()
+			}
+		}
+
+		BeforeEach(func() {
+			taskCh = make(chan struct{})
+			sched = scheduler.NewScheduler(logger, taskSelector, 2, csSleep, clk)
+			taskSelector.SelectTaskStub = func(
+				tasks []scheduler.Task,
+			) scheduler.Task {
+				return tasks[0]
+			}
+		})
+
+		JustBeforeEach(func() {
+			schedDone = make(chan struct{})
+			go func() {
+				sched.Run()
+				close(schedDone)
+			}()
+		})
+
+		AfterEach(func() {
+			close(taskCh)
+			Eventually(sched.Stop).Should(Succeed())
+			Eventually(func() bool {
+				clk.Increment(csSleep)
+				select {
+				case <-schedDone:
+					return true
+				default:
+					return false
+				}
+			}).Should(BeTrue())
+		})
+
+		It("should report the amount of workers", func() {
+			Expect(sched.Status().Workers).To(Equal(2))
+		})
+
+		Context("when the tasks have different exclusion keys", func() {
+			var taskA, taskB, taskC *fakes.FakeTask
+
+			BeforeEach(func() {
+				taskA = newBlockingTask("peer-a")
+				taskB = newBlockingTask("peer-b")
+				taskC = newBlockingTask("peer-c")
+				sched.Schedule(taskA)
+				sched.Schedule(taskB)
+				sched.Schedule(taskC)
+			})
+
+			It("should run as many tasks as workers concurrently", func() {
+				Eventually(taskA.RunCallCount).Should(Equal(1))
+				Eventually(taskB.RunCallCount).Should(Equal(1))
+				Expect(sched.Status().API().RunningTasks).To(Equal(2))
+
+				Consistently(runsAfterSleeping(taskC)).Should(BeZero())
+
+				taskCh <- struct{}{}
+				Eventually(runsAfterSleeping(taskC)).Should(Equal(1))
+			})
+		})
+
+		Context("when the tasks have the same exclusion key", func() {
+			var taskA, taskB *fakes.FakeTask
+
+			BeforeEach(func() {
+				taskA = newBlockingTask("peer")
+				taskB = newBlockingTask("peer")
+				sched.Schedule(taskA)
+				sched.Schedule(taskB)
+			})
+
+			It("should not run them concurrently", func() {
+				Eventually(taskA.RunCallCount).Should(Equal(1))
+				Consistently(runsAfterSleeping(taskB)).Should(BeZero())
+
+				taskCh <- struct{}{}
+				Eventually(runsAfterSleeping(taskB)).Should(Equal(1))
+			})
+		})
+	})
 })
+
+type urgentTask struct {
+	*fakes.FakeTask
+	urgent bool
+}
+
+func (t *urgentTask) Urgent() bool {
+	return t.urgent
+}
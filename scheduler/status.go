@@ -0,0 +1,173 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ice-stuff/clique/api"
+)
+
+type Status struct {
+	// `idle`, `running` or `stopping`
+	State  string
+	Paused bool
+
+	Workers int
+	// Sleep of every worker between its tasks
+	Sleep time.Duration
+
+	TaskSelectorName   string
+	TaskSelectorParams map[string]string
+
+	// Scheduled tasks, in the order that they were scheduled
+	Tasks []TaskStatus
+	// Most recent selections, most recent first
+	Selections []Selection
+}
+
+type TaskStatus struct {
+	Task     string
+	Priority int
+	// Share of the sum of the priorities, i.e. of the lottery tickets
+	Share   float64
+	Running bool
+	// Start of the current run; zero when the task is not running
+	StartTime time.Time
+}
+
+type Selection struct {
+	Task      string
+	Priority  int
+	StartTime time.Time
+	// Zero while the task is running
+	Duration time.Duration
+}
+
+// Status returns a snapshot of the scheduler.
+func (s *Scheduler) Status() Status {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	prioritiesSum := 0
+	for _, task := range s.tasksList {
+		prioritiesSum += task.Priority()
+	}
+
+	tasks := make([]TaskStatus, len(s.tasksList))
+	for i, task := range s.tasksList {
+		tasks[i] = TaskStatus{
+			Task:     describeTask(task),
+			Priority: task.Priority(),
+		}
+		if prioritiesSum > 0 {
+			tasks[i].Share = float64(task.Priority()) / float64(prioritiesSum)
+		}
+		if selection := s.runningTasks[task]; selection != nil {
+			tasks[i].Running = true
+			tasks[i].StartTime = selection.StartTime
+		}
+	}
+
+	selections := make([]Selection, len(s.selections))
+	for i, selection := range s.selections {
+		selections[len(s.selections)-1-i] = *selection
+	}
+
+	return Status{
+		State:  s.state.String(),
+		Paused: s.paused,
+
+		Workers: s.workers,
+		Sleep:   s.csSleep,
+
+		TaskSelectorName:   s.taskSelector.Name(),
+		TaskSelectorParams: s.taskSelector.Params(),
+
+		Tasks:      tasks,
+		Selections: selections,
+	}
+}
+
+// API returns the status as the API reports it.
+func (s Status) API() api.SchedulerStatus {
+	runningTasks := 0
+	queue := make([]api.ScheduledTask, len(s.Tasks))
+	for i, task := range s.Tasks {
+		if task.Running {
+			runningTasks++
+		}
+
+		queue[i] = api.ScheduledTask{
+			Task:      task.Task,
+			Priority:  task.Priority,
+			Share:     task.Share,
+			Running:   task.Running,
+			StartTime: task.StartTime,
+		}
+	}
+
+	selections := make([]api.TaskSelection, len(s.Selections))
+	for i, selection := range s.Selections {
+		selections[i] = api.TaskSelection{
+			Task:      selection.Task,
+			Priority:  selection.Priority,
+			StartTime: selection.StartTime,
+			Duration:  selection.Duration,
+		}
+	}
+
+	return api.SchedulerStatus{
+		State:        s.State,
+		Paused:       s.Paused,
+		Workers:      s.Workers,
+		RunningTasks: runningTasks,
+		Tasks:        len(s.Tasks),
+		Sleep:        s.Sleep,
+		TaskSelector: api.TaskSelectorStatus{
+			Name:   s.TaskSelectorName,
+			Params: s.TaskSelectorParams,
+		},
+		Queue:      queue,
+		Selections: selections,
+	}
+}
+
+// APIScheduler exposes the scheduler to the API.
+type APIScheduler struct {
+	*Scheduler
+}
+
+func (s APIScheduler) Status() api.SchedulerStatus {
+	return s.Scheduler.Status().API()
+}
+
+// Pause stops selecting new tasks until Resume is called. The running tasks
+// are not interrupted.
+func (s *Scheduler) Pause() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.paused {
+		s.logger.Info("Scheduler is paused")
+	}
+	s.paused = true
+}
+
+func (s *Scheduler) Resume() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.paused {
+		s.logger.Info("Scheduler is resumed")
+	}
+	s.paused = false
+}
+
+// describeTask uses the description of the task if it has one.
+func describeTask(task Task) string {
+	if stringer, ok := task.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	return fmt.Sprintf("%T", task)
+}
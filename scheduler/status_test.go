@@ -0,0 +1,253 @@
+package scheduler_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/scheduler"
+	"github.com/ice-stuff/clique/scheduler/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type describedTask struct {
+	*fakes.FakeTask
+	description string
+}
+
+func (t *describedTask) String() string {
+	return t.description
+}
+
+var _ = Describe("Status", func() {
+	var (
+		sched        *scheduler.Scheduler
+		logger       *logrus.Logger
+		taskSelector *fakes.FakeTaskSelector
+		csSleep      time.Duration
+		clk          *fakeclock.FakeClock
+
+		taskA, taskB *describedTask
+		taskCh       chan struct{}
+	)
+
+	newBlockingTask := func(description string, priority int) *describedTask {
+		task := &describedTask{
+			FakeTask:    new(fakes.FakeTask),
+			description: description,
+		}
+		task.PriorityReturns(priority)
+		task.ExclusionKeyReturns(description)
+		task.RunStub = func() {
+			<-taskCh
+		}
+
+		return task
+	}
+
+	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
+		taskSelector = new(fakes.FakeTaskSelector)
+		taskSelector.NameReturns("banana")
+		taskSelector.ParamsReturns(map[string]string{"ripe": "yes"})
+		taskSelector.SelectTaskStub = func(
+			tasks []scheduler.Task,
+		) scheduler.Task {
+			return tasks[0]
+		}
+		csSleep = time.Millisecond * 5
+		t, err := time.Parse(time.RFC3339, "2015-11-24T06:30:00+00:00")
+		Expect(err).NotTo(HaveOccurred())
+		clk = fakeclock.NewFakeClock(t)
+
+		sched = scheduler.NewScheduler(logger, taskSelector, 1, csSleep, clk)
+
+		taskCh = make(chan struct{})
+		taskA = newBlockingTask("task-a", 10)
+		taskB = newBlockingTask("task-b", 5)
+		sched.Schedule(taskA)
+		sched.Schedule(taskB)
+	})
+
+	Context("when the scheduler is not running", func() {
+		It("should report the state and the settings", func() {
+			status := sched.Status()
+
+			Expect(status.State).To(Equal("idle"))
+			Expect(status.Paused).To(BeFalse())
+			Expect(status.Workers).To(Equal(1))
+			Expect(status.Sleep).To(Equal(csSleep))
+			Expect(status.TaskSelectorName).To(Equal("banana"))
+			Expect(status.TaskSelectorParams).To(Equal(
+				map[string]string{"ripe": "yes"},
+			))
+		})
+
+		It("should report the queue with the shares of the tasks", func() {
+			Expect(sched.Status().Tasks).To(Equal([]scheduler.TaskStatus{
+				{Task: "task-a", Priority: 10, Share: 10.0 / 15},
+				{Task: "task-b", Priority: 5, Share: 5.0 / 15},
+			}))
+		})
+
+		It("should report no selections", func() {
+			Expect(sched.Status().Selections).To(BeEmpty())
+		})
+	})
+
+	Context("when the scheduler is running", func() {
+		var schedDone chan struct{}
+
+		BeforeEach(func() {
+			schedDone = make(chan struct{})
+			go func() {
+				sched.Run()
+				close(schedDone)
+			}()
+
+			Eventually(taskA.RunCallCount).Should(Equal(1))
+		})
+
+		AfterEach(func() {
+			close(taskCh)
+			Eventually(sched.Stop).Should(Succeed())
+			Eventually(func() bool {
+				clk.Increment(csSleep)
+				select {
+				case <-schedDone:
+					return true
+				default:
+					return false
+				}
+			}).Should(BeTrue())
+		})
+
+		It("should report the running task", func() {
+			status := sched.Status()
+
+			Expect(status.State).To(Equal("running"))
+			Expect(status.Tasks[0].Running).To(BeTrue())
+			Expect(status.Tasks[0].StartTime).To(Equal(clk.Now()))
+			Expect(status.Tasks[1].Running).To(BeFalse())
+		})
+
+		It("should report the selections, most recent first", func() {
+			start := clk.Now()
+			clk.Increment(time.Second)
+			taskCh <- struct{}{}
+
+			Eventually(func() int {
+				clk.Increment(csSleep)
+				return len(sched.Status().Selections)
+			}).Should(Equal(2))
+
+			selections := sched.Status().Selections
+			Expect(selections[0].Task).To(Equal("task-a"))
+			Expect(selections[0].Duration).To(BeZero())
+			Expect(selections[1].Task).To(Equal("task-a"))
+			Expect(selections[1].Priority).To(Equal(10))
+			Expect(selections[1].StartTime).To(Equal(start))
+			Expect(selections[1].Duration).To(BeNumerically(">=", time.Second))
+		})
+
+		Context("and it is paused", func() {
+			BeforeEach(func() {
+				sched.Pause()
+				taskCh <- struct{}{}
+			})
+
+			It("should report it", func() {
+				Expect(sched.Status().Paused).To(BeTrue())
+			})
+
+			It("should not select any task", func() {
+				Consistently(func() int {
+					clk.Increment(csSleep)
+					return taskSelector.SelectTaskCallCount()
+				}).Should(Equal(1))
+			})
+
+			It("should select tasks again when it is resumed", func() {
+				sched.Resume()
+
+				Eventually(func() int {
+					clk.Increment(csSleep)
+					return taskA.RunCallCount()
+				}).Should(Equal(2))
+				Expect(sched.Status().Paused).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("API", func() {
+		It("should convert the status", func() {
+			startTime := time.Now()
+			status := scheduler.Status{
+				State:              "running",
+				Paused:             true,
+				Workers:            2,
+				Sleep:              time.Second,
+				TaskSelectorName:   "round_robin",
+				TaskSelectorParams: map[string]string{},
+				Tasks: []scheduler.TaskStatus{
+					{
+						Task:      "transfer-a",
+						Priority:  5,
+						Share:     0.5,
+						Running:   true,
+						StartTime: startTime,
+					},
+					{Task: "transfer-b", Priority: 5, Share: 0.5},
+				},
+				Selections: []scheduler.Selection{
+					{Task: "transfer-a", Priority: 5, StartTime: startTime},
+					{
+						Task:      "transfer-b",
+						Priority:  5,
+						StartTime: startTime.Add(-time.Minute),
+						Duration:  time.Second,
+					},
+				},
+			}
+
+			Expect(status.API()).To(Equal(api.SchedulerStatus{
+				State:        "running",
+				Paused:       true,
+				Workers:      2,
+				RunningTasks: 1,
+				Tasks:        2,
+				Sleep:        time.Second,
+				TaskSelector: api.TaskSelectorStatus{
+					Name:   "round_robin",
+					Params: map[string]string{},
+				},
+				Queue: []api.ScheduledTask{
+					{
+						Task:      "transfer-a",
+						Priority:  5,
+						Share:     0.5,
+						Running:   true,
+						StartTime: startTime,
+					},
+					{Task: "transfer-b", Priority: 5, Share: 0.5},
+				},
+				Selections: []api.TaskSelection{
+					{Task: "transfer-a", Priority: 5, StartTime: startTime},
+					{
+						Task:      "transfer-b",
+						Priority:  5,
+						StartTime: startTime.Add(-time.Minute),
+						Duration:  time.Second,
+					},
+				},
+			}))
+		})
+	})
+})
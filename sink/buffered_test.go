@@ -0,0 +1,181 @@
+package sink_test
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/sink"
+	"github.com/ice-stuff/clique/sink/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Buffered", func() {
+	var (
+		logger   *logrus.Logger
+		fakeSink *fakes.FakeSink
+		cfg      sink.BufferConfig
+		clk      *fakeclock.FakeClock
+		buffered *sink.Buffered
+
+		written     [][]api.TransferResults
+		writtenLock sync.Mutex
+	)
+
+	res := func(i int) api.TransferResults {
+		return api.TransferResults{
+			IP:        net.ParseIP("127.0.0.1"),
+			BytesSent: uint64(i),
+		}
+	}
+
+	writtenBatches := func() [][]api.TransferResults {
+		writtenLock.Lock()
+		defer writtenLock.Unlock()
+
+		return written
+	}
+
+	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
+
+		written = nil
+		fakeSink = new(fakes.FakeSink)
+		fakeSink.WriteStub = func(results []api.TransferResults) error {
+			writtenLock.Lock()
+			defer writtenLock.Unlock()
+
+			written = append(written, results)
+			return nil
+		}
+
+		cfg = sink.BufferConfig{
+			BufferSize:    10,
+			BatchSize:     2,
+			FlushInterval: time.Second,
+		}
+		clk = fakeclock.NewFakeClock(time.Now())
+	})
+
+	JustBeforeEach(func() {
+		buffered = sink.NewBuffered(logger, "banana", fakeSink, cfg, clk)
+	})
+
+	It("should write the results in batches", func() {
+		for i := 0; i < 4; i++ {
+			buffered.Push(res(i))
+		}
+
+		Eventually(writtenBatches).Should(Equal([][]api.TransferResults{
+			{res(0), res(1)},
+			{res(2), res(3)},
+		}))
+		Expect(buffered.Close()).To(Succeed())
+	})
+
+	It("should flush incomplete batches after the flush interval", func() {
+		buffered.Push(res(0))
+		Consistently(writtenBatches).Should(BeEmpty())
+
+		clk.WaitForWatcherAndIncrement(time.Second)
+		Eventually(writtenBatches).Should(Equal([][]api.TransferResults{
+			{res(0)},
+		}))
+		Expect(buffered.Close()).To(Succeed())
+	})
+
+	Describe("Close", func() {
+		It("should flush the queued results and close the sink", func() {
+			buffered.Push(res(0))
+
+			Expect(buffered.Close()).To(Succeed())
+			Expect(writtenBatches()).To(Equal([][]api.TransferResults{
+				{res(0)},
+			}))
+			Expect(fakeSink.CloseCallCount()).To(Equal(1))
+		})
+
+		It("should ignore results pushed after closing", func() {
+			Expect(buffered.Close()).To(Succeed())
+
+			buffered.Push(res(0))
+			Expect(writtenBatches()).To(BeEmpty())
+		})
+
+		It("should fail when called twice", func() {
+			Expect(buffered.Close()).To(Succeed())
+			Expect(buffered.Close()).NotTo(Succeed())
+		})
+	})
+
+	Context("when the sink is slow", func() {
+		var unblock chan struct{}
+
+		BeforeEach(func() {
+			cfg.BufferSize = 2
+			cfg.BatchSize = 1
+
+			unblock = make(chan struct{})
+			fakeSink.WriteStub = func(_ []api.TransferResults) error {
+				<-unblock
+				return nil
+			}
+		})
+
+		It("should not block and drop the results that do not fit", func() {
+			pushed := make(chan struct{})
+			go func() {
+				for i := 0; i < 10; i++ {
+					buffered.Push(res(i))
+				}
+				close(pushed)
+			}()
+
+			Eventually(pushed).Should(BeClosed())
+			Expect(buffered.Dropped()).To(BeNumerically(">=", 7))
+
+			close(unblock)
+			Expect(buffered.Close()).To(Succeed())
+		})
+	})
+
+	Context("when the sink fails", func() {
+		BeforeEach(func() {
+			cfg.BatchSize = 1
+			fakeSink.WriteReturns(errors.New("banana"))
+		})
+
+		It("should count the failed results and keep going", func() {
+			buffered.Push(res(0))
+			buffered.Push(res(1))
+
+			Eventually(buffered.Failed).Should(BeEquivalentTo(2))
+			Expect(buffered.Close()).To(Succeed())
+		})
+	})
+
+	Context("when the sink panics", func() {
+		BeforeEach(func() {
+			cfg.BatchSize = 1
+			fakeSink.WriteStub = func(_ []api.TransferResults) error {
+				panic("banana")
+			}
+		})
+
+		It("should recover and count the failed results", func() {
+			buffered.Push(res(0))
+
+			Eventually(buffered.Failed).Should(BeEquivalentTo(1))
+			Expect(buffered.Close()).To(Succeed())
+		})
+	})
+})
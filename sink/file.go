@@ -0,0 +1,35 @@
+package sink
+
+import (
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/export"
+)
+
+type File struct {
+	exporter *export.FileExporter
+}
+
+func NewFile(cfg export.FileConfig) (*File, error) {
+	exporter, err := export.NewFileExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		exporter: exporter,
+	}, nil
+}
+
+func (f *File) Write(results []api.TransferResults) error {
+	for _, res := range results {
+		if err := f.exporter.Export(res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *File) Close() error {
+	return f.exporter.Close()
+}
@@ -0,0 +1,59 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ice-stuff/clique/api"
+)
+
+type HTTPConfig struct {
+	URL     string
+	Timeout time.Duration
+}
+
+// HTTP POSTs every batch of results as a JSON array.
+type HTTP struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewHTTP(cfg HTTPConfig) *HTTP {
+	return &HTTP{
+		url: cfg.URL,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+	}
+}
+
+func (h *HTTP) Write(results []api.TransferResults) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("encoding results: %s", err)
+	}
+
+	resp, err := h.httpClient.Post(
+		h.url, "application/json", bytes.NewReader(data),
+	)
+	if err != nil {
+		return fmt.Errorf("posting results: %s", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("posting results: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (h *HTTP) Close() error {
+	return nil
+}
@@ -0,0 +1,223 @@
+package sink_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/ice-stuff/clique/api"
+	"github.com/ice-stuff/clique/sink"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Remote sinks", func() {
+	var results []api.TransferResults
+
+	BeforeEach(func() {
+		t, err := time.Parse(time.RFC3339, "2015-12-20T17:25:12Z")
+		Expect(err).NotTo(HaveOccurred())
+
+		results = []api.TransferResults{
+			api.TransferResults{
+				IP:        net.ParseIP("12.12.12.13"),
+				BytesSent: 1024 * 1024,
+				Duration:  time.Second * 2,
+				RTT:       time.Millisecond * 12,
+				Time:      t,
+				Size:      1024 * 1024,
+			},
+			api.TransferResults{
+				IP:        net.ParseIP("12.15.12.18"),
+				BytesSent: 1024,
+				Duration:  time.Millisecond * 500,
+				RTT:       time.Millisecond * 17,
+				Time:      t,
+				Size:      1024,
+			},
+		}
+	})
+
+	listenUDP := func() *net.UDPConn {
+		addr, err := net.ResolveUDPAddr("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		conn, err := net.ListenUDP("udp", addr)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+
+		return conn
+	}
+
+	readPacket := func(conn *net.UDPConn) string {
+		buffer := make([]byte, 2048)
+		n, err := conn.Read(buffer)
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(buffer[:n])
+	}
+
+	Describe("HTTP", func() {
+		var (
+			server   *httptest.Server
+			status   int
+			received chan []api.TransferResults
+		)
+
+		BeforeEach(func() {
+			status = 204
+			received = make(chan []api.TransferResults, 10)
+			server = httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					Expect(r.Method).To(Equal("POST"))
+					Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+
+					data, err := ioutil.ReadAll(r.Body)
+					Expect(err).NotTo(HaveOccurred())
+					var res []api.TransferResults
+					Expect(json.Unmarshal(data, &res)).To(Succeed())
+					received <- res
+
+					w.WriteHeader(status)
+				},
+			))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should post the batch of results", func() {
+			s := sink.NewHTTP(sink.HTTPConfig{URL: server.URL, Timeout: time.Second})
+
+			Expect(s.Write(results)).To(Succeed())
+			Expect(received).To(Receive(Equal(results)))
+		})
+
+		Context("when the endpoint responds with an error", func() {
+			BeforeEach(func() {
+				status = 503
+			})
+
+			It("should fail", func() {
+				s := sink.NewHTTP(sink.HTTPConfig{URL: server.URL, Timeout: time.Second})
+
+				Expect(s.Write(results)).To(MatchError(ContainSubstring("503")))
+			})
+		})
+	})
+
+	Describe("StatsD", func() {
+		It("should send the metrics of every result", func() {
+			conn := listenUDP()
+			defer conn.Close()
+
+			s, err := sink.NewStatsD(sink.StatsDConfig{
+				Address: conn.LocalAddr().String(),
+				Prefix:  "clq.",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer s.Close()
+
+			Expect(s.Write(results)).To(Succeed())
+
+			Expect(strings.Split(readPacket(conn), "\n")).To(Equal([]string{
+				"clq.transfers.12_12_12_13.bytes_sent:1048576|c",
+				"clq.transfers.12_12_12_13.duration:2000|ms",
+				"clq.transfers.12_12_12_13.rtt:12|ms",
+				"clq.transfers.12_12_12_13.throughput:4194304|g",
+				"clq.transfers.12_15_12_18.bytes_sent:1024|c",
+				"clq.transfers.12_15_12_18.duration:500|ms",
+				"clq.transfers.12_15_12_18.rtt:17|ms",
+				"clq.transfers.12_15_12_18.throughput:16384|g",
+			}))
+		})
+
+		It("should name the metrics after the peer when there is one", func() {
+			conn := listenUDP()
+			defer conn.Close()
+
+			s, err := sink.NewStatsD(sink.StatsDConfig{
+				Address: conn.LocalAddr().String(),
+				Prefix:  "clq.",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer s.Close()
+
+			res := results[0]
+			res.Peer = "[fe80::1]:5000"
+			Expect(s.Write([]api.TransferResults{res})).To(Succeed())
+
+			Expect(readPacket(conn)).To(HavePrefix(
+				"clq.transfers.fe80__1_5000.bytes_sent:1048576|c\n",
+			))
+		})
+
+		It("should split the metrics in packets that fit in a frame", func() {
+			conn := listenUDP()
+			defer conn.Close()
+
+			s, err := sink.NewStatsD(sink.StatsDConfig{
+				Address: conn.LocalAddr().String(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer s.Close()
+
+			manyResults := []api.TransferResults{}
+			for i := 0; i < 20; i++ {
+				manyResults = append(manyResults, results...)
+			}
+			Expect(s.Write(manyResults)).To(Succeed())
+
+			metricsAmt := 0
+			for metricsAmt < len(manyResults)*4 {
+				packet := readPacket(conn)
+				Expect(len(packet)).To(BeNumerically("<=", 1432))
+				metricsAmt += len(strings.Split(packet, "\n"))
+			}
+			Expect(metricsAmt).To(Equal(len(manyResults) * 4))
+		})
+	})
+
+	Describe("Syslog", func() {
+		It("should send one message per result", func() {
+			conn := listenUDP()
+			defer conn.Close()
+
+			s, err := sink.NewSyslog(sink.SyslogConfig{
+				Network: "udp",
+				Address: conn.LocalAddr().String(),
+				Tag:     "clique-test",
+				Format:  api.ResultsFormatJSONLines,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer s.Close()
+
+			Expect(s.Write(results)).To(Succeed())
+
+			for _, res := range results {
+				msg := readPacket(conn)
+				Expect(msg).To(ContainSubstring("clique-test"))
+				Expect(msg).To(HaveSuffix(fmt.Sprintf(
+					`"ip":"%s","bytes_sent":%d,"checksum":0,"duration":%d,"rtt":%d,"time":"2015-12-20T17:25:12Z","size":%d,"throttled":false}`+"\n",
+					res.IP, res.BytesSent, res.Duration, res.RTT, res.Size,
+				)))
+			}
+		})
+
+		It("should fail when the format cannot be streamed", func() {
+			_, err := sink.NewSyslog(sink.SyslogConfig{
+				Network: "udp",
+				Address: "127.0.0.1:514",
+				Format:  api.ResultsFormatJSON,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,191 @@
+package sink
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/api"
+)
+
+//go:generate counterfeiter . Sink
+type Sink interface {
+	Write(results []api.TransferResults) error
+	Close() error
+}
+
+type BufferConfig struct {
+	// Amount of results that can be queued before new ones are dropped.
+	BufferSize int
+	// Amount of results to hand to the sink at once.
+	BatchSize int
+	// Maximum time a result stays queued before it is handed to the sink.
+	FlushInterval time.Duration
+}
+
+// Buffered decouples a sink from the code that produces the results. Pushing
+// never blocks: results are queued and handed to the sink in batches from a
+// separate goroutine, and they are dropped when the queue is full. Sink
+// failures are logged and do not affect other sinks.
+type Buffered struct {
+	name   string
+	sink   Sink
+	cfg    BufferConfig
+	clock  clock.Clock
+	logger *logrus.Logger
+
+	queue  chan api.TransferResults
+	done   chan struct{}
+	closed bool
+
+	dropped uint64
+	failed  uint64
+
+	lock sync.Mutex
+}
+
+func NewBuffered(
+	logger *logrus.Logger,
+	name string,
+	sink Sink,
+	cfg BufferConfig,
+	clk clock.Clock,
+) *Buffered {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+
+	b := &Buffered{
+		name:   name,
+		sink:   sink,
+		cfg:    cfg,
+		clock:  clk,
+		logger: logger,
+
+		queue: make(chan api.TransferResults, cfg.BufferSize),
+		done:  make(chan struct{}),
+	}
+	go b.loop()
+
+	return b
+}
+
+func (b *Buffered) Name() string {
+	return b.name
+}
+
+func (b *Buffered) Push(res api.TransferResults) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	select {
+	case b.queue <- res:
+	default:
+		b.dropped++
+		b.logger.WithFields(logrus.Fields{
+			"sink":    b.name,
+			"dropped": b.dropped,
+		}).Warn("Result sink is falling behind, dropping result")
+	}
+}
+
+// Dropped returns the amount of results that did not fit in the queue.
+func (b *Buffered) Dropped() uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.dropped
+}
+
+// Failed returns the amount of results that the sink failed to write.
+func (b *Buffered) Failed() uint64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.failed
+}
+
+// Close stops accepting results, flushes the queued ones and closes the sink.
+func (b *Buffered) Close() error {
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return fmt.Errorf("sink `%s` is already closed", b.name)
+	}
+	b.closed = true
+	close(b.queue)
+	b.lock.Unlock()
+
+	<-b.done
+
+	return b.sink.Close()
+}
+
+func (b *Buffered) loop() {
+	defer close(b.done)
+
+	var tickerC <-chan time.Time
+	if b.cfg.FlushInterval > 0 {
+		ticker := b.clock.NewTicker(b.cfg.FlushInterval)
+		defer ticker.Stop()
+		tickerC = ticker.C()
+	}
+
+	batch := make([]api.TransferResults, 0, b.cfg.BatchSize)
+	for {
+		select {
+		case res, ok := <-b.queue:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+
+			batch = append(batch, res)
+			if len(batch) >= b.cfg.BatchSize {
+				b.flush(batch)
+				batch = make([]api.TransferResults, 0, b.cfg.BatchSize)
+			}
+		case <-tickerC:
+			if len(batch) > 0 {
+				b.flush(batch)
+				batch = make([]api.TransferResults, 0, b.cfg.BatchSize)
+			}
+		}
+	}
+}
+
+func (b *Buffered) flush(batch []api.TransferResults) {
+	if len(batch) == 0 {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			b.recordFailure(batch, fmt.Errorf("sink panicked: %v", r))
+		}
+	}()
+
+	if err := b.sink.Write(batch); err != nil {
+		b.recordFailure(batch, err)
+	}
+}
+
+func (b *Buffered) recordFailure(batch []api.TransferResults, err error) {
+	b.lock.Lock()
+	b.failed += uint64(len(batch))
+	b.lock.Unlock()
+
+	b.logger.WithFields(logrus.Fields{
+		"sink":    b.name,
+		"results": len(batch),
+	}).Errorf("Failed to write results to sink: %s", err)
+}
@@ -0,0 +1,111 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ice-stuff/clique/api"
+)
+
+// Keeps StatsD packets within a single Ethernet frame.
+const statsdMaxPacketSize = 1432
+
+type StatsDConfig struct {
+	Address string
+	Prefix  string
+}
+
+// StatsD emits one set of metrics per result over UDP:
+//
+//	<prefix>.transfers.<peer>.bytes_sent:<bytes>|c
+//	<prefix>.transfers.<peer>.duration:<ms>|ms
+//	<prefix>.transfers.<peer>.rtt:<ms>|ms
+//	<prefix>.transfers.<peer>.throughput:<bits per second>|g
+type StatsD struct {
+	conn   net.Conn
+	prefix string
+}
+
+func NewStatsD(cfg StatsDConfig) (*StatsD, error) {
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.TrimSuffix(cfg.Prefix, ".")
+	if prefix == "" {
+		prefix = "clique"
+	}
+
+	return &StatsD{
+		conn:   conn,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *StatsD) Write(results []api.TransferResults) error {
+	packet := new(bytes.Buffer)
+	for _, res := range results {
+		for _, metric := range s.metrics(res) {
+			if packet.Len()+len(metric)+1 > statsdMaxPacketSize {
+				if err := s.send(packet); err != nil {
+					return err
+				}
+			}
+
+			if packet.Len() > 0 {
+				packet.WriteByte('\n')
+			}
+			packet.WriteString(metric)
+		}
+	}
+
+	return s.send(packet)
+}
+
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+func (s *StatsD) metrics(res api.TransferResults) []string {
+	name := fmt.Sprintf("%s.transfers.%s", s.prefix, statsdPeerName(res))
+
+	var throughput uint64
+	if res.Duration > 0 {
+		throughput = uint64(float64(res.BytesSent) * 8 / res.Duration.Seconds())
+	}
+
+	return []string{
+		fmt.Sprintf("%s.bytes_sent:%d|c", name, res.BytesSent),
+		fmt.Sprintf("%s.duration:%d|ms", name, res.Duration/time.Millisecond),
+		fmt.Sprintf("%s.rtt:%d|ms", name, res.RTT/time.Millisecond),
+		fmt.Sprintf("%s.throughput:%d|g", name, throughput),
+	}
+}
+
+func (s *StatsD) send(packet *bytes.Buffer) error {
+	if packet.Len() == 0 {
+		return nil
+	}
+	defer packet.Reset()
+
+	_, err := s.conn.Write(packet.Bytes())
+	return err
+}
+
+var statsdNameReplacer = strings.NewReplacer(
+	".", "_", ":", "_", "[", "", "]", "",
+)
+
+// statsdPeerName names the metrics of the peer after its identity, so that
+// they do not move when its hostname resolves to a different address.
+func statsdPeerName(res api.TransferResults) string {
+	if res.Peer != "" {
+		return statsdNameReplacer.Replace(res.Peer)
+	}
+
+	return statsdNameReplacer.Replace(res.IP.String())
+}
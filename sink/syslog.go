@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"bytes"
+	"log/syslog"
+	"strings"
+
+	"github.com/ice-stuff/clique/api"
+)
+
+type SyslogConfig struct {
+	// Network and address of a remote syslog daemon. The local daemon is used
+	// when empty.
+	Network string
+	Address string
+	Tag     string
+	Format  api.ResultsFormat
+}
+
+// Syslog writes every result as a single informational syslog message.
+type Syslog struct {
+	writer  *syslog.Writer
+	encoder api.ResultsEncoder
+}
+
+func NewSyslog(cfg SyslogConfig) (*Syslog, error) {
+	encoder, err := api.NewResultsEncoder(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := syslog.Dial(
+		cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.Tag,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Syslog{
+		writer:  writer,
+		encoder: encoder,
+	}, nil
+}
+
+func (s *Syslog) Write(results []api.TransferResults) error {
+	for _, res := range results {
+		msg := new(bytes.Buffer)
+		if err := s.encoder.Encode(msg, res); err != nil {
+			return err
+		}
+
+		if err := s.writer.Info(strings.TrimSpace(msg.String())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Syslog) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,69 @@
+package sizing
+
+import (
+	"sync"
+	"time"
+)
+
+type AdaptiveConfig struct {
+	// Duration that every transfer should last
+	TargetDuration time.Duration
+	MinSize        uint64
+	MaxSize        uint64
+}
+
+// Adaptive chooses the size of the next transfer to every peer so that it
+// lasts about the target duration, based on the throughput of the previous
+// transfer to the same peer.
+type Adaptive struct {
+	cfg AdaptiveConfig
+
+	// bytes per second
+	throughputs map[string]float64
+
+	lock sync.Mutex
+}
+
+func NewAdaptive(cfg AdaptiveConfig) *Adaptive {
+	return &Adaptive{
+		cfg:         cfg,
+		throughputs: make(map[string]float64),
+	}
+}
+
+// Size returns the size of the next transfer to the peer. The requested size
+// is used, within the bounds, until there is a previous transfer.
+func (a *Adaptive) Size(peer string, requested uint64) uint64 {
+	a.lock.Lock()
+	throughput, ok := a.throughputs[peer]
+	a.lock.Unlock()
+
+	if !ok {
+		return a.bound(float64(requested))
+	}
+
+	return a.bound(throughput * a.cfg.TargetDuration.Seconds())
+}
+
+// Observe records the outcome of a transfer to the peer.
+func (a *Adaptive) Observe(peer string, bytes uint64, duration time.Duration) {
+	if duration <= 0 {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.throughputs[peer] = float64(bytes) / duration.Seconds()
+}
+
+func (a *Adaptive) bound(size float64) uint64 {
+	if size < float64(a.cfg.MinSize) {
+		return a.cfg.MinSize
+	}
+	if size > float64(a.cfg.MaxSize) {
+		return a.cfg.MaxSize
+	}
+
+	return uint64(size)
+}
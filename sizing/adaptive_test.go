@@ -0,0 +1,75 @@
+package sizing_test
+
+import (
+	"time"
+
+	"github.com/ice-stuff/clique/sizing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adaptive", func() {
+	var a *sizing.Adaptive
+
+	BeforeEach(func() {
+		a = sizing.NewAdaptive(sizing.AdaptiveConfig{
+			TargetDuration: 10 * time.Second,
+			MinSize:        1024,
+			MaxSize:        100 * 1024 * 1024,
+		})
+	})
+
+	Context("when there is no previous transfer to the peer", func() {
+		It("should use the requested size", func() {
+			Expect(a.Size("10.0.0.1:5000", 20*1024)).To(BeEquivalentTo(20 * 1024))
+		})
+
+		It("should bound the requested size", func() {
+			Expect(a.Size("10.0.0.1:5000", 12)).To(BeEquivalentTo(1024))
+			Expect(a.Size("10.0.0.1:5000", 1024*1024*1024)).To(
+				BeEquivalentTo(100 * 1024 * 1024),
+			)
+		})
+	})
+
+	Context("when there is a previous transfer to the peer", func() {
+		It("should aim for the target duration", func() {
+			a.Observe("10.0.0.1:5000", 1024*1024, time.Second)
+
+			Expect(a.Size("10.0.0.1:5000", 20*1024)).To(
+				BeEquivalentTo(10 * 1024 * 1024),
+			)
+		})
+
+		It("should use the most recent transfer", func() {
+			a.Observe("10.0.0.1:5000", 1024*1024, time.Second)
+			a.Observe("10.0.0.1:5000", 2*1024*1024, time.Second)
+
+			Expect(a.Size("10.0.0.1:5000", 20*1024)).To(
+				BeEquivalentTo(20 * 1024 * 1024),
+			)
+		})
+
+		It("should stay within the bounds", func() {
+			a.Observe("10.0.0.1:5000", 1024*1024*1024, time.Second)
+			Expect(a.Size("10.0.0.1:5000", 20*1024)).To(
+				BeEquivalentTo(100 * 1024 * 1024),
+			)
+
+			a.Observe("10.0.0.1:5000", 10, time.Second)
+			Expect(a.Size("10.0.0.1:5000", 20*1024)).To(BeEquivalentTo(1024))
+		})
+
+		It("should not affect the other peers", func() {
+			a.Observe("10.0.0.1:5000", 1024*1024, time.Second)
+
+			Expect(a.Size("10.0.0.2:5000", 20*1024)).To(BeEquivalentTo(20 * 1024))
+		})
+
+		It("should ignore transfers without a duration", func() {
+			a.Observe("10.0.0.1:5000", 1024*1024, 0)
+
+			Expect(a.Size("10.0.0.1:5000", 20*1024)).To(BeEquivalentTo(20 * 1024))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package sizing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSizing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sizing Suite")
+}
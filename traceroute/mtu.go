@@ -0,0 +1,177 @@
+package traceroute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// MaxPacketSize is the largest IP packet that can be probed.
+const MaxPacketSize = 65535
+
+type MTUConfig struct {
+	// Time to wait for the answer of every probe
+	ProbeTimeout time.Duration
+	// Probes that get no answer are sent again this amount of times before
+	// they count as too big, as the peers limit the rate of their ICMP
+	// errors
+	Retries int
+}
+
+type MTU struct {
+	// Largest IP packet that reaches the peer without fragmentation
+	PathMTU int
+	// Local interface of the route to the peer
+	Interface    string
+	InterfaceMTU int
+}
+
+// Mismatch reports if the path MTU is smaller than the MTU of the local
+// interface, e.g. when jumbo frames are not enabled along the whole path.
+func (m MTU) Mismatch() bool {
+	return m.PathMTU < maxProbeSize(m.InterfaceMTU)
+}
+
+func maxProbeSize(interfaceMTU int) int {
+	if interfaceMTU > MaxPacketSize {
+		return MaxPacketSize
+	}
+
+	return interfaceMTU
+}
+
+// MTUProber discovers the path MTU to the peers by sending UDP probes that
+// must not be fragmented (DF bit) to a closed port of the peer. The probes
+// that reach the peer are answered with a port unreachable error. It needs
+// no privileges.
+type MTUProber struct {
+	cfg MTUConfig
+}
+
+func NewMTUProber(cfg MTUConfig) *MTUProber {
+	return &MTUProber{cfg: cfg}
+}
+
+// Probe discovers the path MTU to the peer at the `ip:port` address with a
+// binary search between the minimum MTU of the IP version and the MTU of
+// the local interface. The next-hop MTU in the errors of the routers
+// narrows the search down.
+func (p *MTUProber) Probe(ctx context.Context, ip net.IP, port uint16) (
+	MTU, error,
+) {
+	iface, err := routeInterface(ip, port)
+	if err != nil {
+		return MTU{}, err
+	}
+	res := MTU{Interface: iface.Name, InterfaceMTU: iface.MTU}
+
+	lo, hi := minMTU(ip), maxProbeSize(iface.MTU)
+	// most paths carry the MTU of the interface
+	size := hi
+	for lo <= hi {
+		fits, nextHopMTU, err := p.probe(ctx, ip, port, size)
+		if err != nil {
+			return MTU{}, fmt.Errorf("probing %d bytes: %s", size, err)
+		}
+
+		if fits {
+			res.PathMTU = size
+			lo = size + 1
+			size = lo + (hi-lo)/2
+			continue
+		}
+
+		hi = size - 1
+		if nextHopMTU >= lo && nextHopMTU < hi {
+			// the router told the MTU of the next hop, which is probably
+			// the path MTU
+			hi = nextHopMTU
+			size = hi
+			continue
+		}
+		size = lo + (hi-lo)/2
+	}
+
+	if res.PathMTU == 0 {
+		return MTU{}, errors.New("the peer did not answer any probe")
+	}
+
+	return res, nil
+}
+
+func (p *MTUProber) probe(
+	ctx context.Context, ip net.IP, port uint16, size int,
+) (bool, int, error) {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return false, 0, err
+		}
+
+		timeout := p.cfg.ProbeTimeout
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+			timeout = time.Until(deadline)
+		}
+
+		fits, nextHopMTU, answered, err := probeSize(ip, port, size, timeout)
+		if err != nil || answered || attempt >= p.cfg.Retries {
+			return fits, nextHopMTU, err
+		}
+	}
+}
+
+func minMTU(ip net.IP) int {
+	if ip.To4() != nil {
+		return 68
+	}
+
+	return 1280
+}
+
+// ipHeaderSize is the size of the IP and UDP headers of the probes
+func ipHeaderSize(ip net.IP) int {
+	if ip.To4() != nil {
+		return 20 + 8
+	}
+
+	return 40 + 8
+}
+
+// routeInterface returns the local interface of the route to the peer. It
+// sends no packets.
+func routeInterface(ip net.IP, port uint16) (net.Interface, error) {
+	conn, err := net.Dial(
+		"udp", net.JoinHostPort(ip.String(), strconv.Itoa(int(port))),
+	)
+	if err != nil {
+		return net.Interface{}, fmt.Errorf("routing: %s", err)
+	}
+	localIP := conn.LocalAddr().(*net.UDPAddr).IP
+	conn.Close()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		// untested return
+		return net.Interface{}, fmt.Errorf("listing the interfaces: %s", err)
+	}
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			// untested return
+			continue
+		}
+
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(localIP) {
+				return iface, nil
+			}
+		}
+	}
+
+	return net.Interface{}, fmt.Errorf(
+		"no interface has the local address %s", localIP,
+	)
+}
@@ -0,0 +1,112 @@
+package traceroute
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+const (
+	originLocal = 1
+
+	icmpFragNeeded    = 4
+	icmp6PacketTooBig = 2
+)
+
+// probeSize sends a UDP probe of `size` bytes with the DF bit. It reports
+// if the probe reached the peer, the next-hop MTU when a router or the local
+// stack reports that it is too big, and if the probe was answered at all.
+func probeSize(
+	ip net.IP, port uint16, size int, timeout time.Duration,
+) (bool, int, bool, error) {
+	family, sa := sockaddr(ip, port)
+	fd, err := syscall.Socket(
+		family, syscall.SOCK_DGRAM|syscall.SOCK_NONBLOCK|syscall.SOCK_CLOEXEC, 0,
+	)
+	if err != nil {
+		return false, 0, false, fmt.Errorf("creating socket: %s", err)
+	}
+	file := os.NewFile(uintptr(fd), "probe")
+	defer file.Close()
+
+	if err := setDontFragment(fd, family); err != nil {
+		return false, 0, false, fmt.Errorf("setting the DF bit: %s", err)
+	}
+
+	rawConn, err := file.SyscallConn()
+	if err != nil {
+		// untested return
+		return false, 0, false, err
+	}
+	if err := file.SetDeadline(time.Now().Add(timeout)); err != nil {
+		// untested return
+		return false, 0, false, err
+	}
+
+	if err := syscall.Connect(fd, sa); err != nil {
+		return false, 0, false, fmt.Errorf("connecting: %s", err)
+	}
+	payload := make([]byte, size-ipHeaderSize(ip))
+	if _, err := syscall.Write(fd, payload); err != nil {
+		if err == syscall.EMSGSIZE {
+			return false, 0, true, nil
+		}
+
+		return false, 0, false, fmt.Errorf("sending: %s", err)
+	}
+
+	qe, err := waitErrQueue(rawConn)
+	if err != nil {
+		return false, 0, false, err
+	}
+	if qe == nil {
+		// the probe was lost, dropped for its size, or not answered
+		return false, 0, false, nil
+	}
+
+	switch {
+	case qe.Origin == originLocal && syscall.Errno(qe.Errno) == syscall.EMSGSIZE,
+		qe.Origin == originICMP && qe.Type == icmpDestUnreach &&
+			qe.Code == icmpFragNeeded,
+		qe.Origin == originICMP6 && qe.Type == icmp6PacketTooBig:
+		return false, int(qe.Info), true, nil
+	case qe.Origin == originICMP && qe.Type == icmpDestUnreach &&
+		qe.offender.Equal(ip),
+		qe.Origin == originICMP6 && qe.Type == icmp6DestUnreach &&
+			qe.offender.Equal(ip):
+		return true, 0, true, nil
+	default:
+		return false, 0, false, fmt.Errorf(
+			"probe failed: %s", syscall.Errno(qe.Errno),
+		)
+	}
+}
+
+// setDontFragment makes the socket send with the DF bit regardless of the
+// path MTU that the kernel knows, and report the ICMP errors.
+func setDontFragment(fd, family int) error {
+	if family == syscall.AF_INET {
+		if err := syscall.SetsockoptInt(
+			fd, syscall.SOL_IP, syscall.IP_RECVERR, 1,
+		); err != nil {
+			return err
+		}
+
+		return syscall.SetsockoptInt(
+			fd, syscall.SOL_IP, syscall.IP_MTU_DISCOVER, syscall.IP_PMTUDISC_PROBE,
+		)
+	}
+
+	if err := syscall.SetsockoptInt(
+		fd, syscall.SOL_IPV6, syscall.IPV6_RECVERR, 1,
+	); err != nil {
+		return err
+	}
+
+	return syscall.SetsockoptInt(
+		fd, syscall.SOL_IPV6, syscall.IPV6_MTU_DISCOVER,
+		syscall.IPV6_PMTUDISC_PROBE,
+	)
+}
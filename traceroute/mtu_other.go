@@ -0,0 +1,17 @@
+// +build !linux
+
+package traceroute
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+func probeSize(
+	ip net.IP, port uint16, size int, timeout time.Duration,
+) (bool, int, bool, error) {
+	return false, 0, false, errors.New(
+		"path MTU discovery is only supported on linux",
+	)
+}
@@ -0,0 +1,86 @@
+package traceroute_test
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/ice-stuff/clique/testhelpers"
+	"github.com/ice-stuff/clique/traceroute"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MTUProber", func() {
+	var (
+		port   uint16
+		prober *traceroute.MTUProber
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("Path MTU discovery is only supported on linux.")
+		}
+
+		port = testhelpers.SelectPort(GinkgoParallelNode())
+		prober = traceroute.NewMTUProber(traceroute.MTUConfig{
+			ProbeTimeout: time.Second,
+			Retries:      1,
+		})
+	})
+
+	It("should discover the MTU of the loopback path", func() {
+		mtu, err := prober.Probe(
+			context.Background(), net.ParseIP("127.0.0.1"), port,
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		iface, err := net.InterfaceByName(mtu.Interface)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(iface.Flags & net.FlagLoopback).NotTo(BeZero())
+		Expect(mtu.InterfaceMTU).To(Equal(iface.MTU))
+		Expect(mtu.PathMTU).To(BeNumerically("<=", traceroute.MaxPacketSize))
+		Expect(mtu.Mismatch()).To(BeFalse())
+	})
+
+	It("should discover the MTU of the loopback path over IPv6", func() {
+		mtu, err := prober.Probe(context.Background(), net.ParseIP("::1"), port)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(mtu.PathMTU).To(BeNumerically(">=", 1280))
+		Expect(mtu.Mismatch()).To(BeFalse())
+	})
+
+	Context("when the context is done", func() {
+		It("should stop probing", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := prober.Probe(ctx, net.ParseIP("127.0.0.1"), port)
+			Expect(err).To(MatchError(ContainSubstring("context canceled")))
+		})
+	})
+})
+
+var _ = Describe("MTU", func() {
+	Describe("Mismatch", func() {
+		It("should be false when the path carries the interface MTU", func() {
+			mtu := traceroute.MTU{PathMTU: 9000, InterfaceMTU: 9000}
+			Expect(mtu.Mismatch()).To(BeFalse())
+		})
+
+		It("should be true when the path MTU is smaller", func() {
+			mtu := traceroute.MTU{PathMTU: 1500, InterfaceMTU: 9000}
+			Expect(mtu.Mismatch()).To(BeTrue())
+		})
+
+		It("should cap the interface MTU to the largest packet", func() {
+			mtu := traceroute.MTU{
+				PathMTU:      traceroute.MaxPacketSize,
+				InterfaceMTU: 65536,
+			}
+			Expect(mtu.Mismatch()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,327 @@
+package traceroute
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// origins and types of the ICMP errors in `struct sock_extended_err`
+const (
+	originICMP  = 2
+	originICMP6 = 3
+
+	icmpDestUnreach  = 3
+	icmpTimeExceeded = 11
+
+	icmp6DestUnreach  = 1
+	icmp6TimeExceeded = 3
+)
+
+// extendedErr is the `struct sock_extended_err` of linux
+type extendedErr struct {
+	Errno  uint32
+	Origin uint8
+	Type   uint8
+	Code   uint8
+	Pad    uint8
+	Info   uint32
+	Data   uint32
+}
+
+// probe sends a probe with the TTL and waits for its answer. The ICMP
+// errors of the hops are read from the error queue of the socket
+// (IP_RECVERR), which needs no raw sockets.
+func probe(
+	protocol string, ip net.IP, port uint16, ttl int, timeout time.Duration,
+) (probeResult, error) {
+	sotype := syscall.SOCK_DGRAM
+	if protocol == ProtocolTCP {
+		sotype = syscall.SOCK_STREAM
+	}
+
+	family, sa := sockaddr(ip, port)
+	fd, err := syscall.Socket(
+		family, sotype|syscall.SOCK_NONBLOCK|syscall.SOCK_CLOEXEC, 0,
+	)
+	if err != nil {
+		return probeResult{}, fmt.Errorf("creating socket: %s", err)
+	}
+	// the file makes the socket use the poller of the runtime, and so the
+	// deadlines
+	file := os.NewFile(uintptr(fd), "probe")
+	defer file.Close()
+
+	if err := setTTL(fd, family, ttl); err != nil {
+		return probeResult{}, fmt.Errorf("setting the ttl: %s", err)
+	}
+	if protocol == ProtocolTCP {
+		// the peer gets a reset instead of a connection without a handshake
+		if err := syscall.SetsockoptLinger(
+			fd, syscall.SOL_SOCKET, syscall.SO_LINGER,
+			&syscall.Linger{Onoff: 1, Linger: 0},
+		); err != nil {
+			// untested return
+			return probeResult{}, fmt.Errorf("setting the linger: %s", err)
+		}
+	}
+
+	rawConn, err := file.SyscallConn()
+	if err != nil {
+		// untested return
+		return probeResult{}, err
+	}
+	if err := file.SetDeadline(time.Now().Add(timeout)); err != nil {
+		// untested return
+		return probeResult{}, err
+	}
+
+	err = syscall.Connect(fd, sa)
+	if protocol == ProtocolTCP {
+		if err == nil || err == syscall.ECONNREFUSED {
+			return probeResult{ip: ip, final: true}, nil
+		}
+		if err != syscall.EINPROGRESS {
+			return probeResult{}, fmt.Errorf("connecting: %s", err)
+		}
+
+		return waitTCP(rawConn, ip)
+	}
+
+	if err != nil {
+		return probeResult{}, fmt.Errorf("connecting: %s", err)
+	}
+	if _, err := syscall.Write(fd, []byte("clique")); err != nil {
+		return probeResult{}, fmt.Errorf("sending: %s", err)
+	}
+
+	return waitUDP(rawConn)
+}
+
+func sockaddr(ip net.IP, port uint16) (int, syscall.Sockaddr) {
+	if ip4 := ip.To4(); ip4 != nil {
+		sa := &syscall.SockaddrInet4{Port: int(port)}
+		copy(sa.Addr[:], ip4)
+		return syscall.AF_INET, sa
+	}
+
+	sa := &syscall.SockaddrInet6{Port: int(port)}
+	copy(sa.Addr[:], ip.To16())
+	return syscall.AF_INET6, sa
+}
+
+func setTTL(fd, family, ttl int) error {
+	if family == syscall.AF_INET {
+		if err := syscall.SetsockoptInt(
+			fd, syscall.SOL_IP, syscall.IP_RECVERR, 1,
+		); err != nil {
+			return err
+		}
+
+		return syscall.SetsockoptInt(fd, syscall.SOL_IP, syscall.IP_TTL, ttl)
+	}
+
+	if err := syscall.SetsockoptInt(
+		fd, syscall.SOL_IPV6, syscall.IPV6_RECVERR, 1,
+	); err != nil {
+		return err
+	}
+
+	return syscall.SetsockoptInt(
+		fd, syscall.SOL_IPV6, syscall.IPV6_UNICAST_HOPS, ttl,
+	)
+}
+
+// queuedErr is an error from the error queue of a socket
+type queuedErr struct {
+	extendedErr
+	// address of the hop that sent the ICMP error; nil for the local errors
+	offender net.IP
+}
+
+// hopAnswer interprets the ICMP error of a probe; the peer answers that the
+// port is unreachable.
+func hopAnswer(qe *queuedErr) (probeResult, error) {
+	switch {
+	case qe.Origin == originICMP && qe.Type == icmpTimeExceeded,
+		qe.Origin == originICMP6 && qe.Type == icmp6TimeExceeded:
+		return probeResult{ip: qe.offender}, nil
+	case qe.Origin == originICMP && qe.Type == icmpDestUnreach,
+		qe.Origin == originICMP6 && qe.Type == icmp6DestUnreach:
+		return probeResult{ip: qe.offender, final: true}, nil
+	default:
+		return probeResult{}, fmt.Errorf(
+			"probe failed: %s", syscall.Errno(qe.Errno),
+		)
+	}
+}
+
+// waitUDP waits for the ICMP error of a hop.
+func waitUDP(rawConn syscall.RawConn) (probeResult, error) {
+	qe, err := waitErrQueue(rawConn)
+	if err != nil || qe == nil {
+		return probeResult{}, err
+	}
+
+	return hopAnswer(qe)
+}
+
+// waitErrQueue waits for an error in the error queue of the socket. It
+// returns nil when the deadline of the socket passes first.
+func waitErrQueue(rawConn syscall.RawConn) (*queuedErr, error) {
+	var (
+		qe      *queuedErr
+		readErr error
+	)
+	err := rawConn.Read(func(fd uintptr) bool {
+		qe, readErr = readErrQueue(int(fd))
+		return qe != nil || readErr != nil
+	})
+
+	return qe, waitResult(readErr, err)
+}
+
+// waitTCP waits for the connection to the peer, or for the ICMP error of a
+// hop. A refused connection is also an answer of the peer.
+func waitTCP(rawConn syscall.RawConn, ip net.IP) (probeResult, error) {
+	var (
+		res     probeResult
+		readErr error
+	)
+	err := rawConn.Write(func(fd uintptr) bool {
+		var qe *queuedErr
+		qe, readErr = readErrQueue(int(fd))
+		if readErr != nil {
+			return true
+		}
+		if qe != nil {
+			res, readErr = hopAnswer(qe)
+			return true
+		}
+
+		if _, err := syscall.Getpeername(int(fd)); err == nil {
+			res = probeResult{ip: ip, final: true}
+			return true
+		}
+
+		soErr, err := syscall.GetsockoptInt(
+			int(fd), syscall.SOL_SOCKET, syscall.SO_ERROR,
+		)
+		if err != nil {
+			// untested return
+			readErr = err
+			return true
+		}
+		switch syscall.Errno(soErr) {
+		case 0:
+			return false
+		case syscall.ECONNREFUSED:
+			res = probeResult{ip: ip, final: true}
+		default:
+			readErr = fmt.Errorf("connecting: %s", syscall.Errno(soErr))
+		}
+		return true
+	})
+
+	if err := waitResult(readErr, err); err != nil {
+		return probeResult{}, err
+	}
+
+	return res, nil
+}
+
+// waitResult turns the expired deadline of a socket into a nil error; the
+// hop or the peer did not answer.
+func waitResult(readErr, waitErr error) error {
+	if readErr != nil {
+		return readErr
+	}
+
+	if waitErr != nil {
+		if netErr, ok := waitErr.(interface {
+			Timeout() bool
+		}); ok && netErr.Timeout() {
+			return nil
+		}
+
+		// untested return
+		return waitErr
+	}
+
+	return nil
+}
+
+// readErrQueue reads an error from the error queue of the socket. It
+// returns nil when the queue is empty.
+func readErrQueue(fd int) (*queuedErr, error) {
+	buf := make([]byte, 512)
+	oob := make([]byte, 512)
+	_, oobn, _, _, err := syscall.Recvmsg(fd, buf, oob, syscall.MSG_ERRQUEUE)
+	if err == syscall.EAGAIN {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading the error queue: %s", err)
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		// untested return
+		return nil, fmt.Errorf("parsing the error queue: %s", err)
+	}
+
+	for _, msg := range msgs {
+		isRecvErr := (msg.Header.Level == syscall.SOL_IP &&
+			msg.Header.Type == syscall.IP_RECVERR) ||
+			(msg.Header.Level == syscall.SOL_IPV6 &&
+				msg.Header.Type == syscall.IPV6_RECVERR)
+		if isRecvErr {
+			return parseExtendedErr(msg.Data)
+		}
+	}
+
+	// untested return
+	return nil, nil
+}
+
+// parseExtendedErr parses a `struct sock_extended_err` that is followed by
+// the address of the hop that sent the error.
+func parseExtendedErr(data []byte) (*queuedErr, error) {
+	size := int(unsafe.Sizeof(extendedErr{}))
+	if len(data) < size {
+		// untested return
+		return nil, fmt.Errorf(
+			"extended error of %d bytes is too short", len(data),
+		)
+	}
+
+	return &queuedErr{
+		extendedErr: *(*extendedErr)(unsafe.Pointer(&data[0])),
+		offender:    offender(data[size:]),
+	}, nil
+}
+
+// offender parses the `struct sockaddr_in` or `struct sockaddr_in6` of the
+// hop.
+func offender(sa []byte) net.IP {
+	if len(sa) < 2 {
+		// untested return
+		return nil
+	}
+
+	switch *(*uint16)(unsafe.Pointer(&sa[0])) {
+	case syscall.AF_INET:
+		if len(sa) >= 8 {
+			return net.IP(append([]byte(nil), sa[4:8]...))
+		}
+	case syscall.AF_INET6:
+		if len(sa) >= 24 {
+			return net.IP(append([]byte(nil), sa[8:24]...))
+		}
+	}
+
+	return nil
+}
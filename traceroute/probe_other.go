@@ -0,0 +1,15 @@
+// +build !linux
+
+package traceroute
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+func probe(
+	protocol string, ip net.IP, port uint16, ttl int, timeout time.Duration,
+) (probeResult, error) {
+	return probeResult{}, errors.New("path discovery is only supported on linux")
+}
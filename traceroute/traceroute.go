@@ -0,0 +1,112 @@
+package traceroute
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+)
+
+type Config struct {
+	// `udp` probes a closed UDP port of the peer, `tcp` opens connections
+	// to a listening port of the peer
+	Protocol string
+	// The trace stops at the peer or after this amount of hops
+	MaxHops int
+	// Time to wait for the answer of every hop
+	ProbeTimeout time.Duration
+}
+
+type Hop struct {
+	// Time To Live of the probe that the hop answered
+	TTL int
+	// Nil when the hop did not answer in time
+	IP  net.IP
+	RTT time.Duration
+}
+
+type Path struct {
+	Hops []Hop
+	// The last hop is the peer
+	Reached bool
+}
+
+// Equal reports if the paths go through the same hops. The hops that did
+// not answer match any hop, so that a lost probe does not count as a change
+// of the path.
+func (p Path) Equal(other Path) bool {
+	if p.Reached != other.Reached || len(p.Hops) != len(other.Hops) {
+		return false
+	}
+
+	for i, hop := range p.Hops {
+		otherIP := other.Hops[i].IP
+		if hop.IP == nil || otherIP == nil {
+			continue
+		}
+		if !hop.IP.Equal(otherIP) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type probeResult struct {
+	// nil when the hop did not answer
+	ip net.IP
+	// the hop answered that the destination is unreachable, or it is the
+	// destination itself
+	final bool
+}
+
+// Tracer discovers the path to the peers by increasing the TTL of the
+// probes until the peer answers, one probe per hop. It needs no privileges.
+type Tracer struct {
+	cfg Config
+}
+
+func New(cfg Config) *Tracer {
+	return &Tracer{cfg: cfg}
+}
+
+// Trace discovers the path to the peer at the `ip:port` address.
+func (t *Tracer) Trace(ctx context.Context, ip net.IP, port uint16) (
+	Path, error,
+) {
+	var path Path
+	for ttl := 1; ttl <= t.cfg.MaxHops; ttl++ {
+		if err := ctx.Err(); err != nil {
+			return Path{}, err
+		}
+
+		timeout := t.cfg.ProbeTimeout
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+			timeout = time.Until(deadline)
+		}
+
+		start := time.Now()
+		res, err := probe(t.cfg.Protocol, ip, port, ttl, timeout)
+		if err != nil {
+			return Path{}, fmt.Errorf("probing hop %d: %s", ttl, err)
+		}
+
+		hop := Hop{TTL: ttl, IP: res.ip}
+		if res.ip != nil {
+			hop.RTT = time.Since(start)
+		}
+		path.Hops = append(path.Hops, hop)
+
+		if res.final {
+			path.Reached = res.ip.Equal(ip)
+			break
+		}
+	}
+
+	return path, nil
+}
@@ -0,0 +1,13 @@
+package traceroute_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTraceroute(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Traceroute Suite")
+}
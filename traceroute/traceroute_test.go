@@ -0,0 +1,193 @@
+package traceroute_test
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/ice-stuff/clique/testhelpers"
+	"github.com/ice-stuff/clique/traceroute"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracer", func() {
+	var (
+		port   uint16
+		tracer *traceroute.Tracer
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS != "linux" {
+			Skip("Path discovery is only supported on linux.")
+		}
+
+		port = testhelpers.SelectPort(GinkgoParallelNode())
+	})
+
+	Context("when the protocol is UDP", func() {
+		BeforeEach(func() {
+			tracer = traceroute.New(traceroute.Config{
+				Protocol:     traceroute.ProtocolUDP,
+				MaxHops:      5,
+				ProbeTimeout: time.Second,
+			})
+		})
+
+		It("should reach the peer", func() {
+			path, err := tracer.Trace(
+				context.Background(), net.ParseIP("127.0.0.1"), port,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path.Reached).To(BeTrue())
+			Expect(path.Hops).To(HaveLen(1))
+			Expect(path.Hops[0].TTL).To(Equal(1))
+			Expect(path.Hops[0].IP.Equal(net.ParseIP("127.0.0.1"))).To(BeTrue())
+			Expect(path.Hops[0].RTT).NotTo(BeZero())
+		})
+
+		It("should reach the peer over IPv6", func() {
+			path, err := tracer.Trace(context.Background(), net.ParseIP("::1"), port)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path.Reached).To(BeTrue())
+			Expect(path.Hops).To(HaveLen(1))
+			Expect(path.Hops[0].IP.Equal(net.ParseIP("::1"))).To(BeTrue())
+		})
+	})
+
+	Context("when the protocol is TCP", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen(
+				"tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port))),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			tracer = traceroute.New(traceroute.Config{
+				Protocol:     traceroute.ProtocolTCP,
+				MaxHops:      5,
+				ProbeTimeout: time.Second,
+			})
+		})
+
+		AfterEach(func() {
+			Expect(listener.Close()).To(Succeed())
+		})
+
+		It("should reach the listening peer", func() {
+			path, err := tracer.Trace(
+				context.Background(), net.ParseIP("127.0.0.1"), port,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path.Reached).To(BeTrue())
+			Expect(path.Hops).To(HaveLen(1))
+			Expect(path.Hops[0].IP.Equal(net.ParseIP("127.0.0.1"))).To(BeTrue())
+		})
+
+		It("should reach a peer that refuses the connection", func() {
+			closedPort := testhelpers.SelectPort(GinkgoParallelNode())
+
+			path, err := tracer.Trace(
+				context.Background(), net.ParseIP("127.0.0.1"), closedPort,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path.Reached).To(BeTrue())
+			Expect(path.Hops).To(HaveLen(1))
+		})
+	})
+
+	Context("when the context is done", func() {
+		It("should stop the trace", func() {
+			tracer = traceroute.New(traceroute.Config{
+				Protocol:     traceroute.ProtocolUDP,
+				MaxHops:      5,
+				ProbeTimeout: time.Second,
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := tracer.Trace(ctx, net.ParseIP("127.0.0.1"), port)
+			Expect(err).To(Equal(context.Canceled))
+		})
+	})
+})
+
+var _ = Describe("Path", func() {
+	var path traceroute.Path
+
+	BeforeEach(func() {
+		path = traceroute.Path{
+			Hops: []traceroute.Hop{
+				{TTL: 1, IP: net.ParseIP("10.0.0.1"), RTT: time.Millisecond},
+				{TTL: 2, IP: net.ParseIP("10.0.1.1"), RTT: time.Millisecond * 2},
+				{TTL: 3, IP: net.ParseIP("10.0.2.1"), RTT: time.Millisecond * 3},
+			},
+			Reached: true,
+		}
+	})
+
+	Describe("Equal", func() {
+		It("should ignore the RTTs of the hops", func() {
+			other := traceroute.Path{
+				Hops: []traceroute.Hop{
+					{TTL: 1, IP: net.ParseIP("10.0.0.1"), RTT: time.Second},
+					{TTL: 2, IP: net.ParseIP("10.0.1.1"), RTT: time.Second},
+					{TTL: 3, IP: net.ParseIP("10.0.2.1"), RTT: time.Second},
+				},
+				Reached: true,
+			}
+
+			Expect(path.Equal(other)).To(BeTrue())
+		})
+
+		It("should match the hops that did not answer with any hop", func() {
+			other := traceroute.Path{
+				Hops: []traceroute.Hop{
+					{TTL: 1, IP: net.ParseIP("10.0.0.1")},
+					{TTL: 2},
+					{TTL: 3, IP: net.ParseIP("10.0.2.1")},
+				},
+				Reached: true,
+			}
+
+			Expect(path.Equal(other)).To(BeTrue())
+		})
+
+		It("should detect a different hop", func() {
+			other := traceroute.Path{
+				Hops: []traceroute.Hop{
+					{TTL: 1, IP: net.ParseIP("10.0.0.1")},
+					{TTL: 2, IP: net.ParseIP("10.0.9.1")},
+					{TTL: 3, IP: net.ParseIP("10.0.2.1")},
+				},
+				Reached: true,
+			}
+
+			Expect(path.Equal(other)).To(BeFalse())
+		})
+
+		It("should detect a different amount of hops", func() {
+			other := traceroute.Path{
+				Hops:    path.Hops[1:],
+				Reached: true,
+			}
+
+			Expect(path.Equal(other)).To(BeFalse())
+		})
+
+		It("should detect a path that no longer reaches the peer", func() {
+			other := path
+			other.Reached = false
+
+			Expect(path.Equal(other)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Backend is a protocol that transfers the data between the agents, e.g.
+// `simple` or `iperf`. An agent registers several backends and every
+// transfer selects one of them.
+type Backend struct {
+	Name     string
+	Sender   TransferSender
+	Receiver TransferReceiver
+	// The data goes through the transfer connection, so that its TCP_INFO
+	// describes the transfer.
+	DataOnConn bool
+}
+
+// tcpInfoInterval returns the interval of the TCP_INFO samples of the
+// transfers of the backend, whose connections are not sampled when the data
+// goes through other connections.
+func (b Backend) tcpInfoInterval(interval time.Duration) time.Duration {
+	if !b.DataOnConn {
+		return 0
+	}
+
+	return interval
+}
+
+// Backends are the backends of an agent in the order of preference. The
+// first one is the default.
+type Backends []Backend
+
+// Names returns the names of the backends, which the agent advertises in its
+// identity.
+func (b Backends) Names() []string {
+	names := make([]string, len(b))
+	for i, backend := range b {
+		names[i] = backend.Name
+	}
+
+	return names
+}
+
+// Get returns the backend with the name.
+func (b Backends) Get(name string) (Backend, bool) {
+	for _, backend := range b {
+		if backend.Name == name {
+			return backend, true
+		}
+	}
+
+	return Backend{}, false
+}
+
+// Select returns the backend of a transfer to a peer that supports the
+// `peer` backends: the requested one when both ends support it and the
+// preferred backend that both ends support otherwise. It returns false when
+// the requested backend is not the selected one.
+func (b Backends) Select(requested string, peer []string) (Backend, bool, error) {
+	supported := make(map[string]struct{}, len(peer))
+	for _, name := range peer {
+		supported[name] = struct{}{}
+	}
+
+	if backend, ok := b.Get(requested); ok {
+		if _, ok := supported[requested]; ok {
+			return backend, true, nil
+		}
+	}
+
+	for _, backend := range b {
+		if _, ok := supported[backend.Name]; ok {
+			return backend, requested == "", nil
+		}
+	}
+
+	return Backend{}, false, errors.New("peer supports none of the backends")
+}
+
+// The backend frame is the magic, the length of the name of the backend as a
+// big-endian uint16 and the name. The client sends it after the identities
+// when both ends advertise their backends.
+const backendMagic = "CLQB"
+
+func WriteBackend(w io.Writer, name string) error {
+	if len(name) > 0xffff {
+		return fmt.Errorf("backend name of %d bytes is too long", len(name))
+	}
+
+	frame := make([]byte, len(backendMagic)+2+len(name))
+	copy(frame, backendMagic)
+	binary.BigEndian.PutUint16(frame[len(backendMagic):], uint16(len(name)))
+	copy(frame[len(backendMagic)+2:], name)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+func ReadBackend(r io.Reader) (string, error) {
+	header := make([]byte, len(backendMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+
+	if string(header[:len(backendMagic)]) != backendMagic {
+		return "", errors.New("peer did not select a backend")
+	}
+
+	name := make([]byte, binary.BigEndian.Uint16(header[len(backendMagic):]))
+	if _, err := io.ReadFull(r, name); err != nil {
+		return "", err
+	}
+
+	return string(name), nil
+}
@@ -0,0 +1,79 @@
+package transfer_test
+
+import (
+	"bytes"
+
+	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backends", func() {
+	var backends transfer.Backends
+
+	BeforeEach(func() {
+		backends = transfer.Backends{
+			{Name: "simple", Sender: new(fakes.FakeTransferSender)},
+			{Name: "iperf", Sender: new(fakes.FakeTransferSender)},
+			{Name: "latency", Sender: new(fakes.FakeTransferSender)},
+		}
+	})
+
+	It("should be named in the order of preference", func() {
+		Expect(backends.Names()).To(Equal([]string{"simple", "iperf", "latency"}))
+	})
+
+	Describe("Select", func() {
+		It("should select the requested backend", func() {
+			backend, ok, err := backends.Select(
+				"latency", []string{"latency", "simple"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(backend.Name).To(Equal("latency"))
+		})
+
+		It("should select the preferred backend when none is requested", func() {
+			backend, ok, err := backends.Select("", []string{"latency", "iperf"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(backend.Name).To(Equal("iperf"))
+		})
+
+		It("should fall back when the peer does not support the request", func() {
+			backend, ok, err := backends.Select("latency", []string{"iperf"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(backend.Name).To(Equal("iperf"))
+		})
+
+		It("should fall back when the agent does not support the request", func() {
+			backend, ok, err := backends.Select("udp", []string{"udp", "simple"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(backend.Name).To(Equal("simple"))
+		})
+
+		It("should fail when the peer supports none of the backends", func() {
+			_, _, err := backends.Select("", []string{"udp"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Backend frame", func() {
+	It("should be read as it was written", func() {
+		buffer := new(bytes.Buffer)
+		Expect(transfer.WriteBackend(buffer, "iperf")).To(Succeed())
+		buffer.WriteString("ok")
+
+		Expect(transfer.ReadBackend(buffer)).To(Equal("iperf"))
+		Expect(buffer.String()).To(Equal("ok"))
+	})
+
+	It("should not be read from other messages", func() {
+		_, err := transfer.ReadBackend(bytes.NewBufferString("ok - 5201"))
+		Expect(err).To(MatchError("peer did not select a backend"))
+	})
+})
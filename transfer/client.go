@@ -1,55 +1,164 @@
 package transfer
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 )
 
 //go:generate counterfeiter . TransferSender
 type TransferSender interface {
-	SendTransfer(spec TransferSpec, conn io.ReadWriter) (TransferResults, error)
+	SendTransfer(
+		ctx context.Context, spec TransferSpec, conn io.ReadWriter,
+	) (TransferResults, error)
 }
 
 //go:generate counterfeiter . Connector
 type Connector interface {
-	Connect(ip net.IP, port uint16) (net.Conn, error)
+	Connect(ctx context.Context, ip net.IP, port uint16, src Source) (
+		net.Conn, error,
+	)
 }
 
+// ErrClientClosed is returned by the transfers that are aborted, or not
+// started at all, because the client is closed.
+var ErrClientClosed = errors.New("transfer client is closed")
+
 type Client struct {
-	logger         *logrus.Logger
-	connector      Connector
-	transferSender TransferSender
+	logger    *logrus.Logger
+	identity  Identity
+	connector Connector
+	backends  Backends
+	timeouts  Timeouts
+	// TCP_INFO is not sampled when zero
+	tcpInfoInterval time.Duration
+
+	conns  map[net.Conn]struct{}
+	closed bool
+	lock   sync.Mutex
 }
 
+// NewClient creates a client that introduces itself to the servers with the
+// identity and the names of its backends, of which there is at least one. It
+// samples the TCP_INFO of its connections every `tcpInfoInterval` and at the
+// end of every transfer of the backends that send the data through them.
+// Nothing is sampled when the interval is zero.
 func NewClient(
-	logger *logrus.Logger, connector Connector,
-	transferSender TransferSender,
+	logger *logrus.Logger, identity Identity, connector Connector,
+	backends Backends, timeouts Timeouts,
+	tcpInfoInterval time.Duration,
 ) *Client {
+	identity.Backends = backends.Names()
+
 	return &Client{
-		logger:         logger,
-		connector:      connector,
-		transferSender: transferSender,
+		logger:          logger,
+		identity:        identity,
+		connector:       connector,
+		backends:        backends,
+		timeouts:        timeouts,
+		tcpInfoInterval: tcpInfoInterval,
+
+		conns: make(map[net.Conn]struct{}),
 	}
 }
 
-func (c *Client) Transfer(spec TransferSpec) (TransferResults, error) {
-	conn, err := c.connector.Connect(spec.IP, spec.Port)
+func (c *Client) Transfer(ctx context.Context, spec TransferSpec) (
+	TransferResults, error,
+) {
+	logger := c.logger.WithFields(LogFields(spec.ID, spec.Peer()))
+
+	ctx, cancel := c.timeouts.WithTotal(ctx)
+	defer cancel()
+
+	connectCtx, cancelConnect := c.timeouts.WithConnect(ctx)
+	conn, err := c.connector.Connect(
+		connectCtx, spec.IP, spec.Port, spec.Source,
+	)
+	cancelConnect()
 	if err != nil {
-		c.logger.Errorf("Failed to connect to server: '%s'", err)
+		logger.Errorf("Failed to connect to server: '%s'", err)
 		return TransferResults{}, err
 	}
 	defer conn.Close()
 
-	c.logger.Infof("Starting transfer to %s", conn.RemoteAddr().String())
-	res, err := c.transferSender.SendTransfer(spec, conn)
+	if !c.track(conn) {
+		logger.Info("Outgoing transfer is aborted: client is closed")
+		return TransferResults{}, ErrClientClosed
+	}
+	defer c.untrack(conn)
+
+	ctxConn, legacy, err := c.awaitGreeting(
+		conn, NewCtxConn(ctx, conn, c.timeouts.Idle),
+	)
+	var peer Identity
+	if err == nil && !legacy {
+		peer, err = c.exchangeIdentities(ctxConn, spec.ID)
+	}
 	if err != nil {
-		c.logger.Errorf("Failed to send transfer: '%s'", err)
+		if c.isClosed() {
+			logger.Warnf("Outgoing transfer is aborted: '%s'", err)
+			return TransferResults{}, ErrClientClosed
+		}
+
+		logger.Errorf("Failed to exchange identities: '%s'", err)
+		return TransferResults{}, err
+	}
+	if legacy {
+		logger.Warn("Peer predates the identities, transferring without them")
+	}
+	logger = logger.WithField("node_id", peer.NodeID)
+
+	if err := checkSchedule(c.identity, peer); err != nil {
+		logger.WithFields(logrus.Fields{
+			"schedule":      c.identity.Schedule,
+			"peer_schedule": peer.Schedule,
+		}).Errorf("Refusing the transfer: '%s'", err)
 		return TransferResults{}, err
 	}
+	if c.identity.Schedule != "" && peer.Schedule == "" {
+		logger.Warn("Peer does not follow the measurement schedule")
+	}
 
-	c.logger.WithFields(logrus.Fields{
+	backend, requested, err := c.selectBackend(ctxConn, spec.Backend, peer)
+	if err != nil {
+		logger.Errorf("Failed to select the backend: '%s'", err)
+		return TransferResults{}, err
+	}
+	if !requested {
+		logger.WithField("backend", backend.Name).Warnf(
+			"Peer does not support the `%s` backend, falling back", spec.Backend,
+		)
+	}
+
+	logger.Infof("Starting transfer to %s", conn.RemoteAddr().String())
+	tcpInfoSampler := startTCPInfoSampler(
+		conn, backend.tcpInfoInterval(c.tcpInfoInterval),
+	)
+	res, err := backend.Sender.SendTransfer(ctx, spec, ctxConn)
+	res.TCPInfoSamples, res.TCPInfo = tcpInfoSampler.Stop()
+	res.Source, res.Destination = c.identity, peer
+	res.Backend = backend.Name
+	if err != nil {
+		if c.isClosed() {
+			logger.Warnf("Outgoing transfer is aborted: '%s'", err)
+			return TransferResults{BytesSent: res.BytesSent}, ErrClientClosed
+		}
+
+		logger.Errorf("Failed to send transfer: '%s'", err)
+		return TransferResults{BytesSent: res.BytesSent}, err
+	}
+
+	// the backends that do not measure the RTT get it from the kernel
+	if res.RTT == 0 && res.TCPInfo != nil {
+		res.RTT = res.TCPInfo.RTT
+	}
+
+	logger.WithFields(logrus.Fields{
 		"duration":   res.Duration,
 		"checksum":   res.Checksum,
 		"bytes_sent": res.BytesSent,
@@ -57,3 +166,100 @@ func (c *Client) Transfer(spec TransferSpec) (TransferResults, error) {
 	}).Info("Outgoing transfer is completed")
 	return res, nil
 }
+
+// awaitGreeting listens for a tenth of the identity timeout before the client
+// introduces itself. The servers of the legacy agents, which predate the
+// identities, greet the clients as soon as they connect, and the client goes
+// on with their backend handshake without the identities; it returns true for
+// them along with a connection that replays the greeting. The servers that
+// greet later fail the transfer with ErrLegacyPeer.
+func (c *Client) awaitGreeting(rawConn, conn net.Conn) (net.Conn, bool, error) {
+	if c.timeouts.Identity <= 0 {
+		return conn, false, nil
+	}
+
+	greeting, err := readWithin(rawConn, c.timeouts.Identity/10)
+	if isTimeout(err) {
+		return conn, false, nil
+	}
+	if err != nil {
+		return conn, false, err
+	}
+
+	return &replayConn{Conn: conn, pending: greeting}, true, nil
+}
+
+// exchangeIdentities introduces the client to the server for the transfer,
+// and the server answers with its own identity.
+func (c *Client) exchangeIdentities(conn io.ReadWriter, transferID string) (
+	Identity, error,
+) {
+	id := c.identity
+	id.TransferID = transferID
+	if err := WriteIdentity(conn, id); err != nil {
+		return Identity{}, err
+	}
+
+	return ReadIdentity(conn)
+}
+
+// selectBackend selects the backend of the transfer and tells it to the
+// server. The servers that do not advertise their backends get the default
+// one. It returns false when it falls back from the requested backend.
+func (c *Client) selectBackend(
+	conn io.Writer, requested string, peer Identity,
+) (Backend, bool, error) {
+	if len(peer.Backends) == 0 {
+		backend := c.backends[0]
+		return backend, requested == "" || requested == backend.Name, nil
+	}
+
+	backend, ok, err := c.backends.Select(requested, peer.Backends)
+	if err != nil {
+		return Backend{}, false, err
+	}
+
+	if err := WriteBackend(conn, backend.Name); err != nil {
+		return Backend{}, false, err
+	}
+
+	return backend, ok, nil
+}
+
+// Close aborts the outgoing transfers in progress by closing their
+// connections. Subsequent transfers fail with ErrClientClosed.
+func (c *Client) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closed = true
+	for conn := range c.conns {
+		conn.Close()
+	}
+}
+
+func (c *Client) track(conn net.Conn) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	c.conns[conn] = struct{}{}
+	return true
+}
+
+func (c *Client) untrack(conn net.Conn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.conns, conn)
+}
+
+func (c *Client) isClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.closed
+}
@@ -1,7 +1,10 @@
 package transfer_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"io/ioutil"
 	"net"
 	"time"
 
@@ -17,7 +20,10 @@ var _ = Describe("Client", func() {
 		logger             *logrus.Logger
 		fakeConnector      *fakes.FakeConnector
 		conn               net.Conn
+		clientIdentity     transfer.Identity
+		serverIdentity     transfer.Identity
 		fakeTransferSender *fakes.FakeTransferSender
+		backends           transfer.Backends
 		client             *transfer.Client
 	)
 
@@ -28,48 +34,143 @@ var _ = Describe("Client", func() {
 			Formatter: new(logrus.TextFormatter),
 		}
 
+		clientIdentity = transfer.Identity{NodeID: "boo", Port: 5000}
+		serverIdentity = transfer.Identity{
+			NodeID: "foo",
+			Labels: map[string]string{"dc": "ams"},
+			Port:   5001,
+		}
+
 		fakeConnector = new(fakes.FakeConnector)
-		conn, _ = net.Pipe()
+		conn, _ = connectToFakeServer(serverIdentity)
 		fakeConnector.ConnectReturns(conn, nil)
 
 		fakeTransferSender = new(fakes.FakeTransferSender)
+		backends = transfer.Backends{
+			{Name: "fake", Sender: fakeTransferSender, DataOnConn: true},
+		}
 
-		client = transfer.NewClient(logger, fakeConnector, fakeTransferSender)
+		client = transfer.NewClient(
+			logger, clientIdentity, fakeConnector,
+			backends,
+			transfer.Timeouts{}, 0,
+		)
 	})
 
 	It("should create a connection to the server", func() {
 		spec := transfer.TransferSpec{
-			IP:   net.ParseIP("127.0.0.1"),
-			Port: 1200,
+			IP:     net.ParseIP("127.0.0.1"),
+			Port:   1200,
+			Source: transfer.Source{Interface: "eth1"},
 		}
-		_, err := client.Transfer(spec)
+		_, err := client.Transfer(context.Background(), spec)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeConnector.ConnectCallCount()).To(Equal(1))
-		receivedIP, receivedPort := fakeConnector.ConnectArgsForCall(0)
+		_, receivedIP, receivedPort, receivedSrc := fakeConnector.ConnectArgsForCall(0)
 		Expect(receivedIP).To(Equal(spec.IP))
 		Expect(receivedPort).To(Equal(spec.Port))
+		Expect(receivedSrc).To(Equal(spec.Source))
 	})
 
 	It("should use the transfer sender to send a transfer", func() {
 		spec := transfer.TransferSpec{
 			Size: 10 * 1024,
 		}
-		_, err := client.Transfer(spec)
+		_, err := client.Transfer(context.Background(), spec)
 		Expect(err).NotTo(HaveOccurred())
 
 		Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(1))
-		receivedSpec, _ := fakeTransferSender.SendTransferArgsForCall(0)
+		_, receivedSpec, _ := fakeTransferSender.SendTransferArgsForCall(0)
 		Expect(receivedSpec).To(Equal(spec))
 	})
 
 	It("should use the connection provided by the connector", func() {
-		_, err := client.Transfer(transfer.TransferSpec{})
+		var serverConns chan net.Conn
+		conn, serverConns = connectToFakeServer(serverIdentity)
+		fakeConnector.ConnectReturns(conn, nil)
+		fakeTransferSender.SendTransferStub = func(
+			_ context.Context, _ transfer.TransferSpec, conn io.ReadWriter,
+		) (transfer.TransferResults, error) {
+			_, err := conn.Write([]byte("banana"))
+			return transfer.TransferResults{}, err
+		}
+
+		received := make(chan string, 1)
+		go func() {
+			serverConn := <-serverConns
+			buffer := make([]byte, 16)
+			n, _ := serverConn.Read(buffer)
+			received <- string(buffer[:n])
+		}()
+
+		_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
 		Expect(err).NotTo(HaveOccurred())
+		Eventually(received).Should(Receive(Equal("banana")))
+	})
 
-		Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(1))
-		_, receivedConn := fakeTransferSender.SendTransferArgsForCall(0)
-		Expect(receivedConn).To(Equal(conn))
+	Context("when the idle timeout expires", func() {
+		BeforeEach(func() {
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector,
+				backends,
+				transfer.Timeouts{Idle: time.Millisecond * 50}, 0,
+			)
+			fakeTransferSender.SendTransferStub = func(
+				_ context.Context, _ transfer.TransferSpec, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				_, err := conn.Read(make([]byte, 1))
+				return transfer.TransferResults{}, err
+			}
+		})
+
+		It("should fail the transfer", func() {
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).To(MatchError(ContainSubstring("timeout")))
+		})
+	})
+
+	Context("when the total timeout expires", func() {
+		BeforeEach(func() {
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector,
+				backends,
+				transfer.Timeouts{Total: time.Millisecond * 50}, 0,
+			)
+			fakeTransferSender.SendTransferStub = func(
+				_ context.Context, _ transfer.TransferSpec, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				_, err := conn.Read(make([]byte, 1))
+				return transfer.TransferResults{}, err
+			}
+		})
+
+		It("should fail the transfer with the context error", func() {
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).To(Equal(context.DeadlineExceeded))
+		})
+	})
+
+	Context("when the connect timeout expires", func() {
+		BeforeEach(func() {
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector,
+				backends,
+				transfer.Timeouts{Connect: time.Millisecond * 50}, 0,
+			)
+			fakeConnector.ConnectStub = func(
+				ctx context.Context, _ net.IP, _ uint16, _ transfer.Source,
+			) (net.Conn, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+		})
+
+		It("should fail the transfer", func() {
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(fakeTransferSender.SendTransferCallCount()).To(BeZero())
+		})
 	})
 
 	It("should return the transfer results", func() {
@@ -80,12 +181,299 @@ var _ = Describe("Client", func() {
 		}
 		fakeTransferSender.SendTransferReturns(fakeTransferResults, nil)
 
-		receivedTransferResults, err := client.Transfer(transfer.TransferSpec{})
+		receivedTransferResults, err := client.Transfer(context.Background(), transfer.TransferSpec{})
 		Expect(err).NotTo(HaveOccurred())
 
+		fakeTransferResults.Source = clientIdentity
+		fakeTransferResults.Source.Backends = []string{"fake"}
+		fakeTransferResults.Destination = serverIdentity
+		fakeTransferResults.Backend = "fake"
 		Expect(receivedTransferResults).To(Equal(fakeTransferResults))
 	})
 
+	It("should introduce itself with the id of the transfer", func() {
+		introduced := make(chan transfer.Identity, 1)
+		var serverConn net.Conn
+		conn, serverConn = net.Pipe()
+		fakeConnector.ConnectReturns(conn, nil)
+		go func() {
+			id, _ := transfer.ReadIdentity(serverConn)
+			introduced <- id
+			serverConn.Close()
+		}()
+
+		client.Transfer(context.Background(), transfer.TransferSpec{ID: "7a1d"})
+
+		var id transfer.Identity
+		Eventually(introduced).Should(Receive(&id))
+		Expect(id.NodeID).To(Equal(clientIdentity.NodeID))
+		Expect(id.TransferID).To(Equal("7a1d"))
+	})
+
+	Context("when the fallbacks to the legacy agents are enabled", func() {
+		BeforeEach(func() {
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector,
+				backends,
+				transfer.Timeouts{Identity: time.Millisecond * 500}, 0,
+			)
+		})
+
+		It("should exchange the identities with the servers that have them", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.Destination).To(Equal(serverIdentity))
+		})
+	})
+
+	Context("when the server predates the identities", func() {
+		var received chan []byte
+
+		BeforeEach(func() {
+			var serverConn net.Conn
+			conn, serverConn = net.Pipe()
+			fakeConnector.ConnectReturns(conn, nil)
+
+			// the legacy servers greet first and then read the data
+			received = make(chan []byte, 1)
+			go serverConn.Write([]byte("ok"))
+			go func(received chan []byte) {
+				data, _ := ioutil.ReadAll(serverConn)
+				received <- data
+			}(received)
+
+			fakeTransferSender.SendTransferStub = func(
+				_ context.Context, _ transfer.TransferSpec, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				greeting := make([]byte, 16)
+				n, err := conn.Read(greeting)
+				if err != nil {
+					return transfer.TransferResults{}, err
+				}
+				if string(greeting[:n]) != "ok" {
+					return transfer.TransferResults{}, errors.New("no greeting")
+				}
+
+				_, err = conn.Write([]byte("data"))
+				return transfer.TransferResults{BytesSent: 4}, err
+			}
+
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector,
+				backends,
+				transfer.Timeouts{Identity: time.Millisecond * 500}, 0,
+			)
+		})
+
+		It("should fall back to the legacy handshake", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.BytesSent).To(Equal(uint64(4)))
+
+			Eventually(received).Should(Receive(Equal([]byte("data"))))
+		})
+
+		It("should not know the identity of the server", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.Source.NodeID).To(Equal("boo"))
+			Expect(res.Destination).To(Equal(transfer.Identity{}))
+		})
+
+		Context("and the fallbacks are disabled", func() {
+			BeforeEach(func() {
+				client = transfer.NewClient(
+					logger, clientIdentity, fakeConnector,
+					backends,
+					transfer.Timeouts{}, 0,
+				)
+			})
+
+			It("should fail the transfer with a clear error", func() {
+				_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+				Expect(err).To(Equal(transfer.ErrLegacyPeer))
+				Expect(fakeTransferSender.SendTransferCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Context("when the server does not send its identity", func() {
+		BeforeEach(func() {
+			var serverConn net.Conn
+			conn, serverConn = net.Pipe()
+			fakeConnector.ConnectReturns(conn, nil)
+
+			go func() {
+				transfer.ReadIdentity(serverConn)
+				serverConn.Write([]byte("ok"))
+				serverConn.Close()
+			}()
+		})
+
+		It("should fail the transfer before it starts", func() {
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).To(HaveOccurred())
+			Expect(fakeTransferSender.SendTransferCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the server advertises its backends", func() {
+		var (
+			otherTransferSender *fakes.FakeTransferSender
+			selectedBackends    chan string
+		)
+
+		BeforeEach(func() {
+			otherTransferSender = new(fakes.FakeTransferSender)
+			backends = append(backends, transfer.Backend{
+				Name: "other", Sender: otherTransferSender,
+			})
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector, backends,
+				transfer.Timeouts{}, 0,
+			)
+
+			serverIdentity.Backends = []string{"other", "fake"}
+			conn, selectedBackends = connectToFakeServerWithBackends(
+				serverIdentity,
+			)
+			fakeConnector.ConnectReturns(conn, nil)
+		})
+
+		It("should advertise its own backends", func() {
+			advertised := make(chan transfer.Identity, 1)
+			var serverConn net.Conn
+			conn, serverConn = net.Pipe()
+			fakeConnector.ConnectReturns(conn, nil)
+			go func() {
+				id, _ := transfer.ReadIdentity(serverConn)
+				advertised <- id
+				serverConn.Close()
+			}()
+
+			client.Transfer(context.Background(), transfer.TransferSpec{})
+
+			var id transfer.Identity
+			Eventually(advertised).Should(Receive(&id))
+			Expect(id.Backends).To(Equal([]string{"fake", "other"}))
+		})
+
+		It("should use the requested backend", func() {
+			_, err := client.Transfer(
+				context.Background(), transfer.TransferSpec{Backend: "other"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(selectedBackends).Should(Receive(Equal("other")))
+			Expect(otherTransferSender.SendTransferCallCount()).To(Equal(1))
+			Expect(fakeTransferSender.SendTransferCallCount()).To(BeZero())
+		})
+
+		It("should report the selected backend in the results", func() {
+			otherTransferSender.SendTransferReturns(
+				transfer.TransferResults{Backend: "sender"}, nil,
+			)
+
+			res, err := client.Transfer(
+				context.Background(), transfer.TransferSpec{Backend: "other"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Backend).To(Equal("other"))
+		})
+
+		It("should use the default backend when none is requested", func() {
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(selectedBackends).Should(Receive(Equal("fake")))
+			Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(1))
+		})
+
+		Context("and it does not support the requested backend", func() {
+			BeforeEach(func() {
+				serverIdentity.Backends = []string{"other"}
+				conn, selectedBackends = connectToFakeServerWithBackends(
+					serverIdentity,
+				)
+				fakeConnector.ConnectReturns(conn, nil)
+			})
+
+			It("should fall back to a backend that both ends support", func() {
+				_, err := client.Transfer(
+					context.Background(), transfer.TransferSpec{Backend: "fake"},
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(selectedBackends).Should(Receive(Equal("other")))
+				Expect(otherTransferSender.SendTransferCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("and it supports none of the backends", func() {
+			BeforeEach(func() {
+				serverIdentity.Backends = []string{"udp"}
+				conn, _ = connectToFakeServerWithBackends(serverIdentity)
+				fakeConnector.ConnectReturns(conn, nil)
+			})
+
+			It("should fail the transfer before it starts", func() {
+				_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+				Expect(err).To(HaveOccurred())
+				Expect(fakeTransferSender.SendTransferCallCount()).To(BeZero())
+				Expect(otherTransferSender.SendTransferCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Context("when the agents follow measurement schedules", func() {
+		BeforeEach(func() {
+			clientIdentity.Schedule = "0a1b"
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector, backends,
+				transfer.Timeouts{}, 0,
+			)
+		})
+
+		It("should transfer to the servers of the same schedule", func() {
+			serverIdentity.Schedule = "0a1b"
+			conn, _ = connectToFakeServer(serverIdentity)
+			fakeConnector.ConnectReturns(conn, nil)
+
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(1))
+		})
+
+		It("should refuse to transfer to the servers of another schedule", func() {
+			serverIdentity.Schedule = "2c3d"
+			conn, _ = connectToFakeServer(serverIdentity)
+			fakeConnector.ConnectReturns(conn, nil)
+
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).To(Equal(transfer.ErrScheduleMismatch))
+			Expect(fakeTransferSender.SendTransferCallCount()).To(BeZero())
+		})
+
+		It("should transfer to the servers that are not coordinated", func() {
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the server does not advertise its backends", func() {
+		It("should use the default backend", func() {
+			_, err := client.Transfer(
+				context.Background(), transfer.TransferSpec{Backend: "other"},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(1))
+		})
+	})
+
 	Context("when it failes to enstablish a connection", func() {
 		var connectErr error
 
@@ -95,7 +483,7 @@ var _ = Describe("Client", func() {
 		})
 
 		It("should return the error", func() {
-			_, err := client.Transfer(transfer.TransferSpec{})
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
 			Expect(err).To(Equal(connectErr))
 		})
 	})
@@ -106,13 +494,207 @@ var _ = Describe("Client", func() {
 		BeforeEach(func() {
 			senderErr = errors.New("failed to conduct the transfer")
 			fakeTransferSender.SendTransferReturns(
-				transfer.TransferResults{}, senderErr,
+				transfer.TransferResults{BytesSent: 100, Duration: time.Second},
+				senderErr,
 			)
 		})
 
 		It("should return the error", func() {
-			_, err := client.Transfer(transfer.TransferSpec{})
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
 			Expect(err).To(Equal(senderErr))
 		})
+
+		It("should only return the bytes that were sent", func() {
+			res, _ := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(res).To(Equal(transfer.TransferResults{BytesSent: 100}))
+		})
+	})
+
+	Context("when TCP_INFO is sampled", func() {
+		var (
+			listener net.Listener
+			peerConn chan net.Conn
+		)
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			peerConn = make(chan net.Conn, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err == nil {
+					peerConn <- conn
+					transfer.ReadIdentity(conn)
+					transfer.WriteIdentity(conn, serverIdentity)
+				}
+			}()
+
+			tcpConn, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			fakeConnector.ConnectReturns(tcpConn, nil)
+
+			client = transfer.NewClient(
+				logger, clientIdentity, fakeConnector,
+				backends,
+				transfer.Timeouts{}, 10*time.Millisecond,
+			)
+			fakeTransferSender.SendTransferStub = func(
+				_ context.Context, _ transfer.TransferSpec, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				_, err := conn.Write(make([]byte, 64*1024))
+				time.Sleep(50 * time.Millisecond)
+				return transfer.TransferResults{BytesSent: 64 * 1024}, err
+			}
+		})
+
+		AfterEach(func() {
+			Expect(listener.Close()).To(Succeed())
+			select {
+			case conn := <-peerConn:
+				conn.Close()
+			default:
+			}
+		})
+
+		It("should attach the statistics of the connection", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.TCPInfo).NotTo(BeNil())
+			Expect(res.TCPInfo.CongestionWindow).NotTo(BeZero())
+			Expect(len(res.TCPInfoSamples)).To(BeNumerically(">=", 2))
+			for i := 1; i < len(res.TCPInfoSamples); i++ {
+				Expect(res.TCPInfoSamples[i].Offset).To(
+					BeNumerically(">", res.TCPInfoSamples[i-1].Offset),
+				)
+			}
+		})
+
+		It("should keep the last sample out of the periodic ones", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.TCPInfoSamples).NotTo(BeEmpty())
+			last := res.TCPInfoSamples[len(res.TCPInfoSamples)-1]
+			Expect(last.Offset).To(BeNumerically("<", res.TCPInfo.Offset))
+		})
+
+		It("should report the RTT of the kernel", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.RTT).NotTo(BeZero())
+			Expect(res.RTT).To(Equal(res.TCPInfo.RTT))
+		})
+
+		Context("and the backend sends the data through other connections", func() {
+			BeforeEach(func() {
+				backends[0].DataOnConn = false
+				client = transfer.NewClient(
+					logger, clientIdentity, fakeConnector, backends,
+					transfer.Timeouts{}, 10*time.Millisecond,
+				)
+			})
+
+			It("should not sample the connection", func() {
+				res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(res.TCPInfo).To(BeNil())
+				Expect(res.TCPInfoSamples).To(BeEmpty())
+			})
+		})
+
+		Context("and the sender measures the RTT", func() {
+			BeforeEach(func() {
+				fakeTransferSender.SendTransferReturns(transfer.TransferResults{
+					RTT: 42 * time.Millisecond,
+				}, nil)
+				fakeTransferSender.SendTransferStub = nil
+			})
+
+			It("should keep the RTT of the sender", func() {
+				res, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(res.RTT).To(Equal(42 * time.Millisecond))
+			})
+		})
+	})
+
+	Describe("Close", func() {
+		It("should abort the transfer in progress", func() {
+			fakeTransferSender.SendTransferStub = func(
+				_ context.Context, _ transfer.TransferSpec, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				_, err := conn.Read(make([]byte, 1))
+				return transfer.TransferResults{}, err
+			}
+
+			errChan := make(chan error)
+			go func() {
+				_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+				errChan <- err
+			}()
+			Eventually(fakeTransferSender.SendTransferCallCount).Should(Equal(1))
+
+			client.Close()
+			Eventually(errChan).Should(Receive(Equal(transfer.ErrClientClosed)))
+		})
+
+		It("should not start new transfers", func() {
+			client.Close()
+
+			_, err := client.Transfer(context.Background(), transfer.TransferSpec{})
+			Expect(err).To(Equal(transfer.ErrClientClosed))
+			Expect(fakeTransferSender.SendTransferCallCount()).To(Equal(0))
+		})
 	})
 })
+
+// connectToFakeServer returns a connection to a server that answers the
+// identity of the client and then hands its end of the connection over.
+func connectToFakeServer(id transfer.Identity) (net.Conn, chan net.Conn) {
+	clientConn, serverConn := net.Pipe()
+	serverConns := make(chan net.Conn, 1)
+	go func() {
+		if _, err := transfer.ReadIdentity(serverConn); err != nil {
+			return
+		}
+		if err := transfer.WriteIdentity(serverConn, id); err != nil {
+			return
+		}
+		serverConns <- serverConn
+	}()
+
+	return clientConn, serverConns
+}
+
+// connectToFakeServerWithBackends returns a connection to a server that
+// answers the identity of the client and sends the backend that the client
+// selects to the channel.
+func connectToFakeServerWithBackends(id transfer.Identity) (
+	net.Conn, chan string,
+) {
+	clientConn, serverConn := net.Pipe()
+	selected := make(chan string, 1)
+	go func() {
+		defer serverConn.Close()
+
+		if _, err := transfer.ReadIdentity(serverConn); err != nil {
+			return
+		}
+		if err := transfer.WriteIdentity(serverConn, id); err != nil {
+			return
+		}
+		name, err := transfer.ReadBackend(serverConn)
+		if err != nil {
+			return
+		}
+		selected <- name
+	}()
+
+	return clientConn, selected
+}
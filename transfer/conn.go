@@ -0,0 +1,226 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type Timeouts struct {
+	// Maximum time to establish the connection. Zero means no timeout.
+	Connect time.Duration
+	// Maximum time that a read or a write may block. Zero means no timeout.
+	Idle time.Duration
+	// Maximum duration of the whole transfer. Zero means no timeout.
+	Total time.Duration
+	// Time that the server waits for the identity of a client. The clients
+	// that stay silent for longer are served as agents that predate the
+	// identities, which wait for the server to speak first. The clients wait
+	// for a tenth of it for the greeting of such a server before they
+	// introduce themselves. Zero disables both fallbacks.
+	Identity time.Duration
+}
+
+// WithTotal returns a context that expires after the total timeout.
+func (t Timeouts) WithTotal(ctx context.Context) (
+	context.Context, context.CancelFunc,
+) {
+	if t.Total <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, t.Total)
+}
+
+// WithConnect returns a context that expires after the connect timeout.
+func (t Timeouts) WithConnect(ctx context.Context) (
+	context.Context, context.CancelFunc,
+) {
+	if t.Connect <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, t.Connect)
+}
+
+// readWithin reads the first bytes that the peer sends within the timeout.
+// The deadline of the timeout is cleared before it returns, so that only the
+// idle timeout of the transfer applies to the reads that follow.
+func readWithin(conn net.Conn, timeout time.Duration) (
+	data []byte, err error,
+) {
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		// untested return
+		return nil, err
+	}
+	defer func() {
+		if clearErr := conn.SetReadDeadline(time.Time{}); err == nil {
+			err = clearErr
+		}
+	}()
+
+	buffer := make([]byte, 64)
+	n, err := conn.Read(buffer)
+	if n == 0 && err == nil {
+		// untested return
+		err = io.ErrNoProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer[:n], nil
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+// replayConn returns the bytes that were read ahead of the transfer to its
+// first reads.
+type replayConn struct {
+	net.Conn
+	pending []byte
+}
+
+func (c *replayConn) Read(b []byte) (int, error) {
+	if len(c.pending) > 0 {
+		n := copy(b, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+
+	return c.Conn.Read(b)
+}
+
+// SyscallConn exposes the raw connection of the wrapped one, whose reads do
+// not see the replayed bytes.
+func (c *replayConn) SyscallConn() (syscall.RawConn, error) {
+	sysConn, ok := c.Conn.(syscall.Conn)
+	if !ok {
+		return nil, errors.New("not a system connection")
+	}
+
+	return sysConn.SyscallConn()
+}
+
+type ctxConn struct {
+	net.Conn
+
+	ctx  context.Context
+	idle time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCtxConn wraps a connection so that it is closed when the context is
+// done and every read or write fails after blocking for longer than idle.
+// Errors caused by the context are reported as the context error.
+func NewCtxConn(ctx context.Context, conn net.Conn, idle time.Duration) net.Conn {
+	c := &ctxConn{
+		Conn: conn,
+
+		ctx:  ctx,
+		idle: idle,
+
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-c.done:
+		}
+	}()
+
+	return c
+}
+
+func (c *ctxConn) Read(b []byte) (int, error) {
+	if c.idle > 0 {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.idle)); err != nil {
+			return 0, c.wrapErr(err)
+		}
+	}
+
+	n, err := c.Conn.Read(b)
+	return n, c.wrapErr(err)
+}
+
+func (c *ctxConn) Write(b []byte) (int, error) {
+	if c.idle > 0 {
+		if err := c.Conn.SetWriteDeadline(time.Now().Add(c.idle)); err != nil {
+			return 0, c.wrapErr(err)
+		}
+	}
+
+	n, err := c.Conn.Write(b)
+	return n, c.wrapErr(err)
+}
+
+// SyscallConn exposes the raw connection for sendfile(2) and splice(2). The
+// idle timeout applies to every read and write of the raw connection.
+func (c *ctxConn) SyscallConn() (syscall.RawConn, error) {
+	sysConn, ok := c.Conn.(syscall.Conn)
+	if !ok {
+		return nil, errors.New("not a system connection")
+	}
+
+	rawConn, err := sysConn.SyscallConn()
+	if err != nil {
+		// untested return
+		return nil, err
+	}
+
+	return &ctxRawConn{RawConn: rawConn, conn: c}, nil
+}
+
+func (c *ctxConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return c.Conn.Close()
+}
+
+func (c *ctxConn) wrapErr(err error) error {
+	if err != nil && c.ctx.Err() != nil {
+		return c.ctx.Err()
+	}
+
+	return err
+}
+
+type ctxRawConn struct {
+	syscall.RawConn
+	conn *ctxConn
+}
+
+func (c *ctxRawConn) Read(f func(fd uintptr) bool) error {
+	if c.conn.idle > 0 {
+		err := c.conn.Conn.SetReadDeadline(time.Now().Add(c.conn.idle))
+		if err != nil {
+			return c.conn.wrapErr(err)
+		}
+	}
+
+	return c.conn.wrapErr(c.RawConn.Read(f))
+}
+
+func (c *ctxRawConn) Write(f func(fd uintptr) bool) error {
+	if c.conn.idle > 0 {
+		err := c.conn.Conn.SetWriteDeadline(time.Now().Add(c.conn.idle))
+		if err != nil {
+			return c.conn.wrapErr(err)
+		}
+	}
+
+	return c.conn.wrapErr(c.RawConn.Write(f))
+}
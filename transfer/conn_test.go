@@ -0,0 +1,66 @@
+package transfer_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/ice-stuff/clique/transfer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CtxConn", func() {
+	var (
+		conn, otherEnd net.Conn
+		ctx            context.Context
+		cancel         context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		conn, otherEnd = net.Pipe()
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+		otherEnd.Close()
+	})
+
+	It("should pass the data through", func() {
+		ctxConn := transfer.NewCtxConn(ctx, conn, time.Second)
+		defer ctxConn.Close()
+
+		go otherEnd.Write([]byte("banana"))
+
+		buffer := make([]byte, 16)
+		n, err := ctxConn.Read(buffer)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(buffer[:n])).To(Equal("banana"))
+	})
+
+	It("should fail a read that blocks for longer than the idle timeout", func() {
+		ctxConn := transfer.NewCtxConn(ctx, conn, time.Millisecond*50)
+		defer ctxConn.Close()
+
+		_, err := ctxConn.Read(make([]byte, 16))
+		netErr, ok := err.(net.Error)
+		Expect(ok).To(BeTrue())
+		Expect(netErr.Timeout()).To(BeTrue())
+	})
+
+	It("should unblock with the context error when the context is done", func() {
+		ctxConn := transfer.NewCtxConn(ctx, conn, 0)
+		defer ctxConn.Close()
+
+		errChan := make(chan error)
+		go func() {
+			_, err := ctxConn.Read(make([]byte, 16))
+			errChan <- err
+		}()
+		Consistently(errChan).ShouldNot(Receive())
+
+		cancel()
+		Eventually(errChan).Should(Receive(Equal(context.Canceled)))
+	})
+})
@@ -1,23 +1,46 @@
 package transfer
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"syscall"
 )
 
 type connector struct {
+	opts SocketOptions
 }
 
-func NewConnector() Connector {
-	return &connector{}
+func NewConnector(opts SocketOptions) Connector {
+	return &connector{opts: opts}
 }
 
-func (c *connector) Connect(ip net.IP, port uint16) (net.Conn, error) {
-	address := fmt.Sprintf("%s:%d", ip.String(), port)
-	conn, err := net.Dial("tcp", address)
+func (c *connector) Connect(
+	ctx context.Context, ip net.IP, port uint16, src Source,
+) (net.Conn, error) {
+	address := net.JoinHostPort(ip.String(), fmt.Sprintf("%d", port))
+	dialer := &net.Dialer{
+		Control: func(network, address string, raw syscall.RawConn) error {
+			if err := c.opts.Control(network, address, raw); err != nil {
+				return err
+			}
+
+			return src.Control(network, address, raw)
+		},
+	}
+	if src.IP != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: src.IP}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := c.opts.Tune(conn); err != nil {
+		// untested return
+		conn.Close()
+		return nil, fmt.Errorf("tuning the connection: %s", err)
+	}
+
 	return conn, nil
 }
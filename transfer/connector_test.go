@@ -1,8 +1,10 @@
 package transfer_test
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"runtime"
 
 	"github.com/ice-stuff/clique/testhelpers"
 	"github.com/ice-stuff/clique/transfer"
@@ -17,13 +19,16 @@ var _ = Describe("Connector", func() {
 	)
 
 	BeforeEach(func() {
-		connector = transfer.NewConnector()
+		connector = transfer.NewConnector(transfer.SocketOptions{})
 		randomPort = testhelpers.SelectPort(GinkgoParallelNode())
 	})
 
 	Context("when no server is listening", func() {
 		It("should return an error when the listener is not running", func() {
-			_, err := connector.Connect(net.ParseIP("127.0.0.1"), randomPort)
+			_, err := connector.Connect(
+				context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+				transfer.Source{},
+			)
 			Expect(err).To(MatchError(ContainSubstring("connection refused")))
 		})
 	})
@@ -43,12 +48,147 @@ var _ = Describe("Connector", func() {
 
 		It("enstablishes a connection", func() {
 			go listener.Accept()
-			conn, err := connector.Connect(net.ParseIP("127.0.0.1"), randomPort)
+			conn, err := connector.Connect(
+				context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+				transfer.Source{},
+			)
 			Expect(err).NotTo(HaveOccurred())
 
 			_, err = conn.Write([]byte("hello world"))
 			Expect(err).NotTo(HaveOccurred())
 			Expect(conn.Close()).To(Succeed())
 		})
+
+		It("binds to the source address", func() {
+			go listener.Accept()
+			conn, err := connector.Connect(
+				context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+				transfer.Source{IP: net.ParseIP("127.0.0.2")},
+			)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			localAddr := conn.LocalAddr().(*net.TCPAddr)
+			Expect(localAddr.IP.Equal(net.ParseIP("127.0.0.2"))).To(BeTrue())
+		})
+
+		Context("when the source is an interface", func() {
+			BeforeEach(func() {
+				if runtime.GOOS != "linux" {
+					Skip("Binding to an interface is only supported on linux.")
+				}
+			})
+
+			It("connects through the interface", func() {
+				go listener.Accept()
+				conn, err := connector.Connect(
+					context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+					transfer.Source{Interface: loopbackInterface()},
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conn.Close()).To(Succeed())
+			})
+
+			It("fails when the interface does not exist", func() {
+				_, err := connector.Connect(
+					context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+					transfer.Source{Interface: "banana0"},
+				)
+				Expect(err).To(MatchError(ContainSubstring("interface `banana0`")))
+			})
+		})
+	})
+})
+
+var _ = Describe("Source", func() {
+	Describe("LocalIP", func() {
+		It("should return the source IP", func() {
+			ip, err := transfer.Source{IP: net.ParseIP("10.0.0.1")}.LocalIP(
+				net.ParseIP("10.0.0.2"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip).To(Equal(net.ParseIP("10.0.0.1")))
+		})
+
+		It("should let the system pick the address by default", func() {
+			ip, err := transfer.Source{}.LocalIP(net.ParseIP("10.0.0.2"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip).To(BeNil())
+		})
+
+		It("should return the address of the interface in the remote family", func() {
+			ip, err := transfer.Source{Interface: loopbackInterface()}.LocalIP(
+				net.ParseIP("127.0.0.1"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.IsLoopback()).To(BeTrue())
+			Expect(ip.To4()).NotTo(BeNil())
+		})
+
+		It("should skip the link-local addresses of the interface", func() {
+			iface, ok := linkLocalInterface()
+			if !ok {
+				Skip("No interface has both link-local and global IPv6 addresses.")
+			}
+
+			ip, err := transfer.Source{Interface: iface}.LocalIP(
+				net.ParseIP("2001:db8::1"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.IsLinkLocalUnicast()).To(BeFalse())
+			Expect(ip.To4()).To(BeNil())
+		})
+
+		It("should fail when the interface does not exist", func() {
+			_, err := transfer.Source{Interface: "banana0"}.LocalIP(
+				net.ParseIP("127.0.0.1"),
+			)
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
+
+func loopbackInterface() string {
+	ifaces, err := net.Interfaces()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface.Name
+		}
+	}
+
+	Fail("no loopback interface")
+	return ""
+}
+
+// linkLocalInterface returns an interface with both link-local and global
+// IPv6 addresses.
+func linkLocalInterface() (string, bool) {
+	ifaces, err := net.Interfaces()
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		Expect(err).NotTo(HaveOccurred())
+
+		var linkLocal, global bool
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() != nil {
+				continue
+			}
+
+			if ipNet.IP.IsLinkLocalUnicast() {
+				linkLocal = true
+			} else if ipNet.IP.IsGlobalUnicast() {
+				global = true
+			}
+		}
+		if linkLocal && global {
+			return iface.Name, true
+		}
+	}
+
+	return "", false
+}
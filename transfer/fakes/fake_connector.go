@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"net"
 	"sync"
 
@@ -9,11 +10,13 @@ import (
 )
 
 type FakeConnector struct {
-	ConnectStub        func(ip net.IP, port uint16) (net.Conn, error)
+	ConnectStub        func(ctx context.Context, ip net.IP, port uint16, src transfer.Source) (net.Conn, error)
 	connectMutex       sync.RWMutex
 	connectArgsForCall []struct {
+		ctx  context.Context
 		ip   net.IP
 		port uint16
+		src  transfer.Source
 	}
 	connectReturns struct {
 		result1 net.Conn
@@ -21,15 +24,17 @@ type FakeConnector struct {
 	}
 }
 
-func (fake *FakeConnector) Connect(ip net.IP, port uint16) (net.Conn, error) {
+func (fake *FakeConnector) Connect(ctx context.Context, ip net.IP, port uint16, src transfer.Source) (net.Conn, error) {
 	fake.connectMutex.Lock()
 	fake.connectArgsForCall = append(fake.connectArgsForCall, struct {
+		ctx  context.Context
 		ip   net.IP
 		port uint16
-	}{ip, port})
+		src  transfer.Source
+	}{ctx, ip, port, src})
 	fake.connectMutex.Unlock()
 	if fake.ConnectStub != nil {
-		return fake.ConnectStub(ip, port)
+		return fake.ConnectStub(ctx, ip, port, src)
 	} else {
 		return fake.connectReturns.result1, fake.connectReturns.result2
 	}
@@ -41,10 +46,10 @@ func (fake *FakeConnector) ConnectCallCount() int {
 	return len(fake.connectArgsForCall)
 }
 
-func (fake *FakeConnector) ConnectArgsForCall(i int) (net.IP, uint16) {
+func (fake *FakeConnector) ConnectArgsForCall(i int) (context.Context, net.IP, uint16, transfer.Source) {
 	fake.connectMutex.RLock()
 	defer fake.connectMutex.RUnlock()
-	return fake.connectArgsForCall[i].ip, fake.connectArgsForCall[i].port
+	return fake.connectArgsForCall[i].ctx, fake.connectArgsForCall[i].ip, fake.connectArgsForCall[i].port, fake.connectArgsForCall[i].src
 }
 
 func (fake *FakeConnector) ConnectReturns(result1 net.Conn, result2 error) {
@@ -2,17 +2,21 @@
 package fakes
 
 import (
+	"context"
 	"io"
 	"sync"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/transfer"
 )
 
 type FakeTransferReceiver struct {
-	ReceiveTransferStub        func(conn io.ReadWriter) (transfer.TransferResults, error)
+	ReceiveTransferStub        func(ctx context.Context, logger *logrus.Entry, conn io.ReadWriter) (transfer.TransferResults, error)
 	receiveTransferMutex       sync.RWMutex
 	receiveTransferArgsForCall []struct {
-		conn io.ReadWriter
+		ctx    context.Context
+		logger *logrus.Entry
+		conn   io.ReadWriter
 	}
 	receiveTransferReturns struct {
 		result1 transfer.TransferResults
@@ -20,14 +24,16 @@ type FakeTransferReceiver struct {
 	}
 }
 
-func (fake *FakeTransferReceiver) ReceiveTransfer(conn io.ReadWriter) (transfer.TransferResults, error) {
+func (fake *FakeTransferReceiver) ReceiveTransfer(ctx context.Context, logger *logrus.Entry, conn io.ReadWriter) (transfer.TransferResults, error) {
 	fake.receiveTransferMutex.Lock()
 	fake.receiveTransferArgsForCall = append(fake.receiveTransferArgsForCall, struct {
-		conn io.ReadWriter
-	}{conn})
+		ctx    context.Context
+		logger *logrus.Entry
+		conn   io.ReadWriter
+	}{ctx, logger, conn})
 	fake.receiveTransferMutex.Unlock()
 	if fake.ReceiveTransferStub != nil {
-		return fake.ReceiveTransferStub(conn)
+		return fake.ReceiveTransferStub(ctx, logger, conn)
 	} else {
 		return fake.receiveTransferReturns.result1, fake.receiveTransferReturns.result2
 	}
@@ -39,10 +45,10 @@ func (fake *FakeTransferReceiver) ReceiveTransferCallCount() int {
 	return len(fake.receiveTransferArgsForCall)
 }
 
-func (fake *FakeTransferReceiver) ReceiveTransferArgsForCall(i int) io.ReadWriter {
+func (fake *FakeTransferReceiver) ReceiveTransferArgsForCall(i int) (context.Context, *logrus.Entry, io.ReadWriter) {
 	fake.receiveTransferMutex.RLock()
 	defer fake.receiveTransferMutex.RUnlock()
-	return fake.receiveTransferArgsForCall[i].conn
+	return fake.receiveTransferArgsForCall[i].ctx, fake.receiveTransferArgsForCall[i].logger, fake.receiveTransferArgsForCall[i].conn
 }
 
 func (fake *FakeTransferReceiver) ReceiveTransferReturns(result1 transfer.TransferResults, result2 error) {
@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"io"
 	"sync"
 
@@ -9,9 +10,10 @@ import (
 )
 
 type FakeTransferSender struct {
-	SendTransferStub        func(spec transfer.TransferSpec, conn io.ReadWriter) (transfer.TransferResults, error)
+	SendTransferStub        func(ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter) (transfer.TransferResults, error)
 	sendTransferMutex       sync.RWMutex
 	sendTransferArgsForCall []struct {
+		ctx  context.Context
 		spec transfer.TransferSpec
 		conn io.ReadWriter
 	}
@@ -21,15 +23,16 @@ type FakeTransferSender struct {
 	}
 }
 
-func (fake *FakeTransferSender) SendTransfer(spec transfer.TransferSpec, conn io.ReadWriter) (transfer.TransferResults, error) {
+func (fake *FakeTransferSender) SendTransfer(ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter) (transfer.TransferResults, error) {
 	fake.sendTransferMutex.Lock()
 	fake.sendTransferArgsForCall = append(fake.sendTransferArgsForCall, struct {
+		ctx  context.Context
 		spec transfer.TransferSpec
 		conn io.ReadWriter
-	}{spec, conn})
+	}{ctx, spec, conn})
 	fake.sendTransferMutex.Unlock()
 	if fake.SendTransferStub != nil {
-		return fake.SendTransferStub(spec, conn)
+		return fake.SendTransferStub(ctx, spec, conn)
 	} else {
 		return fake.sendTransferReturns.result1, fake.sendTransferReturns.result2
 	}
@@ -41,10 +44,10 @@ func (fake *FakeTransferSender) SendTransferCallCount() int {
 	return len(fake.sendTransferArgsForCall)
 }
 
-func (fake *FakeTransferSender) SendTransferArgsForCall(i int) (transfer.TransferSpec, io.ReadWriter) {
+func (fake *FakeTransferSender) SendTransferArgsForCall(i int) (context.Context, transfer.TransferSpec, io.ReadWriter) {
 	fake.sendTransferMutex.RLock()
 	defer fake.sendTransferMutex.RUnlock()
-	return fake.sendTransferArgsForCall[i].spec, fake.sendTransferArgsForCall[i].conn
+	return fake.sendTransferArgsForCall[i].ctx, fake.sendTransferArgsForCall[i].spec, fake.sendTransferArgsForCall[i].conn
 }
 
 func (fake *FakeTransferSender) SendTransferReturns(result1 transfer.TransferResults, result2 error) {
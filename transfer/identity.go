@@ -0,0 +1,139 @@
+package transfer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Identity identifies an agent to its peers. The agents exchange their
+// identities at the start of every transfer, before the handshake of the
+// backend, so that the results name both ends even behind NATs.
+type Identity struct {
+	NodeID string            `json:"node_id"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Transfer port of the agent
+	Port uint16 `json:"port"`
+	// Backends that the agent supports in the order of its preference. The
+	// agents that do not advertise them have a single backend, which is
+	// used implicitly.
+	Backends []string `json:"backends,omitempty"`
+	// Hash of the measurement schedule that the agent follows, so that the
+	// agents that disagree on it refuse to transfer. It is empty for the
+	// agents that are not coordinated.
+	Schedule string `json:"schedule,omitempty"`
+	// Transfer that the client introduces itself for, so that both agents log
+	// it with the same id. It is empty in the results and in the identities
+	// of the servers.
+	TransferID string `json:"transfer_id,omitempty"`
+}
+
+// The identity frame is the magic, the length of the JSON encoded identity
+// as a big-endian uint32 and the identity.
+const (
+	identityMagic   = "CLQI"
+	maxIdentitySize = 64 * 1024
+)
+
+// ErrLegacyPeer is returned when the peer greets the client like the agents
+// that predate the identities do, but only after the client introduced
+// itself; they cannot tell the identity from the data of the transfer. The
+// clients fall back to the legacy servers that greet them in time, see
+// Timeouts.Identity.
+var ErrLegacyPeer = errors.New(
+	"peer runs an agent that predates the identities and has to be upgraded",
+)
+
+// ErrScheduleMismatch is returned when the peer follows another measurement
+// schedule, e.g. with other members or slot duration; the transfers of the
+// two agents would not be isolated from each other.
+var ErrScheduleMismatch = errors.New(
+	"peer follows another measurement schedule",
+)
+
+// checkSchedule fails when both agents are coordinated but disagree on the
+// schedule.
+func checkSchedule(self, peer Identity) error {
+	if self.Schedule == "" || peer.Schedule == "" {
+		return nil
+	}
+	if self.Schedule != peer.Schedule {
+		return ErrScheduleMismatch
+	}
+
+	return nil
+}
+
+// legacyGreetings are the first messages of the servers of the legacy
+// agents.
+var legacyGreetings = []string{"ok", "i-am-busy"}
+
+func WriteIdentity(w io.Writer, id Identity) error {
+	data, err := json.Marshal(id)
+	if err != nil {
+		// untested return
+		return fmt.Errorf("encoding the identity: %s", err)
+	}
+
+	frame := make([]byte, len(identityMagic)+4+len(data))
+	copy(frame, identityMagic)
+	binary.BigEndian.PutUint32(frame[len(identityMagic):], uint32(len(data)))
+	copy(frame[len(identityMagic)+4:], data)
+
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadIdentity reads the identity frame. It fails as soon as the peer sends
+// anything else, without waiting for the rest of the frame.
+func ReadIdentity(r io.Reader) (Identity, error) {
+	header := make([]byte, len(identityMagic)+4)
+	for read := 0; read < len(identityMagic); {
+		n, err := r.Read(header[read:len(identityMagic)])
+		read += n
+		if got := string(header[:read]); !strings.HasPrefix(identityMagic, got) {
+			return Identity{}, notIdentityErr(got)
+		}
+		if err != nil {
+			if err == io.EOF && read > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return Identity{}, err
+		}
+	}
+	if _, err := io.ReadFull(r, header[len(identityMagic):]); err != nil {
+		return Identity{}, err
+	}
+
+	size := binary.BigEndian.Uint32(header[len(identityMagic):])
+	if size > maxIdentitySize {
+		return Identity{}, fmt.Errorf("identity of %d bytes is too large", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return Identity{}, err
+	}
+
+	var id Identity
+	if err := json.Unmarshal(data, &id); err != nil {
+		return Identity{}, fmt.Errorf("decoding the identity: %s", err)
+	}
+
+	return id, nil
+}
+
+// notIdentityErr tells the legacy peers apart from the ones that do not speak
+// the protocol at all by the first bytes that they sent.
+func notIdentityErr(got string) error {
+	for _, greeting := range legacyGreetings {
+		if strings.HasPrefix(greeting, got) || strings.HasPrefix(got, greeting) {
+			return ErrLegacyPeer
+		}
+	}
+
+	return errors.New("peer did not send its identity")
+}
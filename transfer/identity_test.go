@@ -0,0 +1,58 @@
+package transfer_test
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/ice-stuff/clique/transfer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Identity", func() {
+	It("should be read as it was written", func() {
+		id := transfer.Identity{
+			NodeID:   "boo",
+			Labels:   map[string]string{"dc": "ams", "rack": "r12"},
+			Port:     5000,
+			Backends: []string{"simple", "iperf"},
+		}
+
+		buffer := new(bytes.Buffer)
+		Expect(transfer.WriteIdentity(buffer, id)).To(Succeed())
+		buffer.WriteString("ok")
+
+		Expect(transfer.ReadIdentity(buffer)).To(Equal(id))
+		Expect(buffer.String()).To(Equal("ok"))
+	})
+
+	It("should not be read from other messages", func() {
+		_, err := transfer.ReadIdentity(bytes.NewBufferString("banana"))
+		Expect(err).To(MatchError("peer did not send its identity"))
+	})
+
+	It("should tell the greetings of the legacy agents apart", func() {
+		_, err := transfer.ReadIdentity(bytes.NewBufferString("i-am-busy"))
+		Expect(err).To(Equal(transfer.ErrLegacyPeer))
+
+		_, err = transfer.ReadIdentity(bytes.NewBufferString("ok - 5201"))
+		Expect(err).To(Equal(transfer.ErrLegacyPeer))
+	})
+
+	It("should not wait for the rest of a legacy greeting", func() {
+		conn, peerConn := net.Pipe()
+		defer conn.Close()
+		defer peerConn.Close()
+		go peerConn.Write([]byte("ok"))
+
+		_, err := transfer.ReadIdentity(conn)
+		Expect(err).To(Equal(transfer.ErrLegacyPeer))
+	})
+
+	It("should not be read when it is too large", func() {
+		_, err := transfer.ReadIdentity(
+			bytes.NewBufferString("CLQI\xff\xff\xff\xff{}"),
+		)
+		Expect(err).To(MatchError(ContainSubstring("too large")))
+	})
+})
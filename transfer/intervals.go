@@ -0,0 +1,77 @@
+package transfer
+
+import "time"
+
+// Interval is the amount of bytes that were sent during a part of a
+// transfer.
+type Interval struct {
+	// Offset from the start of the transfer
+	Start    time.Duration
+	Duration time.Duration
+	Bytes    uint64
+}
+
+// IntervalSampler splits the bytes of a transfer into fixed intervals. The
+// intervals without any progress are kept, so that the stalls show up in the
+// series.
+type IntervalSampler struct {
+	interval  time.Duration
+	startTime time.Time
+
+	current   Interval
+	intervals []Interval
+}
+
+// NewIntervalSampler creates a sampler for a transfer that starts at
+// `startTime`. It samples nothing when the interval is zero.
+func NewIntervalSampler(
+	interval time.Duration, startTime time.Time,
+) *IntervalSampler {
+	return &IntervalSampler{
+		interval:  interval,
+		startTime: startTime,
+
+		intervals: []Interval{},
+	}
+}
+
+// Add accounts `bytes` that were sent at `now`.
+func (s *IntervalSampler) Add(now time.Time, bytes uint64) {
+	if s.interval <= 0 {
+		return
+	}
+
+	s.closeIntervals(now)
+	s.current.Bytes += bytes
+}
+
+// Intervals returns the series up to `now`. The last interval is shorter when
+// the transfer ended in the middle of it.
+func (s *IntervalSampler) Intervals(now time.Time) []Interval {
+	if s.interval <= 0 {
+		return nil
+	}
+
+	s.closeIntervals(now)
+
+	intervals := make([]Interval, len(s.intervals), len(s.intervals)+1)
+	copy(intervals, s.intervals)
+
+	last := s.current
+	last.Duration = now.Sub(s.startTime) - last.Start
+	if last.Duration > 0 || last.Bytes > 0 {
+		intervals = append(intervals, last)
+	}
+
+	return intervals
+}
+
+func (s *IntervalSampler) closeIntervals(now time.Time) {
+	elapsed := now.Sub(s.startTime)
+	for elapsed >= s.current.Start+s.interval {
+		s.current.Duration = s.interval
+		s.intervals = append(s.intervals, s.current)
+
+		s.current = Interval{Start: s.current.Start + s.interval}
+	}
+}
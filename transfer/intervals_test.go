@@ -0,0 +1,70 @@
+package transfer_test
+
+import (
+	"time"
+
+	"github.com/ice-stuff/clique/transfer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IntervalSampler", func() {
+	var (
+		startTime time.Time
+		sampler   *transfer.IntervalSampler
+	)
+
+	BeforeEach(func() {
+		startTime = time.Now()
+		sampler = transfer.NewIntervalSampler(time.Second, startTime)
+	})
+
+	It("should split the bytes into intervals", func() {
+		sampler.Add(startTime.Add(100*time.Millisecond), 10)
+		sampler.Add(startTime.Add(900*time.Millisecond), 20)
+		sampler.Add(startTime.Add(1500*time.Millisecond), 40)
+
+		Expect(sampler.Intervals(startTime.Add(2 * time.Second))).To(Equal(
+			[]transfer.Interval{
+				{Start: 0, Duration: time.Second, Bytes: 30},
+				{Start: time.Second, Duration: time.Second, Bytes: 40},
+			},
+		))
+	})
+
+	It("should keep the intervals without progress", func() {
+		sampler.Add(startTime.Add(100*time.Millisecond), 10)
+		sampler.Add(startTime.Add(2500*time.Millisecond), 20)
+
+		Expect(sampler.Intervals(startTime.Add(3 * time.Second))).To(Equal(
+			[]transfer.Interval{
+				{Start: 0, Duration: time.Second, Bytes: 10},
+				{Start: time.Second, Duration: time.Second, Bytes: 0},
+				{Start: 2 * time.Second, Duration: time.Second, Bytes: 20},
+			},
+		))
+	})
+
+	It("should shorten the last interval", func() {
+		sampler.Add(startTime.Add(1200*time.Millisecond), 10)
+
+		Expect(sampler.Intervals(startTime.Add(1500 * time.Millisecond))).To(Equal(
+			[]transfer.Interval{
+				{Start: 0, Duration: time.Second, Bytes: 0},
+				{Start: time.Second, Duration: 500 * time.Millisecond, Bytes: 10},
+			},
+		))
+	})
+
+	Context("when the interval is zero", func() {
+		BeforeEach(func() {
+			sampler = transfer.NewIntervalSampler(0, startTime)
+		})
+
+		It("should sample nothing", func() {
+			sampler.Add(startTime.Add(time.Second), 10)
+
+			Expect(sampler.Intervals(startTime.Add(2 * time.Second))).To(BeEmpty())
+		})
+	})
+})
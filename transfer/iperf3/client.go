@@ -0,0 +1,411 @@
+package iperf3
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// clientVersion is the version that the client reports to the servers.
+const clientVersion = "3.1.3"
+
+// Client runs iperf3 tests against the servers: the stock ones and the
+// receivers of the other agents.
+type Client struct {
+	logger    *logrus.Logger
+	identity  transfer.Identity
+	connector transfer.Connector
+	cfg       Config
+
+	// random data that every test repeats
+	payload []byte
+
+	cancels map[int]context.CancelFunc
+	lastID  int
+	closed  bool
+	lock    sync.Mutex
+}
+
+// NewClient creates a client that connects to the servers with the
+// connector. The identity is the source of the results of the tests that do
+// not go through a transfer of the clique.
+func NewClient(
+	logger *logrus.Logger, identity transfer.Identity,
+	connector transfer.Connector, cfg Config,
+) (*Client, error) {
+	payload := make([]byte, cfg.blockSize())
+	if _, err := rand.Read(payload); err != nil {
+		// untested return
+		return nil, fmt.Errorf("generating the payload: %s", err)
+	}
+
+	return &Client{
+		logger:    logger,
+		identity:  identity,
+		connector: connector,
+		cfg:       cfg,
+
+		payload: payload,
+
+		cancels: make(map[int]context.CancelFunc),
+	}, nil
+}
+
+// Transfer measures the stock iperf3 server at the address of the spec. The
+// clique handshake is skipped, so the destination of the results has no
+// identity.
+func (c *Client) Transfer(ctx context.Context, spec transfer.TransferSpec) (
+	transfer.TransferResults, error,
+) {
+	logger := c.logger.WithFields(transfer.LogFields(spec.ID, spec.Peer()))
+
+	ctx, cancel := c.cfg.Timeouts.WithTotal(ctx)
+	defer cancel()
+
+	id, ok := c.track(cancel)
+	if !ok {
+		logger.Info("Outgoing iperf3 test is aborted: client is closed")
+		return transfer.TransferResults{}, transfer.ErrClientClosed
+	}
+	defer c.untrack(id)
+
+	logger.Infof("Starting iperf3 test to %s", spec.Peer())
+	res, err := c.RunTest(ctx, spec.IP, spec.Port, spec.Source, spec.Size)
+	res.Source = c.identity
+	if err != nil {
+		if c.isClosed() {
+			logger.Warnf("Outgoing iperf3 test is aborted: '%s'", err)
+			return transfer.TransferResults{}, transfer.ErrClientClosed
+		}
+
+		logger.Errorf("Failed to run iperf3 test: '%s'", err)
+		return transfer.TransferResults{}, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"duration":   res.Duration,
+		"bytes_sent": res.BytesSent,
+		"rtt":        res.RTT,
+	}).Info("Outgoing iperf3 test is completed")
+	return res, nil
+}
+
+// RunTest sends `size` bytes to the iperf3 server at `ip:port`.
+func (c *Client) RunTest(
+	ctx context.Context, ip net.IP, port uint16, src transfer.Source,
+	size uint64,
+) (transfer.TransferResults, error) {
+	t := &clientTest{
+		client: c,
+		ip:     ip,
+		port:   port,
+		src:    src,
+		params: params{
+			TCP:           true,
+			Bytes:         size,
+			Omit:          int(c.cfg.Omit.Seconds()),
+			Parallel:      c.cfg.parallel(),
+			BlockSize:     c.cfg.blockSize(),
+			Window:        c.cfg.Window,
+			Bitrate:       c.cfg.Bitrate,
+			PacingTimer:   1000,
+			ClientVersion: clientVersion,
+		},
+	}
+	defer t.close()
+
+	res, err := t.run(ctx)
+	res.Backend = BackendName
+
+	return res, err
+}
+
+// Close aborts the tests of Transfer that are in progress. Subsequent
+// transfers fail with transfer.ErrClientClosed.
+func (c *Client) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closed = true
+	for _, cancel := range c.cancels {
+		cancel()
+	}
+}
+
+func (c *Client) track(cancel context.CancelFunc) (int, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return 0, false
+	}
+
+	c.lastID++
+	c.cancels[c.lastID] = cancel
+	return c.lastID, true
+}
+
+func (c *Client) untrack(id int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.cancels, id)
+}
+
+func (c *Client) isClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.closed
+}
+
+// clientTest walks through the states that the server announces.
+type clientTest struct {
+	client *Client
+	ip     net.IP
+	port   uint16
+	src    transfer.Source
+	params params
+
+	cookie  []byte
+	ctrl    net.Conn
+	streams []net.Conn
+
+	res   transfer.TransferResults
+	local results
+}
+
+func (t *clientTest) run(ctx context.Context) (transfer.TransferResults, error) {
+	var err error
+	t.cookie, err = newCookie()
+	if err != nil {
+		// untested return
+		return transfer.TransferResults{}, fmt.Errorf("generating the cookie: %s", err)
+	}
+
+	conn, err := t.connect(ctx)
+	if err != nil {
+		return transfer.TransferResults{}, err
+	}
+	t.ctrl = transfer.NewCtxConn(ctx, conn, t.client.cfg.Timeouts.Idle)
+
+	for {
+		s, err := readState(t.ctrl)
+		if err != nil {
+			return transfer.TransferResults{}, fmt.Errorf("reading the state: %s", err)
+		}
+
+		switch s {
+		case stateParamExchange:
+			err = writeJSON(t.ctrl, t.params)
+		case stateCreateStreams:
+			err = t.createStreams(ctx)
+		case stateTestStart:
+		case stateTestRunning:
+			err = t.send(ctx)
+		case stateExchangeResults:
+			err = t.exchangeResults()
+		case stateDisplayResults:
+			return t.res, writeState(t.ctrl, stateIperfDone)
+		case stateAccessDenied:
+			return transfer.TransferResults{}, ErrBusy
+		case stateServerError:
+			return transfer.TransferResults{}, readServerError(t.ctrl)
+		case stateServerTerminate:
+			return transfer.TransferResults{}, errors.New("server terminated the test")
+		default:
+			return transfer.TransferResults{}, fmt.Errorf("unexpected state %d", s)
+		}
+		if err != nil {
+			return transfer.TransferResults{}, err
+		}
+	}
+}
+
+// connect opens a connection to the server and introduces it with the
+// cookie of the test.
+func (t *clientTest) connect(ctx context.Context) (net.Conn, error) {
+	connectCtx, cancel := t.client.cfg.Timeouts.WithConnect(ctx)
+	defer cancel()
+
+	conn, err := t.client.connector.Connect(connectCtx, t.ip, t.port, t.src)
+	if err != nil {
+		return nil, fmt.Errorf("connecting: %s", err)
+	}
+
+	if _, err := conn.Write(t.cookie); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sending the cookie: %s", err)
+	}
+
+	return conn, nil
+}
+
+func (t *clientTest) createStreams(ctx context.Context) error {
+	for i := 0; i < t.params.Parallel; i++ {
+		conn, err := t.connect(ctx)
+		if err != nil {
+			return fmt.Errorf("creating stream #%d: %s", i, err)
+		}
+		t.streams = append(t.streams, conn)
+		if err := setWindow(conn, t.params.Window); err != nil {
+			// untested return
+			return fmt.Errorf("setting the window of stream #%d: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// send shares the bytes of the test between the streams and sends them. The
+// streams send the payload for the omitted time first, which is not counted.
+func (t *clientTest) send(ctx context.Context) error {
+	cpu := readCPUUsage()
+	startTime := time.Now().Add(time.Duration(t.params.Omit) * time.Second)
+	sampler := transfer.NewIntervalSampler(
+		t.client.cfg.MeasurementInterval, startTime,
+	)
+	remaining := t.params.Bytes
+	streamBytes := make([]uint64, len(t.streams))
+	// retransmits of the streams during the omitted time
+	omitted := make([]uint64, len(t.streams))
+	throttled := false
+	var lock sync.Mutex
+
+	errs := make(chan error, len(t.streams))
+	for i, stream := range t.streams {
+		conn := transfer.NewCtxConn(ctx, stream, t.client.cfg.Timeouts.Idle)
+		go func(i int, stream, conn net.Conn) {
+			p := newPacer(t.params.Bitrate)
+			err := func() error {
+				for time.Now().Before(startTime) {
+					written, err := conn.Write(t.client.payload)
+					if err == nil {
+						err = p.pace(ctx, written)
+					}
+					if err != nil {
+						return err
+					}
+				}
+				if info, err := transfer.ReadTCPInfo(stream); err == nil {
+					omitted[i] = uint64(info.Retransmits)
+				}
+
+				for {
+					lock.Lock()
+					n := uint64(len(t.client.payload))
+					if remaining < n {
+						n = remaining
+					}
+					remaining -= n
+					lock.Unlock()
+
+					if n == 0 {
+						return nil
+					}
+
+					written, err := conn.Write(t.client.payload[:n])
+
+					lock.Lock()
+					streamBytes[i] += uint64(written)
+					sampler.Add(time.Now(), uint64(written))
+					lock.Unlock()
+
+					if err == nil {
+						err = p.pace(ctx, written)
+					}
+					if err != nil {
+						return err
+					}
+				}
+			}()
+
+			lock.Lock()
+			throttled = throttled || p.throttled
+			lock.Unlock()
+
+			if err != nil {
+				errs <- fmt.Errorf("sending: %s", err)
+				return
+			}
+			errs <- nil
+		}(i, stream, conn)
+	}
+
+	var err error
+	for range t.streams {
+		if streamErr := <-errs; streamErr != nil && err == nil {
+			err = streamErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	endTime := time.Now()
+
+	t.res.Duration = endTime.Sub(startTime)
+	t.res.Intervals = sampler.Intervals(endTime)
+	t.res.Throttled = throttled
+	for _, bytes := range streamBytes {
+		t.res.BytesSent += bytes
+	}
+
+	t.local = results{Streams: make([]streamResults, len(t.streams))}
+	for i, stream := range t.streams {
+		t.local.Streams[i] = streamResults{
+			ID:          streamID(i),
+			Bytes:       streamBytes[i],
+			Retransmits: -1,
+			EndTime:     t.res.Duration.Seconds(),
+		}
+
+		info, err := transfer.ReadTCPInfo(stream)
+		if err != nil {
+			continue
+		}
+		retransmits := uint64(info.Retransmits) - omitted[i]
+		t.local.SenderHasRetransmits = 1
+		t.local.Streams[i].Retransmits = int64(retransmits)
+		t.res.Retransmits += retransmits
+		if i == 0 {
+			t.res.RTT = info.RTT
+		}
+	}
+	t.local.CPUUtilTotal, t.local.CPUUtilUser, t.local.CPUUtilSystem =
+		cpu.utilization()
+	t.res.SourceCPU = t.local.CPUUtilTotal
+
+	return writeState(t.ctrl, stateTestEnd)
+}
+
+// exchangeResults sends the results of the client and receives the ones of
+// the server.
+func (t *clientTest) exchangeResults() error {
+	if err := writeJSON(t.ctrl, t.local); err != nil {
+		return fmt.Errorf("sending the results: %s", err)
+	}
+
+	var remote results
+	if err := readJSON(t.ctrl, &remote); err != nil {
+		return fmt.Errorf("receiving the results: %s", err)
+	}
+	t.res.DestinationCPU = remote.CPUUtilTotal
+
+	return nil
+}
+
+func (t *clientTest) close() {
+	for _, stream := range t.streams {
+		stream.Close()
+	}
+	if t.ctrl != nil {
+		t.ctrl.Close()
+	}
+}
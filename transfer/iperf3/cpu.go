@@ -0,0 +1,36 @@
+package iperf3
+
+import "time"
+
+// cpuUsage is the CPU time of the agent at a point in time. Like iperf3, the
+// utilisation of a test counts all the work of the process.
+type cpuUsage struct {
+	at     time.Time
+	user   time.Duration
+	system time.Duration
+}
+
+func readCPUUsage() cpuUsage {
+	user, system := processCPUTime()
+
+	return cpuUsage{
+		at:     time.Now(),
+		user:   user,
+		system: system,
+	}
+}
+
+// utilization returns the total, user and system CPU utilisation since the
+// usage in percent of a CPU. It is zero where the CPU time is not known.
+func (u cpuUsage) utilization() (float64, float64, float64) {
+	now := readCPUUsage()
+	elapsed := now.at.Sub(u.at)
+	if elapsed <= 0 {
+		return 0, 0, 0
+	}
+
+	user := 100 * float64(now.user-u.user) / float64(elapsed)
+	system := 100 * float64(now.system-u.system) / float64(elapsed)
+
+	return user + system, user, system
+}
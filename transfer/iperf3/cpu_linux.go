@@ -0,0 +1,17 @@
+package iperf3
+
+import (
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the user and system CPU time of the process.
+func processCPUTime() (time.Duration, time.Duration) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		// untested return
+		return 0, 0
+	}
+
+	return time.Duration(usage.Utime.Nano()), time.Duration(usage.Stime.Nano())
+}
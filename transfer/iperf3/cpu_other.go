@@ -0,0 +1,10 @@
+// +build !linux
+
+package iperf3
+
+import "time"
+
+// processCPUTime is not implemented outside Linux; the utilisation is zero.
+func processCPUTime() (time.Duration, time.Duration) {
+	return 0, 0
+}
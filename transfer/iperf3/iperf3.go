@@ -0,0 +1,60 @@
+// Package iperf3 speaks the control and data protocol of iperf3 without
+// libiperf. Its clients measure stock `iperf3 -s` servers and its servers
+// are measured by stock `iperf3 -c` clients.
+package iperf3
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ice-stuff/clique/transfer"
+)
+
+var ErrBusy = errors.New("server is busy")
+
+// BackendName names the iperf3 transfers in the results. It is the name of
+// the iperf backend, which the agents serve with either implementation of
+// iperf3 and negotiate by it.
+const BackendName = "iperf"
+
+// DefaultBlockSize is the size of the blocks that the clients send when the
+// configuration does not set one. It matches the default of iperf3 for TCP.
+const DefaultBlockSize = 128 * 1024
+
+type Config struct {
+	// Size of the blocks that the clients send. Default: DefaultBlockSize.
+	BlockSize int
+	// Streams of every test of the clients. Default: 1.
+	Parallel int
+	// Socket buffer size of the streams in bytes, like `iperf3 -w`. The
+	// system picks it when it is zero.
+	Window int
+	// Bits per second that every stream of the clients may send. Unlimited
+	// when zero.
+	Bitrate uint64
+	// Time at the start of every test of the clients that is omitted from the
+	// results, e.g. the TCP slow start. iperf3 counts it in whole seconds.
+	Omit time.Duration
+	// Interval of the throughput series of the transfers. Nothing is sampled
+	// when zero.
+	MeasurementInterval time.Duration
+	// Limits of the tests that do not go through a transfer of the clique,
+	// i.e. the tests of stock iperf3 clients and servers.
+	Timeouts transfer.Timeouts
+}
+
+func (c Config) blockSize() int {
+	if c.BlockSize <= 0 {
+		return DefaultBlockSize
+	}
+
+	return c.BlockSize
+}
+
+func (c Config) parallel() int {
+	if c.Parallel <= 0 {
+		return 1
+	}
+
+	return c.Parallel
+}
@@ -0,0 +1,13 @@
+package iperf3_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestIperf3(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Iperf3 Transfer Protocol Suite")
+}
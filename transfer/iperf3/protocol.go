@@ -0,0 +1,190 @@
+package iperf3
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// state is a step of a test, which the server announces to the client over
+// the control connection. The client announces the end of the test and of
+// the whole exchange.
+type state int8
+
+const (
+	stateTestStart       state = 1
+	stateTestRunning     state = 2
+	stateTestEnd         state = 4
+	stateParamExchange   state = 9
+	stateCreateStreams   state = 10
+	stateServerTerminate state = 11
+	stateClientTerminate state = 12
+	stateExchangeResults state = 13
+	stateDisplayResults  state = 14
+	stateIperfDone       state = 16
+	stateAccessDenied    state = -1
+	stateServerError     state = -2
+)
+
+// errUnimplemented is the iperf3 error code (IEUNIMP) of the options that a
+// server does not support.
+const errUnimplemented = 13
+
+// cookieSize is the size of the cookie that the client sends at the start of
+// the control connection and of every stream of the test. It includes the
+// terminating NUL of iperf3.
+const cookieSize = 37
+
+const cookieChars = "abcdefghijklmnopqrstuvwxyz234567"
+
+// maxJSONSize bounds the messages of the peers.
+const maxJSONSize = 1024 * 1024
+
+// params are the settings of a test, which the client sends to the server.
+// iperf3 sends more of them; the ones that do not affect the data path are
+// ignored.
+type params struct {
+	TCP           bool   `json:"tcp,omitempty"`
+	UDP           bool   `json:"udp,omitempty"`
+	SCTP          bool   `json:"sctp,omitempty"`
+	Omit          int    `json:"omit"`
+	Time          int    `json:"time"`
+	Bytes         uint64 `json:"num,omitempty"`
+	BlockCount    uint64 `json:"blockcount,omitempty"`
+	Parallel      int    `json:"parallel"`
+	Reverse       bool   `json:"reverse,omitempty"`
+	Bidirectional bool   `json:"bidirectional,omitempty"`
+	BlockSize     int    `json:"len,omitempty"`
+	Window        int    `json:"window,omitempty"`
+	Bitrate       uint64 `json:"bandwidth,omitempty"`
+	PacingTimer   int    `json:"pacing_timer,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+}
+
+// results are the statistics of one end of a test, which the ends exchange
+// when the test ends.
+type results struct {
+	CPUUtilTotal         float64         `json:"cpu_util_total"`
+	CPUUtilUser          float64         `json:"cpu_util_user"`
+	CPUUtilSystem        float64         `json:"cpu_util_system"`
+	SenderHasRetransmits int             `json:"sender_has_retransmits"`
+	Streams              []streamResults `json:"streams"`
+}
+
+type streamResults struct {
+	ID    int    `json:"id"`
+	Bytes uint64 `json:"bytes"`
+	// -1 when they are not known
+	Retransmits int64   `json:"retransmits"`
+	Jitter      float64 `json:"jitter"`
+	Errors      int64   `json:"errors"`
+	Packets     int64   `json:"packets"`
+	// Seconds from the start of the test
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// streamID returns the identifier that iperf3 gives to the stream at the
+// index: the second stream is the third one.
+func streamID(index int) int {
+	if index == 0 {
+		return 1
+	}
+
+	return index + 2
+}
+
+func newCookie() ([]byte, error) {
+	cookie := make([]byte, cookieSize)
+	if _, err := rand.Read(cookie[:cookieSize-1]); err != nil {
+		return nil, err
+	}
+	for i := range cookie[:cookieSize-1] {
+		cookie[i] = cookieChars[int(cookie[i])%len(cookieChars)]
+	}
+	cookie[cookieSize-1] = 0
+
+	return cookie, nil
+}
+
+func readCookie(r io.Reader) (string, error) {
+	cookie := make([]byte, cookieSize)
+	if _, err := io.ReadFull(r, cookie); err != nil {
+		return "", err
+	}
+
+	return string(cookie), nil
+}
+
+func readState(r io.Reader) (state, error) {
+	var s state
+	if err := binary.Read(r, binary.BigEndian, &s); err != nil {
+		return 0, err
+	}
+
+	return s, nil
+}
+
+func writeState(w io.Writer, s state) error {
+	return binary.Write(w, binary.BigEndian, s)
+}
+
+// readJSON reads a message that is prefixed with its size.
+func readJSON(r io.Reader, v interface{}) error {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return err
+	}
+	if size > maxJSONSize {
+		return fmt.Errorf("message of %d bytes is too large", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unmarshalling JSON: %s", err)
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// untested return
+		return fmt.Errorf("marshalling JSON: %s", err)
+	}
+
+	msg := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(msg, uint32(len(data)))
+	copy(msg[4:], data)
+
+	_, err = w.Write(msg)
+	return err
+}
+
+// readServerError reads the iperf3 error code and the errno that follow the
+// server error state.
+func readServerError(r io.Reader) error {
+	var codes [2]int32
+	if err := binary.Read(r, binary.BigEndian, &codes); err != nil {
+		return fmt.Errorf("reading the server error: %s", err)
+	}
+
+	return fmt.Errorf(
+		"server error: iperf3 error %d, errno %d", codes[0], codes[1],
+	)
+}
+
+func writeServerError(w io.Writer, code int32) error {
+	if err := writeState(w, stateServerError); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.BigEndian, [2]int32{code, 0})
+}
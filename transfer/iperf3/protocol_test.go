@@ -0,0 +1,274 @@
+package iperf3_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/testhelpers"
+	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/iperf3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The peers of these specs follow the messages of iperf3 3.1 byte by byte.
+var _ = Describe("Wire protocol", func() {
+	var (
+		logger    *logrus.Logger
+		iperfPort uint16
+		listener  net.Listener
+	)
+
+	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
+
+		iperfPort = testhelpers.SelectPort(GinkgoParallelNode())
+		var err error
+		listener, err = net.Listen(
+			"tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(iperfPort))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	Describe("Client", func() {
+		var (
+			resChan chan transfer.TransferResults
+			errChan chan error
+		)
+
+		BeforeEach(func() {
+			client, err := iperf3.NewClient(
+				logger, transfer.Identity{},
+				transfer.NewConnector(transfer.SocketOptions{}), iperf3.Config{},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			resChan = make(chan transfer.TransferResults, 1)
+			errChan = make(chan error, 1)
+			go func() {
+				res, err := client.RunTest(
+					context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+					transfer.Source{}, 1000,
+				)
+				resChan <- res
+				errChan <- err
+			}()
+		})
+
+		It("should run the test of a stock server", func() {
+			ctrl, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer ctrl.Close()
+
+			cookie := make([]byte, 37)
+			_, err = io.ReadFull(ctrl, cookie)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cookie[36]).To(BeZero())
+			for _, c := range cookie[:36] {
+				Expect("abcdefghijklmnopqrstuvwxyz234567").To(ContainSubstring(string(c)))
+			}
+
+			writeState(ctrl, 9)
+			var params map[string]interface{}
+			readJSON(ctrl, &params)
+			Expect(params).To(HaveKeyWithValue("tcp", true))
+			Expect(params).To(HaveKeyWithValue("num", BeNumerically("==", 1000)))
+			Expect(params).To(HaveKeyWithValue("parallel", BeNumerically("==", 1)))
+			Expect(params).To(HaveKeyWithValue("len", BeNumerically("==", 128*1024)))
+
+			writeState(ctrl, 10)
+			stream, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer stream.Close()
+			streamCookie := make([]byte, 37)
+			_, err = io.ReadFull(stream, streamCookie)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(streamCookie).To(Equal(cookie))
+
+			writeState(ctrl, 1)
+			writeState(ctrl, 2)
+			data := make([]byte, 1000)
+			_, err = io.ReadFull(stream, data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readState(ctrl)).To(Equal(int8(4)))
+
+			writeState(ctrl, 13)
+			var clientResults map[string]interface{}
+			readJSON(ctrl, &clientResults)
+			Expect(clientResults).To(HaveKey("cpu_util_total"))
+			Expect(clientResults).To(HaveKey("cpu_util_user"))
+			Expect(clientResults).To(HaveKey("cpu_util_system"))
+			Expect(clientResults).To(HaveKey("sender_has_retransmits"))
+			Expect(clientResults["streams"]).To(HaveLen(1))
+			streamResults := clientResults["streams"].([]interface{})[0]
+			Expect(streamResults).To(HaveKeyWithValue("id", BeNumerically("==", 1)))
+			Expect(streamResults).To(HaveKeyWithValue("bytes", BeNumerically("==", 1000)))
+			for _, key := range []string{"retransmits", "jitter", "errors", "packets"} {
+				Expect(streamResults).To(HaveKey(key))
+			}
+			writeJSON(ctrl, map[string]interface{}{
+				"cpu_util_total":         1.5,
+				"cpu_util_user":          0.5,
+				"cpu_util_system":        1,
+				"sender_has_retransmits": 0,
+				"streams": []interface{}{map[string]interface{}{
+					"id": 1, "bytes": 1000, "retransmits": -1, "jitter": 0,
+					"errors": 0, "packets": 0,
+				}},
+			})
+
+			writeState(ctrl, 14)
+			Expect(readState(ctrl)).To(Equal(int8(16)))
+
+			Eventually(errChan).Should(Receive(BeNil()))
+			var res transfer.TransferResults
+			Eventually(resChan).Should(Receive(&res))
+			Expect(res.BytesSent).To(BeNumerically("==", 1000))
+		})
+
+		It("should fail when the server is busy", func() {
+			ctrl, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer ctrl.Close()
+
+			writeState(ctrl, -1)
+
+			Eventually(errChan).Should(Receive(Equal(iperf3.ErrBusy)))
+		})
+
+		It("should report the errors of the server", func() {
+			ctrl, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			defer ctrl.Close()
+
+			writeState(ctrl, -2)
+			Expect(binary.Write(ctrl, binary.BigEndian, [2]int32{13, 0})).To(Succeed())
+
+			Eventually(errChan).Should(Receive(MatchError(
+				"server error: iperf3 error 13, errno 0",
+			)))
+		})
+	})
+
+	Describe("Receiver", func() {
+		var ctrl net.Conn
+
+		BeforeEach(func() {
+			receiver := iperf3.NewReceiver(logger, listener, iperf3.Config{})
+			go receiver.Serve()
+
+			var err error
+			ctrl, err = net.Dial("tcp", listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = ctrl.Write(stockCookie())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			ctrl.Close()
+		})
+
+		It("should serve the test of a stock client", func() {
+			Expect(readState(ctrl)).To(Equal(int8(9)))
+			writeJSON(ctrl, map[string]interface{}{
+				"tcp": true, "omit": 0, "time": 0, "num": 1000, "parallel": 1,
+				"len": 1000, "pacing_timer": 1000, "client_version": "3.1.3",
+			})
+
+			Expect(readState(ctrl)).To(Equal(int8(10)))
+			stream, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			defer stream.Close()
+			_, err = stream.Write(stockCookie())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(readState(ctrl)).To(Equal(int8(1)))
+			Expect(readState(ctrl)).To(Equal(int8(2)))
+			_, err = stream.Write(make([]byte, 1000))
+			Expect(err).NotTo(HaveOccurred())
+			time.Sleep(time.Millisecond * 100)
+			writeState(ctrl, 4)
+
+			Expect(readState(ctrl)).To(Equal(int8(13)))
+			writeJSON(ctrl, map[string]interface{}{
+				"cpu_util_total":         1.5,
+				"cpu_util_user":          0.5,
+				"cpu_util_system":        1,
+				"sender_has_retransmits": 1,
+				"streams": []interface{}{map[string]interface{}{
+					"id": 1, "bytes": 1000, "retransmits": 0, "jitter": 0,
+					"errors": 0, "packets": 0,
+				}},
+			})
+			var serverResults map[string]interface{}
+			readJSON(ctrl, &serverResults)
+			Expect(serverResults).To(HaveKey("cpu_util_total"))
+			Expect(serverResults).To(HaveKey("sender_has_retransmits"))
+			Expect(serverResults["streams"]).To(HaveLen(1))
+			streamResults := serverResults["streams"].([]interface{})[0]
+			Expect(streamResults).To(HaveKeyWithValue("id", BeNumerically("==", 1)))
+			Expect(streamResults).To(HaveKeyWithValue("bytes", BeNumerically("==", 1000)))
+
+			Expect(readState(ctrl)).To(Equal(int8(14)))
+			writeState(ctrl, 16)
+		})
+
+		It("should refuse the tests that it does not support", func() {
+			Expect(readState(ctrl)).To(Equal(int8(9)))
+			writeJSON(ctrl, map[string]interface{}{
+				"tcp": true, "time": 10, "parallel": 1, "reverse": true,
+			})
+
+			Expect(readState(ctrl)).To(Equal(int8(-2)))
+			var codes [2]int32
+			Expect(binary.Read(ctrl, binary.BigEndian, &codes)).To(Succeed())
+			Expect(codes[0]).To(BeNumerically("==", 13))
+		})
+	})
+})
+
+func stockCookie() []byte {
+	return append([]byte(strings.Repeat("a", 36)), 0)
+}
+
+func readState(r io.Reader) int8 {
+	var s int8
+	Expect(binary.Read(r, binary.BigEndian, &s)).To(Succeed())
+	return s
+}
+
+func writeState(w io.Writer, s int8) {
+	Expect(binary.Write(w, binary.BigEndian, s)).To(Succeed())
+}
+
+func readJSON(r io.Reader, v interface{}) {
+	var size uint32
+	Expect(binary.Read(r, binary.BigEndian, &size)).To(Succeed())
+	data := make([]byte, size)
+	_, err := io.ReadFull(r, data)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(json.Unmarshal(data, v)).To(Succeed())
+}
+
+func writeJSON(w io.Writer, v interface{}) {
+	data, err := json.Marshal(v)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(binary.Write(w, binary.BigEndian, uint32(len(data)))).To(Succeed())
+	_, err = w.Write(data)
+	Expect(err).NotTo(HaveOccurred())
+}
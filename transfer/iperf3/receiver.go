@@ -0,0 +1,462 @@
+package iperf3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// cookieTimeout bounds the time that a new connection has to introduce
+// itself with its cookie.
+const cookieTimeout = 10 * time.Second
+
+// maxStreams is the largest amount of parallel streams that iperf3 accepts.
+const maxStreams = 128
+
+// Receiver is an iperf3 server. It serves the tests of the senders of the
+// other agents, which are announced in the transfers of the clique, and the
+// tests of stock iperf3 clients, one test at a time.
+type Receiver struct {
+	logger   *logrus.Logger
+	listener net.Listener
+	cfg      Config
+
+	// test in progress; nil when idle
+	test *serverTest
+	// transfer of the clique that waits for its test
+	pending *pendingTransfer
+	// Interrupt calls that are not resumed yet
+	pauses int
+
+	lock sync.Mutex
+	// broadcasts the end of every test and pending transfer
+	idle *sync.Cond
+}
+
+type pendingTransfer struct {
+	// the test must come from this address; any address is accepted when nil
+	ip      net.IP
+	resChan chan testResult
+}
+
+func (p *pendingTransfer) accepts(ip net.IP) bool {
+	return p.ip == nil || p.ip.Equal(ip)
+}
+
+type serverTest struct {
+	cookie  string
+	streams chan net.Conn
+	// nil when no transfer of the clique waits for the results
+	resChan chan testResult
+}
+
+type testResult struct {
+	res transfer.TransferResults
+	err error
+}
+
+// NewReceiver creates a receiver that serves the tests on the listener.
+func NewReceiver(
+	logger *logrus.Logger, listener net.Listener, cfg Config,
+) *Receiver {
+	r := &Receiver{
+		logger:   logger,
+		listener: listener,
+		cfg:      cfg,
+	}
+	r.idle = sync.NewCond(&r.lock)
+
+	return r
+}
+
+// Serve accepts the connections of the tests until the receiver is closed.
+func (r *Receiver) Serve() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			r.logger.Debugf(
+				"[IPERF3] Received (maybe expected) error while listening for connection: '%s'",
+				err,
+			)
+			return
+		}
+
+		go r.handleConn(conn)
+	}
+}
+
+// Close stops accepting new tests. The tests in progress are not affected.
+func (r *Receiver) Close() error {
+	return r.listener.Close()
+}
+
+// ReceiveTransfer announces the port of the receiver to the sender and waits
+// for the results of its test.
+func (r *Receiver) ReceiveTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	r.lock.Lock()
+	isBusy := r.pauses > 0 || r.test != nil || r.pending != nil
+	pending := &pendingTransfer{
+		ip:      peerIP(transfer.PeerAddress(conn)),
+		resChan: make(chan testResult, 1),
+	}
+	if !isBusy {
+		r.pending = pending
+	}
+	r.lock.Unlock()
+
+	if isBusy {
+		logger.Debug("[IPERF3] Server is busy!")
+		if _, err := conn.Write([]byte("i-am-busy")); err != nil {
+			logger.Errorf("Failed to send busy message: %s", err)
+		}
+
+		return transfer.TransferResults{}, ErrBusy
+	}
+
+	defer func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+
+		// the test took it over otherwise
+		if r.pending == pending {
+			r.pending = nil
+			r.idle.Broadcast()
+		}
+	}()
+
+	logger.Debug("[IPERF3] Handling the transfer...")
+	msg := fmt.Sprintf("ok - %d", r.port())
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return transfer.TransferResults{}, err
+	}
+
+	select {
+	case tr := <-pending.resChan:
+		return tr.res, tr.err
+	case <-ctx.Done():
+		return transfer.TransferResults{}, ctx.Err()
+	}
+}
+
+func (r *Receiver) Interrupt() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.pauses++
+	for r.test != nil || r.pending != nil {
+		r.idle.Wait()
+	}
+}
+
+func (r *Receiver) Resume() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pauses > 0 {
+		r.pauses--
+	}
+}
+
+func (r *Receiver) IsBusy() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.test != nil || r.pending != nil || r.pauses > 0
+}
+
+func (r *Receiver) port() int {
+	if addr, ok := r.listener.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+
+	// untested return
+	return 0
+}
+
+// handleConn tells the new tests from the streams of the test in progress by
+// their cookie.
+func (r *Receiver) handleConn(conn net.Conn) {
+	logger := r.logger.WithField("peer", conn.RemoteAddr().String())
+
+	conn.SetReadDeadline(time.Now().Add(cookieTimeout))
+	cookie, err := readCookie(conn)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		logger.Debugf("[IPERF3] Failed to read the cookie: %s", err)
+		conn.Close()
+		return
+	}
+
+	ip := peerIP(conn.RemoteAddr().String())
+
+	r.lock.Lock()
+	if r.test != nil && r.test.cookie == cookie {
+		select {
+		case r.test.streams <- conn:
+		default:
+			conn.Close()
+		}
+		r.lock.Unlock()
+		return
+	}
+
+	if r.test != nil || r.pauses > 0 ||
+		(r.pending != nil && !r.pending.accepts(ip)) {
+		r.lock.Unlock()
+		logger.Debug("[IPERF3] Server is busy!")
+		writeState(conn, stateAccessDenied)
+		conn.Close()
+		return
+	}
+
+	test := &serverTest{
+		cookie:  cookie,
+		streams: make(chan net.Conn, maxStreams),
+	}
+	if r.pending != nil {
+		test.resChan = r.pending.resChan
+		r.pending = nil
+	}
+	r.test = test
+	r.lock.Unlock()
+
+	ctx, cancel := r.cfg.Timeouts.WithTotal(context.Background())
+	res, err := r.runTest(ctx, logger, conn, test)
+	cancel()
+	res.Backend = BackendName
+
+	r.lock.Lock()
+	r.test = nil
+	r.idle.Broadcast()
+	r.lock.Unlock()
+
+	if test.resChan != nil {
+		test.resChan <- testResult{res: res, err: err}
+		return
+	}
+
+	if err != nil {
+		logger.Errorf("Failed to receive iperf3 test: '%s'", err)
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"duration":   res.Duration,
+		"bytes_sent": res.BytesSent,
+	}).Info("Incoming iperf3 test is completed")
+}
+
+// runTest walks the client through the states of the test.
+func (r *Receiver) runTest(
+	ctx context.Context, logger *logrus.Entry, conn net.Conn, test *serverTest,
+) (transfer.TransferResults, error) {
+	// the control connection is idle while the data flows
+	ctrl := transfer.NewCtxConn(ctx, conn, 0)
+	defer ctrl.Close()
+
+	logger.Debug("[IPERF3] Handling the test...")
+	if err := writeState(ctrl, stateParamExchange); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	var p params
+	if err := readJSON(ctrl, &p); err != nil {
+		return transfer.TransferResults{}, fmt.Errorf("receiving the parameters: %s", err)
+	}
+
+	if p.UDP || p.SCTP || p.Reverse || p.Bidirectional {
+		writeServerError(ctrl, errUnimplemented)
+		return transfer.TransferResults{}, errors.New(
+			"only TCP tests from the client to the server are supported",
+		)
+	}
+	if p.Parallel < 1 || p.Parallel > maxStreams {
+		writeServerError(ctrl, errUnimplemented)
+		return transfer.TransferResults{}, fmt.Errorf(
+			"unsupported amount of streams: %d", p.Parallel,
+		)
+	}
+
+	if err := writeState(ctrl, stateCreateStreams); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	streams, err := r.collectStreams(ctx, test, p.Parallel)
+	defer func() {
+		for _, stream := range streams {
+			stream.Close()
+		}
+	}()
+	if err != nil {
+		return transfer.TransferResults{}, err
+	}
+	for i, stream := range streams {
+		if err := setWindow(stream, p.Window); err != nil {
+			// untested return
+			return transfer.TransferResults{}, fmt.Errorf(
+				"setting the window of stream #%d: %s", i, err,
+			)
+		}
+	}
+
+	if err := writeState(ctrl, stateTestStart); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	if err := writeState(ctrl, stateTestRunning); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	recv := r.startReceiving(
+		ctx, streams, p.BlockSize, time.Duration(p.Omit)*time.Second,
+	)
+
+	s, err := readState(ctrl)
+	if err != nil {
+		return transfer.TransferResults{}, fmt.Errorf("reading the state: %s", err)
+	}
+	switch s {
+	case stateTestEnd:
+	case stateClientTerminate:
+		return transfer.TransferResults{}, errors.New("client terminated the test")
+	default:
+		return transfer.TransferResults{}, fmt.Errorf("unexpected state %d", s)
+	}
+	res, local := recv.results(time.Now())
+
+	if err := writeState(ctrl, stateExchangeResults); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	var remote results
+	if err := readJSON(ctrl, &remote); err != nil {
+		return transfer.TransferResults{}, fmt.Errorf("receiving the results: %s", err)
+	}
+	res.SourceCPU = remote.CPUUtilTotal
+	if err := writeJSON(ctrl, local); err != nil {
+		return transfer.TransferResults{}, fmt.Errorf("sending the results: %s", err)
+	}
+
+	if err := writeState(ctrl, stateDisplayResults); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	// the results are complete; the client may close without saying goodbye
+	if s, err := readState(ctrl); err == nil && s != stateIperfDone {
+		logger.Debugf("[IPERF3] Unexpected state %d at the end of the test", s)
+	}
+
+	return res, nil
+}
+
+func (r *Receiver) collectStreams(
+	ctx context.Context, test *serverTest, parallel int,
+) ([]net.Conn, error) {
+	streams := make([]net.Conn, 0, parallel)
+	for len(streams) < parallel {
+		select {
+		case stream := <-test.streams:
+			streams = append(streams, stream)
+		case <-ctx.Done():
+			return streams, fmt.Errorf("waiting for the streams: %s", ctx.Err())
+		}
+	}
+
+	return streams, nil
+}
+
+// receiving counts the bytes of the streams until the client ends the test.
+// The bytes of the omitted time at the start of the test are not counted.
+type receiving struct {
+	startTime   time.Time
+	cpu         cpuUsage
+	sampler     *transfer.IntervalSampler
+	streamBytes []uint64
+	lock        sync.Mutex
+}
+
+func (r *Receiver) startReceiving(
+	ctx context.Context, streams []net.Conn, blockSize int, omit time.Duration,
+) *receiving {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+
+	startTime := time.Now().Add(omit)
+	recv := &receiving{
+		startTime:   startTime,
+		cpu:         readCPUUsage(),
+		sampler:     transfer.NewIntervalSampler(r.cfg.MeasurementInterval, startTime),
+		streamBytes: make([]uint64, len(streams)),
+	}
+
+	for i, stream := range streams {
+		conn := transfer.NewCtxConn(ctx, stream, r.cfg.Timeouts.Idle)
+		go func(i int, conn net.Conn) {
+			buf := make([]byte, blockSize)
+			for {
+				n, err := conn.Read(buf)
+
+				if now := time.Now(); !now.Before(recv.startTime) {
+					recv.lock.Lock()
+					recv.streamBytes[i] += uint64(n)
+					recv.sampler.Add(now, uint64(n))
+					recv.lock.Unlock()
+				}
+
+				if err != nil {
+					return
+				}
+			}
+		}(i, conn)
+	}
+
+	return recv
+}
+
+// results returns the results of the test when it ends at `endTime`, for
+// the agent and for the client. Like iperf3, the bytes that arrive later
+// are not counted.
+func (recv *receiving) results(endTime time.Time) (
+	transfer.TransferResults, results,
+) {
+	recv.lock.Lock()
+	defer recv.lock.Unlock()
+
+	// the test may end before the omitted time does
+	if endTime.Before(recv.startTime) {
+		endTime = recv.startTime
+	}
+
+	res := transfer.TransferResults{
+		Duration:  endTime.Sub(recv.startTime),
+		Intervals: recv.sampler.Intervals(endTime),
+	}
+	local := results{Streams: make([]streamResults, len(recv.streamBytes))}
+	for i, bytes := range recv.streamBytes {
+		res.BytesSent += bytes
+		local.Streams[i] = streamResults{
+			ID:          streamID(i),
+			Bytes:       bytes,
+			Retransmits: -1,
+			EndTime:     res.Duration.Seconds(),
+		}
+	}
+	local.CPUUtilTotal, local.CPUUtilUser, local.CPUUtilSystem =
+		recv.cpu.utilization()
+	res.DestinationCPU = local.CPUUtilTotal
+
+	return res, local
+}
+
+// peerIP returns the IP of a `host:port` address or nil.
+func peerIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
@@ -0,0 +1,342 @@
+package iperf3_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/testhelpers"
+	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/iperf3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Roundtrip", func() {
+	var (
+		logger       *logrus.Logger
+		iperfPort    uint16
+		listener     net.Listener
+		receiver     *iperf3.Receiver
+		client       *iperf3.Client
+		sender       *iperf3.Sender
+		senderConn   net.Conn
+		receiverConn net.Conn
+	)
+
+	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
+		cfg := iperf3.Config{
+			MeasurementInterval: time.Millisecond * 10,
+			Timeouts:            transfer.Timeouts{Total: time.Second * 5},
+		}
+
+		iperfPort = testhelpers.SelectPort(GinkgoParallelNode())
+		var err error
+		listener, err = net.Listen(
+			"tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(int(iperfPort))),
+		)
+		Expect(err).NotTo(HaveOccurred())
+		receiver = iperf3.NewReceiver(logger, listener, cfg)
+		go receiver.Serve()
+
+		client, err = iperf3.NewClient(
+			logger, transfer.Identity{NodeID: "boo"},
+			transfer.NewConnector(transfer.SocketOptions{}), cfg,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		sender = iperf3.NewSender(logger, client)
+
+		senderConn, receiverConn = net.Pipe()
+	})
+
+	AfterEach(func() {
+		Expect(senderConn.Close()).To(Succeed())
+		Expect(receiverConn.Close()).To(Succeed())
+		Expect(receiver.Close()).To(Succeed())
+	})
+
+	Context("when the connection is closed", func() {
+		BeforeEach(func() {
+			Expect(senderConn.Close()).To(Succeed())
+			Expect(receiverConn.Close()).To(Succeed())
+		})
+
+		Describe("Receiver.ReceiveTransfer", func() {
+			It("returns an error", func() {
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
+				Expect(err).To(MatchError(ContainSubstring("on closed pipe")))
+			})
+
+			It("should not stay busy", func() {
+				receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
+				Expect(receiver.IsBusy()).To(BeFalse())
+			})
+		})
+
+		Describe("Sender.SendTransfer", func() {
+			It("returns an error", func() {
+				spec := transfer.TransferSpec{
+					IP:   net.ParseIP("127.0.0.1"),
+					Size: 10 * 1024 * 1024,
+				}
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
+				Expect(err).To(MatchError(ContainSubstring("on closed pipe")))
+			})
+		})
+	})
+
+	Context("when a receiver handles the transfer", func() {
+		var (
+			receiverRes  transfer.TransferResults
+			receiverErr  error
+			receiverDone chan struct{}
+		)
+
+		BeforeEach(func() {
+			// the receiver expects the test from the address of the sender
+			Expect(senderConn.Close()).To(Succeed())
+			Expect(receiverConn.Close()).To(Succeed())
+			senderConn, receiverConn = tcpPipe()
+
+			done := make(chan struct{})
+			receiverDone = done
+			conn := receiverConn
+			go func() {
+				receiverRes, receiverErr = receiver.ReceiveTransfer(
+					context.Background(), logrus.NewEntry(logger), conn,
+				)
+				close(done)
+			}()
+		})
+
+		It("should send the transfer through the iperf3 port", func() {
+			spec := transfer.TransferSpec{
+				IP:   net.ParseIP("127.0.0.1"),
+				Size: 10 * 1024 * 1024,
+			}
+			res, err := sender.SendTransfer(context.Background(), spec, senderConn)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.Backend).To(Equal(iperf3.BackendName))
+			Expect(res.BytesSent).To(BeNumerically("==", 10*1024*1024))
+			Expect(res.Duration).NotTo(BeZero())
+			Expect(res.Intervals).NotTo(BeEmpty())
+
+			Eventually(receiverDone).Should(BeClosed())
+			Expect(receiverErr).NotTo(HaveOccurred())
+			Expect(receiverRes.Backend).To(Equal(iperf3.BackendName))
+			Expect(receiverRes.BytesSent).To(BeNumerically(">", 0))
+			Expect(receiverRes.BytesSent).To(BeNumerically("<=", 10*1024*1024))
+			Expect(receiverRes.Duration).NotTo(BeZero())
+		})
+
+		It("should be busy until the test ends", func() {
+			Eventually(receiver.IsBusy).Should(BeTrue())
+
+			otherSenderConn, otherReceiverConn := net.Pipe()
+			defer otherSenderConn.Close()
+			defer otherReceiverConn.Close()
+
+			go func() {
+				defer GinkgoRecover()
+
+				_, err := receiver.ReceiveTransfer(
+					context.Background(), logrus.NewEntry(logger), otherReceiverConn,
+				)
+				Expect(err).To(Equal(iperf3.ErrBusy))
+			}()
+
+			spec := transfer.TransferSpec{
+				IP:   net.ParseIP("127.0.0.1"),
+				Size: 1024,
+			}
+			_, err := sender.SendTransfer(
+				context.Background(), spec, otherSenderConn,
+			)
+			Expect(err).To(Equal(iperf3.ErrBusy))
+
+			_, err = sender.SendTransfer(context.Background(), spec, senderConn)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(receiverDone).Should(BeClosed())
+			Expect(receiver.IsBusy()).To(BeFalse())
+		})
+
+		It("should deny the tests of other clients", func() {
+			Eventually(receiver.IsBusy).Should(BeTrue())
+
+			_, err := client.RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{IP: net.ParseIP("127.0.0.2")}, 1024,
+			)
+			Expect(err).To(Equal(iperf3.ErrBusy))
+		})
+	})
+
+	Context("when the sender does not start the test", func() {
+		It("should stop waiting for it when the context is done", func() {
+			go func() {
+				buf := make([]byte, 16)
+				senderConn.Read(buf)
+			}()
+
+			ctx, cancel := context.WithTimeout(
+				context.Background(), time.Millisecond*100,
+			)
+			defer cancel()
+			_, err := receiver.ReceiveTransfer(ctx, logrus.NewEntry(logger), receiverConn)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(receiver.IsBusy()).To(BeFalse())
+		})
+	})
+
+	Context("when a stock client runs a test", func() {
+		It("should serve it", func() {
+			res, err := client.RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{}, 1024*1024,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.BytesSent).To(BeNumerically("==", 1024*1024))
+			Eventually(receiver.IsBusy).Should(BeFalse())
+		})
+	})
+
+	Context("when the client has options", func() {
+		var cfg iperf3.Config
+
+		BeforeEach(func() {
+			cfg = iperf3.Config{
+				MeasurementInterval: time.Millisecond * 10,
+				Timeouts:            transfer.Timeouts{Total: time.Second * 5},
+			}
+		})
+
+		newClient := func() *iperf3.Client {
+			client, err := iperf3.NewClient(
+				logger, transfer.Identity{NodeID: "boo"},
+				transfer.NewConnector(transfer.SocketOptions{}), cfg,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			return client
+		}
+
+		It("should share the bytes between the parallel streams", func() {
+			cfg.Parallel = 4
+			cfg.Window = 64 * 1024
+
+			res, err := newClient().RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{}, 50*1024*1024,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.BytesSent).To(BeNumerically("==", 50*1024*1024))
+			Expect(res.SourceCPU).To(BeNumerically(">", 0))
+			Expect(res.DestinationCPU).To(BeNumerically(">", 0))
+		})
+
+		It("should hold the streams to the bitrate", func() {
+			cfg.Bitrate = 16 * 1000 * 1000
+
+			startTime := time.Now()
+			res, err := newClient().RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{}, 1000*1000,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.BytesSent).To(BeNumerically("==", 1000*1000))
+			Expect(res.Throttled).To(BeTrue())
+			Expect(time.Since(startTime)).To(BeNumerically(">=", time.Millisecond*400))
+		})
+
+		It("should not count the omitted time", func() {
+			cfg.Omit = time.Second
+
+			startTime := time.Now()
+			res, err := newClient().RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{}, 1024*1024,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.BytesSent).To(BeNumerically("==", 1024*1024))
+			Expect(time.Since(startTime)).To(BeNumerically(">=", time.Second))
+			Expect(res.Duration).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	Describe("Client.Transfer", func() {
+		It("should measure the iperf3 server of the spec", func() {
+			res, err := client.Transfer(context.Background(), transfer.TransferSpec{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: iperfPort,
+				Size: 1024 * 1024,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res.Backend).To(Equal(iperf3.BackendName))
+			Expect(res.BytesSent).To(BeNumerically("==", 1024*1024))
+			Expect(res.Source.NodeID).To(Equal("boo"))
+			Expect(res.Destination).To(Equal(transfer.Identity{}))
+		})
+
+		Context("when the client is closed", func() {
+			It("should not start the test", func() {
+				client.Close()
+
+				_, err := client.Transfer(context.Background(), transfer.TransferSpec{
+					IP:   net.ParseIP("127.0.0.1"),
+					Port: iperfPort,
+					Size: 1024 * 1024,
+				})
+				Expect(err).To(Equal(transfer.ErrClientClosed))
+			})
+		})
+	})
+
+	Describe("Receiver.Interrupt", func() {
+		It("should deny the tests until it is resumed", func() {
+			receiver.Interrupt()
+			Expect(receiver.IsBusy()).To(BeTrue())
+
+			_, err := client.RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{}, 1024,
+			)
+			Expect(err).To(Equal(iperf3.ErrBusy))
+
+			receiver.Resume()
+			Expect(receiver.IsBusy()).To(BeFalse())
+
+			_, err = client.RunTest(
+				context.Background(), net.ParseIP("127.0.0.1"), iperfPort,
+				transfer.Source{}, 1024,
+			)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
+
+// tcpPipe returns the ends of a TCP connection over the loopback.
+func tcpPipe() (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	Expect(err).NotTo(HaveOccurred())
+	serverConn, err := listener.Accept()
+	Expect(err).NotTo(HaveOccurred())
+
+	return clientConn, serverConn
+}
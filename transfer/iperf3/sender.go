@@ -0,0 +1,61 @@
+package iperf3
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// Sender sends the transfers of the clique as iperf3 tests. The receiver of
+// the peer, a Receiver or the one of libiperf, announces its port in the
+// handshake.
+type Sender struct {
+	logger *logrus.Logger
+	client *Client
+}
+
+func NewSender(logger *logrus.Logger, client *Client) *Sender {
+	return &Sender{
+		logger: logger,
+		client: client,
+	}
+}
+
+func (s *Sender) SendTransfer(
+	ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger := s.logger.WithFields(transfer.LogFields(spec.ID, spec.Peer()))
+	logger.Debug("[IPERF3] Sending a transfer...")
+
+	iperfPort, err := s.handshake(conn)
+	if err != nil {
+		logger.Debugf("[IPERF3] Handshake failed: %s", err)
+		return transfer.TransferResults{}, err
+	}
+	logger.Debug("[IPERF3] Handshake went through!")
+
+	return s.client.RunTest(ctx, spec.IP, iperfPort, spec.Source, spec.Size)
+}
+
+func (s *Sender) handshake(conn io.ReadWriter) (uint16, error) {
+	msgBytes := make([]byte, 16)
+	n, err := conn.Read(msgBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	msg := string(msgBytes[:n])
+	var iperfPort uint16
+	if _, err := fmt.Sscanf(msg, "ok - %d", &iperfPort); err != nil {
+		if msg == "i-am-busy" {
+			return 0, ErrBusy
+		}
+
+		return 0, fmt.Errorf("unrecognized server response `%s`: %s", msg, err)
+	}
+
+	return iperfPort, nil
+}
@@ -0,0 +1,79 @@
+package iperf3
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// setWindow sets the socket buffers of a stream like `iperf3 -w`. The streams
+// that are not TCP connections, e.g. the pipes of the tests, are left alone.
+func setWindow(conn net.Conn, window int) error {
+	if window <= 0 {
+		return nil
+	}
+
+	bufConn, ok := conn.(interface {
+		SetReadBuffer(int) error
+		SetWriteBuffer(int) error
+	})
+	if !ok {
+		return nil
+	}
+
+	if err := bufConn.SetReadBuffer(window); err != nil {
+		// untested return
+		return err
+	}
+
+	return bufConn.SetWriteBuffer(window)
+}
+
+// pacer holds a stream to the bitrate of the test. It does not wait when the
+// bitrate is zero.
+type pacer struct {
+	bitrate   uint64
+	startTime time.Time
+	bytes     uint64
+	// the stream waited to respect the bitrate
+	throttled bool
+}
+
+func newPacer(bitrate uint64) *pacer {
+	return &pacer{
+		bitrate:   bitrate,
+		startTime: time.Now(),
+	}
+}
+
+// pace counts the bytes that were sent and waits until the bitrate allows
+// them.
+func (p *pacer) pace(ctx context.Context, n int) error {
+	p.bytes += uint64(n)
+	if p.bitrate == 0 {
+		return nil
+	}
+
+	expected := time.Duration(
+		float64(p.bytes*8) / float64(p.bitrate) * float64(time.Second),
+	)
+	ahead := expected - time.Since(p.startTime)
+	if ahead <= 0 {
+		return nil
+	}
+
+	p.throttled = true
+	return sleep(ctx, ahead)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
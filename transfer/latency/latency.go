@@ -0,0 +1,36 @@
+// Package latency measures the round-trip time to the peers with small
+// probes that the receiver echoes over the transfer connection. The size of
+// the transfers is ignored.
+package latency
+
+import "time"
+
+// BackendName names the latency transfers in the results.
+const BackendName = "latency"
+
+// DefaultProbes is the number of round trips of every transfer when the
+// configuration does not set one.
+const DefaultProbes = 10
+
+// Every probe and its echo are the sequence number of the probe as a
+// big-endian uint32, the flags and padding. The sender ends the transfer
+// with a probe that has the end flag.
+const (
+	probeSize = 64
+	flagEnd   = 1
+)
+
+type Config struct {
+	// Round trips of every transfer. Default: DefaultProbes.
+	Probes int
+	// Time between the round trips, which follow each other without it.
+	Interval time.Duration
+}
+
+func (c Config) probes() int {
+	if c.Probes <= 0 {
+		return DefaultProbes
+	}
+
+	return c.Probes
+}
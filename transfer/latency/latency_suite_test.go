@@ -0,0 +1,13 @@
+package latency_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLatency(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Latency Transfer Protocol Suite")
+}
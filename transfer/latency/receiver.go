@@ -0,0 +1,46 @@
+package latency
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// Receiver echoes the probes. Latency transfers are light, so it receives
+// any number of them at once.
+type Receiver struct{}
+
+func NewReceiver() *Receiver {
+	return &Receiver{}
+}
+
+func (r *Receiver) ReceiveTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger.Debug("[LATENCY] Handling the transfer...")
+
+	res := transfer.TransferResults{Backend: BackendName}
+	probe := make([]byte, probeSize)
+
+	startTime := time.Now()
+	for {
+		if _, err := io.ReadFull(conn, probe); err != nil {
+			return transfer.TransferResults{}, err
+		}
+		if probe[4]&flagEnd != 0 {
+			break
+		}
+
+		if _, err := conn.Write(probe); err != nil {
+			return transfer.TransferResults{}, err
+		}
+		res.Packets++
+		res.BytesSent += probeSize
+	}
+	res.Duration = time.Since(startTime)
+
+	return res, nil
+}
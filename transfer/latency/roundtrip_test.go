@@ -0,0 +1,134 @@
+package latency_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/latency"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Roundtrip", func() {
+	var (
+		logger       *logrus.Logger
+		receiver     *latency.Receiver
+		senderConn   net.Conn
+		receiverConn net.Conn
+
+		receiverRes  chan transfer.TransferResults
+		receiverErrs chan error
+	)
+
+	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
+
+		receiver = latency.NewReceiver()
+		senderConn, receiverConn = net.Pipe()
+
+		receiverRes = make(chan transfer.TransferResults, 1)
+		receiverErrs = make(chan error, 1)
+		go func(
+			conn net.Conn, resChan chan transfer.TransferResults, errChan chan error,
+		) {
+			res, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), conn)
+			resChan <- res
+			errChan <- err
+		}(receiverConn, receiverRes, receiverErrs)
+	})
+
+	AfterEach(func() {
+		senderConn.Close()
+		receiverConn.Close()
+	})
+
+	It("should measure the round trips of the probes", func() {
+		sender := latency.NewSender(logger, latency.Config{Probes: 5})
+
+		res, err := sender.SendTransfer(
+			context.Background(), transfer.TransferSpec{Size: 10 * 1024}, senderConn,
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(res.Backend).To(Equal("latency"))
+		Expect(res.Packets).To(Equal(uint64(5)))
+		Expect(res.BytesSent).To(Equal(uint64(5 * 64)))
+		Expect(res.RTT).NotTo(BeZero())
+		Expect(res.Duration).To(BeNumerically(">=", res.RTT))
+	})
+
+	It("should echo every probe", func() {
+		sender := latency.NewSender(logger, latency.Config{Probes: 5})
+
+		_, err := sender.SendTransfer(
+			context.Background(), transfer.TransferSpec{}, senderConn,
+		)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(receiverErrs).Should(Receive(BeNil()))
+		var res transfer.TransferResults
+		Expect(receiverRes).To(Receive(&res))
+		Expect(res.Backend).To(Equal("latency"))
+		Expect(res.Packets).To(Equal(uint64(5)))
+		Expect(res.BytesSent).To(Equal(uint64(5 * 64)))
+	})
+
+	It("should send the default number of probes", func() {
+		sender := latency.NewSender(logger, latency.Config{})
+
+		res, err := sender.SendTransfer(
+			context.Background(), transfer.TransferSpec{}, senderConn,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Packets).To(Equal(uint64(latency.DefaultProbes)))
+	})
+
+	It("should wait for the interval between the probes", func() {
+		sender := latency.NewSender(logger, latency.Config{
+			Probes:   3,
+			Interval: 50 * time.Millisecond,
+		})
+
+		res, err := sender.SendTransfer(
+			context.Background(), transfer.TransferSpec{}, senderConn,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Duration).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+
+	Context("when the context is cancelled between the probes", func() {
+		It("should return the error of the context", func() {
+			sender := latency.NewSender(logger, latency.Config{
+				Probes:   3,
+				Interval: time.Second,
+			})
+
+			ctx, cancel := context.WithTimeout(
+				context.Background(), 100*time.Millisecond,
+			)
+			defer cancel()
+
+			_, err := sender.SendTransfer(ctx, transfer.TransferSpec{}, senderConn)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+		})
+	})
+
+	Context("when the connection is closed", func() {
+		It("should fail the transfer", func() {
+			Expect(receiverConn.Close()).To(Succeed())
+			sender := latency.NewSender(logger, latency.Config{})
+
+			_, err := sender.SendTransfer(
+				context.Background(), transfer.TransferSpec{}, senderConn,
+			)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
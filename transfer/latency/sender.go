@@ -0,0 +1,107 @@
+package latency
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+type Sender struct {
+	logger *logrus.Logger
+	cfg    Config
+}
+
+func NewSender(logger *logrus.Logger, cfg Config) *Sender {
+	return &Sender{
+		logger: logger,
+		cfg:    cfg,
+	}
+}
+
+// SendTransfer sends the probes one at a time. The RTT of the results is the
+// median round trip and the jitter is the mean difference of consecutive
+// round trips.
+func (s *Sender) SendTransfer(
+	ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger := s.logger.WithFields(transfer.LogFields(spec.ID, spec.Peer()))
+	logger.Debug("[LATENCY] Sending a transfer...")
+
+	res := transfer.TransferResults{Backend: BackendName}
+	probe := make([]byte, probeSize)
+	echo := make([]byte, probeSize)
+	rtts := make([]time.Duration, 0, s.cfg.probes())
+
+	startTime := time.Now()
+	for seq := 0; seq < s.cfg.probes(); seq++ {
+		if seq > 0 && s.cfg.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return transfer.TransferResults{}, ctx.Err()
+			case <-time.After(s.cfg.Interval):
+			}
+		}
+
+		binary.BigEndian.PutUint32(probe, uint32(seq))
+		sentAt := time.Now()
+		if _, err := conn.Write(probe); err != nil {
+			return transfer.TransferResults{}, err
+		}
+		if _, err := io.ReadFull(conn, echo); err != nil {
+			return transfer.TransferResults{}, err
+		}
+		rtt := time.Since(sentAt)
+
+		if echoSeq := binary.BigEndian.Uint32(echo); echoSeq != uint32(seq) {
+			return transfer.TransferResults{}, fmt.Errorf(
+				"received the echo of probe %d instead of %d", echoSeq, seq,
+			)
+		}
+
+		rtts = append(rtts, rtt)
+		res.BytesSent += probeSize
+	}
+
+	probe[4] = flagEnd
+	if _, err := conn.Write(probe); err != nil {
+		return transfer.TransferResults{}, err
+	}
+
+	res.Duration = time.Since(startTime)
+	res.Packets = uint64(len(rtts))
+	res.RTT = median(rtts)
+	res.Jitter = jitter(rtts)
+	logger.Debugf("[LATENCY] Median RTT of %d probes is %s", len(rtts), res.RTT)
+
+	return res, nil
+}
+
+func median(rtts []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), rtts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[len(sorted)/2]
+}
+
+func jitter(rtts []time.Duration) time.Duration {
+	if len(rtts) < 2 {
+		return 0
+	}
+
+	var sum time.Duration
+	for i := 1; i < len(rtts); i++ {
+		diff := rtts[i] - rtts[i-1]
+		if diff < 0 {
+			diff = -diff
+		}
+		sum += diff
+	}
+
+	return sum / time.Duration(len(rtts)-1)
+}
@@ -1,35 +1,70 @@
 package transfer
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 )
 
+// TransferReceiver receives the transfers. It logs with the logger of the
+// transfer, which carries the transfer id of the client.
+//
 //go:generate counterfeiter . TransferReceiver
 type TransferReceiver interface {
-	ReceiveTransfer(conn io.ReadWriter) (TransferResults, error)
+	ReceiveTransfer(
+		ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+	) (TransferResults, error)
 }
 
 type Server struct {
-	logger           *logrus.Logger
-	listener         net.Listener
-	transferReceiver TransferReceiver
+	logger   *logrus.Logger
+	identity Identity
+	listener net.Listener
+	backends Backends
+	timeouts Timeouts
+	// TCP_INFO is not sampled when zero
+	tcpInfoInterval time.Duration
 
 	resChan chan TransferResults
+
+	conns     map[net.Conn]struct{}
+	connsWG   sync.WaitGroup
+	draining  bool
+	aborting  bool
+	connsLock sync.Mutex
 }
 
+// NewServer creates a server that introduces itself to the clients with the
+// identity and the names of its backends, of which there is at least one. It
+// samples the TCP_INFO of its connections every `tcpInfoInterval` and at the
+// end of every transfer of the backends that send the data through them.
+// Nothing is sampled when the interval is zero.
 func NewServer(
-	logger *logrus.Logger, listener net.Listener,
-	transferReceiver TransferReceiver,
+	logger *logrus.Logger, identity Identity, listener net.Listener,
+	backends Backends, timeouts Timeouts,
+	tcpInfoInterval time.Duration,
 ) *Server {
+	identity.Backends = backends.Names()
+
 	return &Server{
-		logger:           logger,
-		listener:         listener,
-		transferReceiver: transferReceiver,
+		logger:          logger,
+		identity:        identity,
+		listener:        listener,
+		backends:        backends,
+		timeouts:        timeouts,
+		tcpInfoInterval: tcpInfoInterval,
 
 		resChan: make(chan TransferResults, 1024),
+
+		conns: make(map[net.Conn]struct{}),
 	}
 }
 
@@ -44,26 +79,257 @@ func (s *Server) Serve() {
 			return
 		}
 
+		if !s.track(conn) {
+			s.logger.Debugf(
+				"Rejecting a transfer from %s: server is draining",
+				conn.RemoteAddr().String(),
+			)
+			conn.Close()
+			continue
+		}
+
 		go func() {
-			s.logger.Infof("Handling a transfer from %s", conn.RemoteAddr().String())
-			res, err := s.transferReceiver.ReceiveTransfer(conn)
+			defer s.untrack(conn)
+
+			// the clients that do not send their transfer ids get one of the
+			// server
+			logger := s.logger.WithFields(
+				LogFields(NewTransferID(), PeerAddress(conn)),
+			)
+
+			ctx, cancel := s.timeouts.WithTotal(context.Background())
+			defer cancel()
+
+			ctxConn, err := s.awaitIdentity(
+				conn, NewCtxConn(ctx, conn, s.timeouts.Idle),
+			)
+			var peer Identity
+			var transferID string
+			if err == nil {
+				peer, transferID, err = s.exchangeIdentities(ctxConn)
+			}
+			if err == errLegacyClient {
+				logger.Info("Client predates the identities, serving it without them")
+				err = nil
+			}
 			if err != nil {
 				conn.Close()
-				s.logger.Errorf("Failed to receive connection: '%s'", err)
+				if closedSilently(err) {
+					logger.Debugf("Connection is closed before the identities: '%s'", err)
+					return
+				}
+
+				logger.Errorf("Failed to exchange identities: '%s'", err)
+				return
+			}
+			if transferID != "" {
+				logger = logger.WithField("transfer_id", transferID)
+			}
+			logger = logger.WithField("node_id", peer.NodeID)
+
+			// the client refuses the transfer too, as it got the schedule of
+			// the server
+			if err := checkSchedule(s.identity, peer); err != nil {
+				conn.Close()
+				logger.WithFields(logrus.Fields{
+					"schedule":      s.identity.Schedule,
+					"peer_schedule": peer.Schedule,
+				}).Errorf("Refusing the transfer: '%s'", err)
+				return
+			}
+
+			backend, err := s.selectedBackend(ctxConn, peer)
+			if err != nil {
+				conn.Close()
+				logger.Errorf("Failed to select the backend: '%s'", err)
+				return
+			}
+
+			logger.Infof("Handling a transfer from %s", conn.RemoteAddr().String())
+			tcpInfoSampler := startTCPInfoSampler(
+				conn, backend.tcpInfoInterval(s.tcpInfoInterval),
+			)
+			res, err := backend.Receiver.ReceiveTransfer(ctx, logger, ctxConn)
+			res.TCPInfoSamples, res.TCPInfo = tcpInfoSampler.Stop()
+			res.Source, res.Destination = peer, s.identity
+			res.Backend = backend.Name
+			if err != nil {
+				conn.Close()
+				if s.isAborting() {
+					logger.Warnf("Incoming transfer is aborted: '%s'", err)
+					return
+				}
+
+				logger.Errorf("Failed to receive connection: '%s'", err)
 				return
 			}
 			conn.Close()
 
-			s.logger.WithFields(logrus.Fields{
+			fields := logrus.Fields{
 				"duration":   res.Duration,
 				"checksum":   res.Checksum,
 				"bytes_sent": res.BytesSent,
-			}).Info("Incoming transfer is completed")
+			}
+			if res.TCPInfo != nil {
+				fields["rtt"] = res.TCPInfo.RTT
+				fields["retransmits"] = res.TCPInfo.Retransmits
+			}
+			logger.WithFields(fields).Info("Incoming transfer is completed")
 			s.resChan <- res
 		}()
 	}
 }
 
+// errLegacyClient is returned for the clients that do not send their
+// identities in time.
+var errLegacyClient = errors.New("client did not send its identity in time")
+
+// awaitIdentity waits for the client to speak within the identity timeout.
+// The clients that stay silent are legacy agents, which wait for the backend
+// of the server to speak first; nothing is sent to them before it. It returns
+// the connection to go on with, which replays what the client sent.
+func (s *Server) awaitIdentity(rawConn, conn net.Conn) (net.Conn, error) {
+	if s.timeouts.Identity <= 0 {
+		return conn, nil
+	}
+
+	first, err := readWithin(rawConn, s.timeouts.Identity)
+	if isTimeout(err) {
+		return conn, errLegacyClient
+	}
+	if err != nil {
+		return conn, err
+	}
+
+	return &replayConn{Conn: conn, pending: first}, nil
+}
+
+// exchangeIdentities answers the identity of the client with the identity of
+// the server. It returns the transfer id that the client sent along with its
+// identity separately, so that it does not end up in the results.
+func (s *Server) exchangeIdentities(conn io.ReadWriter) (
+	Identity, string, error,
+) {
+	peer, err := ReadIdentity(conn)
+	if err != nil {
+		return Identity{}, "", err
+	}
+
+	if err := WriteIdentity(conn, s.identity); err != nil {
+		return Identity{}, "", err
+	}
+
+	transferID := peer.TransferID
+	peer.TransferID = ""
+	return peer, transferID, nil
+}
+
+// closedSilently reports if the peer closed the connection without sending
+// anything, like the TCP probes of the path discovery do.
+func closedSilently(err error) bool {
+	if err == io.EOF {
+		return true
+	}
+
+	if opErr, ok := err.(*net.OpError); ok {
+		if sysErr, ok := opErr.Err.(*os.SyscallError); ok {
+			return sysErr.Err == syscall.ECONNRESET
+		}
+	}
+
+	return false
+}
+
+// selectedBackend reads the backend that the client selected. The clients
+// that do not advertise their backends get the default one.
+func (s *Server) selectedBackend(conn io.Reader, peer Identity) (
+	Backend, error,
+) {
+	if len(peer.Backends) == 0 {
+		return s.backends[0], nil
+	}
+
+	name, err := ReadBackend(conn)
+	if err != nil {
+		return Backend{}, err
+	}
+
+	backend, ok := s.backends.Get(name)
+	if !ok {
+		return Backend{}, fmt.Errorf("unsupported backend `%s`", name)
+	}
+
+	return backend, nil
+}
+
 func (s *Server) LastTransfer() TransferResults {
 	return <-s.resChan
 }
+
+// Close stops accepting new transfers. The transfers in progress are not
+// affected.
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+// Drain waits for the incoming transfers in progress to complete. The ones
+// that are still running when the grace period expires are aborted. It
+// returns false if any transfer was aborted.
+func (s *Server) Drain(grace time.Duration) bool {
+	s.connsLock.Lock()
+	s.draining = true
+	s.connsLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.connsWG.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+	}
+
+	s.connsLock.Lock()
+	s.aborting = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connsLock.Unlock()
+
+	<-done
+	return false
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	if s.draining {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+	s.connsWG.Add(1)
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	delete(s.conns, conn)
+	s.connsWG.Done()
+}
+
+func (s *Server) isAborting() bool {
+	s.connsLock.Lock()
+	defer s.connsLock.Unlock()
+
+	return s.aborting
+}
@@ -1,8 +1,10 @@
 package transfer_test
 
 import (
+	"context"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Server", func() {
@@ -20,6 +23,8 @@ var _ = Describe("Server", func() {
 		fakeListener         *fakes.FakeListener
 		fakeTransferReceiver *fakes.FakeTransferReceiver
 		server               *transfer.Server
+		serverIdentity       transfer.Identity
+		clientIdentity       transfer.Identity
 
 		listenerConnChan chan net.Conn
 		listenerErrChan  chan error
@@ -34,7 +39,17 @@ var _ = Describe("Server", func() {
 		}
 		fakeListener = new(fakes.FakeListener)
 		fakeTransferReceiver = new(fakes.FakeTransferReceiver)
-		server = transfer.NewServer(logger, fakeListener, fakeTransferReceiver)
+		serverIdentity = transfer.Identity{NodeID: "foo", Port: 5001}
+		clientIdentity = transfer.Identity{
+			NodeID: "boo",
+			Labels: map[string]string{"dc": "ams"},
+			Port:   5000,
+		}
+		server = transfer.NewServer(
+			logger, serverIdentity, fakeListener,
+			transfer.Backends{{Name: "fake", Receiver: fakeTransferReceiver}},
+			transfer.Timeouts{}, 0,
+		)
 
 		listenerConnChan = make(chan net.Conn, 100)
 		listenerErrChan = make(chan error)
@@ -73,13 +88,274 @@ var _ = Describe("Server", func() {
 		})
 
 		It("should process the connection", func() {
-			pushedConn, _ := net.Pipe()
+			fakeTransferReceiver.ReceiveTransferStub = func(
+				_ context.Context, _ *logrus.Entry, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				_, err := conn.Write([]byte("banana"))
+				return transfer.TransferResults{}, err
+			}
+
+			pushedConn, clientConns := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			var clientConn net.Conn
+			Eventually(clientConns).Should(Receive(&clientConn))
+			buffer := make([]byte, 16)
+			n, err := clientConn.Read(buffer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buffer[:n])).To(Equal("banana"))
+			Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the client sends the id of the transfer", func() {
+		var logs *gbytes.Buffer
+
+		BeforeEach(func() {
+			logs = gbytes.NewBuffer()
+			logger.Out = logs
+			clientIdentity.TransferID = "7a1d"
+		})
+
+		It("should log the transfer with it", func() {
+			pushedConn, _ := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			Eventually(fakeTransferReceiver.ReceiveTransferCallCount).Should(Equal(1))
+			_, receiverLogger, _ := fakeTransferReceiver.ReceiveTransferArgsForCall(0)
+			Expect(receiverLogger.Data).To(HaveKeyWithValue("transfer_id", "7a1d"))
+			Expect(receiverLogger.Data).To(HaveKeyWithValue("node_id", "boo"))
+			Eventually(logs).Should(gbytes.Say("transfer_id=7a1d"))
+		})
+
+		It("should not report it in the identity of the client", func() {
+			pushedConn, _ := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			res := server.LastTransfer()
+			Expect(res.Source.NodeID).To(Equal("boo"))
+			Expect(res.Source.TransferID).To(BeEmpty())
+		})
+	})
+
+	Context("when the client does not send the id of the transfer", func() {
+		It("should log the transfer with an id of its own", func() {
+			pushedConn, _ := connectFakeClient(clientIdentity)
 			listenerConnChan <- pushedConn
 
 			Eventually(fakeTransferReceiver.ReceiveTransferCallCount).Should(Equal(1))
-			Expect(fakeTransferReceiver.ReceiveTransferArgsForCall(0)).To(
-				Equal(pushedConn),
+			_, receiverLogger, _ := fakeTransferReceiver.ReceiveTransferArgsForCall(0)
+			Expect(receiverLogger.Data["transfer_id"]).NotTo(BeEmpty())
+		})
+	})
+
+	Context("when the identities have a timeout", func() {
+		var lastTransfer func() transfer.TransferResults
+
+		BeforeEach(func() {
+			server = transfer.NewServer(
+				logger, serverIdentity, fakeListener,
+				transfer.Backends{{Name: "fake", Receiver: fakeTransferReceiver}},
+				transfer.Timeouts{Identity: time.Millisecond * 50}, 0,
+			)
+			fakeTransferReceiver.ReceiveTransferStub = func(
+				_ context.Context, _ *logrus.Entry, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				if _, err := conn.Write([]byte("ok")); err != nil {
+					return transfer.TransferResults{}, err
+				}
+
+				data, err := ioutil.ReadAll(conn)
+				return transfer.TransferResults{BytesSent: uint64(len(data))}, err
+			}
+
+			lastTransfer = func() transfer.TransferResults {
+				results := make(chan transfer.TransferResults, 1)
+				go func() {
+					results <- server.LastTransfer()
+				}()
+
+				var res transfer.TransferResults
+				Eventually(results).Should(Receive(&res))
+				return res
+			}
+		})
+
+		It("should receive the transfers of the clients with identities", func() {
+			pushedConn, clientConns := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			var clientConn net.Conn
+			Eventually(clientConns).Should(Receive(&clientConn))
+			buffer := make([]byte, 16)
+			n, err := clientConn.Read(buffer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buffer[:n])).To(Equal("ok"))
+			_, err = clientConn.Write([]byte("data"))
+			Expect(err).NotTo(HaveOccurred())
+			clientConn.Close()
+
+			res := lastTransfer()
+			Expect(res.Source.NodeID).To(Equal("boo"))
+			Expect(res.BytesSent).To(Equal(uint64(4)))
+		})
+
+		Context("and the client predates the identities", func() {
+			It("should receive the transfer without them", func() {
+				pushedConn, clientConn := net.Pipe()
+				listenerConnChan <- pushedConn
+
+				// the legacy clients wait for the server to speak first
+				buffer := make([]byte, 16)
+				n, err := clientConn.Read(buffer)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(buffer[:n])).To(Equal("ok"))
+				// after the identity timeout expired
+				time.Sleep(time.Millisecond * 100)
+				_, err = clientConn.Write([]byte("data"))
+				Expect(err).NotTo(HaveOccurred())
+				clientConn.Close()
+
+				res := lastTransfer()
+				Expect(res.BytesSent).To(Equal(uint64(4)))
+				Expect(res.Source).To(Equal(transfer.Identity{}))
+				Expect(res.Destination.NodeID).To(Equal("foo"))
+			})
+		})
+	})
+
+	Context("when the agents follow measurement schedules", func() {
+		var logs *gbytes.Buffer
+
+		BeforeEach(func() {
+			logs = gbytes.NewBuffer()
+			logger.Out = logs
+
+			serverIdentity.Schedule = "0a1b"
+			server = transfer.NewServer(
+				logger, serverIdentity, fakeListener,
+				transfer.Backends{{Name: "fake", Receiver: fakeTransferReceiver}},
+				transfer.Timeouts{}, 0,
+			)
+		})
+
+		It("should receive the transfers of the same schedule", func() {
+			clientIdentity.Schedule = "0a1b"
+			pushedConn, _ := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			Eventually(fakeTransferReceiver.ReceiveTransferCallCount).Should(Equal(1))
+		})
+
+		It("should refuse the transfers of another schedule", func() {
+			clientIdentity.Schedule = "2c3d"
+			pushedConn, clientConns := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			var clientConn net.Conn
+			Eventually(clientConns).Should(Receive(&clientConn))
+			_, err := clientConn.Read(make([]byte, 1))
+			Expect(err).To(HaveOccurred())
+
+			Eventually(logs).Should(gbytes.Say("Refusing the transfer"))
+			Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(BeZero())
+		})
+
+		It("should receive the transfers of the agents that are not coordinated", func() {
+			pushedConn, _ := connectFakeClient(clientIdentity)
+			listenerConnChan <- pushedConn
+
+			Eventually(fakeTransferReceiver.ReceiveTransferCallCount).Should(Equal(1))
+		})
+	})
+
+	Context("when the client does not send its identity", func() {
+		It("should not receive the transfer", func() {
+			pushedConn, clientConn := net.Pipe()
+			listenerConnChan <- pushedConn
+
+			clientConn.Write([]byte("banana banana"))
+			_, err := clientConn.Read(make([]byte, 1))
+			Expect(err).To(HaveOccurred())
+			Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the client closes the connection without a word", func() {
+		var logs *gbytes.Buffer
+
+		BeforeEach(func() {
+			logs = gbytes.NewBuffer()
+			logger.Out = logs
+		})
+
+		It("should not log an error, as the path probes do it", func() {
+			pushedConn, clientConn := net.Pipe()
+			listenerConnChan <- pushedConn
+			Expect(clientConn.Close()).To(Succeed())
+
+			Eventually(logs).Should(gbytes.Say("Connection is closed before the identities"))
+			Expect(logs.Contents()).NotTo(ContainSubstring("Failed to exchange identities"))
+			Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(BeZero())
+		})
+	})
+
+	Context("when the client advertises its backends", func() {
+		var otherTransferReceiver *fakes.FakeTransferReceiver
+
+		BeforeEach(func() {
+			otherTransferReceiver = new(fakes.FakeTransferReceiver)
+			server = transfer.NewServer(
+				logger, serverIdentity, fakeListener,
+				transfer.Backends{
+					{Name: "fake", Receiver: fakeTransferReceiver},
+					{Name: "other", Receiver: otherTransferReceiver},
+				},
+				transfer.Timeouts{}, 0,
 			)
+			clientIdentity.Backends = []string{"other"}
+		})
+
+		It("should advertise its own backends", func() {
+			pushedConn, clientConn := net.Pipe()
+			listenerConnChan <- pushedConn
+
+			Expect(transfer.WriteIdentity(clientConn, clientIdentity)).To(Succeed())
+			id, err := transfer.ReadIdentity(clientConn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id.Backends).To(Equal([]string{"fake", "other"}))
+			clientConn.Close()
+		})
+
+		It("should receive the transfer with the selected backend", func() {
+			pushedConn, _ := connectFakeClientWithBackend(clientIdentity, "other")
+			listenerConnChan <- pushedConn
+
+			Eventually(otherTransferReceiver.ReceiveTransferCallCount).Should(Equal(1))
+			Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(BeZero())
+		})
+
+		It("should report the selected backend in the results", func() {
+			pushedConn, _ := connectFakeClientWithBackend(clientIdentity, "other")
+			listenerConnChan <- pushedConn
+
+			Expect(server.LastTransfer().Backend).To(Equal("other"))
+		})
+
+		Context("and it selects an unsupported backend", func() {
+			It("should not receive the transfer", func() {
+				pushedConn, clientConns := connectFakeClientWithBackend(
+					clientIdentity, "udp",
+				)
+				listenerConnChan <- pushedConn
+
+				var clientConn net.Conn
+				Eventually(clientConns).Should(Receive(&clientConn))
+				_, err := clientConn.Read(make([]byte, 1))
+				Expect(err).To(HaveOccurred())
+				Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(BeZero())
+				Expect(otherTransferReceiver.ReceiveTransferCallCount()).To(BeZero())
+			})
 		})
 	})
 
@@ -88,9 +364,9 @@ var _ = Describe("Server", func() {
 
 		BeforeEach(func() {
 			resultsChan = make(chan transfer.TransferResults, 100)
-			fakeTransferReceiver.ReceiveTransferStub = func(_ io.ReadWriter) (
-				transfer.TransferResults, error,
-			) {
+			fakeTransferReceiver.ReceiveTransferStub = func(
+				_ context.Context, _ *logrus.Entry, _ io.ReadWriter,
+			) (transfer.TransferResults, error) {
 				return <-resultsChan, nil
 			}
 		})
@@ -100,7 +376,7 @@ var _ = Describe("Server", func() {
 		})
 
 		It("blocks until a transfer is handled by the server", func() {
-			conn, _ := net.Pipe()
+			conn, _ := connectFakeClient(clientIdentity)
 			listenerConnChan <- conn
 
 			receivedResultsChan := make(chan transfer.TransferResults, 100)
@@ -113,7 +389,120 @@ var _ = Describe("Server", func() {
 				Duration: time.Second,
 			}
 			resultsChan <- pushedResults
+
+			pushedResults.Source = clientIdentity
+			pushedResults.Destination = serverIdentity
+			pushedResults.Destination.Backends = []string{"fake"}
+			pushedResults.Backend = "fake"
 			Eventually(receivedResultsChan).Should(Receive(Equal(pushedResults)))
 		})
 	})
+
+	Describe("Drain", func() {
+		var (
+			pushedConn net.Conn
+			unblock    chan struct{}
+		)
+
+		BeforeEach(func() {
+			unblock = make(chan struct{})
+			fakeTransferReceiver.ReceiveTransferStub = func(
+				_ context.Context, _ *logrus.Entry, conn io.ReadWriter,
+			) (transfer.TransferResults, error) {
+				readErr := make(chan error, 1)
+				go func() {
+					_, err := conn.Read(make([]byte, 1))
+					readErr <- err
+				}()
+
+				select {
+				case <-unblock:
+					return transfer.TransferResults{}, nil
+				case err := <-readErr:
+					return transfer.TransferResults{}, err
+				}
+			}
+
+			pushedConn, _ = connectFakeClient(clientIdentity)
+		})
+
+		JustBeforeEach(func() {
+			listenerConnChan <- pushedConn
+			Eventually(fakeTransferReceiver.ReceiveTransferCallCount).Should(Equal(1))
+		})
+
+		It("should wait for the transfers in progress", func() {
+			drained := make(chan bool)
+			go func() {
+				drained <- server.Drain(time.Second)
+			}()
+			Consistently(drained).ShouldNot(Receive())
+
+			close(unblock)
+			Eventually(drained).Should(Receive(BeTrue()))
+		})
+
+		It("should abort the transfers that outlive the grace period", func() {
+			Expect(server.Drain(time.Millisecond * 100)).To(BeFalse())
+
+			_, err := pushedConn.Write([]byte("a"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject new transfers", func() {
+			close(unblock)
+			Expect(server.Drain(time.Second)).To(BeTrue())
+
+			conn, otherEnd := net.Pipe()
+			listenerConnChan <- conn
+
+			Eventually(func() error {
+				_, err := otherEnd.Write([]byte("a"))
+				return err
+			}).Should(HaveOccurred())
+			Expect(fakeTransferReceiver.ReceiveTransferCallCount()).To(Equal(1))
+		})
+	})
 })
+
+// connectFakeClient returns the server end of a connection from a client
+// that sends its identity and then hands its end of the connection over.
+func connectFakeClient(id transfer.Identity) (net.Conn, chan net.Conn) {
+	serverConn, clientConn := net.Pipe()
+	clientConns := make(chan net.Conn, 1)
+	go func() {
+		if err := transfer.WriteIdentity(clientConn, id); err != nil {
+			return
+		}
+		if _, err := transfer.ReadIdentity(clientConn); err != nil {
+			return
+		}
+		clientConns <- clientConn
+	}()
+
+	return serverConn, clientConns
+}
+
+// connectFakeClientWithBackend returns the server end of a connection from a
+// client that sends its identity, selects the backend and then hands its end
+// of the connection over.
+func connectFakeClientWithBackend(id transfer.Identity, backend string) (
+	net.Conn, chan net.Conn,
+) {
+	serverConn, clientConn := net.Pipe()
+	clientConns := make(chan net.Conn, 1)
+	go func() {
+		if err := transfer.WriteIdentity(clientConn, id); err != nil {
+			return
+		}
+		if _, err := transfer.ReadIdentity(clientConn); err != nil {
+			return
+		}
+		if err := transfer.WriteBackend(clientConn, backend); err != nil {
+			return
+		}
+		clientConns <- clientConn
+	}()
+
+	return serverConn, clientConns
+}
@@ -1,8 +1,12 @@
 package simple
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"hash/crc32"
 	"io"
+	"net"
 	"sync"
 	"time"
 
@@ -11,43 +15,49 @@ import (
 )
 
 type Receiver struct {
-	logger *logrus.Logger
+	cfg Config
 
-	isBusy   bool
-	isPaused bool
+	isBusy bool
+	// Interrupt calls that are not resumed yet
+	pauses int
 
 	stateMutex          *sync.Mutex
 	transferFinishMutex *sync.Mutex
 	transferFinish      *sync.Cond
 }
 
-func NewReceiver(logger *logrus.Logger) *Receiver {
+// NewReceiver creates a receiver that reads blocks of the buffer size. It
+// only discards the data with splice(2) when it skips the checksums.
+func NewReceiver(cfg Config) (*Receiver, error) {
+	if cfg.ZeroCopy && !zeroCopySupported {
+		return nil, errors.New("zero copy is only supported on linux")
+	}
+
 	transferFinishMutex := new(sync.Mutex)
 	return &Receiver{
-		logger: logger,
+		cfg: cfg,
 
-		isBusy:   false,
-		isPaused: false,
+		isBusy: false,
 
 		stateMutex:          new(sync.Mutex),
 		transferFinishMutex: transferFinishMutex,
 		transferFinish:      sync.NewCond(transferFinishMutex),
-	}
+	}, nil
 }
 
-func (r *Receiver) ReceiveTransfer(conn io.ReadWriter) (
-	transfer.TransferResults, error,
-) {
+func (r *Receiver) ReceiveTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
 	r.stateMutex.Lock()
-	isBusy := r.isPaused || r.isBusy
+	isBusy := r.pauses > 0 || r.isBusy
 	if !isBusy {
 		r.isBusy = true
 	}
 	r.stateMutex.Unlock()
 
 	if isBusy {
-		if err := r.handleBusy(conn); err != nil {
-			r.logger.Errorf("Failed to send busy message: %s", err)
+		if err := r.handleBusy(logger, conn); err != nil {
+			logger.Errorf("Failed to send busy message: %s", err)
 		}
 
 		return transfer.TransferResults{}, ErrBusy
@@ -60,12 +70,12 @@ func (r *Receiver) ReceiveTransfer(conn io.ReadWriter) (
 		r.stateMutex.Unlock()
 		r.transferFinish.Broadcast()
 	}()
-	return r.handleTransfer(conn)
+	return r.handleTransfer(ctx, logger, conn)
 }
 
 func (r *Receiver) Interrupt() {
 	r.stateMutex.Lock()
-	r.isPaused = true
+	r.pauses++
 	isBusy := r.isBusy
 	r.stateMutex.Unlock()
 
@@ -79,18 +89,20 @@ func (r *Receiver) Interrupt() {
 func (r *Receiver) Resume() {
 	r.stateMutex.Lock()
 	defer r.stateMutex.Unlock()
-	r.isPaused = false
+	if r.pauses > 0 {
+		r.pauses--
+	}
 }
 
 func (r *Receiver) IsBusy() bool {
 	r.stateMutex.Lock()
 	defer r.stateMutex.Unlock()
 
-	return r.isBusy || r.isPaused
+	return r.isBusy || r.pauses > 0
 }
 
-func (r *Receiver) handleBusy(conn io.ReadWriter) error {
-	r.logger.Debug("[SIMPLE] Server is busy!")
+func (r *Receiver) handleBusy(logger *logrus.Entry, conn io.ReadWriter) error {
+	logger.Debug("[SIMPLE] Server is busy!")
 	if _, err := conn.Write([]byte("i-am-busy")); err != nil {
 		return err
 	}
@@ -98,30 +110,66 @@ func (r *Receiver) handleBusy(conn io.ReadWriter) error {
 	return nil
 }
 
-func (r *Receiver) handleTransfer(conn io.ReadWriter) (
-	transfer.TransferResults, error,
-) {
-	r.logger.Debug("[SIMPLE] Handling the transfer...")
+func (r *Receiver) handleTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger.Debug("[SIMPLE] Handling the transfer...")
 	if _, err := conn.Write([]byte("ok")); err != nil {
 		return transfer.TransferResults{}, err
 	}
 
-	res := transfer.TransferResults{}
-	buffer := make([]byte, 1024)
+	var checksum uint32
+
+	buffer := make([]byte, r.cfg.bufferSize())
+	read := func() (int, error) {
+		n, err := conn.Read(buffer)
+		if !r.cfg.SkipChecksum {
+			checksum = crc32.Update(checksum, crc32.IEEETable, buffer[:n])
+		}
+
+		return n, err
+	}
+
+	if r.cfg.ZeroCopy && r.cfg.SkipChecksum {
+		if raw := rawConn(conn); raw != nil {
+			d, err := newDiscarder(len(buffer))
+			if err != nil {
+				// untested return
+				return transfer.TransferResults{}, fmt.Errorf(
+					"setting up zero copy: %s", err,
+				)
+			}
+			defer d.Close()
+
+			read = func() (int, error) {
+				return d.discard(raw)
+			}
+		} else {
+			logger.Debug("[SIMPLE] Connection does not support zero copy")
+		}
+	}
+
+	res := transfer.TransferResults{Backend: BackendName}
 
 	startTime := time.Now()
 	for {
-		n, err := conn.Read(buffer)
+		n, err := read()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return transfer.TransferResults{}, ctxErr
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return transfer.TransferResults{}, err
+		}
+
+		res.BytesSent += uint64(n)
 		if err != nil { // done reading
 			break
 		}
-
-		res.BytesSent += uint32(n)
-		res.Checksum = crc32.Update(res.Checksum, crc32.IEEETable, buffer)
 	}
 	endTime := time.Now()
 
 	res.Duration = endTime.Sub(startTime)
+	res.Checksum = checksum
 
 	return res, nil
 }
@@ -1,7 +1,9 @@
 package simple_test
 
 import (
+	"context"
 	"net"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/ice-stuff/clique/transfer"
@@ -26,8 +28,11 @@ var _ = Describe("Roundtrip", func() {
 			Formatter: new(logrus.TextFormatter),
 		}
 
-		receiver = simple.NewReceiver(logger)
-		sender = simple.NewSender(logger)
+		var err error
+		receiver, err = simple.NewReceiver(simple.Config{})
+		Expect(err).NotTo(HaveOccurred())
+		sender, err = simple.NewSender(logger, simple.Config{})
+		Expect(err).NotTo(HaveOccurred())
 
 		senderConn, receiverConn = net.Pipe()
 	})
@@ -51,7 +56,7 @@ var _ = Describe("Roundtrip", func() {
 
 		Describe("Receiver.ReceiveTransfer", func() {
 			It("returns an error", func() {
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).To(MatchError(ContainSubstring("on closed pipe")))
 			})
 		})
@@ -61,7 +66,7 @@ var _ = Describe("Roundtrip", func() {
 				spec := transfer.TransferSpec{
 					Size: 10 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, senderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).To(MatchError(ContainSubstring("on closed pipe")))
 			})
 		})
@@ -78,7 +83,7 @@ var _ = Describe("Roundtrip", func() {
 					spec := transfer.TransferSpec{
 						Size: 10 * 1024 * 1024,
 					}
-					_, err := sender.SendTransfer(spec, senderConn)
+					_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(senderConn.Close()).To(Succeed())
 
@@ -87,7 +92,7 @@ var _ = Describe("Roundtrip", func() {
 
 				Consistently(senderDone).ShouldNot(BeClosed())
 
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(senderDone).Should(BeClosed())
 
@@ -107,7 +112,7 @@ var _ = Describe("Roundtrip", func() {
 			go func() {
 				defer GinkgoRecover()
 				var err error
-				receiverRes, err = receiver.ReceiveTransfer(receiverConn)
+				receiverRes, err = receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).NotTo(HaveOccurred())
 				close(receiverDone)
 			}()
@@ -119,7 +124,7 @@ var _ = Describe("Roundtrip", func() {
 					Size: 10 * 1024 * 1024,
 				}
 
-				senderRes, err := sender.SendTransfer(spec, senderConn)
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 
@@ -128,12 +133,26 @@ var _ = Describe("Roundtrip", func() {
 				Expect(senderRes.BytesSent).To(Equal(receiverRes.BytesSent))
 			})
 
+			It("should report the backend on both ends", func() {
+				spec := transfer.TransferSpec{
+					Size: 1024,
+				}
+
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(senderConn.Close()).To(Succeed())
+
+				Eventually(receiverDone).Should(BeClosed())
+				Expect(senderRes.Backend).To(Equal(simple.BackendName))
+				Expect(receiverRes.Backend).To(Equal(simple.BackendName))
+			})
+
 			It("should have the same checksum with the receiver", func() {
 				spec := transfer.TransferSpec{
 					Size: 10 * 1024 * 1024,
 				}
 
-				senderRes, err := sender.SendTransfer(spec, senderConn)
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 
@@ -147,7 +166,7 @@ var _ = Describe("Roundtrip", func() {
 					Size: 10 * 1024 * 1024,
 				}
 
-				senderRes, err := sender.SendTransfer(spec, senderConn)
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 
@@ -157,6 +176,140 @@ var _ = Describe("Roundtrip", func() {
 					BeNumerically("~", receiverRes.Duration, 5000000),
 				) // +/- 5000000ns = 5ms
 			})
+
+			It("should not be throttled", func() {
+				spec := transfer.TransferSpec{
+					Size: 1024 * 1024,
+				}
+
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(senderConn.Close()).To(Succeed())
+
+				Eventually(receiverDone).Should(BeClosed())
+				Expect(senderRes.Throttled).To(BeFalse())
+			})
+
+			It("should send sizes that are not a multiple of the buffer size", func() {
+				spec := transfer.TransferSpec{
+					Size: simple.DefaultBufferSize + 1000,
+				}
+
+				senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(senderConn.Close()).To(Succeed())
+
+				Eventually(receiverDone).Should(BeClosed())
+				Expect(senderRes.BytesSent).To(Equal(spec.Size))
+				Expect(receiverRes.BytesSent).To(Equal(spec.Size))
+				Expect(senderRes.Checksum).To(Equal(receiverRes.Checksum))
+			})
+
+			Context("and the checksums are skipped", func() {
+				BeforeEach(func() {
+					var err error
+					sender, err = simple.NewSender(logger, simple.Config{
+						BufferSize:   4096,
+						SkipChecksum: true,
+					})
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("should not compute the checksum", func() {
+					spec := transfer.TransferSpec{
+						Size: 1024 * 1024,
+					}
+
+					senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+
+					Eventually(receiverDone).Should(BeClosed())
+					Expect(senderRes.BytesSent).To(Equal(spec.Size))
+					Expect(senderRes.Checksum).To(BeZero())
+				})
+			})
+
+			Context("and the sender is rate limited", func() {
+				BeforeEach(func() {
+					// 1MB/s
+					var err error
+					sender, err = simple.NewSender(logger, simple.Config{
+						RateLimit:           1024 * 1024,
+						MeasurementInterval: 100 * time.Millisecond,
+					})
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("should not exceed the rate limit", func() {
+					spec := transfer.TransferSpec{
+						Size: 256 * 1024,
+					}
+
+					senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+
+					Eventually(receiverDone).Should(BeClosed())
+					Expect(senderRes.BytesSent).To(Equal(spec.Size))
+					Expect(senderRes.Duration).To(
+						BeNumerically(">=", 250*time.Millisecond),
+					)
+					Expect(senderRes.Throttled).To(BeTrue())
+				})
+
+				It("should sample the bytes sent in every interval", func() {
+					spec := transfer.TransferSpec{
+						Size: 256 * 1024,
+					}
+
+					senderRes, err := sender.SendTransfer(context.Background(), spec, senderConn)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+					Eventually(receiverDone).Should(BeClosed())
+
+					Expect(len(senderRes.Intervals)).To(BeNumerically(">=", 2))
+					var bytesSent uint64
+					for i, interval := range senderRes.Intervals {
+						Expect(interval.Start).To(Equal(time.Duration(i) * 100 * time.Millisecond))
+						bytesSent += interval.Bytes
+					}
+					Expect(bytesSent).To(Equal(senderRes.BytesSent))
+				})
+
+				It("should stop when the context is done", func() {
+					spec := transfer.TransferSpec{
+						Size: 10 * 1024 * 1024,
+					}
+					ctx, cancel := context.WithTimeout(
+						context.Background(), 100*time.Millisecond,
+					)
+					defer cancel()
+
+					_, err := sender.SendTransfer(ctx, spec, senderConn)
+					Expect(err).To(Equal(context.DeadlineExceeded))
+					Expect(senderConn.Close()).To(Succeed())
+					Eventually(receiverDone).Should(BeClosed())
+				})
+
+				It("should report the bytes sent before the context is done", func() {
+					spec := transfer.TransferSpec{
+						Size: 10 * 1024 * 1024,
+					}
+					ctx, cancel := context.WithTimeout(
+						context.Background(), 100*time.Millisecond,
+					)
+					defer cancel()
+
+					senderRes, err := sender.SendTransfer(ctx, spec, senderConn)
+					Expect(err).To(HaveOccurred())
+					Expect(senderConn.Close()).To(Succeed())
+					Eventually(receiverDone).Should(BeClosed())
+
+					Expect(senderRes.BytesSent).To(BeNumerically(">", 0))
+					Expect(senderRes.BytesSent).To(BeNumerically("<", spec.Size))
+				})
+			})
 		})
 	})
 
@@ -167,7 +320,7 @@ var _ = Describe("Roundtrip", func() {
 			receiverDone = make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).NotTo(HaveOccurred())
 				close(receiverDone)
 			}()
@@ -177,7 +330,7 @@ var _ = Describe("Roundtrip", func() {
 		})
 
 		AfterEach(func() {
-			_, err := sender.SendTransfer(transfer.TransferSpec{
+			_, err := sender.SendTransfer(context.Background(), transfer.TransferSpec{
 				Size: 10 * 1024 * 1024,
 			}, senderConn)
 			Expect(err).NotTo(HaveOccurred())
@@ -194,12 +347,12 @@ var _ = Describe("Roundtrip", func() {
 					spec := transfer.TransferSpec{
 						Size: 10 * 1024 * 1024,
 					}
-					_, err := sender.SendTransfer(spec, newSenderConn)
+					_, err := sender.SendTransfer(context.Background(), spec, newSenderConn)
 					Expect(err).To(HaveOccurred())
 					close(newSenderDone)
 				}()
 
-				_, err := receiver.ReceiveTransfer(newReceiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), newReceiverConn)
 				Expect(err).To(Equal(simple.ErrBusy))
 				Eventually(newSenderDone).Should(BeClosed())
 
@@ -213,7 +366,7 @@ var _ = Describe("Roundtrip", func() {
 				newReceiverDone := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
-					_, err := receiver.ReceiveTransfer(newReceiverConn)
+					_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), newReceiverConn)
 					Expect(err).To(HaveOccurred())
 					close(newReceiverDone)
 				}()
@@ -221,7 +374,7 @@ var _ = Describe("Roundtrip", func() {
 				spec := transfer.TransferSpec{
 					Size: 10 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, newSenderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, newSenderConn)
 				Expect(err).To(Equal(simple.ErrBusy))
 				Eventually(newReceiverDone).Should(BeClosed())
 
@@ -244,12 +397,12 @@ var _ = Describe("Roundtrip", func() {
 					spec := transfer.TransferSpec{
 						Size: 10 * 1024 * 1024,
 					}
-					_, err := sender.SendTransfer(spec, senderConn)
+					_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 					Expect(err).To(HaveOccurred())
 					close(senderDone)
 				}()
 
-				_, err := receiver.ReceiveTransfer(receiverConn)
+				_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 				Expect(err).To(Equal(simple.ErrBusy))
 				Eventually(senderDone).Should(BeClosed())
 
@@ -263,7 +416,7 @@ var _ = Describe("Roundtrip", func() {
 				receiverDone := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
-					_, err := receiver.ReceiveTransfer(receiverConn)
+					_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 					Expect(err).To(HaveOccurred())
 					close(receiverDone)
 				}()
@@ -271,7 +424,7 @@ var _ = Describe("Roundtrip", func() {
 				spec := transfer.TransferSpec{
 					Size: 10 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, senderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).To(Equal(simple.ErrBusy))
 				Eventually(receiverDone).Should(BeClosed())
 
@@ -279,6 +432,20 @@ var _ = Describe("Roundtrip", func() {
 			}, 5.0)
 		})
 
+		Context("by more than one transfer", func() {
+			BeforeEach(func() {
+				receiver.Interrupt()
+			})
+
+			It("should stay busy until every interruption is resumed", func() {
+				receiver.Resume()
+				Expect(receiver.IsBusy()).To(BeTrue())
+
+				receiver.Resume()
+				Expect(receiver.IsBusy()).To(BeFalse())
+			})
+		})
+
 		Context("and then resumed", func() {
 			BeforeEach(func() {
 				receiver.Resume()
@@ -289,7 +456,7 @@ var _ = Describe("Roundtrip", func() {
 				receiverDone := make(chan struct{})
 				go func() {
 					defer GinkgoRecover()
-					_, err := receiver.ReceiveTransfer(receiverConn)
+					_, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), receiverConn)
 					Expect(err).NotTo(HaveOccurred())
 					close(receiverDone)
 				}()
@@ -297,7 +464,7 @@ var _ = Describe("Roundtrip", func() {
 				spec := transfer.TransferSpec{
 					Size: 10 * 1024 * 1024,
 				}
-				_, err := sender.SendTransfer(spec, senderConn)
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(senderConn.Close()).To(Succeed())
 				Eventually(receiverDone).Should(BeClosed())
@@ -306,4 +473,94 @@ var _ = Describe("Roundtrip", func() {
 			}, 5.0)
 		})
 	})
+
+	Context("when zero copy is enabled", func() {
+		var (
+			tcpSenderConn   net.Conn
+			tcpReceiverConn net.Conn
+			ctx             context.Context
+			cancel          context.CancelFunc
+		)
+
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+
+			accepted := make(chan net.Conn, 1)
+			go func() {
+				defer GinkgoRecover()
+				conn, err := listener.Accept()
+				Expect(err).NotTo(HaveOccurred())
+				accepted <- conn
+			}()
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel = context.WithCancel(context.Background())
+			tcpSenderConn = transfer.NewCtxConn(ctx, conn, time.Second)
+			tcpReceiverConn = transfer.NewCtxConn(ctx, <-accepted, time.Second)
+		})
+
+		AfterEach(func() {
+			cancel()
+			tcpSenderConn.Close()
+			tcpReceiverConn.Close()
+		})
+
+		roundtrip := func(cfg simple.Config, size uint64) (
+			transfer.TransferResults, transfer.TransferResults,
+		) {
+			cfg.ZeroCopy = true
+			zcSender, err := simple.NewSender(logger, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			zcReceiver, err := simple.NewReceiver(cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			var receiverRes transfer.TransferResults
+			receiverDone := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				var err error
+				receiverRes, err = zcReceiver.ReceiveTransfer(ctx, logrus.NewEntry(logger), tcpReceiverConn)
+				Expect(err).NotTo(HaveOccurred())
+				close(receiverDone)
+			}()
+
+			senderRes, err := zcSender.SendTransfer(
+				ctx, transfer.TransferSpec{Size: size}, tcpSenderConn,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tcpSenderConn.Close()).To(Succeed())
+			Eventually(receiverDone, 5.0).Should(BeClosed())
+
+			return senderRes, receiverRes
+		}
+
+		It("should send the requested number of bytes", func() {
+			senderRes, receiverRes := roundtrip(simple.Config{}, 10*1024*1024+1000)
+
+			Expect(senderRes.BytesSent).To(Equal(uint64(10*1024*1024 + 1000)))
+			Expect(receiverRes.BytesSent).To(Equal(senderRes.BytesSent))
+		})
+
+		It("should have the same checksum with the receiver", func() {
+			senderRes, receiverRes := roundtrip(simple.Config{}, 10*1024*1024)
+
+			Expect(senderRes.Checksum).NotTo(BeZero())
+			Expect(senderRes.Checksum).To(Equal(receiverRes.Checksum))
+		})
+
+		Context("and the checksums are skipped", func() {
+			It("should discard the received data", func() {
+				senderRes, receiverRes := roundtrip(
+					simple.Config{SkipChecksum: true}, 10*1024*1024,
+				)
+
+				Expect(receiverRes.BytesSent).To(Equal(senderRes.BytesSent))
+				Expect(receiverRes.Checksum).To(BeZero())
+			})
+		})
+	})
 })
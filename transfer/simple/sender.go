@@ -1,10 +1,14 @@
 package simple
 
 import (
+	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
+	"io/ioutil"
+	"os"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -13,32 +17,77 @@ import (
 
 type Sender struct {
 	logger *logrus.Logger
+	cfg    Config
+
+	// random data that every transfer repeats
+	payload []byte
+	// the payload on disk for sendfile(2); nil without zero copy
+	payloadFile *os.File
 }
 
-func NewSender(logger *logrus.Logger) *Sender {
-	return &Sender{
-		logger: logger,
+// NewSender creates a sender that repeats a random payload of the buffer
+// size. With zero copy the payload is also written to an unlinked temporary
+// file, which lives as long as the process.
+func NewSender(logger *logrus.Logger, cfg Config) (*Sender, error) {
+	if cfg.ZeroCopy && !zeroCopySupported {
+		return nil, errors.New("zero copy is only supported on linux")
 	}
-}
 
-func (s *Sender) SendTransfer(spec transfer.TransferSpec, conn io.ReadWriter) (
-	transfer.TransferResults, error,
-) {
-	s.logger.Debug("[SIMPLE] Sending a transfer...")
+	payload := make([]byte, cfg.bufferSize())
+	if _, err := rand.Read(payload); err != nil {
+		// untested return
+		return nil, fmt.Errorf("generating the payload: %s", err)
+	}
 
-	if err := s.handshake(conn); err != nil {
-		s.logger.Debugf("[SIMPLE] Handshake failed: %s", err)
-		return transfer.TransferResults{}, err
+	s := &Sender{
+		logger:  logger,
+		cfg:     cfg,
+		payload: payload,
+	}
+
+	if cfg.ZeroCopy {
+		payloadFile, err := writePayloadFile(payload)
+		if err != nil {
+			// untested return
+			return nil, fmt.Errorf("writing the payload: %s", err)
+		}
+		s.payloadFile = payloadFile
 	}
-	s.logger.Debug("[SIMPLE] Handshake went through!")
 
-	s.logger.Debug("[SIMPLE] About to run the test...")
-	randomData, err := s.randomBlock(1024)
+	return s, nil
+}
+
+func writePayloadFile(payload []byte) (*os.File, error) {
+	f, err := ioutil.TempFile("", "clique-payload")
 	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+
+	if _, err := f.Write(payload); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (s *Sender) SendTransfer(
+	ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger := s.logger.WithFields(transfer.LogFields(spec.ID, spec.Peer()))
+	logger.Debug("[SIMPLE] Sending a transfer...")
+
+	if err := s.handshake(conn); err != nil {
+		logger.Debugf("[SIMPLE] Handshake failed: %s", err)
 		return transfer.TransferResults{}, err
 	}
+	logger.Debug("[SIMPLE] Handshake went through!")
 
-	return s.sendData(conn, spec.Size, randomData)
+	logger.Debug("[SIMPLE] About to run the test...")
+	write := s.writer(logger, conn)
+
+	return s.sendData(ctx, write, spec.Size)
 }
 
 func (s *Sender) handshake(conn io.ReadWriter) error {
@@ -58,34 +107,98 @@ func (s *Sender) handshake(conn io.ReadWriter) error {
 	}
 }
 
-func (s *Sender) randomBlock(size uint16) ([]byte, error) {
-	randomData := make([]byte, size)
-	if _, err := rand.Read(randomData); err != nil {
-		return nil, err
+// writer returns the function that sends the first bytes of the payload.
+func (s *Sender) writer(
+	logger *logrus.Entry, conn io.ReadWriter,
+) func(count int) (int, error) {
+	if s.payloadFile != nil {
+		if raw := rawConn(conn); raw != nil {
+			return func(count int) (int, error) {
+				return sendfile(raw, s.payloadFile, count)
+			}
+		}
+
+		logger.Debug("[SIMPLE] Connection does not support zero copy")
 	}
 
-	return randomData, nil
+	return func(count int) (int, error) {
+		return conn.Write(s.payload[:count])
+	}
 }
 
-func (s *Sender) sendData(conn io.ReadWriter, size uint32, block []byte) (
-	transfer.TransferResults, error,
-) {
-	res := transfer.TransferResults{}
-	packetsAmt := uint32(size / 1024)
+func (s *Sender) sendData(
+	ctx context.Context, write func(count int) (int, error), size uint64,
+) (transfer.TransferResults, error) {
+	res := transfer.TransferResults{Backend: BackendName}
 
 	startTime := time.Now()
-	for i := uint32(0); i < packetsAmt; i++ {
-		n, err := conn.Write(block)
+	sampler := transfer.NewIntervalSampler(s.cfg.MeasurementInterval, startTime)
+	for res.BytesSent < size {
+		if err := ctx.Err(); err != nil {
+			return sentSoFar(res), err
+		}
+
+		count := len(s.payload)
+		if remaining := size - res.BytesSent; remaining < uint64(count) {
+			count = int(remaining)
+		}
+
+		n, err := write(count)
+		res.BytesSent += uint64(n)
 		if err != nil {
-			return transfer.TransferResults{}, err
+			return sentSoFar(res), err
+		}
+		sampler.Add(time.Now(), uint64(n))
+
+		if !s.cfg.SkipChecksum {
+			res.Checksum = crc32.Update(
+				res.Checksum, crc32.IEEETable, s.payload[:n],
+			)
 		}
-		res.BytesSent += uint32(n)
 
-		res.Checksum = crc32.Update(res.Checksum, crc32.IEEETable, block)
+		if ahead := s.aheadOfRate(res.BytesSent, time.Since(startTime)); ahead > 0 {
+			res.Throttled = true
+			if err := sleep(ctx, ahead); err != nil {
+				return sentSoFar(res), err
+			}
+		}
 	}
 	endTime := time.Now()
 
 	res.Duration = endTime.Sub(startTime)
+	res.Intervals = sampler.Intervals(endTime)
 
 	return res, nil
 }
+
+// sentSoFar returns the results of a transfer that failed, which only tell
+// how many bytes it sent.
+func sentSoFar(res transfer.TransferResults) transfer.TransferResults {
+	return transfer.TransferResults{BytesSent: res.BytesSent}
+}
+
+// aheadOfRate returns how much earlier than the rate limit allows the bytes
+// were sent.
+func (s *Sender) aheadOfRate(bytesSent uint64, elapsed time.Duration) time.Duration {
+	if s.cfg.RateLimit == 0 {
+		return 0
+	}
+
+	expected := time.Duration(
+		float64(bytesSent) / float64(s.cfg.RateLimit) * float64(time.Second),
+	)
+
+	return expected - elapsed
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
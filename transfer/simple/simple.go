@@ -1,5 +1,60 @@
 package simple
 
-import "errors"
+import (
+	"errors"
+	"syscall"
+	"time"
+)
 
 var ErrBusy = errors.New("server is busy")
+
+// BackendName names the simple transfers in the results.
+const BackendName = "simple"
+
+// DefaultBufferSize is the size of the blocks that are sent and received
+// when the configuration does not set one.
+const DefaultBufferSize = 128 * 1024
+
+// Config tunes the data path of the transfers.
+type Config struct {
+	// Size of the blocks that are sent and received. Default:
+	// DefaultBufferSize.
+	BufferSize int
+	// Send the payload with sendfile(2) and discard the received data with
+	// splice(2). Only supported on linux. The receiver still copies the data
+	// when it computes the checksum.
+	ZeroCopy bool
+	// Do not compute the checksums of the transfers. The checksum of the
+	// results is zero.
+	SkipChecksum bool
+
+	// Bytes per second that the sender may send. Unlimited when zero.
+	RateLimit uint64
+	// Interval of the throughput series of the sender. Nothing is sampled
+	// when zero.
+	MeasurementInterval time.Duration
+}
+
+func (c Config) bufferSize() int {
+	if c.BufferSize <= 0 {
+		return DefaultBufferSize
+	}
+
+	return c.BufferSize
+}
+
+// rawConn returns the raw connection for the zero copy calls, or nil when
+// the connection has no file descriptor, e.g. an in-memory pipe.
+func rawConn(conn interface{}) syscall.RawConn {
+	sysConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+
+	raw, err := sysConn.SyscallConn()
+	if err != nil {
+		return nil
+	}
+
+	return raw
+}
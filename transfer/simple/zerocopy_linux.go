@@ -0,0 +1,139 @@
+package simple
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const zeroCopySupported = true
+
+// sendfile sends `count` bytes of the payload from its beginning. The file
+// offset is not used, so concurrent transfers can share the payload.
+func sendfile(conn syscall.RawConn, payload *os.File, count int) (int, error) {
+	var (
+		written int
+		sendErr error
+		offset  int64
+	)
+
+	payloadFd := int(payload.Fd())
+	err := conn.Write(func(fd uintptr) bool {
+		for written < count {
+			n, err := syscall.Sendfile(int(fd), payloadFd, &offset, count-written)
+			if n > 0 {
+				written += n
+			}
+
+			switch {
+			case err == syscall.EAGAIN:
+				return false
+			case err == syscall.EINTR:
+				continue
+			case err != nil:
+				sendErr = err
+				return true
+			case n == 0:
+				sendErr = io.ErrUnexpectedEOF
+				return true
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return written, err
+	}
+
+	return written, sendErr
+}
+
+// discarder throws the received data away without copying it to the user
+// space, by splicing it through a pipe to /dev/null.
+type discarder struct {
+	pipe    [2]int
+	devNull *os.File
+	size    int
+}
+
+func newDiscarder(size int) (*discarder, error) {
+	d := &discarder{size: size}
+	if err := syscall.Pipe2(d.pipe[:], syscall.O_CLOEXEC); err != nil {
+		return nil, err
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		// untested return
+		d.closePipe()
+		return nil, err
+	}
+	d.devNull = devNull
+
+	return d, nil
+}
+
+// discard moves at most one buffer from the connection to /dev/null. It
+// returns io.EOF when the peer has closed the connection.
+func (d *discarder) discard(conn syscall.RawConn) (int, error) {
+	var (
+		n         int64
+		spliceErr error
+	)
+	err := conn.Read(func(fd uintptr) bool {
+		for {
+			n, spliceErr = syscall.Splice(
+				int(fd), nil, d.pipe[1], nil, d.size,
+				spliceFlagMove|spliceFlagNonblock,
+			)
+			if spliceErr == syscall.EINTR {
+				continue
+			}
+
+			return spliceErr != syscall.EAGAIN
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if spliceErr != nil {
+		return 0, spliceErr
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	// the pipe is drained before the next call, so it never fills up
+	for drained := int64(0); drained < n; {
+		m, err := syscall.Splice(
+			d.pipe[0], nil, int(d.devNull.Fd()), nil, int(n-drained),
+			spliceFlagMove,
+		)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			// untested return
+			return int(drained), err
+		}
+		drained += m
+	}
+
+	return int(n), nil
+}
+
+func (d *discarder) Close() error {
+	d.closePipe()
+	return d.devNull.Close()
+}
+
+func (d *discarder) closePipe() {
+	syscall.Close(d.pipe[0])
+	syscall.Close(d.pipe[1])
+}
+
+// from <fcntl.h>; the syscall package does not define them
+const (
+	spliceFlagMove     = 0x1
+	spliceFlagNonblock = 0x2
+)
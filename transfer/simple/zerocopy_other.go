@@ -0,0 +1,31 @@
+// +build !linux
+
+package simple
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const zeroCopySupported = false
+
+var errZeroCopyUnsupported = errors.New("zero copy is only supported on linux")
+
+func sendfile(conn syscall.RawConn, payload *os.File, count int) (int, error) {
+	return 0, errZeroCopyUnsupported
+}
+
+type discarder struct{}
+
+func newDiscarder(size int) (*discarder, error) {
+	return nil, errZeroCopyUnsupported
+}
+
+func (d *discarder) discard(conn syscall.RawConn) (int, error) {
+	return 0, errZeroCopyUnsupported
+}
+
+func (d *discarder) Close() error {
+	return nil
+}
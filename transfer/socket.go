@@ -0,0 +1,109 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// SocketOptions tune the sockets of the transfers. The zero value keeps the
+// defaults of the system.
+type SocketOptions struct {
+	// SO_SNDBUF and SO_RCVBUF in bytes. The system default is used when they
+	// are zero.
+	SendBuffer    int
+	ReceiveBuffer int
+	// Disable Nagle's algorithm (TCP_NODELAY).
+	NoDelay bool
+	// TCP congestion control algorithm, e.g. `cubic` or `bbr`. The system
+	// default is used when it is empty.
+	CongestionControl string
+}
+
+// Control sets the options on a socket before it connects or listens, so
+// that the buffer sizes are taken into account by the window scaling. The
+// accepted connections inherit them from the listener.
+func (o SocketOptions) Control(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = o.setSockopts(int(fd))
+	})
+	if err != nil {
+		// untested return
+		return err
+	}
+
+	return sockErr
+}
+
+func (o SocketOptions) setSockopts(fd int) error {
+	if o.SendBuffer > 0 {
+		err := syscall.SetsockoptInt(
+			fd, syscall.SOL_SOCKET, syscall.SO_SNDBUF, o.SendBuffer,
+		)
+		if err != nil {
+			return fmt.Errorf("setting the send buffer: %s", err)
+		}
+	}
+
+	if o.ReceiveBuffer > 0 {
+		err := syscall.SetsockoptInt(
+			fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, o.ReceiveBuffer,
+		)
+		if err != nil {
+			return fmt.Errorf("setting the receive buffer: %s", err)
+		}
+	}
+
+	if o.CongestionControl != "" {
+		if err := setCongestionControl(fd, o.CongestionControl); err != nil {
+			return fmt.Errorf(
+				"setting the congestion control `%s`: %s", o.CongestionControl, err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// Tune sets the options that Go overrides when it establishes a connection.
+func (o SocketOptions) Tune(conn net.Conn) error {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	return tcpConn.SetNoDelay(o.NoDelay)
+}
+
+// Listen listens for transfers on a TCP address with the socket options.
+func Listen(address string, opts SocketOptions) (net.Listener, error) {
+	listenConfig := net.ListenConfig{Control: opts.Control}
+	listener, err := listenConfig.Listen(context.Background(), "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tunedListener{Listener: listener, opts: opts}, nil
+}
+
+type tunedListener struct {
+	net.Listener
+	opts SocketOptions
+}
+
+func (l *tunedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.opts.Tune(conn); err != nil {
+		// untested return
+		conn.Close()
+		return nil, fmt.Errorf("tuning the connection: %s", err)
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,15 @@
+package transfer
+
+import "syscall"
+
+func setCongestionControl(fd int, algorithm string) error {
+	return syscall.SetsockoptString(
+		fd, syscall.IPPROTO_TCP, syscall.TCP_CONGESTION, algorithm,
+	)
+}
+
+func bindToDevice(fd int, iface string) error {
+	return syscall.SetsockoptString(
+		fd, syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface,
+	)
+}
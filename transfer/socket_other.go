@@ -0,0 +1,13 @@
+// +build !linux
+
+package transfer
+
+import "errors"
+
+func setCongestionControl(fd int, algorithm string) error {
+	return errors.New("only supported on linux")
+}
+
+func bindToDevice(fd int, iface string) error {
+	return errors.New("only supported on linux")
+}
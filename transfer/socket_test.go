@@ -0,0 +1,105 @@
+package transfer_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/ice-stuff/clique/testhelpers"
+	"github.com/ice-stuff/clique/transfer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SocketOptions", func() {
+	var (
+		opts       transfer.SocketOptions
+		randomPort uint16
+		listener   net.Listener
+	)
+
+	getsockopt := func(conn net.Conn, level, opt int) int {
+		rawConn, err := conn.(syscall.Conn).SyscallConn()
+		Expect(err).NotTo(HaveOccurred())
+
+		var (
+			value   int
+			sockErr error
+		)
+		Expect(rawConn.Control(func(fd uintptr) {
+			value, sockErr = syscall.GetsockoptInt(int(fd), level, opt)
+		})).To(Succeed())
+		Expect(sockErr).NotTo(HaveOccurred())
+
+		return value
+	}
+
+	BeforeEach(func() {
+		opts = transfer.SocketOptions{
+			SendBuffer:    256 * 1024,
+			ReceiveBuffer: 512 * 1024,
+			NoDelay:       false,
+		}
+		randomPort = testhelpers.SelectPort(GinkgoParallelNode())
+
+		var err error
+		listener, err = transfer.Listen(
+			fmt.Sprintf("127.0.0.1:%d", randomPort), opts,
+		)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(listener.Close()).To(Succeed())
+	})
+
+	It("should tune the outgoing and the accepted connections", func() {
+		acceptedConns := make(chan net.Conn, 1)
+		go func() {
+			defer GinkgoRecover()
+
+			conn, err := listener.Accept()
+			Expect(err).NotTo(HaveOccurred())
+			acceptedConns <- conn
+		}()
+
+		conn, err := transfer.NewConnector(opts).Connect(
+			context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+			transfer.Source{},
+		)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		var acceptedConn net.Conn
+		Eventually(acceptedConns).Should(Receive(&acceptedConn))
+		defer acceptedConn.Close()
+
+		for _, c := range []net.Conn{conn, acceptedConn} {
+			// the kernel doubles the requested sizes for its bookkeeping
+			Expect(getsockopt(c, syscall.SOL_SOCKET, syscall.SO_SNDBUF)).To(
+				BeNumerically(">=", opts.SendBuffer),
+			)
+			Expect(getsockopt(c, syscall.SOL_SOCKET, syscall.SO_RCVBUF)).To(
+				BeNumerically(">=", opts.ReceiveBuffer),
+			)
+			Expect(getsockopt(c, syscall.IPPROTO_TCP, syscall.TCP_NODELAY)).To(
+				BeZero(),
+			)
+		}
+	})
+
+	Context("when the congestion control algorithm is unknown", func() {
+		BeforeEach(func() {
+			opts.CongestionControl = "banana"
+		})
+
+		It("should fail to connect", func() {
+			_, err := transfer.NewConnector(opts).Connect(
+				context.Background(), net.ParseIP("127.0.0.1"), randomPort,
+				transfer.Source{},
+			)
+			Expect(err).To(MatchError(ContainSubstring("congestion control `banana`")))
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package transfer
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// Source selects the local end of the outgoing transfers on multi-homed
+// hosts. The zero value lets the routing table of the system pick it.
+type Source struct {
+	// Local address that the connections bind to
+	IP net.IP
+	// Network interface that the connections go through, e.g. `eth1`. Linux
+	// only.
+	Interface string
+}
+
+// LocalIP returns the address that the connections to the remote address
+// bind to: the source IP, or the first address of the source interface in
+// the family of the remote address. The link-local addresses of the
+// interface only reach the link-local remote addresses. It is nil when the
+// system picks it.
+func (s Source) LocalIP(remote net.IP) (net.IP, error) {
+	if s.IP != nil || s.Interface == "" {
+		return s.IP, nil
+	}
+
+	iface, err := net.InterfaceByName(s.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		// untested return
+		return nil, fmt.Errorf("listing the addresses of `%s`: %s", s.Interface, err)
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		if ipNet.IP.IsLinkLocalUnicast() && !remote.IsLinkLocalUnicast() {
+			continue
+		}
+		if (ipNet.IP.To4() == nil) == (remote.To4() == nil) {
+			return ipNet.IP, nil
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"interface `%s` has no address in the family of %s", s.Interface, remote,
+	)
+}
+
+// Control binds a socket to the source interface before it connects.
+func (s Source) Control(network, address string, c syscall.RawConn) error {
+	if s.Interface == "" {
+		return nil
+	}
+
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = bindToDevice(int(fd), s.Interface)
+	})
+	if err != nil {
+		// untested return
+		return err
+	}
+	if sockErr != nil {
+		return fmt.Errorf("binding to the interface `%s`: %s", s.Interface, sockErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package transfer
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// TCPInfo is a sample of the kernel statistics of a connection (TCP_INFO).
+type TCPInfo struct {
+	// Offset from the start of the transfer
+	Offset time.Duration
+	// Segments that were retransmitted since the connection was established
+	Retransmits uint32
+	// Congestion window in segments
+	CongestionWindow uint32
+	// Smoothed round trip time and its variance
+	RTT    time.Duration
+	RTTVar time.Duration
+	// Bytes per second
+	PacingRate   uint64
+	DeliveryRate uint64
+}
+
+// tcpInfoSampler reads the statistics of a connection periodically while a
+// transfer is running.
+type tcpInfoSampler struct {
+	conn      net.Conn
+	startTime time.Time
+
+	samples []TCPInfo
+	lock    sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startTCPInfoSampler samples the connection every interval. It samples
+// nothing when the interval is zero or the system does not report the
+// statistics of the connection.
+func startTCPInfoSampler(conn net.Conn, interval time.Duration) *tcpInfoSampler {
+	s := &tcpInfoSampler{
+		conn:      conn,
+		startTime: time.Now(),
+
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if interval <= 0 {
+		close(s.done)
+		return s
+	}
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if info := s.sample(); info != nil {
+					s.lock.Lock()
+					s.samples = append(s.samples, *info)
+					s.lock.Unlock()
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// Stop takes the last sample and returns it along with the periodic ones,
+// which do not include it. The last sample is nil when nothing was sampled.
+func (s *tcpInfoSampler) Stop() ([]TCPInfo, *TCPInfo) {
+	select {
+	case <-s.done:
+		// sampling is disabled
+		return nil, nil
+	default:
+	}
+
+	close(s.stop)
+	<-s.done
+
+	last := s.sample()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.samples, last
+}
+
+func (s *tcpInfoSampler) sample() *TCPInfo {
+	info, err := ReadTCPInfo(s.conn)
+	if err != nil {
+		return nil
+	}
+	info.Offset = time.Since(s.startTime)
+
+	return &info
+}
@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// rawTCPInfo is the beginning of the `struct tcp_info` of linux. The
+// syscall package stops before the pacing and the delivery rate. Older
+// kernels fill in fewer fields and leave the rest zero.
+type rawTCPInfo struct {
+	State       uint8
+	CAState     uint8
+	Retransmits uint8
+	Probes      uint8
+	Backoff     uint8
+	Options     uint8
+	WScale      uint8
+	AppLimited  uint8
+
+	RTO    uint32
+	ATO    uint32
+	SndMSS uint32
+	RcvMSS uint32
+
+	Unacked uint32
+	Sacked  uint32
+	Lost    uint32
+	Retrans uint32
+	Fackets uint32
+
+	LastDataSent uint32
+	LastAckSent  uint32
+	LastDataRecv uint32
+	LastAckRecv  uint32
+
+	PMTU        uint32
+	RcvSsthresh uint32
+	RTT         uint32
+	RTTVar      uint32
+	SndSsthresh uint32
+	SndCwnd     uint32
+	AdvMSS      uint32
+	Reordering  uint32
+
+	RcvRTT   uint32
+	RcvSpace uint32
+
+	TotalRetrans uint32
+
+	PacingRate    uint64
+	MaxPacingRate uint64
+	BytesAcked    uint64
+	BytesReceived uint64
+	SegsOut       uint32
+	SegsIn        uint32
+
+	NotsentBytes uint32
+	MinRTT       uint32
+	DataSegsIn   uint32
+	DataSegsOut  uint32
+
+	DeliveryRate uint64
+}
+
+// ReadTCPInfo returns the current statistics of a TCP connection.
+func ReadTCPInfo(conn net.Conn) (TCPInfo, error) {
+	sysConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return TCPInfo{}, errors.New("not a system connection")
+	}
+
+	rawConn, err := sysConn.SyscallConn()
+	if err != nil {
+		return TCPInfo{}, err
+	}
+
+	var (
+		raw     rawTCPInfo
+		sockErr error
+	)
+	err = rawConn.Control(func(fd uintptr) {
+		size := uint32(unsafe.Sizeof(raw))
+		_, _, errno := syscall.Syscall6(
+			syscall.SYS_GETSOCKOPT, fd,
+			syscall.IPPROTO_TCP, syscall.TCP_INFO,
+			uintptr(unsafe.Pointer(&raw)), uintptr(unsafe.Pointer(&size)), 0,
+		)
+		if errno != 0 {
+			sockErr = errno
+		}
+	})
+	if err != nil {
+		return TCPInfo{}, err
+	}
+	if sockErr != nil {
+		return TCPInfo{}, sockErr
+	}
+
+	return TCPInfo{
+		Retransmits:      raw.TotalRetrans,
+		CongestionWindow: raw.SndCwnd,
+		RTT:              time.Duration(raw.RTT) * time.Microsecond,
+		RTTVar:           time.Duration(raw.RTTVar) * time.Microsecond,
+		PacingRate:       raw.PacingRate,
+		DeliveryRate:     raw.DeliveryRate,
+	}, nil
+}
@@ -0,0 +1,13 @@
+// +build !linux
+
+package transfer
+
+import (
+	"errors"
+	"net"
+)
+
+// ReadTCPInfo returns the current statistics of a TCP connection.
+func ReadTCPInfo(conn net.Conn) (TCPInfo, error) {
+	return TCPInfo{}, errors.New("only supported on linux")
+}
@@ -1,21 +1,134 @@
 package transfer
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"net"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Sirupsen/logrus"
 )
 
 type TransferSpec struct {
+	ID string
+	// Hostname or address of the peer; the IP is used when it is empty.
+	Host string
+	// Address that the transfer connects to
 	IP   net.IP
 	Port uint16
-	Size uint32
+	Size uint64
+	// Local end of the transfer
+	Source Source
+	// Requested backend; the default one is used when it is empty or the
+	// peer does not support it.
+	Backend string
+}
+
+// Peer returns the identity of the peer, which does not change when its
+// hostname resolves to a different address.
+func (s TransferSpec) Peer() string {
+	host := s.Host
+	if host == "" {
+		host = s.IP.String()
+	}
+
+	return PeerName(host, s.Port)
+}
+
+// PeerName returns the identity of the peer at the host and port. IP
+// addresses are written in their canonical form and hostnames in lower case,
+// so that every spelling of a peer has the same identity.
+func PeerName(host string, port uint16) string {
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	} else {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+	}
+
+	return net.JoinHostPort(host, fmt.Sprintf("%d", port))
+}
+
+// ParsePeer splits the `host:port` address of a peer, where the host is a
+// hostname or an IPv4 or IPv6 address; IPv6 addresses are in brackets.
+func ParsePeer(addr string) (string, uint16, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid address `%s`: %s", addr, err)
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("invalid address `%s`: missing host", addr)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return "", 0, fmt.Errorf("invalid port in address `%s`", addr)
+	}
+
+	return host, uint16(port), nil
 }
 
 type TransferResults struct {
 	Duration  time.Duration
 	Checksum  uint32
-	BytesSent uint32
+	BytesSent uint64
 	RTT       time.Duration
+	// The sender slowed down to respect its rate limit.
+	Throttled bool
+	// Datagrams or messages of the backends that count them, the ones of them
+	// that the receiver did not get and the variation of their delay.
+	Packets     uint64
+	LostPackets uint64
+	Jitter      time.Duration
+	// Segments that the sender retransmitted and the CPU utilisation of the
+	// agents in percent, as reported by the backends that measure them
+	Retransmits    uint64
+	SourceCPU      float64
+	DestinationCPU float64
+	// Bytes sent in every measurement interval; empty when the backend does
+	// not sample the transfer.
+	Intervals []Interval
+	// Statistics of the connection at the end of the transfer and during it;
+	// empty when they are not sampled.
+	TCPInfo        *TCPInfo
+	TCPInfoSamples []TCPInfo
+	// Sending and receiving agents
+	Source      Identity
+	Destination Identity
+	// Backend that made the transfer, e.g. `simple` or `iperf`
+	Backend string
+}
+
+// NewTransferID returns a random identifier to correlate the log lines of a
+// transfer.
+func NewTransferID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Errorf("cannot generate transfer id: %s", err))
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// LogFields returns the fields that every transfer-related log line carries.
+func LogFields(id, peer string) logrus.Fields {
+	return logrus.Fields{
+		"transfer_id": id,
+		"peer":        peer,
+	}
+}
+
+// PeerAddress returns the remote address of a connection or an empty string
+// if it is not a network connection.
+func PeerAddress(conn io.ReadWriter) string {
+	if netConn, ok := conn.(net.Conn); ok && netConn.RemoteAddr() != nil {
+		return netConn.RemoteAddr().String()
+	}
+
+	return ""
 }
 
 // Only for testing
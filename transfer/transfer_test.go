@@ -0,0 +1,70 @@
+package transfer_test
+
+import (
+	"net"
+
+	"github.com/ice-stuff/clique/transfer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TransferSpec", func() {
+	Describe("Peer", func() {
+		It("should identify the peer by its IP", func() {
+			spec := transfer.TransferSpec{IP: net.ParseIP("10.0.0.1"), Port: 5000}
+			Expect(spec.Peer()).To(Equal("10.0.0.1:5000"))
+		})
+
+		It("should identify the peer by its host when there is one", func() {
+			spec := transfer.TransferSpec{
+				Host: "peer.example.com",
+				IP:   net.ParseIP("10.0.0.1"),
+				Port: 5000,
+			}
+			Expect(spec.Peer()).To(Equal("peer.example.com:5000"))
+		})
+	})
+})
+
+var _ = Describe("PeerName", func() {
+	It("should bracket the IPv6 addresses", func() {
+		Expect(transfer.PeerName("fe80:0::1", 5000)).To(Equal("[fe80::1]:5000"))
+	})
+
+	It("should lower-case the hostnames", func() {
+		Expect(transfer.PeerName("Peer.Example.COM.", 5000)).To(
+			Equal("peer.example.com:5000"),
+		)
+	})
+})
+
+var _ = Describe("ParsePeer", func() {
+	It("should parse hostnames", func() {
+		host, port, err := transfer.ParsePeer("peer.example.com:5000")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(host).To(Equal("peer.example.com"))
+		Expect(port).To(Equal(uint16(5000)))
+	})
+
+	It("should parse IPv6 addresses", func() {
+		host, port, err := transfer.ParsePeer("[fe80::1]:50000")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(host).To(Equal("fe80::1"))
+		Expect(port).To(Equal(uint16(50000)))
+	})
+
+	It("should reject the addresses without a port", func() {
+		_, _, err := transfer.ParsePeer("peer.example.com")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject the invalid ports", func() {
+		_, _, err := transfer.ParsePeer("peer.example.com:70000")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject the addresses without a host", func() {
+		_, _, err := transfer.ParsePeer(":5000")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,219 @@
+package udp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+type Receiver struct {
+	isBusy bool
+	// Interrupt calls that are not resumed yet
+	pauses int
+
+	stateMutex          *sync.Mutex
+	transferFinishMutex *sync.Mutex
+	transferFinish      *sync.Cond
+}
+
+func NewReceiver() *Receiver {
+	transferFinishMutex := new(sync.Mutex)
+	return &Receiver{
+		stateMutex:          new(sync.Mutex),
+		transferFinishMutex: transferFinishMutex,
+		transferFinish:      sync.NewCond(transferFinishMutex),
+	}
+}
+
+func (r *Receiver) ReceiveTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	r.stateMutex.Lock()
+	isBusy := r.pauses > 0 || r.isBusy
+	if !isBusy {
+		r.isBusy = true
+	}
+	r.stateMutex.Unlock()
+
+	if isBusy {
+		logger.Debug("[UDP] Server is busy!")
+		if _, err := conn.Write([]byte("i-am-busy")); err != nil {
+			logger.Errorf("Failed to send busy message: %s", err)
+		}
+
+		return transfer.TransferResults{}, ErrBusy
+	}
+
+	defer func() {
+		// reset state
+		r.stateMutex.Lock()
+		r.isBusy = false
+		r.stateMutex.Unlock()
+		r.transferFinish.Broadcast()
+	}()
+	return r.handleTransfer(ctx, logger, conn)
+}
+
+func (r *Receiver) Interrupt() {
+	r.stateMutex.Lock()
+	r.pauses++
+	isBusy := r.isBusy
+	r.stateMutex.Unlock()
+
+	if isBusy {
+		r.transferFinishMutex.Lock()
+		defer r.transferFinishMutex.Unlock()
+		r.transferFinish.Wait()
+	}
+}
+
+func (r *Receiver) Resume() {
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+	if r.pauses > 0 {
+		r.pauses--
+	}
+}
+
+func (r *Receiver) IsBusy() bool {
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+
+	return r.isBusy || r.pauses > 0
+}
+
+// handleTransfer opens a UDP port on the local address of the transfer
+// connection, announces it and counts the datagrams until the sender is done.
+func (r *Receiver) handleTransfer(
+	ctx context.Context, logger *logrus.Entry, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger.Debug("[UDP] Handling the transfer...")
+
+	laddr := &net.UDPAddr{}
+	if netConn, ok := conn.(net.Conn); ok {
+		if tcpAddr, ok := netConn.LocalAddr().(*net.TCPAddr); ok {
+			laddr.IP = tcpAddr.IP
+		}
+	}
+	udpConn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		// untested return
+		return transfer.TransferResults{}, fmt.Errorf(
+			"opening the udp port: %s", err,
+		)
+	}
+	defer udpConn.Close()
+
+	port := udpConn.LocalAddr().(*net.UDPAddr).Port
+	if _, err := conn.Write([]byte(fmt.Sprintf("ok - %d", port))); err != nil {
+		return transfer.TransferResults{}, err
+	}
+
+	stats := new(streamStats)
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		stats.read(udpConn)
+	}()
+
+	var sent uint64
+	for {
+		msgType, n, err := readProgress(conn)
+		if err != nil {
+			udpConn.Close()
+			<-readerDone
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return transfer.TransferResults{}, ctxErr
+			}
+
+			return transfer.TransferResults{}, err
+		}
+
+		sent = n
+		if msgType == msgEnd {
+			break
+		}
+	}
+
+	udpConn.SetReadDeadline(time.Now().Add(drainTimeout))
+	<-readerDone
+
+	rep := stats.report(sent)
+	if err := writeReport(conn, rep); err != nil {
+		return transfer.TransferResults{}, err
+	}
+
+	return transfer.TransferResults{
+		Backend:     BackendName,
+		BytesSent:   rep.Bytes,
+		Duration:    stats.lastArrival.Sub(stats.firstArrival),
+		Packets:     rep.Packets,
+		LostPackets: rep.Lost,
+		Jitter:      time.Duration(rep.Jitter),
+	}, nil
+}
+
+// streamStats are the statistics of the received datagrams. The jitter is
+// the smoothed variation of their transit time (RFC 3550), which does not
+// depend on the offset between the clocks of the agents.
+type streamStats struct {
+	packets      uint64
+	bytes        uint64
+	jitter       float64
+	lastTransit  time.Duration
+	firstArrival time.Time
+	lastArrival  time.Time
+}
+
+// read counts the datagrams until the connection fails, e.g. because it is
+// closed or its deadline passes.
+func (s *streamStats) read(conn *net.UDPConn) {
+	datagram := make([]byte, MaxDatagramSize)
+	for {
+		n, err := conn.Read(datagram)
+		if err != nil {
+			return
+		}
+		if n < HeaderSize {
+			continue
+		}
+
+		arrival := time.Now()
+		sentAt := time.Unix(0, int64(binary.BigEndian.Uint64(datagram[8:])))
+		transit := arrival.Sub(sentAt)
+		if s.packets == 0 {
+			s.firstArrival = arrival
+		} else {
+			d := transit - s.lastTransit
+			if d < 0 {
+				d = -d
+			}
+			s.jitter += (float64(d) - s.jitter) / 16
+		}
+
+		s.packets++
+		s.bytes += uint64(n)
+		s.lastTransit = transit
+		s.lastArrival = arrival
+	}
+}
+
+func (s *streamStats) report(sent uint64) report {
+	rep := report{
+		Packets: s.packets,
+		Bytes:   s.bytes,
+		Jitter:  int64(s.jitter),
+	}
+	if sent > s.packets {
+		rep.Lost = sent - s.packets
+	}
+
+	return rep
+}
@@ -0,0 +1,192 @@
+package udp_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+	"github.com/ice-stuff/clique/transfer/udp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Roundtrip", func() {
+	var (
+		logger       *logrus.Logger
+		receiver     *udp.Receiver
+		senderConn   net.Conn
+		receiverConn net.Conn
+		spec         transfer.TransferSpec
+
+		receiverRes  chan transfer.TransferResults
+		receiverErrs chan error
+	)
+
+	BeforeEach(func() {
+		logger = &logrus.Logger{
+			Out:       GinkgoWriter,
+			Level:     logrus.DebugLevel,
+			Formatter: new(logrus.TextFormatter),
+		}
+
+		receiver = udp.NewReceiver()
+		senderConn, receiverConn = tcpPipe()
+		spec = transfer.TransferSpec{
+			IP:   net.ParseIP("127.0.0.1"),
+			Size: 100 * udp.DefaultDatagramSize,
+		}
+
+		receiverRes = make(chan transfer.TransferResults, 1)
+		receiverErrs = make(chan error, 1)
+	})
+
+	JustBeforeEach(func() {
+		go func(
+			receiver *udp.Receiver, conn net.Conn,
+			resChan chan transfer.TransferResults, errChan chan error,
+		) {
+			res, err := receiver.ReceiveTransfer(context.Background(), logrus.NewEntry(logger), conn)
+			resChan <- res
+			errChan <- err
+		}(receiver, receiverConn, receiverRes, receiverErrs)
+	})
+
+	AfterEach(func() {
+		senderConn.Close()
+		receiverConn.Close()
+	})
+
+	It("should send the size of the transfer in datagrams", func() {
+		sender := udp.NewSender(logger, udp.Config{Rate: 10 * 1024 * 1024})
+
+		res, err := sender.SendTransfer(context.Background(), spec, senderConn)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(res.Backend).To(Equal("udp"))
+		Expect(res.Packets).To(Equal(uint64(100)))
+		Expect(res.BytesSent).To(Equal(spec.Size))
+		Expect(res.LostPackets).To(BeZero())
+		Expect(res.Duration).NotTo(BeZero())
+	})
+
+	It("should count the received datagrams", func() {
+		sender := udp.NewSender(logger, udp.Config{Rate: 10 * 1024 * 1024})
+
+		_, err := sender.SendTransfer(context.Background(), spec, senderConn)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(receiverErrs).Should(Receive(BeNil()))
+		var res transfer.TransferResults
+		Expect(receiverRes).To(Receive(&res))
+		Expect(res.Backend).To(Equal("udp"))
+		Expect(res.Packets).To(Equal(uint64(100)))
+		Expect(res.BytesSent).To(Equal(spec.Size))
+		Expect(res.LostPackets).To(BeZero())
+	})
+
+	It("should pace the datagrams to the rate", func() {
+		sender := udp.NewSender(logger, udp.Config{
+			Rate:         100 * 1000, // 100 datagrams per second
+			DatagramSize: 1000,
+		})
+		spec.Size = 11 * 1000
+
+		res, err := sender.SendTransfer(context.Background(), spec, senderConn)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(res.Packets).To(Equal(uint64(11)))
+		Expect(res.Duration).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+
+	Context("when the receiver is interrupted", func() {
+		BeforeEach(func() {
+			receiver.Interrupt()
+		})
+
+		It("should be busy", func() {
+			sender := udp.NewSender(logger, udp.Config{})
+
+			_, err := sender.SendTransfer(context.Background(), spec, senderConn)
+			Expect(err).To(Equal(udp.ErrBusy))
+			Eventually(receiverErrs).Should(Receive(Equal(udp.ErrBusy)))
+		})
+
+		Context("and resumed", func() {
+			BeforeEach(func() {
+				receiver.Resume()
+			})
+
+			It("should receive the transfer", func() {
+				sender := udp.NewSender(logger, udp.Config{Rate: 10 * 1024 * 1024})
+
+				_, err := sender.SendTransfer(context.Background(), spec, senderConn)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when datagrams are lost", func() {
+		It("should report them to the sender", func() {
+			var port int
+			msg := make([]byte, 16)
+			n, err := senderConn.Read(msg)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fmt.Sscanf(string(msg[:n]), "ok - %d", &port)
+			Expect(err).NotTo(HaveOccurred())
+
+			udpConn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+			Expect(err).NotTo(HaveOccurred())
+			defer udpConn.Close()
+			for seq := 0; seq < 6; seq += 2 {
+				datagram := make([]byte, 100)
+				binary.BigEndian.PutUint64(datagram, uint64(seq))
+				binary.BigEndian.PutUint64(datagram[8:], uint64(time.Now().UnixNano()))
+				_, err := udpConn.Write(datagram)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			// the end of the transfer after 6 datagrams
+			end := make([]byte, 9)
+			end[0] = 2
+			binary.BigEndian.PutUint64(end[1:], 6)
+			_, err = senderConn.Write(end)
+			Expect(err).NotTo(HaveOccurred())
+
+			report := make([]uint64, 4)
+			Expect(binary.Read(senderConn, binary.BigEndian, report)).To(Succeed())
+			Expect(report[0]).To(Equal(uint64(3)))   // packets
+			Expect(report[1]).To(Equal(uint64(300))) // bytes
+			Expect(report[2]).To(Equal(uint64(3)))   // lost
+
+			var res transfer.TransferResults
+			Eventually(receiverRes).Should(Receive(&res))
+			Expect(res.Packets).To(Equal(uint64(3)))
+			Expect(res.LostPackets).To(Equal(uint64(3)))
+		})
+	})
+
+	Context("when the sender goes away", func() {
+		It("should fail the transfer", func() {
+			Expect(senderConn.Close()).To(Succeed())
+
+			Eventually(receiverErrs).Should(Receive(HaveOccurred()))
+		})
+	})
+})
+
+func tcpPipe() (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	Expect(err).NotTo(HaveOccurred())
+	serverConn, err := listener.Accept()
+	Expect(err).NotTo(HaveOccurred())
+
+	return clientConn, serverConn
+}
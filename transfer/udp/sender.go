@@ -0,0 +1,134 @@
+package udp
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/ice-stuff/clique/transfer"
+)
+
+// Sender sends the size of the transfer in datagrams at the configured rate.
+// Its results count the bytes that it sent; the loss and the jitter are
+// reported by the receiver.
+type Sender struct {
+	logger *logrus.Logger
+	cfg    Config
+}
+
+func NewSender(logger *logrus.Logger, cfg Config) *Sender {
+	return &Sender{
+		logger: logger,
+		cfg:    cfg,
+	}
+}
+
+func (s *Sender) SendTransfer(
+	ctx context.Context, spec transfer.TransferSpec, conn io.ReadWriter,
+) (transfer.TransferResults, error) {
+	logger := s.logger.WithFields(transfer.LogFields(spec.ID, spec.Peer()))
+	logger.Debug("[UDP] Sending a transfer...")
+
+	port, err := s.handshake(conn)
+	if err != nil {
+		logger.Debugf("[UDP] Handshake failed: %s", err)
+		return transfer.TransferResults{}, err
+	}
+	logger.Debug("[UDP] Handshake went through!")
+
+	localIP, err := spec.Source.LocalIP(spec.IP)
+	if err != nil {
+		return transfer.TransferResults{}, err
+	}
+	udpConn, err := net.DialUDP(
+		"udp", &net.UDPAddr{IP: localIP},
+		&net.UDPAddr{IP: spec.IP, Port: int(port)},
+	)
+	if err != nil {
+		return transfer.TransferResults{}, fmt.Errorf(
+			"connecting to the udp port: %s", err,
+		)
+	}
+	defer udpConn.Close()
+
+	size := s.cfg.datagramSize()
+	count := (spec.Size + uint64(size) - 1) / uint64(size)
+	if count == 0 {
+		count = 1
+	}
+	// time between the datagrams at the rate
+	gap := time.Duration(float64(size) / float64(s.cfg.rate()) * float64(time.Second))
+
+	res := transfer.TransferResults{Backend: BackendName}
+	datagram := make([]byte, size)
+
+	startTime := time.Now()
+	lastProgress := startTime
+	for seq := uint64(0); seq < count; seq++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return transfer.TransferResults{}, ctxErr
+		}
+
+		if wait := time.Until(startTime.Add(time.Duration(seq) * gap)); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		binary.BigEndian.PutUint64(datagram, seq)
+		binary.BigEndian.PutUint64(datagram[8:], uint64(time.Now().UnixNano()))
+		if _, err := udpConn.Write(datagram); err != nil {
+			return transfer.TransferResults{}, err
+		}
+		res.BytesSent += uint64(size)
+
+		if time.Since(lastProgress) >= progressInterval {
+			if err := writeProgress(conn, msgProgress, seq+1); err != nil {
+				return transfer.TransferResults{}, err
+			}
+			lastProgress = time.Now()
+		}
+	}
+	res.Duration = time.Since(startTime)
+
+	if err := writeProgress(conn, msgEnd, count); err != nil {
+		return transfer.TransferResults{}, err
+	}
+	rep, err := readReport(conn)
+	if err != nil {
+		return transfer.TransferResults{}, fmt.Errorf(
+			"reading the report of the receiver: %s", err,
+		)
+	}
+
+	res.Packets = count
+	res.LostPackets = rep.Lost
+	res.Jitter = time.Duration(rep.Jitter)
+	logger.Debugf(
+		"[UDP] Receiver lost %d of %d datagrams", res.LostPackets, res.Packets,
+	)
+
+	return res, nil
+}
+
+func (s *Sender) handshake(conn io.ReadWriter) (uint16, error) {
+	msgBytes := make([]byte, 16)
+	n, err := conn.Read(msgBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	msg := string(msgBytes[:n])
+	var port uint16
+	if _, err := fmt.Sscanf(msg, "ok - %d", &port); err != nil {
+		if msg == "i-am-busy" {
+			return 0, ErrBusy
+		}
+
+		return 0, fmt.Errorf("unrecognized server response `%s`: %s", msg, err)
+	}
+
+	return port, nil
+}
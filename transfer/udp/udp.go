@@ -0,0 +1,111 @@
+// Package udp measures the loss and the jitter of a stream of datagrams that
+// the sender paces to a rate. The transfer connection carries the handshake,
+// the progress of the sender and the report of the receiver, while the
+// datagrams go to a UDP port that the receiver opens for every transfer.
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrBusy = errors.New("server is busy")
+
+// BackendName names the UDP transfers in the results.
+const BackendName = "udp"
+
+// DefaultRate is the rate of the sender, 10Mbit/s, when the configuration
+// does not set one.
+const DefaultRate = 10 * 1000 * 1000 / 8
+
+// DefaultDatagramSize fits the datagrams in the common MTU of 1500 bytes.
+const DefaultDatagramSize = 1400
+
+// Every datagram starts with its sequence number and the time that it was
+// sent, in nanoseconds since the epoch, as big-endian integers.
+const HeaderSize = 16
+
+// MaxDatagramSize is the largest payload of a UDP datagram over IPv4.
+const MaxDatagramSize = 65507
+
+type Config struct {
+	// Bytes per second that the sender sends. Default: DefaultRate.
+	Rate uint64
+	// Size of the datagrams including their header. Default:
+	// DefaultDatagramSize.
+	DatagramSize int
+}
+
+func (c Config) rate() uint64 {
+	if c.Rate == 0 {
+		return DefaultRate
+	}
+
+	return c.Rate
+}
+
+func (c Config) datagramSize() int {
+	if c.DatagramSize <= 0 {
+		return DefaultDatagramSize
+	}
+
+	return c.DatagramSize
+}
+
+// The sender reports the datagrams that it has sent every progressInterval,
+// which keeps the transfer connection from idling out, and once more when
+// it is done.
+const (
+	progressInterval = time.Second
+
+	msgProgress byte = 1
+	msgEnd      byte = 2
+)
+
+// drainTimeout is the time that the receiver waits for the datagrams in
+// flight after the sender is done.
+const drainTimeout = 200 * time.Millisecond
+
+func writeProgress(w io.Writer, msgType byte, sent uint64) error {
+	msg := make([]byte, 9)
+	msg[0] = msgType
+	binary.BigEndian.PutUint64(msg[1:], sent)
+
+	_, err := w.Write(msg)
+	return err
+}
+
+func readProgress(r io.Reader) (byte, uint64, error) {
+	msg := make([]byte, 9)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return 0, 0, err
+	}
+
+	if msg[0] != msgProgress && msg[0] != msgEnd {
+		return 0, 0, fmt.Errorf("unrecognized message type %d", msg[0])
+	}
+
+	return msg[0], binary.BigEndian.Uint64(msg[1:]), nil
+}
+
+// report is what the receiver got from the sender.
+type report struct {
+	Packets uint64
+	Bytes   uint64
+	Lost    uint64
+	// Nanoseconds
+	Jitter int64
+}
+
+func writeReport(w io.Writer, rep report) error {
+	return binary.Write(w, binary.BigEndian, rep)
+}
+
+func readReport(r io.Reader) (report, error) {
+	var rep report
+	err := binary.Read(r, binary.BigEndian, &rep)
+	return rep, err
+}